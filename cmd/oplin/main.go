@@ -6,6 +6,7 @@ import (
 	"log"
 	"oplin/internal/env"
 	"oplin/internal/lineage/wiring"
+	"os"
 	"strconv"
 )
 
@@ -18,6 +19,37 @@ func init() {
 func main() {
 	flag.Parse()
 	env.Setup()
+	if flag.Arg(0) == "migrate" {
+		if err := wiring.RunMigrate(flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "reindex" {
+		if err := wiring.RunReindex(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "prune" {
+		if err := wiring.RunPrune(flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "token" {
+		if err := wiring.RunToken(flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "replay" {
+		if err := wiring.RunReplay(flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	r := wiring.NewGinEngine()
 	err := wiring.SetupLineage(r)
 	if err != nil {
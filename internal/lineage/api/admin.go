@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MergeDatasetsRequest struct {
+	Namespace string    `json:"namespace" binding:"required"`
+	Name      string    `json:"name" binding:"required"`
+	Into      DatasetID `json:"into"`
+}
+
+type SplitDatasetRequest struct {
+	Namespace string `json:"namespace" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+}
+
+type ReplayError struct {
+	RequestID int64  `json:"requestId"`
+	Error     string `json:"error"`
+}
+
+// ReplayResult counts the requests a replay recorded again, Errors holds
+// the first failures
+type ReplayResult struct {
+	Requests    int           `json:"requests"`
+	Failed      int           `json:"failed"`
+	Errors      []ReplayError `json:"errors"`
+	Aliases     int           `json:"aliases"`
+	LostAliases int           `json:"lostAliases"`
+}
+
+// MakeMergeDatasets makes a dataset an alias of another one so lineage shows
+// them as one dataset
+func MakeMergeDatasets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		req := MergeDatasetsRequest{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		alias, err := ops.MergeDatasets(ctx, deps, req.Namespace, req.Name, req.Into.Namespace, req.Into.Name)
+		if errors.Is(err, ops.ErrMergeIntoItself) {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		ds, err := ops.GetDatasetWithNamespace(ctx, deps, alias.DatasetID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		writeData(c, toDataset(*ds))
+	}
+}
+
+// MakeSplitDataset takes a dataset out of the dataset it was merged into,
+// by hand or by symlinks
+func MakeSplitDataset(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		req := SplitDatasetRequest{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := ops.SplitDataset(ctx, deps, req.Namespace, req.Name); err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeData(c, DatasetID{Namespace: req.Namespace, Name: req.Name})
+	}
+}
+
+// ReplayRequest holds the options of a replay, Force replays even though
+// requests were pruned
+type ReplayRequest struct {
+	Force bool `json:"force"`
+}
+
+// ReplayStatus is the progress of a replay and, once it is over, its result
+// or error
+type ReplayStatus struct {
+	State      string        `json:"state"`
+	Done       int           `json:"done"`
+	Total      int           `json:"total"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+	Result     *ReplayResult `json:"result,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+const (
+	ReplayRunning = "RUNNING"
+	ReplayDone    = "DONE"
+	ReplayFailed  = "FAILED"
+)
+
+// replayLocation is where the status of the replay is followed
+const replayLocation = "/api/v1/admin/replay"
+
+// ReplayJob runs the replays of the admin API in the background, one at a
+// time, and keeps the status of the last one. The status is only known to
+// the server that runs the replay.
+type ReplayJob struct {
+	mu     sync.Mutex
+	status *ReplayStatus
+}
+
+func NewReplayJob() *ReplayJob {
+	return &ReplayJob{}
+}
+
+// start sets a running status, false when a replay is already running
+func (j *ReplayJob) start() (ReplayStatus, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != nil && j.status.State == ReplayRunning {
+		return *j.status, false
+	}
+	j.status = &ReplayStatus{State: ReplayRunning, StartedAt: utils.NowUTC()}
+	return *j.status, true
+}
+
+func (j *ReplayJob) progress(done int, total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Done = done
+	j.status.Total = total
+}
+
+func (j *ReplayJob) finish(res *ol_ops.ReplayResult, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := utils.NowUTC()
+	j.status.FinishedAt = &now
+	if err != nil {
+		j.status.State = ReplayFailed
+		j.status.Error = err.Error()
+		return
+	}
+	j.status.State = ReplayDone
+	view := toReplayResult(res)
+	j.status.Result = &view
+}
+
+// Status returns the status of the last replay, nil when there was none
+func (j *ReplayJob) Status() *ReplayStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status == nil {
+		return nil
+	}
+	status := *j.status
+	return &status
+}
+
+func toReplayResult(res *ol_ops.ReplayResult) ReplayResult {
+	view := ReplayResult{
+		Requests:    res.Requests,
+		Failed:      res.Failed,
+		Errors:      []ReplayError{},
+		Aliases:     res.Aliases,
+		LostAliases: res.LostAliases,
+	}
+	for _, e := range res.Errors {
+		view.Errors = append(view.Errors, ReplayError{RequestID: e.RequestID, Error: e.Err.Error()})
+	}
+	return view
+}
+
+// MakeReplay starts rebuilding the lineage from the stored requests in the
+// background and answers with a 202 and where to follow the replay. It
+// answers with a 409 when a replay is already running or when requests were
+// pruned and the replay is not forced.
+func MakeReplay(deps Deps, job *ReplayJob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		req := ReplayRequest{}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if !req.Force {
+			err := ol_ops.CheckReplay(ctx, deps)
+			if errors.Is(err, ol_ops.ErrRequestsPruned) {
+				writeError(c, http.StatusConflict, err)
+				return
+			}
+			if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		status, ok := job.start()
+		if !ok {
+			writeError(c, http.StatusConflict, errReplayRunning)
+			return
+		}
+		go func() {
+			res, err := ol_ops.Replay(ctx, deps, req.Force, job.progress)
+			if err != nil {
+				log.Printf("replay failed: %v", err)
+			}
+			job.finish(res, err)
+		}()
+		writeQueued(c, status, replayLocation)
+	}
+}
+
+// errReplayRunning is returned when a replay is started beside another one
+var errReplayRunning = errors.New("a replay is already running")
+
+// MakeGetReplay answers with the status of the last replay started on this
+// server
+func MakeGetReplay(job *ReplayJob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := job.Status()
+		if status == nil {
+			writeError(c, http.StatusNotFound, errNoReplay)
+			return
+		}
+		writeData(c, status)
+	}
+}
+
+// errNoReplay is returned when no replay was started on this server
+var errNoReplay = errors.New("no replay was started")
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/api"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/wiring"
+	"oplin/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	deps := &api.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(ctx, deps)
+	assert.Nil(t, err)
+	r := wiring.NewGinEngine()
+	wiring.SetupRouter(r, deps)
+
+	do := func(method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	status := func(w *httptest.ResponseRecorder) api.ReplayStatus {
+		res := struct {
+			Data api.ReplayStatus `json:"data"`
+		}{}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Data
+	}
+	wait := func() api.ReplayStatus {
+		for i := 0; i < 100; i++ {
+			w := do("GET", "/api/v1/admin/replay", "")
+			assert.Equal(t, 200, w.Code)
+			if s := status(w); s.State != api.ReplayRunning {
+				return s
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("replay did not finish")
+		return api.ReplayStatus{}
+	}
+
+	assert.Equal(t, 404, do("GET", "/api/v1/admin/replay", "").Code)
+
+	payload := `{"run": {"runId": "6871ff97-c518-4081-97aa-8520f7e634b7"},
+	"job": {"namespace": "abs", "name": "xyz"},
+	"eventType": "START",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "R-Kelly",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}`
+	assert.Equal(t, 200, do("POST", "/api/v1/lineage", payload).Code)
+
+	w := do("POST", "/api/v1/admin/replay", "")
+	assert.Equal(t, 202, w.Code)
+	assert.Equal(t, "/api/v1/admin/replay", w.Header().Get("Location"))
+	s := wait()
+	assert.Equal(t, api.ReplayDone, s.State)
+	assert.Equal(t, 1, s.Done)
+	assert.Equal(t, 1, s.Total)
+	assert.NotNil(t, s.FinishedAt)
+	assert.Equal(t, 1, s.Result.Requests)
+
+	// once requests were pruned the replay has to be forced
+	_, err = deps.GetStore().Queries().CreateRequest(ctx, db.CreateRequestParams{
+		Payload:   []byte(`{"eventType": "START"}`),
+		CreatedAt: utils.NowUTC().AddDate(0, 0, -40),
+		Status:    int32(lineage.RequestStatusProcessed),
+	})
+	assert.Nil(t, err)
+	_, err = ol_ops.Prune(ctx, deps, ol_ops.RetentionPolicy{RequestDays: 30}, false)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 409, do("POST", "/api/v1/admin/replay", "").Code)
+	assert.Equal(t, 409, do("POST", "/api/v1/admin/replay", `{"force": false}`).Code)
+	assert.Equal(t, 202, do("POST", "/api/v1/admin/replay", `{"force": true}`).Code)
+	s = wait()
+	assert.Equal(t, api.ReplayDone, s.State)
+	assert.Equal(t, 1, s.Result.Requests)
+	assert.Nil(t, ol_ops.CheckReplay(ctx, deps))
+}
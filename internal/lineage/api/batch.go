@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"oplin/internal/lineage/auth"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// BatchResult reports what happened to one event of a batch. Index is the
+// position of the event in the array or among the non blank NDJSON lines.
+type BatchResult struct {
+	Index      int                      `json:"index"`
+	RunEventID int64                    `json:"runEventId,omitempty"`
+	RunID      int64                    `json:"runId,omitempty"`
+	Duplicate  bool                     `json:"duplicate,omitempty"`
+	RequestID  int64                    `json:"requestId,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+	Fields     []openlineage.FieldError `json:"fields,omitempty"`
+}
+
+// splitBatch splits a JSON array or NDJSON body into the raw events. Blank
+// NDJSON lines are skipped.
+func splitBatch(contentType string, body []byte) ([]json.RawMessage, error) {
+	var msgs []json.RawMessage
+	if strings.HasPrefix(contentType, ndjsonContentType) {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			msgs = append(msgs, json.RawMessage(append([]byte{}, line...)))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, eris.Wrap(err, "could not read ndjson body")
+		}
+		return msgs, nil
+	}
+
+	if err := json.Unmarshal(body, &msgs); err != nil {
+		return nil, eris.Wrap(err, "body is not a json array of events")
+	}
+	return msgs, nil
+}
+
+func parseRunEvent(msg json.RawMessage) (*openlineage.RunEvent, error) {
+	ev := openlineage.NewRunEvent()
+	if err := json.Unmarshal(msg, ev); err != nil {
+		return nil, eris.Wrap(err, "could not parse event")
+	}
+	if ev.Run == nil {
+		return nil, eris.New("event has no run")
+	}
+	if ev.Job == nil {
+		return nil, eris.New("event has no job")
+	}
+	ol_ops.NormalizeEventType(ev)
+	return ev, nil
+}
+
+// MakeCreateWithOpenLineageRunEvents records a batch of run events sent as a
+// JSON array or as NDJSON. Events are processed in order and a failing event
+// does not stop the others, the response holds a result per event. Events are
+// validated against the spec and deduplicated like single events, an
+// Idempotency-Key header applies to the whole batch and is suffixed with the
+// index of each event. When the mode is async the new events are queued for
+// the request workers, each result holds its request id, and the response is
+// a 202. Events outside the scope of the API token are refused in their
+// result.
+func MakeCreateWithOpenLineageRunEvents(
+	deps Deps, validator *openlineage.Validator, mode ol_ops.IngestMode,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		msgs, err := splitBatch(c.ContentType(), body)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		results := make([]BatchResult, len(msgs))
+		key := c.GetHeader(idempotencyKeyHeader)
+		var evs []*openlineage.RunEvent
+		var keys []string
+		var payloads []json.RawMessage
+		var indexes []int
+		for i, msg := range msgs {
+			results[i].Index = i
+			if errs := validator.Validate(openlineage.EventKindRun, msg); len(errs) > 0 {
+				results[i].Error = "event does not match the OpenLineage spec"
+				results[i].Fields = errs
+				continue
+			}
+			ev, err := parseRunEvent(msg)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			if !auth.TokenAllows(c, ev.Producer, jobNamespace(ev.Job)) {
+				results[i].Error = errTokenScope.Error()
+				continue
+			}
+			evs = append(evs, ev)
+			payloads = append(payloads, msg)
+			indexes = append(indexes, i)
+			if key != "" {
+				keys = append(keys, fmt.Sprintf("%s/%d", key, i))
+			}
+		}
+
+		if mode == ol_ops.IngestAsync {
+			enqueueBatch(c, deps, evs, keys, payloads, indexes, results)
+			return
+		}
+
+		created, err := ol_ops.CreateWithOpenLineageRunEvents(ctx, deps, evs, keys, payloads)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for j, r := range created {
+			res := &results[indexes[j]]
+			if r.Err != nil {
+				res.Error = r.Err.Error()
+			} else {
+				res.RunEventID = r.RunEvent.ID
+				res.RunID = r.RunEvent.RunID
+				res.Duplicate = r.RunEvent.Duplicate
+			}
+		}
+		writeData(c, results)
+	}
+}
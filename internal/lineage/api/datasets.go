@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MakeListDatasets lists the datasets in a namespace
+func MakeListDatasets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		rows, err := ops.ListDatasetsWithNamespacesByNamespace(ctx, deps, c.Param("namespace"))
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []Dataset{}
+		for _, row := range rows {
+			res = append(res, toDataset(row))
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeGetDataset gets a dataset by any of its names along with the fields of
+// its current version and its aliases
+func MakeGetDataset(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("dataset"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		res := toDataset(*ds)
+		if ds.Dataset.CurrentVersionID > 0 {
+			fields, err := ops.ListFieldsForDatasetVersion(ctx, deps, ds.Dataset.CurrentVersionID)
+			if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
+				return
+			}
+			res.Fields = toFields(fields)
+		}
+
+		aliases, err := ops.ListDatasetAliases(ctx, deps, ds.Dataset.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for _, a := range aliases {
+			// a split dataset is an alias of itself
+			if a.Namespace == ds.DatasetNamespace.Name && a.Name == ds.Dataset.Name {
+				continue
+			}
+			res.Aliases = append(res.Aliases, DatasetID{Namespace: a.Namespace, Name: a.Name})
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeListDatasetVersions lists the versions of a dataset
+func MakeListDatasetVersions(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("dataset"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		versions, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []DatasetVersion{}
+		for _, v := range versions {
+			dv, err := toDatasetVersion(ctx, deps, ds.DatasetNamespace.Name, v)
+			if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
+				return
+			}
+			res = append(res, *dv)
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeGetDatasetVersion gets a version of a dataset by its uuid
+func MakeGetDatasetVersion(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("dataset"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		version, err := uuid.Parse(c.Param("version"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		v, err := ops.GetDatasetVersionWithUUID(ctx, deps, ds.Dataset.ID, version)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		res, err := toDatasetVersion(ctx, deps, ds.DatasetNamespace.Name, *v)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		writeData(c, res)
+	}
+}
+
+func toDatasetVersion(ctx context.Context, deps Deps, namespace string, v lineage.DatasetVersion) (*DatasetVersion, error) {
+	fields, err := ops.ListFieldsForDatasetVersion(ctx, deps, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	createdBy, err := ops.GetDatasetVersionCreatedByRun(ctx, deps, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &DatasetVersion{
+		Version:      v.VersionUUID,
+		Namespace:    namespace,
+		Name:         v.Name,
+		CreatedByRun: createdBy,
+		CreatedAt:    v.CreatedAt,
+		UpdatedAt:    optionalTime(v.UpdatedAt),
+		Fields:       toFields(fields),
+		Changes:      toSchemaChanges(changes),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func writeFacets(c *gin.Context, deps Deps, entityType lineage.FacetEntityType, id int64) {
+	facets, err := ops.ListFacets(context.Background(), deps, entityType, id)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+	res := []Facet{}
+	for _, f := range facets {
+		res = append(res, toFacet(f))
+	}
+	writeData(c, res)
+}
+
+// MakeListJobFacets lists every facet of a job by name, custom facets included
+func MakeListJobFacets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeFacets(c, deps, lineage.FacetEntityTypeJob, jns.Job.ID)
+	}
+}
+
+// MakeListDatasetFacets lists every facet of a dataset by name, custom facets
+// included
+func MakeListDatasetFacets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("dataset"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeFacets(c, deps, lineage.FacetEntityTypeDataset, ds.Dataset.ID)
+	}
+}
+
+// MakeListRunFacets lists every facet of a run by name, custom facets included
+func MakeListRunFacets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		runUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		run, err := ops.GetRunWithUUID(ctx, deps, runUUID)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeFacets(c, deps, lineage.FacetEntityTypeRun, run.ID)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphNode struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type GraphEdge struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+}
+
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// queryDepth reads a depth query parameter falling back to the default
+func queryDepth(c *gin.Context, key string) (int, error) {
+	s := c.Query(key)
+	if s == "" {
+		return ops.DefaultLineageDepth, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// MakeGetLineageGraph returns the datasets and jobs upstream and downstream of
+// the nodeId query parameter, e.g. ?nodeId=dataset:ns:name&upstream=2&downstream=1
+func MakeGetLineageGraph(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		nodeID, err := lineage.NodeIDFromString(c.Query("nodeId"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		upstream, err := queryDepth(c, "upstream")
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		downstream, err := queryDepth(c, "downstream")
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		g, err := ops.GetLineageGraph(ctx, deps, nodeID, upstream, downstream)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		res := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+		for _, n := range g.Nodes {
+			res.Nodes = append(res.Nodes, GraphNode{
+				ID:        n.NodeID.String(),
+				Type:      n.NodeID.Type.String(),
+				Namespace: n.NodeID.Namespace,
+				Name:      n.NodeID.Name,
+			})
+		}
+		for _, e := range g.Edges {
+			res.Edges = append(res.Edges, GraphEdge{Origin: e.Origin.String(), Destination: e.Destination.String()})
+		}
+		writeData(c, res)
+	}
+}
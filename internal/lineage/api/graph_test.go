@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const graphPayload = `
+{"run": {"runId": "%s"},
+"job": {"namespace": "graph", "name": "%s"},
+"inputs": [{"namespace": "graph", "name": "%s"}],
+"outputs": [{"namespace": "graph", "name": "%s"}],
+"eventType": "COMPLETE",
+"eventTime": "2023-02-05T15:48:28.660754+02:00",
+"producer": "test",
+"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+`
+
+func TestLineageGraph(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	// a -> job1 -> b -> job2 -> c
+	for _, ev := range []string{
+		fmt.Sprintf(graphPayload, "a1b2c3d4-0000-4000-8000-000000000001", "job1", "a", "b"),
+		fmt.Sprintf(graphPayload, "a1b2c3d4-0000-4000-8000-000000000002", "job2", "b", "c"),
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(ev))
+		r.ServeHTTP(w, req)
+		require.Equal(t, 200, w.Code)
+	}
+
+	code, body := get(t, r, "/api/v1/lineage/graph?nodeId=dataset:graph:b&upstream=1&downstream=1")
+	assert.Equal(t, 200, code)
+	g := body["data"].(map[string]interface{})
+	assert.Len(t, g["nodes"], 3)
+	assert.Len(t, g["edges"], 2)
+
+	code, body = get(t, r, "/api/v1/lineage/graph?nodeId=job:graph:job1&upstream=0&downstream=3")
+	assert.Equal(t, 200, code)
+	g = body["data"].(map[string]interface{})
+	assert.Len(t, g["nodes"], 4)
+	assert.Len(t, g["edges"], 3)
+
+	code, _ = get(t, r, "/api/v1/lineage/graph?nodeId=dataset:graph:missing")
+	assert.Equal(t, 404, code)
+
+	code, _ = get(t, r, "/api/v1/lineage/graph?nodeId=bogus")
+	assert.Equal(t, 400, code)
+}
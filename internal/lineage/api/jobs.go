@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MakeListJobs lists the jobs in a namespace
+func MakeListJobs(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		rows, err := ops.ListJobsWithNamespacesByNamespace(ctx, deps, c.Param("namespace"))
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []Job{}
+		for _, row := range rows {
+			res = append(res, toJob(row))
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeGetJob gets a job by namespace and name
+func MakeGetJob(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeData(c, toJob(*jns))
+	}
+}
+
+// MakeListJobRuns lists the runs of every version of a job, newest first
+func MakeListJobRuns(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		runs, err := ops.ListRunsByJobID(ctx, deps, jns.Job.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		job := JobID{Namespace: jns.JobNamespace.Name, Name: jns.Job.Name}
+		res := []Run{}
+		for _, run := range runs {
+			res = append(res, toRun(run, job))
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeListJobVersions lists the versions of a job
+func MakeListJobVersions(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		versions, err := ops.ListJobVersions(ctx, deps, jns.Job.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []JobVersion{}
+		for _, v := range versions {
+			res = append(res, toJobVersion(jns.JobNamespace.Name, v))
+		}
+		writeData(c, res)
+	}
+}
+
+// MakeGetJobVersion gets a version of a job by its uuid
+func MakeGetJobVersion(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		version, err := uuid.Parse(c.Param("version"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		v, err := ops.GetJobVersionWithUUID(ctx, deps, jns.Job.ID, version)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeData(c, toJobVersion(jns.JobNamespace.Name, *v))
+	}
+}
+
+func toJobVersion(namespace string, v lineage.JobVersion) JobVersion {
+	return JobVersion{
+		Version:   v.VersionUUID,
+		Namespace: namespace,
+		Name:      v.Name,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: optionalTime(v.UpdatedAt),
+	}
+}
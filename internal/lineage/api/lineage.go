@@ -3,10 +3,14 @@ package api
 import (
 	"bytes"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/auth"
 	ol_ops "oplin/internal/lineage/ops/openlineage"
 	"oplin/internal/openlineage"
 
@@ -24,27 +28,149 @@ func writeError(c *gin.Context, httpStatus int, err error) {
 	})
 }
 
+// writeLookupError writes a 404 when the resource does not exist and a 500
+// for everything else
+func writeLookupError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(c, http.StatusNotFound, err)
+	} else {
+		writeError(c, http.StatusInternalServerError, err)
+	}
+}
+
+// writeValidationError writes a 400 listing the fields of the event that do
+// not match the OpenLineage spec
+func writeValidationError(c *gin.Context, errs []openlineage.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "event does not match the OpenLineage spec",
+		"fields": errs,
+	})
+}
+
+// errTokenScope is returned for an event the API token may not send
+var errTokenScope = errors.New("api token may not send events for this producer or namespace")
+
+// jobNamespace is the namespace an API token scope is checked against
+func jobNamespace(job *openlineage.Job) string {
+	if job == nil {
+		return ""
+	}
+	return job.Namespace
+}
+
 func writeData(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, gin.H{
 		"data": data,
 	})
 }
 
-func MakeCreateWithOpenLineageRunEvent(deps Deps) gin.HandlerFunc {
+// idempotencyKeyHeader names the header a client sets to make retries of a
+// run event safe
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// MakeCreateWithOpenLineageRunEvent records an OpenLineage event. Despite the
+// name it also accepts dataset and job events, which carry static metadata
+// without a run, and routes each kind to its own operation. Events are
+// validated against the spec first and stored as received. A run event
+// already recorded is not
+// recorded again, the original run event is returned marked as a duplicate.
+// Run events are matched by their Idempotency-Key header when given and by
+// their content. When the mode is async valid events are queued for the
+// request workers and the queued request is returned with a 202. When API
+// tokens are required an event outside the scope of the token gets a 403.
+func MakeCreateWithOpenLineageRunEvent(
+	deps Deps, validator *openlineage.Validator, mode ol_ops.IngestMode,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
-		ev := openlineage.NewRunEvent()
 
 		body, _ := ioutil.ReadAll(c.Request.Body)
 		fmt.Print(string(body))
 		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
 
-		if err := c.BindJSON(&ev); err != nil {
-			c.Error(err)
-		} else {
-			id, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, ev)
+		kind, err := openlineage.DetectEventKind(body)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		if errs := validator.Validate(kind, body); len(errs) > 0 {
+			writeValidationError(c, errs)
+			return
+		}
+
+		switch kind {
+		case openlineage.EventKindDataset:
+			ev := &openlineage.DatasetEvent{}
+			if err := c.BindJSON(ev); err != nil {
+				c.Error(err)
+				return
+			}
+			ns := ""
+			if ev.Dataset != nil {
+				ns = ev.Dataset.Namespace
+			}
+			if !auth.TokenAllows(c, ev.Producer, ns) {
+				writeError(c, http.StatusForbidden, errTokenScope)
+				return
+			}
+			if mode == ol_ops.IngestAsync {
+				enqueue(c, deps, ev, "", body)
+				return
+			}
+			dv, err := ol_ops.CreateWithOpenLineageDatasetEvent(ctx, deps, ev, body)
+			if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
+			} else {
+				writeData(c, dv)
+			}
+		case openlineage.EventKindJob:
+			ev := &openlineage.JobEvent{}
+			if err := c.BindJSON(ev); err != nil {
+				c.Error(err)
+				return
+			}
+			if !auth.TokenAllows(c, ev.Producer, jobNamespace(ev.Job)) {
+				writeError(c, http.StatusForbidden, errTokenScope)
+				return
+			}
+			if mode == ol_ops.IngestAsync {
+				enqueue(c, deps, ev, "", body)
+				return
+			}
+			jv, err := ol_ops.CreateWithOpenLineageJobEvent(ctx, deps, ev, body)
 			if err != nil {
 				writeError(c, http.StatusInternalServerError, err)
+			} else {
+				writeData(c, jv)
+			}
+		default:
+			ev := openlineage.NewRunEvent()
+			if err := c.BindJSON(&ev); err != nil {
+				c.Error(err)
+				return
+			}
+			if !auth.TokenAllows(c, ev.Producer, jobNamespace(ev.Job)) {
+				writeError(c, http.StatusForbidden, errTokenScope)
+				return
+			}
+			ol_ops.NormalizeEventType(ev)
+			key := c.GetHeader(idempotencyKeyHeader)
+			var id *lineage.RunEvent
+			if mode == ol_ops.IngestAsync {
+				// duplicates are answered right away, the workers catch
+				// those queued before the original was recorded
+				id, err = ol_ops.FindDuplicateRunEvent(ctx, deps, ev, key)
+				if err == nil && id == nil {
+					enqueue(c, deps, ev, key, body)
+					return
+				}
+			} else {
+				id, err = ol_ops.CreateWithOpenLineageRunEventAndKey(ctx, deps, ev, key, body)
+			}
+			if errors.Is(err, ol_ops.ErrIdempotencyKeyReused) {
+				writeError(c, http.StatusConflict, err)
+			} else if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
 			} else {
 				writeData(c, id)
 			}
@@ -2,25 +2,34 @@ package api_test
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"oplin/internal/lineage"
 	"oplin/internal/lineage/api"
+	"oplin/internal/lineage/auth"
 	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
 	"oplin/internal/lineage/wiring"
-	"oplin/internal/utils"
+	"oplin/internal/openlineage"
+	"oplin/resources"
 	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func setupSuite(tb testing.TB) (*gin.Engine, func(tb testing.TB)) {
 	ctx := context.Background()
-	db := utils.GetTestDB()
+	st := store.GetTestStore()
 
-	deps := api.TestDeps{DB: db}
+	deps := api.TestDeps{Store: st}
 	err := ops.InitializeTestDB(ctx, &deps)
 	if err != nil {
 		log.Fatalf("Failed to initialize test db[%v]", err)
@@ -48,7 +57,8 @@ func TestCreateWithOpenLineageRunEvent(t *testing.T) {
 	"outputs": [{"namespace": "dns2", "name": "table2"}], 
 	"eventType": "start", 
 	"eventTime": "2023-02-05T15:48:28.660754+02:00", 
-	"Producer": "R-Kelly"}
+	"producer": "R-Kelly",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
 	`
 
 	req, _ := http.NewRequest(
@@ -58,3 +68,294 @@ func TestCreateWithOpenLineageRunEvent(t *testing.T) {
 
 	assert.Equal(t, 200, w.Code)
 }
+
+func TestCreateWithOpenLineageRunEvents(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	event := `{"run": {"runId": "%s"}, 
+	"job": {"namespace": "abs", "name": "xyz"}, 
+	"outputs": [{"namespace": "dns2", "name": "table2"}], 
+	"eventType": "%s", 
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}`
+	runID := "6871ff97-c518-4081-97aa-8520f7e634b7"
+
+	payload := "[" + fmt.Sprintf(event, runID, "start") + `, {"run": {}}, ` +
+		fmt.Sprintf(event, runID, "complete") + "]"
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/lineage/batch", strings.NewReader(payload))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var res struct {
+		Data []api.BatchResult `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(res.Data))
+	assert.Equal(t, "", res.Data[0].Error)
+	assert.NotEqual(t, "", res.Data[1].Error)
+	assert.Equal(t, "", res.Data[2].Error)
+	assert.Equal(t, res.Data[0].RunID, res.Data[2].RunID)
+
+	line := strings.ReplaceAll(event, "\n", "")
+	payload = fmt.Sprintf(line, uuid.New(), "start") + "\n\n{not json}\n" +
+		fmt.Sprintf(line, uuid.New(), "start") + "\n"
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/lineage/batch", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	err = json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(res.Data))
+	assert.Equal(t, "", res.Data[0].Error)
+	assert.NotEqual(t, "", res.Data[1].Error)
+	assert.Equal(t, "", res.Data[2].Error)
+}
+
+func TestCreateWithDuplicateRunEvent(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	post := func(payload string, key string) (int, lineage.RunEvent) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(payload))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		var res struct {
+			Data lineage.RunEvent `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &res)
+		return w.Code, res.Data
+	}
+
+	runID := uuid.New()
+	payload := fmt.Sprintf(`{"run": {"runId": "%s", "facets": {"a": {"b": "1", "c": 2}}},
+	"job": {"namespace": "abs", "name": "xyz"},
+	"eventType": "START",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}`, runID)
+	code, first := post(payload, "")
+	assert.Equal(t, 200, code)
+	assert.False(t, first.Duplicate)
+
+	// a retry serializing the facets differently is the same event
+	retry := strings.Replace(payload, `{"b": "1", "c": 2}`, `{"c": 2, "b": "1"}`, 1)
+	code, dup := post(retry, "")
+	assert.Equal(t, 200, code)
+	assert.True(t, dup.Duplicate)
+	assert.Equal(t, first.ID, dup.ID)
+
+	complete := strings.Replace(payload, "START", "COMPLETE", 1)
+	code, second := post(complete, "key-1")
+	assert.Equal(t, 200, code)
+	assert.False(t, second.Duplicate)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	code, dup = post(complete, "key-1")
+	assert.Equal(t, 200, code)
+	assert.True(t, dup.Duplicate)
+	assert.Equal(t, second.ID, dup.ID)
+
+	code, _ = post(strings.Replace(payload, "START", "FAIL", 1), "key-1")
+	assert.Equal(t, 409, code)
+}
+
+func TestCreateWithQueuedRunEvent(t *testing.T) {
+	ctx := context.Background()
+	deps := &api.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(ctx, deps)
+	assert.Nil(t, err)
+	spec, err := resources.Static.ReadFile("static/openapi/OpenLineage.json")
+	assert.Nil(t, err)
+	validator, err := openlineage.NewValidator(spec, openlineage.ValidationModeLenient)
+	assert.Nil(t, err)
+
+	r := wiring.NewGinEngine()
+	r.POST("/api/v1/lineage", api.MakeCreateWithOpenLineageRunEvent(deps, validator, ol_ops.IngestAsync))
+	r.GET("/api/v1/lineage/requests/:id", api.MakeGetRequest(deps))
+
+	payload := fmt.Sprintf(`{"run": {"runId": "%s"},
+	"job": {"namespace": "abs", "name": "xyz"},
+	"eventType": "start",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}`, uuid.New())
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(payload))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 202, w.Code)
+
+	var res struct {
+		Data api.Request `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, "PENDING", res.Data.Status)
+	location := w.Header().Get("Location")
+	assert.Equal(t, fmt.Sprintf("/api/v1/lineage/requests/%d", res.Data.ID), location)
+
+	// the request keeps the body as it was posted
+	stored, err := ops.GetRequest(ctx, deps, res.Data.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, string(stored.Payload))
+
+	found, err := ol_ops.ProcessNextRequest(ctx, deps)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", location, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	err = json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, "PROCESSED", res.Data.Status)
+	assert.NotEqual(t, int64(0), res.Data.RunEventID)
+
+	// a retry of a recorded event is answered without queueing it
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(payload))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestCreateWithInvalidEvent(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	payload := `
+	{"run": {"runId": "6871ff97-c518-4081-97aa-8520f7e634b7"},
+	"eventType": "START",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+	`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(payload))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	var res struct {
+		Fields []openlineage.FieldError `json:"fields"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Fields))
+	assert.Contains(t, res.Fields[0].Message, "job")
+}
+
+func TestCreateWithAPIToken(t *testing.T) {
+	ctx := context.Background()
+	deps := &api.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(ctx, deps)
+	assert.Nil(t, err)
+	spec, err := resources.Static.ReadFile("static/openapi/OpenLineage.json")
+	assert.Nil(t, err)
+	validator, err := openlineage.NewValidator(spec, openlineage.ValidationModeLenient)
+	assert.Nil(t, err)
+
+	r := wiring.NewGinEngine()
+	r.POST("/api/v1/lineage", auth.RequireToken(deps), api.MakeCreateWithOpenLineageRunEvent(deps, validator, ol_ops.IngestSync))
+	r.POST("/api/v1/lineage/batch", auth.RequireToken(deps), api.MakeCreateWithOpenLineageRunEvents(deps, validator, ol_ops.IngestSync))
+
+	_, scoped, err := ops.CreateAPIToken(ctx, deps, "airflow", "", "abs")
+	assert.Nil(t, err)
+
+	event := `{"run": {"runId": "%s"},
+	"job": {"namespace": "%s", "name": "xyz"},
+	"eventType": "START",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}`
+	post := func(path string, payload string, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/v1/lineage", fmt.Sprintf(event, uuid.New(), "abs"), "")
+	assert.Equal(t, 401, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+
+	w = post("/api/v1/lineage", fmt.Sprintf(event, uuid.New(), "abs"), "oplin_unknown")
+	assert.Equal(t, 401, w.Code)
+
+	w = post("/api/v1/lineage", fmt.Sprintf(event, uuid.New(), "abs"), scoped)
+	assert.Equal(t, 200, w.Code)
+
+	w = post("/api/v1/lineage", fmt.Sprintf(event, uuid.New(), "other"), scoped)
+	assert.Equal(t, 403, w.Code)
+
+	batch := "[" + fmt.Sprintf(event, uuid.New(), "abs") + ", " + fmt.Sprintf(event, uuid.New(), "other") + "]"
+	w = post("/api/v1/lineage/batch", batch, scoped)
+	assert.Equal(t, 200, w.Code)
+	var res struct {
+		Data []api.BatchResult `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(res.Data)) {
+		assert.Equal(t, "", res.Data[0].Error)
+		assert.Contains(t, res.Data[1].Error, "namespace")
+	}
+}
+
+func TestReadWithAuth(t *testing.T) {
+	ctx := context.Background()
+	deps := &api.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(ctx, deps)
+	assert.Nil(t, err)
+	_, secret, err := ops.CreateAPIToken(ctx, deps, "reader", "", "")
+	assert.Nil(t, err)
+
+	setFlags := func(flags map[string]string) {
+		for name, value := range flags {
+			assert.Nil(t, flag.Set(name, value))
+		}
+	}
+	defer setFlags(map[string]string{"ingest_auth": "false", "ui_auth": "none", "basic_auth_user": "", "basic_auth_password": ""})
+
+	read := func(r http.Handler, path string, setAuth func(req *http.Request)) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if setAuth != nil {
+			setAuth(req)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	bearer := func(token string) func(req *http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	basic := func(req *http.Request) {
+		req.SetBasicAuth("admin", "secret")
+	}
+	paths := []string{"/api/v1/namespaces", "/api/v1/search?q=x", "/marquez/api/v1/namespaces"}
+
+	// Tokens or the login of the UI, with or without tokens to send events
+	for _, ingest := range []string{"true", "false"} {
+		setFlags(map[string]string{"ingest_auth": ingest, "ui_auth": "basic", "basic_auth_user": "admin", "basic_auth_password": "secret"})
+		r := wiring.NewGinEngine()
+		wiring.SetupRouter(r, deps)
+		for _, path := range paths {
+			assert.Equal(t, 401, read(r, path, nil), path)
+			assert.Equal(t, 401, read(r, path, bearer("oplin_unknown")), path)
+			assert.Equal(t, 200, read(r, path, bearer(secret)), path)
+			assert.Equal(t, 200, read(r, path, basic), path)
+		}
+	}
+}
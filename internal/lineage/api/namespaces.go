@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MakeListNamespaces lists the job and dataset namespaces merged by name
+func MakeListNamespaces(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		rows, err := ops.ListNamespaces(ctx, deps)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []Namespace{}
+		for _, ns := range rows {
+			res = append(res, Namespace{Name: ns.Name, CreatedAt: ns.CreatedAt, UpdatedAt: optionalTime(ns.UpdatedAt)})
+		}
+		writeData(c, res)
+	}
+}
@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readPayload = `
+{"run": {"runId": "0d6b7c36-96d5-4b31-9ff1-3a87c2bbd5a1"},
+"job": {"namespace": "read-ns", "name": "read-job"},
+"inputs": [{"namespace": "read-ns", "name": "db.public/in"}],
+"outputs": [{"namespace": "read-ns", "name": "db.public/out",
+	"facets": {"schema": {"fields": [{"name": "id", "type": "int"}]}}}],
+"eventType": "COMPLETE",
+"eventTime": "2023-02-05T15:48:28.660754+02:00",
+"producer": "test",
+"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+`
+
+func get(t *testing.T, r http.Handler, path string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	r.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestReadAPI(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(readPayload))
+	r.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code)
+
+	code, body := get(t, r, "/api/v1/namespaces")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["data"], 1)
+
+	code, body = get(t, r, "/api/v1/namespaces/read-ns/jobs/read-job")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "read-job", body["data"].(map[string]interface{})["name"])
+
+	code, body = get(t, r, "/api/v1/namespaces/read-ns/datasets/db.public%2Fout")
+	assert.Equal(t, 200, code)
+	ds := body["data"].(map[string]interface{})
+	assert.Equal(t, "db.public/out", ds["name"])
+	assert.Len(t, ds["fields"], 1)
+
+	code, body = get(t, r, "/api/v1/namespaces/read-ns/datasets/db.public%2Fout/versions")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["data"], 1)
+	dv := body["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "0d6b7c36-96d5-4b31-9ff1-3a87c2bbd5a1", dv["createdByRun"])
+	assert.Len(t, dv["fields"], 1)
+	assert.NotContains(t, dv, "id")
+
+	path := fmt.Sprintf("/api/v1/namespaces/read-ns/datasets/db.public%%2Fout/versions/%s", dv["version"])
+	code, body = get(t, r, path)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, dv, body["data"])
+
+	// a version of another dataset is not found
+	path = fmt.Sprintf("/api/v1/namespaces/read-ns/datasets/db.public%%2Fin/versions/%s", dv["version"])
+	code, _ = get(t, r, path)
+	assert.Equal(t, 404, code)
+	code, _ = get(t, r, "/api/v1/namespaces/read-ns/datasets/db.public%2Fout/versions/1")
+	assert.Equal(t, 400, code)
+
+	code, body = get(t, r, "/api/v1/namespaces/read-ns/jobs/read-job/versions")
+	assert.Equal(t, 200, code)
+	jv := body["data"].([]interface{})[0].(map[string]interface{})
+	assert.NotContains(t, jv, "id")
+	code, body = get(t, r, fmt.Sprintf("/api/v1/namespaces/read-ns/jobs/read-job/versions/%s", jv["version"]))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, jv, body["data"])
+
+	code, body = get(t, r, "/api/v1/namespaces/read-ns/jobs/read-job/runs")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["data"], 1)
+
+	code, body = get(t, r, "/api/v1/runs/0d6b7c36-96d5-4b31-9ff1-3a87c2bbd5a1")
+	assert.Equal(t, 200, code)
+	run := body["data"].(map[string]interface{})
+	assert.Equal(t, "COMPLETED", run["state"])
+	assert.Len(t, run["inputs"], 1)
+	assert.Len(t, run["outputs"], 1)
+
+	code, _ = get(t, r, "/api/v1/namespaces/read-ns/jobs/missing")
+	assert.Equal(t, 404, code)
+
+	code, _ = get(t, r, "/api/v1/runs/not-a-uuid")
+	assert.Equal(t, 400, code)
+}
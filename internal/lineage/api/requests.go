@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeQueued writes a 202 with the queued request and where to follow it
+func writeQueued(c *gin.Context, data interface{}, location string) {
+	if location != "" {
+		c.Header("Location", location)
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"data": data,
+	})
+}
+
+func requestLocation(id int64) string {
+	return fmt.Sprintf("/api/v1/lineage/requests/%d", id)
+}
+
+// enqueue queues the event for the request workers, ev is any of the
+// OpenLineage event kinds and body what was received
+func enqueue(c *gin.Context, deps Deps, ev interface{}, key string, body []byte) {
+	req, err := ol_ops.EnqueueRequest(context.Background(), deps, ev, key, body)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+	writeQueued(c, toRequest(*req), requestLocation(req.ID))
+}
+
+// enqueueBatch queues the run events of a batch that are not duplicates and
+// fills in their results, indexes are the positions of the events in the
+// batch and payloads the events as received
+func enqueueBatch(
+	c *gin.Context, deps Deps, evs []*openlineage.RunEvent, keys []string, payloads []json.RawMessage,
+	indexes []int, results []BatchResult,
+) {
+	ctx := context.Background()
+	for j, ev := range evs {
+		res := &results[indexes[j]]
+		var key string
+		if keys != nil {
+			key = keys[j]
+		}
+		dup, err := ol_ops.FindDuplicateRunEvent(ctx, deps, ev, key)
+		if err != nil {
+			res.Error = err.Error()
+			continue
+		}
+		if dup != nil {
+			res.RunEventID = dup.ID
+			res.RunID = dup.RunID
+			res.Duplicate = true
+			continue
+		}
+		req, err := ol_ops.EnqueueRequest(ctx, deps, ev, key, payloads[j])
+		if err != nil {
+			res.Error = err.Error()
+			continue
+		}
+		res.RequestID = req.ID
+	}
+	writeQueued(c, results, "")
+}
+
+// MakeGetRequest gets a received request with its status in the ingestion
+// queue, the run event it recorded or the error of its last attempt
+func MakeGetRequest(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		req, err := ops.GetRequest(ctx, deps, id)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		writeData(c, toRequest(*req))
+	}
+}
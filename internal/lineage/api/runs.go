@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MakeGetRun gets a run by its uuid along with its job, parent and datasets
+func MakeGetRun(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		runUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		run, err := ops.GetRunWithUUID(ctx, deps, runUUID)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		jv, err := ops.GetJobVersionByID(ctx, deps, run.JobVersionID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		jns, err := ops.GetJobWithNamespace(ctx, deps, jv.JobID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := toRun(*run, JobID{Namespace: jns.JobNamespace.Name, Name: jns.Job.Name})
+		if run.ParentRunID > 0 {
+			parent, err := ops.GetRunWithID(ctx, deps, run.ParentRunID)
+			if err != nil {
+				writeError(c, http.StatusInternalServerError, err)
+				return
+			}
+			res.ParentRunID = &parent.RunUUID
+		}
+
+		datasets, err := ops.ListRunDatasetVersionsWithRelationshipsByRunID(ctx, deps, run.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for _, ds := range datasets {
+			id := DatasetID{Namespace: ds.DatasetNamespace.Name, Name: ds.DatasetVersion.Name}
+			switch ds.RunIODataset.IOType {
+			case lineage.IOTypeInput:
+				res.Inputs = append(res.Inputs, id)
+			case lineage.IOTypeOutput:
+				res.Outputs = append(res.Outputs, id)
+			}
+		}
+		writeData(c, res)
+	}
+}
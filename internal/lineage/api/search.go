@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var searchTypes = map[string]lineage.NodeType{
+	"":        lineage.NodeTypeUnknown,
+	"dataset": lineage.NodeTypeDataset,
+	"job":     lineage.NodeTypeJob,
+}
+
+// MakeSearch finds datasets and jobs by name, namespace, field, description,
+// owner or SQL. The type query parameter limits the search to datasets or
+// jobs.
+func MakeSearch(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			writeError(c, http.StatusBadRequest, eris.New("query parameter q is required"))
+			return
+		}
+		nodeType, ok := searchTypes[c.Query("type")]
+		if !ok {
+			writeError(c, http.StatusBadRequest, eris.Errorf("unknown type[%s], expected dataset or job", c.Query("type")))
+			return
+		}
+		limit := defaultSearchLimit
+		if s := c.Query("limit"); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 || limit > maxSearchLimit {
+				writeError(c, http.StatusBadRequest, eris.Errorf("invalid limit[%s], expected 1 to %d", s, maxSearchLimit))
+				return
+			}
+		}
+
+		rows, err := ops.FullTextSearch(ctx, deps, q, nodeType, limit)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []SearchResult{}
+		for _, row := range rows {
+			r := SearchResult{
+				Type:      strings.ToLower(row.NodeID.Type.String()),
+				Namespace: row.NodeID.Namespace,
+				Name:      row.NodeID.Name,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: optionalTime(row.UpdatedAt),
+				Matches:   []SearchMatch{},
+			}
+			for _, m := range row.Matches {
+				r.Matches = append(r.Matches, SearchMatch{Field: string(m.Field), Content: m.Content})
+			}
+			res = append(res, r)
+		}
+		writeData(c, res)
+	}
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const searchPayload = `
+{"run": {"runId": "6f0a2c9e-4a53-4d2e-8f59-0c1b0f1b6d2e"},
+"job": {"namespace": "search-ns", "name": "monthly_rollup",
+	"facets": {"sql": {"query": "insert into summary select * from orders"},
+		"ownership": {"owners": [{"name": "team:finance"}]}}},
+"outputs": [{"namespace": "search-ns", "name": "public.customers",
+	"facets": {"schema": {"fields": [{"name": "customer_email", "type": "varchar", "description": "Primary contact"}]}}}],
+"eventType": "COMPLETE",
+"eventTime": "2023-02-05T15:48:28.660754+02:00",
+"producer": "test",
+"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+`
+
+func TestSearch(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/lineage", strings.NewReader(searchPayload))
+	r.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code)
+
+	code, body := get(t, r, "/api/v1/search?q=CUSTOMER_EMAIL")
+	assert.Equal(t, 200, code)
+	require.Len(t, body["data"], 1)
+	res := body["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "dataset", res["type"])
+	assert.Equal(t, "public.customers", res["name"])
+	match := res["matches"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "field", match["field"])
+	assert.Equal(t, "customer_email", match["content"])
+
+	code, body = get(t, r, "/api/v1/search?q=from%20orders")
+	assert.Equal(t, 200, code)
+	require.Len(t, body["data"], 1)
+	assert.Equal(t, "monthly_rollup", body["data"].([]interface{})[0].(map[string]interface{})["name"])
+
+	code, body = get(t, r, "/api/v1/search?q=finance&type=dataset")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["data"], 0)
+
+	code, body = get(t, r, "/api/v1/search?q=contact")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["data"], 1)
+
+	code, _ = get(t, r, "/api/v1/search?q=100%25")
+	assert.Equal(t, 200, code)
+
+	code, _ = get(t, r, "/api/v1/search")
+	assert.Equal(t, 400, code)
+}
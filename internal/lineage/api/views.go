@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"oplin/internal/lineage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The types in this file are the JSON representations returned by the read
+// API. Resources are identified by namespace and name, versions and runs by
+// uuid, so the internal ids never leak out.
+
+type Namespace struct {
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type DatasetID struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type JobID struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+type Dataset struct {
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt *time.Time  `json:"updatedAt,omitempty"`
+	Fields    []Field     `json:"fields,omitempty"`
+	Aliases   []DatasetID `json:"aliases,omitempty"`
+}
+
+type SchemaChange struct {
+	Field               string `json:"field"`
+	Change              string `json:"change"`
+	PreviousType        string `json:"previousType,omitempty"`
+	Type                string `json:"type,omitempty"`
+	PreviousDescription string `json:"previousDescription,omitempty"`
+	Description         string `json:"description,omitempty"`
+}
+
+type DatasetVersion struct {
+	Version      uuid.UUID      `json:"version"`
+	Namespace    string         `json:"namespace"`
+	Name         string         `json:"name"`
+	CreatedByRun *uuid.UUID     `json:"createdByRun,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    *time.Time     `json:"updatedAt,omitempty"`
+	Fields       []Field        `json:"fields"`
+	Changes      []SchemaChange `json:"changes"`
+}
+
+type Job struct {
+	Namespace   string     `json:"namespace"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+type JobVersion struct {
+	Version   uuid.UUID  `json:"version"`
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type Run struct {
+	RunID            uuid.UUID   `json:"runId"`
+	Job              JobID       `json:"job"`
+	ParentRunID      *uuid.UUID  `json:"parentRunId,omitempty"`
+	State            string      `json:"state"`
+	Placeholder      bool        `json:"placeholder,omitempty"`
+	StartedAt        *time.Time  `json:"startedAt,omitempty"`
+	EndedAt          *time.Time  `json:"endedAt,omitempty"`
+	DurationMs       *int64      `json:"durationMs,omitempty"`
+	NominalStartTime *time.Time  `json:"nominalStartTime,omitempty"`
+	NominalEndTime   *time.Time  `json:"nominalEndTime,omitempty"`
+	ErrorMessage     string      `json:"errorMessage,omitempty"`
+	Inputs           []DatasetID `json:"inputs,omitempty"`
+	Outputs          []DatasetID `json:"outputs,omitempty"`
+	CreatedAt        time.Time   `json:"createdAt"`
+	UpdatedAt        *time.Time  `json:"updatedAt,omitempty"`
+}
+
+// Facet is a facet as it was sent, keyed by name with the producer and
+// schema url it declared
+type Facet struct {
+	Name      string          `json:"name"`
+	Producer  string          `json:"producer,omitempty"`
+	SchemaURL string          `json:"schemaURL,omitempty"`
+	Facet     json.RawMessage `json:"facet"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt *time.Time      `json:"updatedAt,omitempty"`
+}
+
+type SearchMatch struct {
+	Field   string `json:"field"`
+	Content string `json:"content"`
+}
+
+// SearchResult is a dataset or job found by search with the texts that
+// matched
+type SearchResult struct {
+	Type      string        `json:"type"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt *time.Time    `json:"updatedAt,omitempty"`
+	Matches   []SearchMatch `json:"matches"`
+}
+
+// Request is an event received by the lineage API with where it is in the
+// ingestion queue
+type Request struct {
+	ID            int64      `json:"id"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	RunEventID    int64      `json:"runEventId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ProcessedAt   *time.Time `json:"processedAt,omitempty"`
+}
+
+// optionalTime returns nil for the zero time so it is omitted from the JSON
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toFields(fields []lineage.Field) []Field {
+	res := []Field{}
+	for _, f := range fields {
+		res = append(res, Field{Name: f.Name, Type: f.DataType, Description: f.Description})
+	}
+	return res
+}
+
+func toSchemaChanges(changes []lineage.SchemaChange) []SchemaChange {
+	res := []SchemaChange{}
+	for _, c := range changes {
+		res = append(res, SchemaChange{
+			Field:               c.FieldName,
+			Change:              c.ChangeType.String(),
+			PreviousType:        c.PreviousDataType,
+			Type:                c.DataType,
+			PreviousDescription: c.PreviousDescription,
+			Description:         c.Description,
+		})
+	}
+	return res
+}
+
+func toDataset(ds lineage.DatasetWithNamespace) Dataset {
+	return Dataset{
+		Namespace: ds.DatasetNamespace.Name,
+		Name:      ds.Dataset.Name,
+		CreatedAt: ds.Dataset.CreatedAt,
+		UpdatedAt: optionalTime(ds.Dataset.UpdatedAt),
+	}
+}
+
+func toJob(jns lineage.JobWithNamespace) Job {
+	return Job{
+		Namespace:   jns.JobNamespace.Name,
+		Name:        jns.Job.Name,
+		Description: jns.Job.Facets.Documentation.Description,
+		CreatedAt:   jns.Job.CreatedAt,
+		UpdatedAt:   optionalTime(jns.Job.UpdatedAt),
+	}
+}
+
+func toRun(run lineage.Run, job JobID) Run {
+	res := Run{
+		RunID:            run.RunUUID,
+		Job:              job,
+		State:            run.State.String(),
+		Placeholder:      run.IsPlaceholder,
+		StartedAt:        optionalTime(run.StartedAt),
+		EndedAt:          optionalTime(run.EndedAt),
+		NominalStartTime: optionalTime(run.NominalStartedAt),
+		NominalEndTime:   optionalTime(run.NominalEndedAt),
+		ErrorMessage:     run.ErrorMessage,
+		CreatedAt:        run.CreatedAt,
+		UpdatedAt:        optionalTime(run.UpdatedAt),
+	}
+	if run.State.IsFinished() {
+		ms := run.Duration().Milliseconds()
+		res.DurationMs = &ms
+	}
+	return res
+}
+
+func toFacet(f lineage.Facet) Facet {
+	return Facet{
+		Name:      f.Name,
+		Producer:  f.Producer,
+		SchemaURL: f.SchemaURL,
+		Facet:     f.Payload,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: optionalTime(f.UpdatedAt),
+	}
+}
+
+func toRequest(req lineage.Request) Request {
+	res := Request{
+		ID:          req.ID,
+		Status:      req.Status.String(),
+		Attempts:    req.Attempts,
+		Error:       req.ErrorMessage,
+		RunEventID:  req.RunEventID,
+		CreatedAt:   req.CreatedAt,
+		ProcessedAt: optionalTime(req.ProcessedAt),
+	}
+	if req.Status == lineage.RequestStatusPending {
+		res.NextAttemptAt = optionalTime(req.NextAttemptAt)
+	}
+	return res
+}
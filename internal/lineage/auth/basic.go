@@ -0,0 +1,8 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// Basic protects the UI with a single user and password
+func Basic(user string, password string) gin.HandlerFunc {
+	return gin.BasicAuthForRealm(gin.Accounts{user: password}, "oplin")
+}
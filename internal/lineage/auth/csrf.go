@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	csrfCookie = "oplin_csrf"
+	// CSRFField is the form field the pages send the token back in
+	CSRFField  = "csrf_token"
+	csrfHeader = "X-CSRF-Token"
+	// csrfKey is where CSRF keeps the token of the request
+	csrfKey = "oplin.csrf"
+)
+
+// CSRF refuses requests that change something unless they send back the
+// token of the csrf cookie, in the csrf_token form field or the X-CSRF-Token
+// header. Another site can make the browser send the basic auth credentials
+// or the session cookie, it cannot read the cookie. JSON requests pass as
+// another site cannot send them without a CORS preflight, which is never
+// answered.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := c.Cookie(csrfCookie)
+		if !safeMethod(c.Request.Method) && !isJSON(c.Request) {
+			sent := c.PostForm(CSRFField)
+			if sent == "" {
+				sent = c.GetHeader(csrfHeader)
+			}
+			if err != nil || token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid csrf token"})
+				return
+			}
+		}
+		if err != nil || token == "" {
+			token, err = randomString(24)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   c.Request.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		c.Set(csrfKey, token)
+		c.Next()
+	}
+}
+
+// CSRFToken returns the token the pages put in their forms, empty when the
+// UI has no login to protect
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(csrfKey)
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func isJSON(req *http.Request) bool {
+	t, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && t == "application/json"
+}
@@ -0,0 +1,59 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"oplin/internal/lineage/auth"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.CSRF())
+	r.GET("/lineage/tokens", func(c *gin.Context) {
+		c.String(http.StatusOK, auth.CSRFToken(c))
+	})
+	r.POST("/lineage/tokens", func(c *gin.Context) {
+		c.String(http.StatusOK, "created")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/lineage/tokens", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+	require.Equal(t, 1, len(cookies))
+	token := w.Body.String()
+	assert.Equal(t, cookies[0].Value, token)
+
+	post := func(body string, contentType string, header string, cookies []*http.Cookie) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/lineage/tokens", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	form := "application/x-www-form-urlencoded"
+	withToken := url.Values{auth.CSRFField: {token}, "name": {"airflow"}}.Encode()
+
+	assert.Equal(t, http.StatusForbidden, post("name=airflow", form, "", cookies))
+	assert.Equal(t, http.StatusForbidden, post(withToken, form, "", nil))
+	assert.Equal(t, http.StatusForbidden, post(url.Values{auth.CSRFField: {"other"}}.Encode(), form, "", cookies))
+	assert.Equal(t, http.StatusForbidden, post(`{"name": "airflow"}`, "text/plain", "", cookies))
+	assert.Equal(t, http.StatusOK, post(withToken, form, "", cookies))
+	assert.Equal(t, http.StatusOK, post("name=airflow", form, token, cookies))
+	assert.Equal(t, http.StatusOK, post(`{"name": "airflow"}`, "application/json", "", nil))
+}
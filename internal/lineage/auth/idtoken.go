@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/rotisserie/eris"
+)
+
+// clockSkew is how far the clocks of the issuer and the server may drift
+const clockSkew = time.Minute
+
+// idTokenClaims are the claims of an ID token the login uses, the verifier
+// checks the others
+type idTokenClaims struct {
+	Subject           string `json:"sub"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// displayName is what the UI calls the person signed in
+func (c *idTokenClaims) displayName() string {
+	for _, s := range []string{c.Email, c.PreferredUsername, c.Name} {
+		if s != "" {
+			return s
+		}
+	}
+	return c.Subject
+}
+
+// verifyIDToken checks the ID token with the keys of the issuer, its issuer,
+// audience, expiry and not before time, then the nonce of the login, the
+// issued at time and the authorized party of a token for several clients
+func (o *OIDC) verifyIDToken(ctx context.Context, raw string, nonce string) (*idTokenClaims, error) {
+	tok, err := o.verifier.Verify(oidc.ClientContext(ctx, o.client), raw)
+	if err != nil {
+		return nil, eris.Wrap(err, "invalid id token")
+	}
+	if tok.Nonce != nonce {
+		return nil, eris.New("id token nonce does not match")
+	}
+	if tok.IssuedAt.After(time.Now().Add(clockSkew)) {
+		return nil, fmt.Errorf("id token issued in the future at[%s]", tok.IssuedAt)
+	}
+
+	claims := &idTokenClaims{}
+	if err := tok.Claims(claims); err != nil {
+		return nil, eris.Wrap(err, "invalid id token claims")
+	}
+	if (len(tok.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != o.cfg.ClientID {
+		return nil, fmt.Errorf("id token is authorized for[%s] not client[%s]", claims.AuthorizedParty, o.cfg.ClientID)
+	}
+	return claims, nil
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+	"golang.org/x/oauth2"
+)
+
+const (
+	sessionCookie = "oplin_session"
+	stateCookie   = "oplin_oidc_state"
+	// sessionKey is where RequireLogin keeps the session of the request
+	sessionKey = "oplin.session"
+)
+
+// loginTimeout is how long the issuer has to send the browser back
+const loginTimeout = 10 * time.Minute
+
+// OIDCConfig configures the UI login with an OpenID Connect issuer using the
+// authorization code flow
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the issuer, it ends
+	// with /auth/callback
+	RedirectURL string
+	// SessionKey signs the session cookies, replicas must share it
+	SessionKey []byte
+	SessionTTL time.Duration
+	// Client talks to the issuer, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// OIDC signs people in to the UI with an OpenID Connect issuer
+type OIDC struct {
+	cfg      OIDCConfig
+	codec    cookieCodec
+	client   *http.Client
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	secure   bool
+}
+
+// loginState follows the browser to the issuer and back
+type loginState struct {
+	State   string `json:"state"`
+	Nonce   string `json:"nonce"`
+	Next    string `json:"next"`
+	Expires int64  `json:"exp"`
+}
+
+// NewOIDC discovers the endpoints and the keys of the issuer
+func NewOIDC(ctx context.Context, cfg OIDCConfig) (*OIDC, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, eris.New("oidc needs an issuer, a client id and a redirect url")
+	}
+	if len(cfg.SessionKey) == 0 {
+		key, err := randomString(32)
+		if err != nil {
+			return nil, eris.Wrap(err, "could not generate a session key")
+		}
+		cfg.SessionKey = []byte(key)
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = 12 * time.Hour
+	}
+	o := &OIDC{
+		cfg:    cfg,
+		codec:  cookieCodec{key: cfg.SessionKey},
+		client: cfg.Client,
+		secure: strings.HasPrefix(cfg.RedirectURL, "https://"),
+	}
+	if o.client == nil {
+		o.client = http.DefaultClient
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, o.client), cfg.Issuer)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not discover issuer[%s]", cfg.Issuer)
+	}
+	o.oauth = oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+	o.verifier = provider.Verifier(&oidc.Config{ClientID: cfg.ClientID})
+	return o, nil
+}
+
+// Register adds the login, callback and logout routes
+func (o *OIDC) Register(r gin.IRoutes) {
+	r.GET("/auth/login", o.MakeLogin())
+	r.GET("/auth/callback", o.MakeCallback())
+	r.GET("/auth/logout", o.MakeLogout())
+}
+
+// RequireLogin sends people without a session to the issuer, requests other
+// than GET are refused
+func (o *OIDC) RequireLogin() gin.HandlerFunc {
+	return o.requireSession(true)
+}
+
+// RequireSession refuses requests without a session, the API answers with a
+// 401 instead of sending programs to the issuer
+func (o *OIDC) RequireSession() gin.HandlerFunc {
+	return o.requireSession(false)
+}
+
+func (o *OIDC) requireSession(redirect bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s, ok := o.session(c); ok {
+			c.Set(sessionKey, s)
+			c.Next()
+			return
+		}
+		if !redirect || c.Request.Method != http.MethodGet {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "login required"})
+			return
+		}
+		c.Redirect(http.StatusFound, "/auth/login?next="+url.QueryEscape(c.Request.URL.RequestURI()))
+		c.Abort()
+	}
+}
+
+func (o *OIDC) session(c *gin.Context) (*Session, bool) {
+	cookie, err := c.Cookie(sessionCookie)
+	if err != nil {
+		return nil, false
+	}
+	s := &Session{}
+	if err := o.codec.decode(cookie, s); err != nil {
+		return nil, false
+	}
+	return s, time.Now().Unix() < s.Expires
+}
+
+func (o *OIDC) setCookie(c *gin.Context, name string, value string, ttl time.Duration) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// MakeLogin sends the browser to the issuer
+func (o *OIDC) MakeLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, err := randomString(24)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		nonce, err := randomString(24)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		value, err := o.codec.encode(loginState{
+			State:   state,
+			Nonce:   nonce,
+			Next:    c.Query("next"),
+			Expires: time.Now().Add(loginTimeout).Unix(),
+		})
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		o.setCookie(c, stateCookie, value, loginTimeout)
+		c.Redirect(http.StatusFound, o.oauth.AuthCodeURL(state, oidc.Nonce(nonce)))
+	}
+}
+
+// MakeCallback exchanges the code sent back by the issuer for an ID token
+// and starts a session
+func (o *OIDC) MakeCallback() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		if e := c.Query("error"); e != "" {
+			c.String(http.StatusUnauthorized, "login failed: %s %s", e, c.Query("error_description"))
+			return
+		}
+		cookie, err := c.Cookie(stateCookie)
+		var st loginState
+		if err == nil {
+			err = o.codec.decode(cookie, &st)
+		}
+		if err != nil || st.State != c.Query("state") || time.Now().Unix() > st.Expires {
+			c.String(http.StatusBadRequest, "login expired, try again")
+			return
+		}
+		o.setCookie(c, stateCookie, "", -time.Second)
+
+		claims, err := o.exchange(ctx, c.Query("code"), st.Nonce)
+		if err != nil {
+			c.Error(err)
+			c.String(http.StatusUnauthorized, "login failed")
+			return
+		}
+		value, err := o.codec.encode(Session{
+			Subject: claims.Subject,
+			Name:    claims.displayName(),
+			Expires: time.Now().Add(o.cfg.SessionTTL).Unix(),
+		})
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		o.setCookie(c, sessionCookie, value, o.cfg.SessionTTL)
+		c.Redirect(http.StatusSeeOther, safeNext(st.Next))
+	}
+}
+
+// MakeLogout ends the session
+func (o *OIDC) MakeLogout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		o.setCookie(c, sessionCookie, "", -time.Second)
+		c.String(http.StatusOK, "signed out")
+	}
+}
+
+// exchange redeems the code at the token endpoint and verifies the ID token
+func (o *OIDC) exchange(ctx context.Context, code string, nonce string) (*idTokenClaims, error) {
+	tok, err := o.oauth.Exchange(oidc.ClientContext(ctx, o.client), code)
+	if err != nil {
+		return nil, eris.Wrap(err, "token request failed")
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, eris.New("token response has no id token")
+	}
+	return o.verifyIDToken(ctx, raw, nonce)
+}
+
+// safeNext keeps the redirect after login on this site
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// SessionFromContext returns the session of the request, nil when the UI
+// does not use OIDC
+func SessionFromContext(c *gin.Context) *Session {
+	v, ok := c.Get(sessionKey)
+	if !ok {
+		return nil
+	}
+	s, _ := v.(*Session)
+	return s
+}
@@ -0,0 +1,222 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"oplin/internal/lineage/auth"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuer is an OpenID Connect issuer that signs in everyone as alice
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	nonces map[string]string
+	// badNonce makes the issuer sign ID tokens for another nonce
+	badNonce bool
+	// sharedAudience makes the issuer sign ID tokens for another client too,
+	// without saying which one asked for it
+	sharedAudience bool
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	m := &mockIssuer{key: key, nonces: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"n":   enc.EncodeToString(key.N.Bytes()),
+				"e":   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		m.mu.Lock()
+		m.nonces["code-1"] = q.Get("nonce")
+		m.mu.Unlock()
+		back := q.Get("redirect_uri") + "?" + url.Values{"code": {"code-1"}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, back, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "oplin" || secret != "shh" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		m.mu.Lock()
+		nonce, ok := m.nonces[r.PostFormValue("code")]
+		if m.badNonce {
+			nonce = "other"
+		}
+		var aud interface{} = "oplin"
+		if m.sharedAudience {
+			aud = []string{"oplin", "other"}
+		}
+		m.mu.Unlock()
+		if !ok {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-1",
+			"token_type":   "Bearer",
+			"id_token": m.sign(t, map[string]interface{}{
+				"iss":   m.URL,
+				"sub":   "alice",
+				"aud":   aud,
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"iat":   time.Now().Unix(),
+				"nonce": nonce,
+				"email": "alice@example.com",
+			}),
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+func (m *mockIssuer) sign(t *testing.T, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	msg := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(msg))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	require.Nil(t, err)
+	return msg + "." + enc.EncodeToString(sig)
+}
+
+func TestOIDC(t *testing.T) {
+	issuer := newMockIssuer(t)
+	defer issuer.Close()
+
+	o, err := auth.NewOIDC(context.Background(), auth.OIDCConfig{
+		Issuer:       issuer.URL,
+		ClientID:     "oplin",
+		ClientSecret: "shh",
+		RedirectURL:  "http://oplin.test/auth/callback",
+		SessionKey:   []byte("test"),
+	})
+	require.Nil(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	o.Register(r)
+	r.GET("/lineage/datasets", o.RequireLogin(), func(c *gin.Context) {
+		c.String(http.StatusOK, auth.SessionFromContext(c).Name)
+	})
+	r.POST("/lineage/tokens", o.RequireLogin(), func(c *gin.Context) {
+		c.String(http.StatusOK, "created")
+	})
+	r.GET("/api/v1/namespaces", o.RequireSession(), func(c *gin.Context) {
+		c.String(http.StatusOK, "namespaces")
+	})
+
+	serve := func(method string, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	// login goes through the issuer and returns the callback path with its
+	// query and the state cookie
+	login := func() (string, []*http.Cookie) {
+		w := serve("GET", "/lineage/datasets", nil)
+		require.Equal(t, http.StatusFound, w.Code)
+		w = serve("GET", w.Header().Get("Location"), nil)
+		require.Equal(t, http.StatusFound, w.Code)
+		resp, err := noRedirect.Get(w.Header().Get("Location"))
+		require.Nil(t, err)
+		resp.Body.Close()
+		back, err := url.Parse(resp.Header.Get("Location"))
+		require.Nil(t, err)
+		return back.RequestURI(), w.Result().Cookies()
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/lineage/tokens", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/api/v1/namespaces", nil).Code)
+
+	callback, state := login()
+	assert.Equal(t, http.StatusBadRequest, serve("GET", callback, nil).Code)
+	w := serve("GET", callback, state)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/lineage/datasets", w.Header().Get("Location"))
+	session := w.Result().Cookies()
+
+	w = serve("GET", "/lineage/datasets", session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice@example.com", w.Body.String())
+	assert.Equal(t, http.StatusOK, serve("POST", "/lineage/tokens", session).Code)
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/namespaces", session).Code)
+
+	forged := []*http.Cookie{{Name: session[len(session)-1].Name, Value: session[len(session)-1].Value + "x"}}
+	assert.Equal(t, http.StatusFound, serve("GET", "/lineage/datasets", forged).Code)
+
+	w = serve("GET", "/auth/logout", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	issuer.mu.Lock()
+	issuer.badNonce = true
+	issuer.mu.Unlock()
+	callback, state = login()
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", callback, state).Code)
+
+	issuer.mu.Lock()
+	issuer.badNonce = false
+	issuer.sharedAudience = true
+	issuer.mu.Unlock()
+	callback, state = login()
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", callback, state).Code)
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	issuer := newMockIssuer(t)
+	defer issuer.Close()
+
+	_, err := auth.NewOIDC(context.Background(), auth.OIDCConfig{
+		Issuer:      issuer.URL + "/other",
+		ClientID:    "oplin",
+		RedirectURL: "http://oplin.test/auth/callback",
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// errInvalidCookie is returned for a cookie that was not signed with the key
+var errInvalidCookie = errors.New("invalid cookie")
+
+// Session is who signed in to the UI, it is kept in a signed cookie
+type Session struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	Expires int64  `json:"exp"`
+}
+
+// cookieCodec signs cookie values with HMAC-SHA256 so they cannot be forged,
+// they are not encrypted
+type cookieCodec struct {
+	key []byte
+}
+
+func (cc cookieCodec) sign(msg string) string {
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write([]byte(msg))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (cc cookieCodec) encode(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	msg := base64.RawURLEncoding.EncodeToString(b)
+	return msg + "." + cc.sign(msg), nil
+}
+
+func (cc cookieCodec) decode(s string, v interface{}) error {
+	msg, sig, ok := strings.Cut(s, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(cc.sign(msg))) {
+		return errInvalidCookie
+	}
+	b, err := base64.RawURLEncoding.DecodeString(msg)
+	if err != nil {
+		return errInvalidCookie
+	}
+	return json.Unmarshal(b, v)
+}
+
+// randomString returns n random bytes encoded for urls
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
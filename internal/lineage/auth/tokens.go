@@ -0,0 +1,92 @@
+// Package auth authenticates producers sending events with API tokens and
+// people using the UI with OIDC or basic auth
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Deps interface {
+	GetStore() store.Store
+}
+
+// tokenKey is where RequireToken keeps the token of the request
+const tokenKey = "oplin.apiToken"
+
+// NoAuth lets every request through
+func NoAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
+
+// RequireToken refuses requests without a valid API token sent as
+// Authorization: Bearer <token>, the token is kept for TokenFromContext
+func RequireToken(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			unauthorized(c, errors.New("missing bearer token"))
+			return
+		}
+		tok, err := ops.AuthenticateAPIToken(ctx, deps, strings.TrimSpace(token))
+		if errors.Is(err, ops.ErrInvalidAPIToken) {
+			unauthorized(c, err)
+			return
+		}
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(tokenKey, tok)
+		c.Next()
+	}
+}
+
+// TokenOrLogin lets requests with a valid API token through and sends the
+// others to the login, so programs and people signed in to the UI can both
+// read the lineage
+func TokenOrLogin(deps Deps, login gin.HandlerFunc) gin.HandlerFunc {
+	requireToken := RequireToken(deps)
+	return func(c *gin.Context) {
+		if strings.HasPrefix(strings.ToLower(c.GetHeader("Authorization")), "bearer ") {
+			requireToken(c)
+			return
+		}
+		login(c)
+	}
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="oplin"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// TokenFromContext returns the API token of the request, nil when tokens
+// are not required
+func TokenFromContext(c *gin.Context) *lineage.APIToken {
+	v, ok := c.Get(tokenKey)
+	if !ok {
+		return nil
+	}
+	tok, _ := v.(*lineage.APIToken)
+	return tok
+}
+
+// TokenAllows is true when the request may send an event with the producer
+// and the namespace, always when tokens are not required
+func TokenAllows(c *gin.Context, producer string, namespace string) bool {
+	tok := TokenFromContext(c)
+	return tok == nil || tok.Allows(producer, namespace)
+}
@@ -0,0 +1,80 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"oplin/internal/lineage/auth"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireToken(t *testing.T) {
+	ctx := context.Background()
+	deps := &ops.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(ctx, deps)
+	require.Nil(t, err)
+
+	tok, secret, err := ops.CreateAPIToken(ctx, deps, "airflow", "https://airflow", "")
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(secret, tok.Prefix))
+	assert.True(t, tok.LastUsedAt.IsZero())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/events", auth.RequireToken(deps), func(c *gin.Context) {
+		c.String(http.StatusOK, "%s %v %v", auth.TokenFromContext(c).Name,
+			auth.TokenAllows(c, "https://airflow", "any"), auth.TokenAllows(c, "https://spark", "any"))
+	})
+	post := func(authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("").Code)
+	assert.Equal(t, http.StatusUnauthorized, post("Basic "+secret).Code)
+	assert.Equal(t, http.StatusUnauthorized, post("Bearer "+secret+"x").Code)
+
+	w := post("bearer " + secret)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "airflow true false", w.Body.String())
+
+	tokens, err := ops.ListAPITokens(ctx, deps)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tokens))
+	assert.False(t, tokens[0].LastUsedAt.IsZero())
+
+	err = ops.RevokeAPIToken(ctx, deps, tok.ID)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, post("Bearer "+secret).Code)
+	assert.NotNil(t, ops.RevokeAPIToken(ctx, deps, tok.ID))
+}
+
+func TestBasic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", auth.Basic("admin", "secret"), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req.SetBasicAuth("admin", "secret")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Dialect is the flavour of SQL spoken by the database the queries run on
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// NewWithDialect returns the queries for a database of the dialect. The
+// queries are written for Postgres and rewritten for SQLite.
+func NewWithDialect(db DBTX, dialect Dialect) *Queries {
+	if dialect == DialectSQLite {
+		return New(&sqliteDBTX{db: db})
+	}
+	return New(db)
+}
+
+var (
+	sqliteSchemaRegexp = regexp.MustCompile(`\blineage\.`)
+	sqliteILikeRegexp  = regexp.MustCompile(`(?i)\bilike\b`)
+	sqliteLockRegexp   = regexp.MustCompile(`(?i)\s*\bfor update( skip locked)?\b`)
+	sqliteJSONRegexp   = regexp.MustCompile(`(?i)\bjson_array_elements_text\b`)
+	sqliteQueries      sync.Map
+)
+
+// ToSQLite rewrites a Postgres query for SQLite, SQLite has no schemas and
+// its like is already case insensitive for ascii. Row locks are dropped: the
+// store begins every SQLite transaction immediate, which takes the write lock
+// of the whole database, so a worker claiming a queued request waits for the
+// others instead of skipping the rows they locked. The elements of a JSON
+// array, such as a list of ids, are read with json_each. String literals,
+// quoted identifiers and comments are left as they are.
+func ToSQLite(query string) string {
+	if q, ok := sqliteQueries.Load(query); ok {
+		return q.(string)
+	}
+	var b strings.Builder
+	code := 0
+	for i := 0; i < len(query); {
+		end := sqlTokenEnd(query, i)
+		if end == i {
+			i++
+			continue
+		}
+		b.WriteString(rewriteSQLiteCode(query[code:i]))
+		b.WriteString(query[i:end])
+		i, code = end, end
+	}
+	b.WriteString(rewriteSQLiteCode(query[code:]))
+	q := b.String()
+	sqliteQueries.Store(query, q)
+	return q
+}
+
+// sqlTokenEnd returns the end of the string literal, quoted identifier or
+// comment starting at i, i when none starts there
+func sqlTokenEnd(query string, i int) int {
+	switch {
+	case query[i] == '\'' || query[i] == '"':
+		// a doubled quote escapes the quote
+		for j := i + 1; j < len(query); j++ {
+			if query[j] != query[i] {
+				continue
+			}
+			if j+1 < len(query) && query[j+1] == query[i] {
+				j++
+				continue
+			}
+			return j + 1
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "--"):
+		if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "/*"):
+		if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+			return i + 2 + j + 2
+		}
+		return len(query)
+	}
+	return i
+}
+
+func rewriteSQLiteCode(code string) string {
+	code = sqliteSchemaRegexp.ReplaceAllString(code, "")
+	code = sqliteILikeRegexp.ReplaceAllString(code, "like")
+	code = sqliteJSONRegexp.ReplaceAllString(code, "json_each")
+	return sqliteLockRegexp.ReplaceAllString(code, "")
+}
+
+// sqliteDBTX rewrites the queries before handing them to SQLite
+type sqliteDBTX struct {
+	db DBTX
+}
+
+func (d *sqliteDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.db.ExecContext(ctx, ToSQLite(query), args...)
+}
+
+func (d *sqliteDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, ToSQLite(query))
+}
+
+func (d *sqliteDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, ToSQLite(query), args...)
+}
+
+func (d *sqliteDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRowContext(ctx, ToSQLite(query), args...)
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSQLite(t *testing.T) {
+	q := `select * from lineage.requests r
+where r.name ilike $1 and payload->>'lineage.name' = 'for update' -- lineage.x ilike
+  and "lineage.ilike" is not null
+for update skip locked`
+	assert.Equal(t, `select * from requests r
+where r.name like $1 and payload->>'lineage.name' = 'for update' -- lineage.x ilike
+  and "lineage.ilike" is not null`, db.ToSQLite(q))
+	assert.Equal(t, `select 'it''s lineage.x' from jobs`, db.ToSQLite(`select 'it''s lineage.x' from lineage.jobs`))
+	assert.Equal(t, `select * from jobs where id in (select cast(value as bigint) from json_each($1))`,
+		db.ToSQLite(`select * from lineage.jobs where id in (select cast(value as bigint) from json_array_elements_text($1))`))
+}
+
+// TestQueriesOnSQLite prepares every generated query rewritten for SQLite
+// against the migrated schema
+func TestQueriesOnSQLite(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "query.sql.go", nil, 0)
+	require.Nil(t, err)
+	st, err := store.OpenSQLiteMemory()
+	require.Nil(t, err)
+	defer st.Close()
+
+	count := 0
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			lit, ok := vs.Values[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			query, err := strconv.Unquote(lit.Value)
+			require.Nil(t, err)
+			stmt, err := st.DB.PrepareContext(context.Background(), db.ToSQLite(query))
+			if assert.Nil(t, err, vs.Names[0].Name) {
+				stmt.Close()
+			}
+			count++
+		}
+	}
+	assert.Greater(t, count, 100)
+}
@@ -1,7 +1,16 @@
+drop table if exists schema_migrations;
+drop table if exists lineage.request_prunes;
+drop table if exists lineage.api_tokens;
+drop table if exists lineage.failed_events;
 drop table if exists lineage.requests;
+drop table if exists lineage.search_documents;
+drop table if exists lineage.facets;
+drop table if exists lineage.dataset_aliases;
 drop table if exists lineage.lifecycle_state_changes;
+drop table if exists lineage.schema_changes;
 drop table if exists lineage.fields;
 drop table if exists lineage.column_lineages;
+drop table if exists lineage.job_version_io_datasets;
 drop table if exists lineage.run_dataset_versions;
 drop table if exists lineage.dataset_versions;
 drop table if exists lineage.datasets;
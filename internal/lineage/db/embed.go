@@ -1,11 +1,11 @@
 package db
 
 import (
-	_ "embed"
+	"embed"
 )
 
-//go:embed schema.sql
-var SchemaSQL string
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var MigrationFS embed.FS
 
 //go:embed drop.sql
 var DropSQL string
@@ -9,41 +9,60 @@ import (
 	"github.com/rotisserie/eris"
 )
 
-func InitializeDB(ctx context.Context, db *sql.DB) error {
-	sql := "SELECT exists(select schema_name FROM information_schema.schemata WHERE schema_name = 'lineage');"
-	rows, err := db.Query(sql)
+// InitializeDB brings the schema up to date by applying pending migrations
+func InitializeDB(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	_, err := MigrateUp(ctx, db, dialect)
 	if err != nil {
-		return eris.Wrap(err, "Failed when querying if schema exists")
+		return eris.Wrap(err, "Failed to migrate db")
 	}
-	defer rows.Close()
+	return nil
+}
 
-	var exists bool
-	for rows.Next() {
-		err := rows.Scan(&exists)
-		if err != nil {
-			return eris.Wrap(err, "Failed when scanning if schema exists")
-		}
+func InitializeTestDB(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	var err error
+	if dialect == DialectSQLite {
+		err = dropSQLiteTables(ctx, db)
+	} else {
+		err = utils.RunStatements(db, strings.Split(DropSQL, ";"))
 	}
-	if !exists {
-		stmts := strings.Split(SchemaSQL, ";")
-		err := utils.RunStatements(db, stmts)
-		if err != nil {
-			return eris.Wrap(err, "Failed to initialize db")
-		}
+	if err != nil {
+		return eris.Wrap(err, "Failed to initialize db")
 	}
-	return nil
+	return InitializeDB(ctx, db, dialect)
 }
 
-func InitializeTestDB(ctx context.Context, db *sql.DB) error {
-	stmts := strings.Split(DropSQL, ";")
-	err := utils.RunStatements(db, stmts)
+// dropSQLiteTables drops every table, foreign keys are switched off on the
+// connection so the order does not matter
+func dropSQLiteTables(ctx context.Context, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return eris.Wrap(err, "Failed to initialize db")
+		return err
 	}
-	stmts = strings.Split(SchemaSQL, ";")
-	err = utils.RunStatements(db, stmts)
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, "select name from sqlite_master where type = 'table' and name not like 'sqlite_%'")
 	if err != nil {
-		return eris.Wrap(err, "Failed to initialize db")
+		return err
+	}
+	var stmts []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		stmts = append(stmts, "drop table "+name)
+	}
+	rows.Close()
+
+	if _, err := conn.ExecContext(ctx, "pragma foreign_keys = off"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "pragma foreign_keys = on")
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,289 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// migrationLockID is the key of the advisory lock held while migrating so
+// replicas starting at the same time apply every migration once
+const migrationLockID = 7171414
+
+// ErrMigrationsNotInitialized is returned by MigrationStatuses for a database
+// that has never been migrated
+var ErrMigrationsNotInitialized = errors.New("migrations are not initialised, run oplin migrate up")
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a numbered change of the schema with the statements to apply
+// and to revert it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the embedded migrations of the dialect ordered by
+// version. Every migration must have an up and a down file.
+func LoadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := "migrations/" + string(dialect)
+	entries, err := fs.ReadDir(MigrationFS, dir)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not read migrations for dialect[%s]", dialect)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := migrationFileRegexp.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, eris.Errorf("invalid migration file name[%s]", e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		b, err := MigrationFS.ReadFile(dir + "/" + e.Name())
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not read migration[%s]", e.Name())
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, eris.Errorf("migrations[%s, %s] share version %d", mig.Name, m[2], version)
+		}
+		if m[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+
+	var res []Migration
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, eris.Errorf("migration[%d_%s] needs an up and a down file", mig.Version, mig.Name)
+		}
+		res = append(res, *mig)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// withMigrationLock runs f on a single connection holding the migration
+// lock, SQLite has no advisory locks and is not shared by replicas
+func withMigrationLock(ctx context.Context, db *sql.DB, dialect Dialect, f func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return eris.Wrap(err, "could not get a connection")
+	}
+	defer conn.Close()
+
+	if dialect == DialectPostgres {
+		if _, err := conn.ExecContext(ctx, "select pg_advisory_lock($1)", migrationLockID); err != nil {
+			return eris.Wrap(err, "could not take the migration lock")
+		}
+		defer conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", migrationLockID)
+	}
+
+	_, err = conn.ExecContext(ctx, `create table if not exists schema_migrations (
+  version     bigint primary key,
+  name        varchar not null,
+  applied_at  timestamp not null
+)`)
+	if err != nil {
+		return eris.Wrap(err, "could not create schema_migrations")
+	}
+	if dialect == DialectPostgres {
+		if err := baselineMigrations(ctx, conn); err != nil {
+			return err
+		}
+	}
+	return f(conn)
+}
+
+// baselineMigrations records the initial migration as applied for databases
+// created before there were migrations, they already have the lineage schema
+func baselineMigrations(ctx context.Context, conn *sql.Conn) error {
+	var count int
+	if err := conn.QueryRowContext(ctx, "select count(*) from schema_migrations").Scan(&count); err != nil {
+		return eris.Wrap(err, "could not count migrations")
+	}
+	if count > 0 {
+		return nil
+	}
+	var exists bool
+	err := conn.QueryRowContext(ctx,
+		"select exists(select schema_name from information_schema.schemata where schema_name = 'lineage')").Scan(&exists)
+	if err != nil {
+		return eris.Wrap(err, "Failed when querying if schema exists")
+	}
+	if !exists {
+		return nil
+	}
+	_, err = conn.ExecContext(ctx,
+		"insert into schema_migrations (version, name, applied_at) values (1, 'initial', $1)", time.Now().UTC())
+	if err != nil {
+		return eris.Wrap(err, "could not baseline migrations")
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, eris.Wrap(err, "could not list applied migrations")
+	}
+	defer rows.Close()
+	res := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, eris.Wrap(err, "could not scan applied migration")
+		}
+		res[version] = appliedAt
+	}
+	return res, rows.Err()
+}
+
+// runMigration applies or reverts a migration and records it in one
+// transaction so a failing migration leaves nothing behind
+func runMigration(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return eris.Wrap(err, "begin transaction failed")
+	}
+	defer tx.Rollback()
+
+	stmts, record, args := mig.Down, "delete from schema_migrations where version = $1", []interface{}{mig.Version}
+	if up {
+		stmts = mig.Up
+		record = "insert into schema_migrations (version, name, applied_at) values ($1, $2, $3)"
+		args = append(args, mig.Name, time.Now().UTC())
+	}
+	if _, err := tx.ExecContext(ctx, stmts); err != nil {
+		return eris.Wrapf(err, "migration[%d_%s] failed", mig.Version, mig.Name)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return eris.Wrapf(err, "could not record migration[%d_%s]", mig.Version, mig.Name)
+	}
+	return tx.Commit()
+}
+
+// MigrateUp applies the migrations that have not been applied yet in order and
+// returns them
+func MigrateUp(ctx context.Context, db *sql.DB, dialect Dialect) ([]Migration, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var res []Migration
+	err = withMigrationLock(ctx, db, dialect, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			res = append(res, mig)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// MigrateDown reverts the last steps applied migrations newest first and
+// returns them
+func MigrateDown(ctx context.Context, db *sql.DB, dialect Dialect, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var res []Migration
+	err = withMigrationLock(ctx, db, dialect, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(res) < steps; i-- {
+			mig := migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, mig, false); err != nil {
+				return err
+			}
+			res = append(res, mig)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// MigrationStatuses lists every migration and whether it has been applied.
+// It only reads, a database without schema_migrations is reported with
+// ErrMigrationsNotInitialized instead of being created or baselined.
+func MigrationStatuses(ctx context.Context, db *sql.DB, dialect Dialect) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "could not get a connection")
+	}
+	defer conn.Close()
+
+	exists, err := migrationsTableExists(ctx, conn, dialect)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrMigrationsNotInitialized
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var res []MigrationStatus
+	for _, mig := range migrations {
+		appliedAt, ok := applied[mig.Version]
+		res = append(res, MigrationStatus{Migration: mig, Applied: ok, AppliedAt: appliedAt})
+	}
+	return res, nil
+}
+
+func migrationsTableExists(ctx context.Context, conn *sql.Conn, dialect Dialect) (bool, error) {
+	query := "select to_regclass('schema_migrations') is not null"
+	if dialect != DialectPostgres {
+		query = "select count(*) > 0 from sqlite_master where type = 'table' and name = 'schema_migrations'"
+	}
+	var exists bool
+	if err := conn.QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return false, eris.Wrap(err, "could not look up schema_migrations")
+	}
+	return exists, nil
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
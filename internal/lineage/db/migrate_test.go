@@ -0,0 +1,21 @@
+package db_test
+
+import (
+	"oplin/internal/lineage/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []db.Dialect{db.DialectPostgres, db.DialectSQLite} {
+		migrations, err := db.LoadMigrations(dialect)
+		assert.Nil(t, err)
+		assert.Equal(t, "0001_initial", migrations[0].String())
+		for i, m := range migrations {
+			assert.Equal(t, int64(i+1), m.Version)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+	}
+}
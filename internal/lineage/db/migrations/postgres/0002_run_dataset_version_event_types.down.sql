@@ -0,0 +1,4 @@
+alter table lineage.run_dataset_versions
+  drop column first_event_type,
+  drop column last_event_type,
+  drop column updated_at;
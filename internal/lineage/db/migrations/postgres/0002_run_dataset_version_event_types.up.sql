@@ -0,0 +1,4 @@
+alter table lineage.run_dataset_versions
+  add column first_event_type int not null default 0,
+  add column last_event_type  int not null default 0,
+  add column updated_at       timestamp;
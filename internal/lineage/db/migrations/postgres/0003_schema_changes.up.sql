@@ -0,0 +1,18 @@
+create table lineage.schema_changes (
+  id                            bigserial primary key,
+  dataset_version_id            bigint not null,
+  previous_version_id           bigint not null,
+  change_type                   int not null, -- ADDED|REMOVED|CHANGED
+  field_name                    varchar not null,
+  previous_data_type            varchar,
+  data_type                     varchar,
+  previous_description          varchar,
+  description                   varchar,
+  created_at                    timestamp not null,
+  constraint     
+    fk_dataset_version_id foreign key(dataset_version_id) 
+      references lineage.dataset_versions(id),
+  constraint     
+    fk_previous_version_id foreign key(previous_version_id) 
+      references lineage.dataset_versions(id)
+);
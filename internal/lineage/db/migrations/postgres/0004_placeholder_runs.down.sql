@@ -0,0 +1,2 @@
+alter table lineage.runs
+  drop column is_placeholder;
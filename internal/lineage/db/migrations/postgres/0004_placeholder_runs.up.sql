@@ -0,0 +1,2 @@
+alter table lineage.runs
+  add column is_placeholder boolean not null default false;
@@ -0,0 +1 @@
+drop table if exists lineage.job_version_io_datasets;
@@ -0,0 +1,14 @@
+create table lineage.job_version_io_datasets (
+  job_version_id         bigint not null,
+  dataset_id             bigint not null,
+  io_type                int not null, -- INPUT|OUTPUT
+  io_facets              jsonb,
+  created_at             timestamp not null,
+  primary key (job_version_id, dataset_id, io_type),
+  constraint     
+    fk_job_version_id foreign key(job_version_id) 
+      references lineage.job_versions(id),
+  constraint     
+    fk_dataset_id foreign key(dataset_id) 
+      references lineage.datasets(id)
+);
@@ -0,0 +1,12 @@
+create table lineage.facets (
+  id              bigserial primary key,
+  entity_type     int not null, -- JOB|RUN|DATASET
+  entity_id       bigint not null,
+  name            varchar(255) not null,
+  producer        varchar,
+  schema_url      varchar,
+  payload         jsonb not null,
+  created_at      timestamp not null,
+  updated_at      timestamp,
+  unique(entity_type, entity_id, name)
+);
@@ -0,0 +1,6 @@
+alter table lineage.lifecycle_state_changes
+  drop constraint fk_previous_dataset_id,
+  drop constraint fk_run_id,
+  drop column previous_dataset_id,
+  drop column run_id,
+  add column data_type varchar not null default '';
@@ -0,0 +1,11 @@
+-- data_type was never written
+alter table lineage.lifecycle_state_changes
+  drop column data_type,
+  add column run_id              bigint,
+  add column previous_dataset_id bigint,
+  add constraint
+    fk_run_id foreign key(run_id)
+      references lineage.runs(id),
+  add constraint
+    fk_previous_dataset_id foreign key(previous_dataset_id)
+      references lineage.datasets(id);
@@ -0,0 +1 @@
+drop table if exists lineage.dataset_aliases;
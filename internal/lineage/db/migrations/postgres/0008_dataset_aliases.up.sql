@@ -0,0 +1,13 @@
+create table lineage.dataset_aliases (
+  id                bigserial primary key,
+  namespace         varchar(255) not null,
+  name              varchar(255) not null,
+  dataset_id        bigint not null,
+  is_manual         boolean not null default false,
+  created_at        timestamp not null,
+  updated_at        timestamp,
+  unique(namespace, name),
+  constraint
+    fk_dataset_id foreign key(dataset_id)
+      references lineage.datasets(id)
+);
@@ -0,0 +1,16 @@
+create extension if not exists pg_trgm;
+
+create table lineage.search_documents (
+  id              bigserial primary key,
+  entity_type     int not null, -- JOB|DATASET
+  entity_id       bigint not null,
+  field           varchar(255) not null, -- name|namespace|field|description|sql|owner
+  content         varchar not null,
+  created_at      timestamp not null
+);
+
+create index search_documents_entity_idx
+  on lineage.search_documents(entity_type, entity_id);
+
+create index search_documents_content_idx
+  on lineage.search_documents using gin (content gin_trgm_ops);
@@ -0,0 +1,3 @@
+alter table lineage.runs
+  drop column state,
+  drop column last_event_time;
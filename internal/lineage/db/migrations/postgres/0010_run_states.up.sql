@@ -0,0 +1,17 @@
+alter table lineage.runs
+  add column state int not null default 1, -- NEW|RUNNING|COMPLETED|ABORTED|FAILED
+  add column last_event_time timestamp;
+
+update lineage.runs set last_event_time = (
+  select max(e.event_time) from lineage.run_events e where e.run_id = runs.id
+);
+
+update lineage.runs set state = case
+  when last_event_type in (1, 2) then 2
+  when last_event_type in (3, 4, 5) then last_event_type
+  else 1
+end;
+
+-- runs that failed or were aborted never got an end time
+update lineage.runs set ended_at = last_event_time
+where state in (4, 5) and ended_at is null;
@@ -0,0 +1,6 @@
+drop index if exists lineage.run_events_idempotency_key_idx;
+drop index if exists lineage.run_events_payload_hash_idx;
+
+alter table lineage.run_events
+  drop column payload_hash,
+  drop column idempotency_key;
@@ -0,0 +1,9 @@
+alter table lineage.run_events
+  add column payload_hash varchar(64), -- sha256 of the canonical event
+  add column idempotency_key varchar(255);
+
+create unique index run_events_payload_hash_idx
+  on lineage.run_events(run_id, event_type, event_time, payload_hash);
+
+create unique index run_events_idempotency_key_idx
+  on lineage.run_events(idempotency_key);
@@ -0,0 +1,10 @@
+drop index if exists lineage.requests_pending_idx;
+
+alter table lineage.requests
+  drop column status,
+  drop column attempts,
+  drop column next_attempt_at,
+  drop column error_message,
+  drop column idempotency_key,
+  drop column run_event_id,
+  drop column processed_at;
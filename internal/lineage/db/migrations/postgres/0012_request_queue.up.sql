@@ -0,0 +1,14 @@
+alter table lineage.requests
+  add column status int not null default 2, -- PENDING|PROCESSED|FAILED
+  add column attempts int not null default 0,
+  add column next_attempt_at timestamp,
+  add column error_message varchar,
+  add column idempotency_key varchar(255),
+  add column run_event_id bigint,
+  add column processed_at timestamp;
+
+-- requests received before the queue were recorded as they arrived
+update lineage.requests set attempts = 1, processed_at = created_at;
+
+create index requests_pending_idx
+  on lineage.requests(next_attempt_at, id) where status = 1;
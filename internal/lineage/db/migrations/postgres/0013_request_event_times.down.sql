@@ -0,0 +1,3 @@
+drop index if exists lineage.requests_event_time_idx;
+
+alter table lineage.requests drop column event_time;
@@ -0,0 +1,20 @@
+alter table lineage.requests add column event_time timestamp;
+
+-- stored payloads were never validated, an eventTime that is not a time is
+-- left null rather than failing the migration
+create function pg_temp.try_timestamptz(s text) returns timestamptz as $$
+begin
+  return s::timestamptz;
+exception when others then
+  return null;
+end;
+$$ language plpgsql;
+
+update lineage.requests
+set event_time = pg_temp.try_timestamptz(payload->>'eventTime') at time zone 'UTC'
+where payload->>'eventTime' is not null;
+
+drop function pg_temp.try_timestamptz(text);
+
+create index requests_event_time_idx
+  on lineage.requests(coalesce(event_time, created_at), id);
@@ -0,0 +1,11 @@
+create table lineage.failed_events (
+  id              bigserial primary key,
+  payload         jsonb not null,
+  producer        varchar,
+  error_message   varchar not null,
+  stacktrace      varchar,
+  request_id      bigint, -- the queued request that failed
+  attempts        int not null default 1,
+  created_at      timestamp not null,
+  updated_at      timestamp
+);
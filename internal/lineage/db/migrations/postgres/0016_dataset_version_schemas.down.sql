@@ -0,0 +1,2 @@
+alter table lineage.dataset_versions
+  drop column schema_recorded;
@@ -0,0 +1,7 @@
+-- whether an event recorded the schema of the version, a version without
+-- fields may have had an empty schema or none at all
+alter table lineage.dataset_versions
+  add column schema_recorded boolean not null default false;
+
+update lineage.dataset_versions set schema_recorded = true
+where exists (select 1 from lineage.fields f where f.dataset_version_id = dataset_versions.id);
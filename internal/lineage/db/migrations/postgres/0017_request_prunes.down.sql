@@ -0,0 +1 @@
+drop table lineage.request_prunes;
@@ -0,0 +1,2 @@
+alter table lineage.job_versions drop column version_uuid;
+alter table lineage.dataset_versions drop column version_uuid;
@@ -0,0 +1,10 @@
+-- the versions are addressed by uuid so the ids stay internal
+alter table lineage.dataset_versions add column version_uuid uuid;
+update lineage.dataset_versions set version_uuid = gen_random_uuid();
+alter table lineage.dataset_versions alter column version_uuid set not null;
+create unique index dataset_versions_version_uuid_idx on lineage.dataset_versions(version_uuid);
+
+alter table lineage.job_versions add column version_uuid uuid;
+update lineage.job_versions set version_uuid = gen_random_uuid();
+alter table lineage.job_versions alter column version_uuid set not null;
+create unique index job_versions_version_uuid_idx on lineage.job_versions(version_uuid);
@@ -0,0 +1,5 @@
+drop index if exists lineage.runs_job_version_created_idx;
+drop index if exists lineage.job_versions_job_id_idx;
+drop index if exists lineage.runs_created_idx;
+drop index if exists lineage.jobs_updated_idx;
+drop index if exists lineage.datasets_updated_idx;
@@ -0,0 +1,16 @@
+drop table if exists requests;
+drop table if exists dataset_aliases;
+drop table if exists facets;
+drop table if exists lifecycle_state_changes;
+drop table if exists schema_changes;
+drop table if exists fields;
+drop table if exists job_version_io_datasets;
+drop table if exists run_dataset_versions;
+drop table if exists dataset_versions;
+drop table if exists datasets;
+drop table if exists dataset_namespaces;
+drop table if exists run_events;
+drop table if exists runs;
+drop table if exists job_versions;
+drop table if exists jobs;
+drop table if exists job_namespaces;
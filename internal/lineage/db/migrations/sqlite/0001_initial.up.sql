@@ -0,0 +1,233 @@
+-- SQLite has no schemas, the queries are run with the lineage prefix removed
+
+create table job_namespaces (
+  id             integer primary key autoincrement,
+  name           varchar(255) not null,
+  created_at     timestamp not null,
+  updated_at     timestamp,
+  unique(name)
+);
+
+create table jobs (
+  id                       integer primary key autoincrement,
+  current_version_id       bigint,
+  namespace_id   bigint not null,
+  name           varchar(255) not null,
+  facets         blob,
+  created_at     timestamp not null,
+  updated_at     timestamp,
+  unique(namespace_id, name),
+  constraint
+    fk_namespace foreign key(namespace_id)
+      references job_namespaces(id)
+);
+
+create table job_versions (
+  id             integer primary key autoincrement,
+  job_id         bigint  not null,
+  namespace_id   bigint  not null,
+  name           varchar(255) not null,
+  facets         blob,
+  created_at     timestamp not null,
+  updated_at     timestamp,
+  constraint
+    fk_job_id foreign key(job_id)
+      references jobs(id),
+  constraint
+    fk_namespace foreign key(namespace_id)
+      references job_namespaces(id)
+);
+
+create table runs (
+  id                    integer primary key autoincrement,
+  run_uuid              text      not null,
+  job_version_id        bigint    not null,
+  parent_run_id         bigint,
+  last_event_type       int not null default 0,
+  facets                blob,
+  started_at            timestamp,
+  ended_at              timestamp,
+  nominal_started_at    timestamp,
+  nominal_ended_at      timestamp,
+  error_message         varchar,
+  programming_language  varchar,
+  stacktrace            varchar,
+  created_at            timestamp not null,
+  updated_at            timestamp,
+  is_placeholder        boolean not null default false,
+  unique(run_uuid),
+  constraint
+    fk_job_version_id foreign key(job_version_id)
+      references job_versions(id)
+);
+
+create table run_events (
+  id              integer primary key autoincrement,
+  run_id          bigint       not null,
+  event_type      int          not null default 0,
+  event_time      timestamp    not null,
+  facets          blob,
+  created_at      timestamp not null,
+  updated_at      timestamp,
+  constraint
+    fk_run foreign key(run_id)
+      references runs(id)
+);
+
+create table dataset_namespaces (
+  id             integer primary key autoincrement,
+  name           varchar(255) not null,
+  created_at     timestamp not null,
+  updated_at     timestamp,
+  unique(name)
+);
+
+create table datasets (
+  id                        integer primary key autoincrement,
+  current_version_id        bigint,
+  namespace_id              bigint not null,
+  name                      varchar(255) not null,
+  facets                    blob,
+  created_at                timestamp not null,
+  updated_at                timestamp,
+  unique(namespace_id, name),
+  constraint
+    fk_namespace foreign key(namespace_id)
+      references dataset_namespaces(id)
+);
+
+create table dataset_versions (
+  id             integer primary key autoincrement,
+  dataset_id     bigint  not null,
+  namespace_id   bigint not null,
+  name           varchar(255) not null,
+  created_at     timestamp not null,
+  updated_at     timestamp,
+  constraint
+    fk_dataset_id foreign key(dataset_id)
+      references datasets(id),
+  constraint
+    fk_namespace foreign key(namespace_id)
+      references dataset_namespaces(id)
+);
+
+create table run_dataset_versions (
+  run_id                 bigint not null,
+  dataset_version_id     bigint not null,
+  io_type                int not null, -- INPUT|OUTPUT
+  dataset_facets         blob,
+  io_facets              blob,
+  created_at             timestamp not null,
+  first_event_type       int not null default 0,
+  last_event_type        int not null default 0,
+  updated_at             timestamp,
+  primary key (run_id, dataset_version_id),
+  constraint
+    fk_dataset_version_id foreign key(dataset_version_id)
+      references dataset_versions(id),
+  constraint
+    fk_run_id foreign key(run_id)
+      references runs(id)
+);
+
+create table job_version_io_datasets (
+  job_version_id         bigint not null,
+  dataset_id             bigint not null,
+  io_type                int not null, -- INPUT|OUTPUT
+  io_facets              blob,
+  created_at             timestamp not null,
+  primary key (job_version_id, dataset_id, io_type),
+  constraint
+    fk_job_version_id foreign key(job_version_id)
+      references job_versions(id),
+  constraint
+    fk_dataset_id foreign key(dataset_id)
+      references datasets(id)
+);
+
+create table fields (
+  id                            integer primary key autoincrement,
+  dataset_version_id            bigint not null,
+  name                          varchar not null,
+  data_type                     varchar not null,
+  description                   varchar,
+  created_at                    timestamp not null,
+  updated_at                    timestamp,
+  unique(dataset_version_id, name),
+  constraint
+    fk_dataset_version_id foreign key(dataset_version_id)
+      references dataset_versions(id)
+);
+
+create table schema_changes (
+  id                            integer primary key autoincrement,
+  dataset_version_id            bigint not null,
+  previous_version_id           bigint not null,
+  change_type                   int not null, -- ADDED|REMOVED|CHANGED
+  field_name                    varchar not null,
+  previous_data_type            varchar,
+  data_type                     varchar,
+  previous_description          varchar,
+  description                   varchar,
+  created_at                    timestamp not null,
+  constraint
+    fk_dataset_version_id foreign key(dataset_version_id)
+      references dataset_versions(id),
+  constraint
+    fk_previous_version_id foreign key(previous_version_id)
+      references dataset_versions(id)
+);
+
+create table lifecycle_state_changes (
+  id                            integer primary key autoincrement,
+  dataset_id                    bigint not null,
+  change                        varchar,
+  namespace                     varchar,
+  name                          varchar,
+  created_at                    timestamp not null,
+  updated_at                    timestamp,
+  run_id                        bigint,
+  previous_dataset_id           bigint,
+  constraint
+    fk_dataset_id foreign key(dataset_id)
+      references datasets(id),
+  constraint
+    fk_run_id foreign key(run_id)
+      references runs(id),
+  constraint
+    fk_previous_dataset_id foreign key(previous_dataset_id)
+      references datasets(id)
+);
+
+create table facets (
+  id              integer primary key autoincrement,
+  entity_type     int not null, -- JOB|RUN|DATASET
+  entity_id       bigint not null,
+  name            varchar(255) not null,
+  producer        varchar,
+  schema_url      varchar,
+  payload         blob not null,
+  created_at      timestamp not null,
+  updated_at      timestamp,
+  unique(entity_type, entity_id, name)
+);
+
+create table dataset_aliases (
+  id                integer primary key autoincrement,
+  namespace         varchar(255) not null,
+  name              varchar(255) not null,
+  dataset_id        bigint not null,
+  is_manual         boolean not null default false,
+  created_at        timestamp not null,
+  updated_at        timestamp,
+  unique(namespace, name),
+  constraint
+    fk_dataset_id foreign key(dataset_id)
+      references datasets(id)
+);
+
+create table requests (
+  id              integer primary key autoincrement,
+  payload         blob not null,
+  created_at      timestamp not null
+);
@@ -0,0 +1,11 @@
+create table search_documents (
+  id              integer primary key autoincrement,
+  entity_type     int not null, -- JOB|DATASET
+  entity_id       bigint not null,
+  field           varchar(255) not null, -- name|namespace|field|description|sql|owner
+  content         varchar not null,
+  created_at      timestamp not null
+);
+
+create index search_documents_entity_idx
+  on search_documents(entity_type, entity_id);
@@ -0,0 +1,2 @@
+alter table runs drop column state;
+alter table runs drop column last_event_time;
@@ -0,0 +1,5 @@
+drop index if exists run_events_idempotency_key_idx;
+drop index if exists run_events_payload_hash_idx;
+
+alter table run_events drop column payload_hash;
+alter table run_events drop column idempotency_key;
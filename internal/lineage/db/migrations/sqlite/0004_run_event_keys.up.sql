@@ -0,0 +1,8 @@
+alter table run_events add column payload_hash varchar(64); -- sha256 of the canonical event
+alter table run_events add column idempotency_key varchar(255);
+
+create unique index run_events_payload_hash_idx
+  on run_events(run_id, event_type, event_time, payload_hash);
+
+create unique index run_events_idempotency_key_idx
+  on run_events(idempotency_key);
@@ -0,0 +1,9 @@
+drop index if exists requests_pending_idx;
+
+alter table requests drop column status;
+alter table requests drop column attempts;
+alter table requests drop column next_attempt_at;
+alter table requests drop column error_message;
+alter table requests drop column idempotency_key;
+alter table requests drop column run_event_id;
+alter table requests drop column processed_at;
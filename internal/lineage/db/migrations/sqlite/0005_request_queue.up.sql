@@ -0,0 +1,13 @@
+alter table requests add column status int not null default 2; -- PENDING|PROCESSED|FAILED
+alter table requests add column attempts int not null default 0;
+alter table requests add column next_attempt_at timestamp;
+alter table requests add column error_message varchar;
+alter table requests add column idempotency_key varchar(255);
+alter table requests add column run_event_id bigint;
+alter table requests add column processed_at timestamp;
+
+-- requests received before the queue were recorded as they arrived
+update requests set attempts = 1, processed_at = created_at;
+
+create index requests_pending_idx
+  on requests(next_attempt_at, id) where status = 1;
@@ -0,0 +1,3 @@
+drop index if exists requests_event_time_idx;
+
+alter table requests drop column event_time;
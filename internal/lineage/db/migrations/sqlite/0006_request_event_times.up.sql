@@ -0,0 +1,9 @@
+alter table requests add column event_time timestamp;
+
+-- in the format the driver writes times so they sort the same
+update requests
+set event_time = strftime('%Y-%m-%d %H:%M:%f', json_extract(payload, '$.eventTime')) || '+00:00'
+where json_valid(payload) and json_extract(payload, '$.eventTime') is not null;
+
+create index requests_event_time_idx
+  on requests(coalesce(event_time, created_at), id);
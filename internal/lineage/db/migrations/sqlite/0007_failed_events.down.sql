@@ -0,0 +1 @@
+drop table if exists failed_events;
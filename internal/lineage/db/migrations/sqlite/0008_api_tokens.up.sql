@@ -0,0 +1,11 @@
+create table api_tokens (
+  id              integer primary key autoincrement,
+  name            varchar(255) not null,
+  token_hash      varchar(64) not null, -- sha256 of the token
+  token_prefix    varchar(16) not null,
+  producer        varchar, -- the producer the token may send events for
+  namespace       varchar(255), -- the namespace the token may send events for
+  created_at      timestamp not null,
+  last_used_at    timestamp,
+  unique(token_hash)
+);
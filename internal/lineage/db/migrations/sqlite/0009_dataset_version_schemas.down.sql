@@ -0,0 +1 @@
+alter table dataset_versions drop column schema_recorded;
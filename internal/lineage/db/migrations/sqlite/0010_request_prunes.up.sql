@@ -0,0 +1,8 @@
+-- the requests pruned by the retention, a replay cannot rebuild what they
+-- recorded
+create table request_prunes (
+  id              integer primary key autoincrement,
+  created_before  timestamp not null, -- the requests created before were deleted
+  requests        bigint not null,
+  created_at      timestamp not null
+);
@@ -0,0 +1,4 @@
+drop index if exists job_versions_version_uuid_idx;
+alter table job_versions drop column version_uuid;
+drop index if exists dataset_versions_version_uuid_idx;
+alter table dataset_versions drop column version_uuid;
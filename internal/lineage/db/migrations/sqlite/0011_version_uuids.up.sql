@@ -0,0 +1,16 @@
+-- the versions are addressed by uuid so the ids stay internal, sqlite has no
+-- uuid function so those of the versions recorded so far are made of random
+-- bytes
+alter table dataset_versions add column version_uuid text not null default '';
+update dataset_versions set version_uuid = lower(
+  hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+  substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+);
+create unique index dataset_versions_version_uuid_idx on dataset_versions(version_uuid);
+
+alter table job_versions add column version_uuid text not null default '';
+update job_versions set version_uuid = lower(
+  hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+  substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+);
+create unique index job_versions_version_uuid_idx on job_versions(version_uuid);
@@ -0,0 +1,5 @@
+drop index if exists runs_job_version_created_idx;
+drop index if exists job_versions_job_id_idx;
+drop index if exists runs_created_idx;
+drop index if exists jobs_updated_idx;
+drop index if exists datasets_updated_idx;
@@ -0,0 +1,17 @@
+-- the list pages sort newest first on these keys, an index scanned
+-- backwards serves them without sorting the whole table
+create index datasets_updated_idx
+  on datasets(coalesce(updated_at, created_at), id);
+
+create index jobs_updated_idx
+  on jobs(coalesce(updated_at, created_at), id);
+
+create index runs_created_idx
+  on runs(created_at, id);
+
+-- a job's runs are reached through its versions
+create index job_versions_job_id_idx
+  on job_versions(job_id);
+
+create index runs_job_version_created_idx
+  on runs(job_version_id, created_at, id);
@@ -13,6 +13,17 @@ import (
 	"github.com/tabbed/pqtype"
 )
 
+type LineageApiToken struct {
+	ID          int64
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	Producer    sql.NullString
+	Namespace   sql.NullString
+	CreatedAt   time.Time
+	LastUsedAt  sql.NullTime
+}
+
 type LineageDataset struct {
 	ID               int64
 	CurrentVersionID sql.NullInt64
@@ -23,6 +34,16 @@ type LineageDataset struct {
 	UpdatedAt        sql.NullTime
 }
 
+type LineageDatasetAlias struct {
+	ID        int64
+	Namespace string
+	Name      string
+	DatasetID int64
+	IsManual  bool
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
 type LineageDatasetNamespace struct {
 	ID        int64
 	Name      string
@@ -31,12 +52,38 @@ type LineageDatasetNamespace struct {
 }
 
 type LineageDatasetVersion struct {
-	ID          int64
-	DatasetID   int64
-	NamespaceID int64
-	Name        string
-	CreatedAt   time.Time
-	UpdatedAt   sql.NullTime
+	ID             int64
+	DatasetID      int64
+	NamespaceID    int64
+	Name           string
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+	SchemaRecorded bool
+	VersionUuid    uuid.UUID
+}
+
+type LineageFacet struct {
+	ID         int64
+	EntityType int32
+	EntityID   int64
+	Name       string
+	Producer   sql.NullString
+	SchemaUrl  sql.NullString
+	Payload    json.RawMessage
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
+}
+
+type LineageFailedEvent struct {
+	ID           int64
+	Payload      json.RawMessage
+	Producer     sql.NullString
+	ErrorMessage string
+	Stacktrace   sql.NullString
+	RequestID    sql.NullInt64
+	Attempts     int32
+	CreatedAt    time.Time
+	UpdatedAt    sql.NullTime
 }
 
 type LineageField struct {
@@ -74,23 +121,48 @@ type LineageJobVersion struct {
 	Facets      pqtype.NullRawMessage
 	CreatedAt   time.Time
 	UpdatedAt   sql.NullTime
+	VersionUuid uuid.UUID
+}
+
+type LineageJobVersionIoDataset struct {
+	JobVersionID int64
+	DatasetID    int64
+	IoType       int32
+	IoFacets     pqtype.NullRawMessage
+	CreatedAt    time.Time
 }
 
 type LineageLifecycleStateChange struct {
-	ID        int64
-	DatasetID int64
-	Change    sql.NullString
-	Namespace sql.NullString
-	Name      sql.NullString
-	DataType  string
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	ID                int64
+	DatasetID         int64
+	Change            sql.NullString
+	Namespace         sql.NullString
+	Name              sql.NullString
+	CreatedAt         time.Time
+	UpdatedAt         sql.NullTime
+	RunID             sql.NullInt64
+	PreviousDatasetID sql.NullInt64
 }
 
 type LineageRequest struct {
-	ID        int64
-	Payload   json.RawMessage
-	CreatedAt time.Time
+	ID             int64
+	Payload        json.RawMessage
+	CreatedAt      time.Time
+	Status         int32
+	Attempts       int32
+	NextAttemptAt  sql.NullTime
+	ErrorMessage   sql.NullString
+	IdempotencyKey sql.NullString
+	RunEventID     sql.NullInt64
+	ProcessedAt    sql.NullTime
+	EventTime      sql.NullTime
+}
+
+type LineageRequestPrune struct {
+	ID            int64
+	CreatedBefore time.Time
+	Requests      int64
+	CreatedAt     time.Time
 }
 
 type LineageRun struct {
@@ -109,6 +181,9 @@ type LineageRun struct {
 	Stacktrace          sql.NullString
 	CreatedAt           time.Time
 	UpdatedAt           sql.NullTime
+	IsPlaceholder       bool
+	State               int32
+	LastEventTime       sql.NullTime
 }
 
 type LineageRunDatasetVersion struct {
@@ -118,14 +193,41 @@ type LineageRunDatasetVersion struct {
 	DatasetFacets    pqtype.NullRawMessage
 	IoFacets         pqtype.NullRawMessage
 	CreatedAt        time.Time
+	FirstEventType   int32
+	LastEventType    int32
+	UpdatedAt        sql.NullTime
 }
 
 type LineageRunEvent struct {
-	ID        int64
-	RunID     int64
-	EventType int32
-	EventTime time.Time
-	Facets    pqtype.NullRawMessage
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	ID             int64
+	RunID          int64
+	EventType      int32
+	EventTime      time.Time
+	Facets         pqtype.NullRawMessage
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+	PayloadHash    sql.NullString
+	IdempotencyKey sql.NullString
+}
+
+type LineageSchemaChange struct {
+	ID                  int64
+	DatasetVersionID    int64
+	PreviousVersionID   int64
+	ChangeType          int32
+	FieldName           string
+	PreviousDataType    sql.NullString
+	DataType            sql.NullString
+	PreviousDescription sql.NullString
+	Description         sql.NullString
+	CreatedAt           time.Time
+}
+
+type LineageSearchDocument struct {
+	ID         int64
+	EntityType int32
+	EntityID   int64
+	Field      string
+	Content    string
+	CreatedAt  time.Time
 }
@@ -61,11 +61,67 @@ from lineage.jobs j
 join lineage.job_namespaces ns on ns.id = j.namespace_id
 where j.id = $1;
 
+-- name: GetJobWithNamespaceByName :one
+select 
+  j.id, 
+  j.current_version_id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = sqlc.arg(namespace_name) and j.name = sqlc.arg(name);
+
+-- name: ListJobsWithNamespacesByNamespace :many
+select 
+  j.id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = sqlc.arg(namespace_name)
+order by j.name;
+
+-- name: SearchJobsWithNamespaces :many
+select 
+  j.id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where j.name ilike sqlc.arg(pattern) or ns.name ilike sqlc.arg(pattern)
+order by j.name
+limit sqlc.arg(row_limit);
+
 -- name: UpdateCurrentJobVersion :one
 update lineage.jobs set current_version_id = $1, updated_at = $2 
 where id = $3
 returning *;
 
+-- name: UpdateJob :one
+update lineage.jobs set 
+  facets = $1,
+  updated_at = $2 
+where id = $3
+returning *;
+
 -- name: CreateJob :one
 insert into lineage.jobs (
   namespace_id,
@@ -93,9 +149,10 @@ insert into lineage.job_versions (
   namespace_id,
   name,
   facets,
-  created_at
+  created_at,
+  version_uuid
 ) values (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6
 )
 returning *;
 
@@ -112,10 +169,16 @@ where run_uuid = $1 limit 1;
 select * from lineage.runs
 order by job_version_id, id;
 
--- name: ListRunsByJobVersionID :many
+-- name: ListRunsByJobID :many
+select r.* from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+order by r.created_at desc;
+
+-- name: ListRunsByParentRunID :many
 select * from lineage.runs
-where job_version_id = $1
-order by created_at desc;
+where parent_run_id = $1
+order by created_at;
 
 -- name: CreateRun :one
 INSERT INTO lineage.runs (
@@ -130,12 +193,26 @@ INSERT INTO lineage.runs (
   error_message,
   programming_language,
   stacktrace,
-  created_at
+  created_at,
+  is_placeholder
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 )
 RETURNING *;
 
+-- name: FillPlaceholderRun :one
+UPDATE lineage.runs SET 
+  job_version_id = $2,
+  facets = $3,
+  parent_run_id = $4,
+  started_at = $5,
+  nominal_started_at = $6,
+  nominal_ended_at = $7,
+  updated_at = $8,
+  is_placeholder = false
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateRun :one
 UPDATE lineage.runs SET 
   facets = $2,
@@ -143,8 +220,11 @@ UPDATE lineage.runs SET
   error_message = $4,
   programming_language = $5,
   stacktrace = $6,
-  ended_at = $7,
-  updated_at = $8
+  started_at = $7,
+  ended_at = $8,
+  updated_at = $9,
+  state = $10,
+  last_event_time = $11
 WHERE id = $1
 RETURNING *;
 
@@ -163,9 +243,11 @@ INSERT INTO lineage.run_events (
   event_type,
   event_time,
   facets,
-  created_at
+  created_at,
+  payload_hash,
+  idempotency_key
 ) VALUES (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6, $7
 )
 RETURNING *;
 
@@ -224,7 +306,11 @@ select
   ns.updated_at as namespace_updated_at,
   ns.created_at as namespace_created_at
 from lineage.datasets d
-join lineage.dataset_namespaces ns on ns.id = d.namespace_id;
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+);
 
 -- name: GetDatasetWithNamespace :one
 select 
@@ -242,6 +328,60 @@ from lineage.datasets d
 join lineage.dataset_namespaces ns on ns.id = d.namespace_id
 where d.id = $1;
 
+-- name: GetDatasetWithNamespaceByName :one
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = sqlc.arg(namespace_name) and d.name = sqlc.arg(name);
+
+-- name: ListDatasetsWithNamespacesByNamespace :many
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = sqlc.arg(namespace_name) and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+order by d.name;
+
+
+-- name: SearchDatasetsWithNamespaces :many
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where d.name ilike sqlc.arg(pattern) or ns.name ilike sqlc.arg(pattern)
+order by d.name
+limit sqlc.arg(row_limit);
 
 -- name: UpdateCurrentDatasetVersion :one
 update lineage.datasets set current_version_id = $1, updated_at = $2 
@@ -269,9 +409,10 @@ insert into lineage.dataset_versions (
   dataset_id,
   namespace_id,
   name,
-  created_at
+  created_at,
+  version_uuid
 ) values (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5
 )
 returning *;
 
@@ -286,12 +427,23 @@ insert into lineage.run_dataset_versions (
   io_type,
   dataset_facets,
   io_facets,
+  first_event_type,
+  last_event_type,
   created_at
 ) values (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
 returning *;
 
+-- name: UpdateRunDatasetVersion :one
+update lineage.run_dataset_versions set
+  dataset_facets = $3,
+  io_facets = $4,
+  last_event_type = $5,
+  updated_at = $6
+where run_id = $1 and dataset_version_id = $2
+returning *;
+
 -- name: GetLatestRunDatasetVersionByDatasetVersionID :one
 select * from lineage.run_dataset_versions
 where dataset_version_id = $1 order by created_at desc limit 1;
@@ -303,13 +455,17 @@ select
   r.io_type,
   r.dataset_facets,
   r.io_facets,
+  r.first_event_type,
+  r.last_event_type,
   r.created_at,
+  r.updated_at,
   v.id as version_id, 
   v.dataset_id as version_dataset_id, 
   v.name as version_name, 
   v.namespace_id as version_namespace_id, 
   v.updated_at as version_updated_at,
   v.created_at as version_created_at,
+  v.version_uuid,
   n.id as namespace_id, 
   n.name as namespace_name,
   n.updated_at as namespace_updated_at,
@@ -319,6 +475,32 @@ join lineage.dataset_versions v on v.id = r.dataset_version_id
 join lineage.dataset_namespaces n on n.id = v.namespace_id
 where r.run_id = $1;
 
+-- name: ListDatasetEdgesByJobID :many
+select distinct dv.dataset_id, rdv.io_type
+from lineage.run_dataset_versions rdv
+join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+join lineage.runs r on r.id = rdv.run_id
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+union
+select jvio.dataset_id, jvio.io_type
+from lineage.job_version_io_datasets jvio
+join lineage.jobs j on j.current_version_id = jvio.job_version_id
+where j.id = $1;
+
+-- name: ListJobEdgesByDatasetID :many
+select distinct jv.job_id, rdv.io_type
+from lineage.run_dataset_versions rdv
+join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+join lineage.runs r on r.id = rdv.run_id
+join lineage.job_versions jv on jv.id = r.job_version_id
+where dv.dataset_id = $1
+union
+select j.id, jvio.io_type
+from lineage.job_version_io_datasets jvio
+join lineage.jobs j on j.current_version_id = jvio.job_version_id
+where jvio.dataset_id = $1;
+
 -- name: CreateField :one
 insert into lineage.fields (
   dataset_version_id,
@@ -336,33 +518,987 @@ returning *;
 select * from lineage.fields
 where dataset_version_id = $1 order by name; 
 
+-- name: CreateSchemaChange :one
+insert into lineage.schema_changes (
+  dataset_version_id,
+  previous_version_id,
+  change_type,
+  field_name,
+  previous_data_type,
+  data_type,
+  previous_description,
+  description,
+  created_at
+) values (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+returning *;
+
+-- name: ListSchemaChangesByDatasetVersionID :many
+select * from lineage.schema_changes
+where dataset_version_id = $1
+order by field_name;
+
 -- name: CreateLifecycleStateChange :one
 insert into lineage.lifecycle_state_changes (
   dataset_id,
+  run_id,
+  change,
   namespace,
   name,
-  data_type,
-  change,
-  updated_at,
+  previous_dataset_id,
   created_at
 ) values (
   $1, $2, $3, $4, $5, $6, $7
 )
 returning *;
 
--- name: ListLifecycleStateChangesByDatasetID :many
+-- name: GetLatestLifecycleStateChangeByDatasetID :one
 select * from lineage.lifecycle_state_changes
-where dataset_id = $1 order by created_at; 
+where dataset_id = $1
+order by id desc
+limit 1;
+
+-- name: ListLifecycleStateChangesByDatasetID :many
+select c.id, c.dataset_id, c.change, c.namespace, c.name, c.created_at, c.updated_at, c.run_id, c.previous_dataset_id,
+  d.name as dataset_name, n.name as namespace_name, r.run_uuid
+from lineage.lifecycle_state_changes c
+join lineage.datasets d on d.id = c.dataset_id
+join lineage.dataset_namespaces n on n.id = d.namespace_id
+left join lineage.runs r on r.id = c.run_id
+where c.dataset_id = $1 or c.previous_dataset_id = $1
+order by c.created_at, c.id;
 
 -- name: CreateRequest :one
 INSERT INTO lineage.requests (
   payload,
-  created_at
+  created_at,
+  status,
+  attempts,
+  next_attempt_at,
+  idempotency_key,
+  run_event_id,
+  processed_at,
+  event_time
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 RETURNING *;
 
--- name: ListRequests :many
+-- name: CreateJobVersionIODataset :one
+insert into lineage.job_version_io_datasets (
+  job_version_id,
+  dataset_id,
+  io_type,
+  io_facets,
+  created_at
+) values (
+  $1, $2, $3, $4, $5
+)
+returning *;
+
+-- name: ListJobVersionIODatasetsByJobVersionID :many
+select 
+  jvio.*,
+  d.name as dataset_name,
+  n.name as namespace_name
+from lineage.job_version_io_datasets jvio
+join lineage.datasets d on d.id = jvio.dataset_id
+join lineage.dataset_namespaces n on n.id = d.namespace_id
+where jvio.job_version_id = $1
+order by jvio.io_type, n.name, d.name;
+
+-- name: CreateDatasetAliasIfNotExists :exec
+insert into lineage.dataset_aliases (
+  namespace,
+  name,
+  dataset_id,
+  created_at
+) values (
+  $1, $2, $3, $4
+)
+on conflict (namespace, name) do nothing;
+
+-- name: UpsertManualDatasetAlias :one
+insert into lineage.dataset_aliases (
+  namespace,
+  name,
+  dataset_id,
+  is_manual,
+  created_at
+) values (
+  $1, $2, $3, true, $4
+)
+on conflict (namespace, name) do update set
+  dataset_id = excluded.dataset_id,
+  is_manual = true,
+  updated_at = excluded.created_at
+returning *;
+
+-- name: GetDatasetAliasByName :one
+select * from lineage.dataset_aliases
+where namespace = $1 and name = $2;
+
+-- name: ListDatasetAliasesByDatasetID :many
+select * from lineage.dataset_aliases
+where dataset_id = $1
+order by namespace, name;
+
+-- name: ListAliasedDatasetIDs :many
+select d.id
+from lineage.dataset_aliases a
+join lineage.dataset_namespaces ns on ns.name = a.namespace
+join lineage.datasets d on d.namespace_id = ns.id and d.name = a.name
+where a.dataset_id = $1 and d.id <> $1;
+
+-- name: RepointDatasetAliases :exec
+update lineage.dataset_aliases
+set dataset_id = sqlc.arg(dataset_id), updated_at = sqlc.arg(updated_at)
+where dataset_id = sqlc.arg(previous_dataset_id);
+
+-- name: DeleteDatasetAlias :exec
+delete from lineage.dataset_aliases
+where namespace = $1 and name = $2;
+
+-- name: UpsertFacet :one
+insert into lineage.facets (
+  entity_type,
+  entity_id,
+  name,
+  producer,
+  schema_url,
+  payload,
+  created_at
+) values (
+  $1, $2, $3, $4, $5, $6, $7
+)
+on conflict (entity_type, entity_id, name) do update set
+  producer = excluded.producer,
+  schema_url = excluded.schema_url,
+  payload = excluded.payload,
+  updated_at = excluded.created_at
+returning *;
+
+-- name: ListFacetsByEntity :many
+select * from lineage.facets
+where entity_type = $1 and entity_id = $2
+order by name;
+
+-- name: ListDatasetsPageByName :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where (ns.name = sqlc.arg(namespace) or sqlc.arg(namespace) = '')
+  and d.name ilike sqlc.arg(name_pattern) escape '\'
+  and coalesce(d.updated_at, d.created_at) >= sqlc.arg(updated_since)
+  and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  and (ns.name, d.name, d.id) > (sqlc.arg(after_namespace), sqlc.arg(after_name), sqlc.arg(after_id))
+order by ns.name, d.name, d.id
+limit sqlc.arg(row_limit);
+
+-- name: ListDatasetsPageByUpdated :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where (ns.name = sqlc.arg(namespace) or sqlc.arg(namespace) = '')
+  and d.name ilike sqlc.arg(name_pattern) escape '\'
+  and coalesce(d.updated_at, d.created_at) >= sqlc.arg(updated_since)
+  and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  and (coalesce(d.updated_at, d.created_at), d.id) < (sqlc.arg(before_updated_at), sqlc.arg(before_id))
+order by coalesce(d.updated_at, d.created_at) desc, d.id desc
+limit sqlc.arg(row_limit);
+
+-- name: ListJobsPageByName :many
+select
+  j.id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = sqlc.arg(namespace) or sqlc.arg(namespace) = '')
+  and j.name ilike sqlc.arg(name_pattern) escape '\'
+  and coalesce(j.updated_at, j.created_at) >= sqlc.arg(updated_since)
+  and (ns.name, j.name, j.id) > (sqlc.arg(after_namespace), sqlc.arg(after_name), sqlc.arg(after_id))
+order by ns.name, j.name, j.id
+limit sqlc.arg(row_limit);
+
+-- name: ListJobsPageByUpdated :many
+select
+  j.id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = sqlc.arg(namespace) or sqlc.arg(namespace) = '')
+  and j.name ilike sqlc.arg(name_pattern) escape '\'
+  and coalesce(j.updated_at, j.created_at) >= sqlc.arg(updated_since)
+  and (coalesce(j.updated_at, j.created_at), j.id) < (sqlc.arg(before_updated_at), sqlc.arg(before_id))
+order by coalesce(j.updated_at, j.created_at) desc, j.id desc
+limit sqlc.arg(row_limit);
+
+-- name: ListRequestsPage :many
+select * from lineage.requests
+where created_at >= sqlc.arg(created_since)
+  and id < sqlc.arg(before_id)
+order by id desc
+limit sqlc.arg(row_limit);
+
+-- name: ListRunsPageByJobID :many
+select r.* from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = sqlc.arg(job_id)
+  and (r.job_version_id = sqlc.arg(job_version_id) or sqlc.arg(job_version_id) = 0)
+  and (r.state = sqlc.arg(state) or sqlc.arg(state) = 0)
+  and coalesce(r.updated_at, r.created_at) >= sqlc.arg(updated_since)
+  and coalesce(r.updated_at, r.created_at) < sqlc.arg(updated_before)
+  and (r.created_at, r.id) < (sqlc.arg(before_created_at), sqlc.arg(before_id))
+order by r.created_at desc, r.id desc
+limit sqlc.arg(row_limit);
+
+-- name: CreateSearchDocument :exec
+insert into lineage.search_documents (
+  entity_type, entity_id, field, content, created_at
+) values (
+  sqlc.arg(entity_type), sqlc.arg(entity_id), sqlc.arg(field), sqlc.arg(content), sqlc.arg(created_at)
+);
+
+-- name: DeleteSearchDocumentsByEntity :exec
+delete from lineage.search_documents
+where entity_type = sqlc.arg(entity_type) and entity_id = sqlc.arg(entity_id);
+
+-- name: DeleteSearchDocuments :exec
+delete from lineage.search_documents;
+
+-- name: SearchDatasetDocuments :many
+with matches as (
+  select
+    sd.id,
+    sd.entity_id,
+    sd.field,
+    sd.content,
+    case sd.field
+      when 'name' then 0
+      when 'namespace' then 1
+      when 'field' then 2
+      when 'description' then 3
+      when 'owner' then 4
+      else 5
+    end as field_rank
+  from lineage.search_documents sd
+  where sd.entity_type = sqlc.arg(entity_type)
+    and sd.content ilike sqlc.arg(pattern) escape '\'
+), ranked as (
+  select
+    d.id,
+    d.name,
+    ns.name as namespace_name,
+    d.created_at,
+    d.updated_at,
+    min(m.field_rank * 1000000 + length(m.content)) as score
+  from matches m
+  join lineage.datasets d on d.id = m.entity_id
+  join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+  where not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  group by d.id, d.name, ns.name, d.created_at, d.updated_at
+  order by score, d.name, d.id
+  limit sqlc.arg(row_limit)
+)
+select
+  r.id,
+  r.name,
+  r.namespace_name,
+  r.created_at,
+  r.updated_at,
+  r.score,
+  m.field,
+  m.content
+from ranked r
+join (
+  select
+    matches.*,
+    row_number() over (partition by matches.entity_id order by matches.field_rank, matches.id) as match_number
+  from matches
+) m on m.entity_id = r.id
+where m.match_number <= sqlc.arg(matches_per_result)
+order by r.score, r.name, r.id, m.field_rank, m.id;
+
+-- name: SearchJobDocuments :many
+with matches as (
+  select
+    sd.id,
+    sd.entity_id,
+    sd.field,
+    sd.content,
+    case sd.field
+      when 'name' then 0
+      when 'namespace' then 1
+      when 'field' then 2
+      when 'description' then 3
+      when 'owner' then 4
+      else 5
+    end as field_rank
+  from lineage.search_documents sd
+  where sd.entity_type = sqlc.arg(entity_type)
+    and sd.content ilike sqlc.arg(pattern) escape '\'
+), ranked as (
+  select
+    j.id,
+    j.name,
+    ns.name as namespace_name,
+    j.created_at,
+    j.updated_at,
+    min(m.field_rank * 1000000 + length(m.content)) as score
+  from matches m
+  join lineage.jobs j on j.id = m.entity_id
+  join lineage.job_namespaces ns on ns.id = j.namespace_id
+  group by j.id, j.name, ns.name, j.created_at, j.updated_at
+  order by score, j.name, j.id
+  limit sqlc.arg(row_limit)
+)
+select
+  r.id,
+  r.name,
+  r.namespace_name,
+  r.created_at,
+  r.updated_at,
+  r.score,
+  m.field,
+  m.content
+from ranked r
+join (
+  select
+    matches.*,
+    row_number() over (partition by matches.entity_id order by matches.field_rank, matches.id) as match_number
+  from matches
+) m on m.entity_id = r.id
+where m.match_number <= sqlc.arg(matches_per_result)
+order by r.score, r.name, r.id, m.field_rank, m.id;
+
+-- name: ListDatasetIDs :many
+select id from lineage.datasets
+order by id;
+
+-- name: ListJobIDs :many
+select id from lineage.jobs
+order by id;
+
+-- name: ListRunsPage :many
+select
+  r.*,
+  j.id as job_id,
+  j.name as job_name,
+  ns.name as namespace_name
+from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+join lineage.jobs j on j.id = jv.job_id
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = sqlc.arg(namespace) or sqlc.arg(namespace) = '')
+  and (r.state = sqlc.arg(state) or sqlc.arg(state) = 0)
+  and coalesce(r.updated_at, r.created_at) >= sqlc.arg(updated_since)
+  and coalesce(r.updated_at, r.created_at) < sqlc.arg(updated_before)
+  and (r.created_at, r.id) < (sqlc.arg(before_created_at), sqlc.arg(before_id))
+order by r.created_at desc, r.id desc
+limit sqlc.arg(row_limit);
+
+-- name: GetRunEventByPayloadHash :one
+select e.* from lineage.run_events e
+join lineage.runs r on r.id = e.run_id
+where r.run_uuid = sqlc.arg(run_uuid)
+  and e.event_type = sqlc.arg(event_type)
+  and e.event_time = sqlc.arg(event_time)
+  and e.payload_hash = sqlc.arg(payload_hash)
+limit 1;
+
+-- name: GetRunEventByIdempotencyKey :one
+select * from lineage.run_events
+where idempotency_key = sqlc.arg(idempotency_key)
+limit 1;
+
+-- name: GetRequestByID :one
+select * from lineage.requests
+where id = sqlc.arg(id) limit 1;
+
+-- name: ClaimPendingRequest :one
 select * from lineage.requests
-order by created_at; 
\ No newline at end of file
+where status = 1 and next_attempt_at <= sqlc.arg(now)
+order by next_attempt_at, id
+limit 1
+for update skip locked;
+
+-- name: UpdateRequestStatus :exec
+update lineage.requests set
+  status = sqlc.arg(status),
+  attempts = sqlc.arg(attempts),
+  next_attempt_at = sqlc.arg(next_attempt_at),
+  error_message = sqlc.arg(error_message),
+  run_event_id = sqlc.arg(run_event_id),
+  processed_at = sqlc.arg(processed_at)
+where id = sqlc.arg(id);
+
+-- name: GetMaxRequestID :one
+select coalesce(max(id), 0) as max_id from lineage.requests;
+
+-- name: CountRequestsForReplay :one
+select count(*) from lineage.requests
+where status <> 1 and id <= sqlc.arg(max_id);
+
+-- name: ListRequestsForReplay :many
+select * from lineage.requests
+where status <> 1 and id <= sqlc.arg(max_id)
+  and (coalesce(event_time, created_at), id) > (sqlc.arg(after_event_time), sqlc.arg(after_id))
+order by coalesce(event_time, created_at), id
+limit sqlc.arg(row_limit);
+
+-- name: DeleteFacets :exec
+delete from lineage.facets;
+
+-- name: DeleteDatasetAliases :exec
+delete from lineage.dataset_aliases;
+
+-- name: DeleteLifecycleStateChanges :exec
+delete from lineage.lifecycle_state_changes;
+
+-- name: DeleteSchemaChanges :exec
+delete from lineage.schema_changes;
+
+-- name: DeleteFields :exec
+delete from lineage.fields;
+
+-- name: DeleteJobVersionIODatasets :exec
+delete from lineage.job_version_io_datasets;
+
+-- name: DeleteRunDatasetVersions :exec
+delete from lineage.run_dataset_versions;
+
+-- name: DeleteDatasetVersions :exec
+delete from lineage.dataset_versions;
+
+-- name: DeleteDatasets :exec
+delete from lineage.datasets;
+
+-- name: DeleteDatasetNamespaces :exec
+delete from lineage.dataset_namespaces;
+
+-- name: DeleteRunEvents :exec
+delete from lineage.run_events;
+
+-- name: DeleteRuns :exec
+delete from lineage.runs;
+
+-- name: DeleteJobVersions :exec
+delete from lineage.job_versions;
+
+-- name: DeleteJobs :exec
+delete from lineage.jobs;
+
+-- name: DeleteJobNamespaces :exec
+delete from lineage.job_namespaces;
+
+-- name: CreateFailedEvent :one
+insert into lineage.failed_events (
+  payload, producer, error_message, stacktrace, request_id, created_at
+) values (
+  sqlc.arg(payload), sqlc.arg(producer), sqlc.arg(error_message), sqlc.arg(stacktrace), sqlc.arg(request_id),
+  sqlc.arg(created_at)
+)
+returning *;
+
+-- name: GetFailedEventByID :one
+select * from lineage.failed_events
+where id = sqlc.arg(id) limit 1;
+
+-- name: ListFailedEventsPage :many
+select * from lineage.failed_events
+where created_at >= sqlc.arg(created_since)
+  and id < sqlc.arg(before_id)
+order by id desc
+limit sqlc.arg(row_limit);
+
+-- name: UpdateFailedEvent :exec
+update lineage.failed_events set
+  payload = sqlc.arg(payload),
+  producer = sqlc.arg(producer),
+  error_message = sqlc.arg(error_message),
+  stacktrace = sqlc.arg(stacktrace),
+  attempts = sqlc.arg(attempts),
+  updated_at = sqlc.arg(updated_at)
+where id = sqlc.arg(id);
+
+-- name: DeleteFailedEvent :exec
+delete from lineage.failed_events
+where id = sqlc.arg(id);
+
+-- name: DeleteRequestsCreatedBefore :execrows
+delete from lineage.requests
+where created_at < sqlc.arg(created_before)
+  and status <> 1;
+
+-- name: ClearRequestRunEventsForOldRuns :exec
+update lineage.requests set run_event_id = null
+where run_event_id in (
+  select e.id from lineage.run_events e
+  where e.run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+  )
+);
+
+-- name: ClearLifecycleStateChangeRunsForOldRuns :exec
+update lineage.lifecycle_state_changes set run_id = null
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: ClearParentRunsForOldRuns :exec
+update lineage.runs set parent_run_id = null
+where parent_run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: DeleteFacetsForOldRuns :exec
+delete from lineage.facets
+where entity_type = 2
+  and entity_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: DeleteRunEventsForOldRuns :execrows
+delete from lineage.run_events
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: DeleteRunDatasetVersionsForOldRuns :exec
+delete from lineage.run_dataset_versions
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: DeleteOldRuns :execrows
+delete from lineage.runs
+where id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > sqlc.arg(runs_per_job)
+);
+
+-- name: ListUnreferencedDatasetVersions :many
+select v.* from lineage.dataset_versions v
+where not exists (select 1 from lineage.run_dataset_versions rdv where rdv.dataset_version_id = v.id)
+  and not exists (select 1 from lineage.datasets d where d.current_version_id = v.id)
+order by v.id;
+
+-- name: GetNextDatasetVersionID :one
+select n.id from lineage.dataset_versions v
+join lineage.dataset_versions n on n.dataset_id = v.dataset_id and n.id > v.id
+where v.id = sqlc.arg(id)
+order by n.id
+limit 1;
+
+-- name: GetPreviousDatasetVersionID :one
+select p.id from lineage.dataset_versions v
+join lineage.dataset_versions p on p.dataset_id = v.dataset_id and p.id < v.id
+where v.id = sqlc.arg(id)
+order by p.id desc
+limit 1;
+
+-- name: DeleteSchemaChangesByDatasetVersionID :exec
+delete from lineage.schema_changes
+where dataset_version_id = sqlc.arg(dataset_version_id);
+
+-- name: DeleteFieldsByDatasetVersionID :exec
+delete from lineage.fields
+where dataset_version_id = sqlc.arg(dataset_version_id);
+
+-- name: DeleteDatasetVersion :exec
+delete from lineage.dataset_versions
+where id = sqlc.arg(id);
+
+-- name: CreateAPIToken :one
+insert into lineage.api_tokens (
+  name, token_hash, token_prefix, producer, namespace, created_at
+) values (
+  sqlc.arg(name), sqlc.arg(token_hash), sqlc.arg(token_prefix), sqlc.arg(producer), sqlc.arg(namespace),
+  sqlc.arg(created_at)
+)
+returning *;
+
+-- name: GetAPITokenByHash :one
+select * from lineage.api_tokens
+where token_hash = sqlc.arg(token_hash) limit 1;
+
+-- name: ListAPITokens :many
+select * from lineage.api_tokens
+order by id;
+
+-- name: UpdateAPITokenLastUsedAt :exec
+update lineage.api_tokens set
+  last_used_at = sqlc.arg(last_used_at)
+where id = sqlc.arg(id);
+
+-- name: DeleteAPIToken :execrows
+delete from lineage.api_tokens
+where id = sqlc.arg(id);
+
+-- name: SetDatasetVersionSchemaRecorded :exec
+update lineage.dataset_versions set schema_recorded = true
+where id = sqlc.arg(id);
+
+-- name: GetDatasetVersionCreatedByRunUUID :one
+select r.run_uuid from lineage.run_dataset_versions rdv
+join lineage.runs r on r.id = rdv.run_id
+where rdv.dataset_version_id = sqlc.arg(dataset_version_id)
+  and rdv.io_type = 2
+order by rdv.created_at, r.id
+limit 1;
+
+-- name: ListManualDatasetAliases :many
+select a.namespace, a.name, ns.name as dataset_namespace, d.name as dataset_name
+from lineage.dataset_aliases a
+join lineage.datasets d on d.id = a.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where a.is_manual
+order by a.id;
+
+-- name: ListDatasetsWithNamespacesByNamespacePage :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = sqlc.arg(namespace_name) and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+order by d.name, d.id
+limit sqlc.arg(row_limit) offset sqlc.arg(row_offset);
+
+-- name: CountDatasetsByNamespace :one
+select count(*) from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = sqlc.arg(namespace_name) and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+);
+
+-- name: ListCurrentFieldsByNamespacePage :many
+select
+  p.id as dataset_id,
+  f.id,
+  f.name,
+  f.data_type,
+  f.description,
+  f.created_at,
+  f.updated_at
+from (
+  select d.id, d.current_version_id from lineage.datasets d
+  join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+  where ns.name = sqlc.arg(namespace_name) and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  order by d.name, d.id
+  limit sqlc.arg(row_limit) offset sqlc.arg(row_offset)
+) p
+join lineage.fields f on f.dataset_version_id = p.current_version_id
+order by p.id, f.name;
+
+-- name: ListJobsWithNamespacesByNamespacePage :many
+select
+  j.id,
+  j.current_version_id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = sqlc.arg(namespace_name)
+order by j.name, j.id
+limit sqlc.arg(row_limit) offset sqlc.arg(row_offset);
+
+-- name: CountJobsByNamespace :one
+select count(*) from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = sqlc.arg(namespace_name);
+
+-- name: ListDatasetEdgesByNamespacePage :many
+with page as (
+  select j.id, j.current_version_id from lineage.jobs j
+  join lineage.job_namespaces ns on ns.id = j.namespace_id
+  where ns.name = sqlc.arg(namespace_name)
+  order by j.name, j.id
+  limit sqlc.arg(row_limit) offset sqlc.arg(row_offset)
+), edges as (
+  select distinct jv.job_id, dv.dataset_id, rdv.io_type
+  from page p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+  join lineage.run_dataset_versions rdv on rdv.run_id = r.id
+  join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+  union
+  select p.id, jvio.dataset_id, jvio.io_type
+  from page p
+  join lineage.job_version_io_datasets jvio on jvio.job_version_id = p.current_version_id
+)
+select distinct
+  e.job_id,
+  e.io_type,
+  cns.name as namespace_name,
+  cd.name as dataset_name
+from edges e
+join lineage.datasets d on d.id = e.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+left join lineage.dataset_aliases a on a.namespace = ns.name and a.name = d.name
+join lineage.datasets cd on cd.id = coalesce(a.dataset_id, d.id)
+join lineage.dataset_namespaces cns on cns.id = cd.namespace_id
+order by e.job_id, cns.name, cd.name;
+
+-- name: ListLatestRunsByNamespacePage :many
+select
+  r.*,
+  latest.job_id
+from (
+  select r.id, jv.job_id, row_number() over (partition by jv.job_id order by r.created_at desc, r.id desc) as run_rank
+  from (
+    select j.id, j.current_version_id from lineage.jobs j
+    join lineage.job_namespaces ns on ns.id = j.namespace_id
+    where ns.name = sqlc.arg(namespace_name)
+    order by j.name, j.id
+    limit sqlc.arg(row_limit) offset sqlc.arg(row_offset)
+  ) p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+) latest
+join lineage.runs r on r.id = latest.id
+where latest.run_rank = 1;
+
+-- name: ListRunsByJobIDPage :many
+select r.* from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = sqlc.arg(job_id)
+order by r.created_at desc, r.id desc
+limit sqlc.arg(row_limit) offset sqlc.arg(row_offset);
+
+-- name: CountRunsByJobID :one
+select count(*) from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = sqlc.arg(job_id);
+
+-- name: ListRunDatasetVersionsByJobIDPage :many
+select
+  rdv.run_id,
+  rdv.io_type,
+  n.name as namespace_name,
+  v.name as dataset_name
+from (
+  select r.id from lineage.runs r
+  join lineage.job_versions jv on jv.id = r.job_version_id
+  where jv.job_id = sqlc.arg(job_id)
+  order by r.created_at desc, r.id desc
+  limit sqlc.arg(row_limit) offset sqlc.arg(row_offset)
+) p
+join lineage.run_dataset_versions rdv on rdv.run_id = p.id
+join lineage.dataset_versions v on v.id = rdv.dataset_version_id
+join lineage.dataset_namespaces n on n.id = v.namespace_id
+order by rdv.run_id, n.name, v.name;
+
+-- name: CreateRequestPrune :exec
+insert into lineage.request_prunes (
+  created_before, requests, created_at
+) values (
+  sqlc.arg(created_before), sqlc.arg(requests), sqlc.arg(created_at)
+);
+
+-- name: GetLastRequestPrune :one
+select * from lineage.request_prunes
+order by id desc
+limit 1;
+
+-- name: DeleteRequestPrunes :exec
+delete from lineage.request_prunes;
+
+-- name: GetDatasetVersionByDatasetIDAndVersionUuid :one
+select * from lineage.dataset_versions
+where dataset_id = sqlc.arg(dataset_id) and version_uuid = sqlc.arg(version_uuid)
+limit 1;
+
+-- name: GetJobVersionByJobIDAndVersionUuid :one
+select * from lineage.job_versions
+where job_id = sqlc.arg(job_id) and version_uuid = sqlc.arg(version_uuid)
+limit 1;
+
+-- name: ListDatasetsWithNamespacesByIDs :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where d.id in (select cast(value as bigint) from json_array_elements_text(sqlc.arg(ids)))
+order by d.id;
+
+-- name: ListCurrentFieldsByDatasetIDs :many
+select
+  d.id as dataset_id,
+  f.id,
+  f.name,
+  f.data_type,
+  f.description,
+  f.created_at,
+  f.updated_at
+from lineage.datasets d
+join lineage.fields f on f.dataset_version_id = d.current_version_id
+where d.id in (select cast(value as bigint) from json_array_elements_text(sqlc.arg(ids)))
+order by d.id, f.name;
+
+-- name: ListJobsWithNamespacesByIDs :many
+select
+  j.id,
+  j.current_version_id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where j.id in (select cast(value as bigint) from json_array_elements_text(sqlc.arg(ids)))
+order by j.id;
+
+-- name: ListDatasetEdgesByJobIDs :many
+with page as (
+  select j.id, j.current_version_id from lineage.jobs j
+  where j.id in (select cast(value as bigint) from json_array_elements_text(sqlc.arg(ids)))
+), edges as (
+  select distinct jv.job_id, dv.dataset_id, rdv.io_type
+  from page p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+  join lineage.run_dataset_versions rdv on rdv.run_id = r.id
+  join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+  union
+  select p.id, jvio.dataset_id, jvio.io_type
+  from page p
+  join lineage.job_version_io_datasets jvio on jvio.job_version_id = p.current_version_id
+)
+select distinct
+  e.job_id,
+  e.io_type,
+  cns.name as namespace_name,
+  cd.name as dataset_name
+from edges e
+join lineage.datasets d on d.id = e.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+left join lineage.dataset_aliases a on a.namespace = ns.name and a.name = d.name
+join lineage.datasets cd on cd.id = coalesce(a.dataset_id, d.id)
+join lineage.dataset_namespaces cns on cns.id = cd.namespace_id
+order by e.job_id, cns.name, cd.name;
+
+-- name: ListLatestRunsByJobIDs :many
+select
+  r.*,
+  latest.job_id
+from (
+  select r.id, jv.job_id, row_number() over (partition by jv.job_id order by r.created_at desc, r.id desc) as run_rank
+  from lineage.job_versions jv
+  join lineage.runs r on r.job_version_id = jv.id
+  where jv.job_id in (select cast(value as bigint) from json_array_elements_text(sqlc.arg(ids)))
+) latest
+join lineage.runs r on r.id = latest.id
+where latest.run_rank = 1;
@@ -15,6 +15,183 @@ import (
 	"github.com/tabbed/pqtype"
 )
 
+const claimPendingRequest = `-- name: ClaimPendingRequest :one
+select id, payload, created_at, status, attempts, next_attempt_at, error_message, idempotency_key, run_event_id, processed_at, event_time from lineage.requests
+where status = 1 and next_attempt_at <= $1
+order by next_attempt_at, id
+limit 1
+for update skip locked
+`
+
+func (q *Queries) ClaimPendingRequest(ctx context.Context, now time.Time) (LineageRequest, error) {
+	row := q.db.QueryRowContext(ctx, claimPendingRequest, now)
+	var i LineageRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ErrorMessage,
+		&i.IdempotencyKey,
+		&i.RunEventID,
+		&i.ProcessedAt,
+		&i.EventTime,
+	)
+	return i, err
+}
+
+const clearLifecycleStateChangeRunsForOldRuns = `-- name: ClearLifecycleStateChangeRunsForOldRuns :exec
+update lineage.lifecycle_state_changes set run_id = null
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) ClearLifecycleStateChangeRunsForOldRuns(ctx context.Context, runsPerJob int64) error {
+	_, err := q.db.ExecContext(ctx, clearLifecycleStateChangeRunsForOldRuns, runsPerJob)
+	return err
+}
+
+const clearParentRunsForOldRuns = `-- name: ClearParentRunsForOldRuns :exec
+update lineage.runs set parent_run_id = null
+where parent_run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) ClearParentRunsForOldRuns(ctx context.Context, runsPerJob int64) error {
+	_, err := q.db.ExecContext(ctx, clearParentRunsForOldRuns, runsPerJob)
+	return err
+}
+
+const clearRequestRunEventsForOldRuns = `-- name: ClearRequestRunEventsForOldRuns :exec
+update lineage.requests set run_event_id = null
+where run_event_id in (
+  select e.id from lineage.run_events e
+  where e.run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+  )
+)
+`
+
+func (q *Queries) ClearRequestRunEventsForOldRuns(ctx context.Context, runsPerJob int64) error {
+	_, err := q.db.ExecContext(ctx, clearRequestRunEventsForOldRuns, runsPerJob)
+	return err
+}
+
+const countDatasetsByNamespace = `-- name: CountDatasetsByNamespace :one
+select count(*) from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = $1 and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+`
+
+func (q *Queries) CountDatasetsByNamespace(ctx context.Context, namespaceName string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDatasetsByNamespace, namespaceName)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countJobsByNamespace = `-- name: CountJobsByNamespace :one
+select count(*) from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = $1
+`
+
+func (q *Queries) CountJobsByNamespace(ctx context.Context, namespaceName string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countJobsByNamespace, namespaceName)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRequestsForReplay = `-- name: CountRequestsForReplay :one
+select count(*) from lineage.requests
+where status <> 1 and id <= $1
+`
+
+func (q *Queries) CountRequestsForReplay(ctx context.Context, maxID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRequestsForReplay, maxID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRunsByJobID = `-- name: CountRunsByJobID :one
+select count(*) from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+`
+
+func (q *Queries) CountRunsByJobID(ctx context.Context, jobID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRunsByJobID, jobID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+insert into lineage.api_tokens (
+  name, token_hash, token_prefix, producer, namespace, created_at
+) values (
+  $1, $2, $3, $4, $5,
+  $6
+)
+returning id, name, token_hash, token_prefix, producer, namespace, created_at, last_used_at
+`
+
+type CreateAPITokenParams struct {
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	Producer    sql.NullString
+	Namespace   sql.NullString
+	CreatedAt   time.Time
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (LineageApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.Name,
+		arg.TokenHash,
+		arg.TokenPrefix,
+		arg.Producer,
+		arg.Namespace,
+		arg.CreatedAt,
+	)
+	var i LineageApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.TokenHash,
+		&i.TokenPrefix,
+		&i.Producer,
+		&i.Namespace,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
 const createDataset = `-- name: CreateDataset :one
 insert into lineage.datasets (
   namespace_id,
@@ -54,6 +231,35 @@ func (q *Queries) CreateDataset(ctx context.Context, arg CreateDatasetParams) (L
 	return i, err
 }
 
+const createDatasetAliasIfNotExists = `-- name: CreateDatasetAliasIfNotExists :exec
+insert into lineage.dataset_aliases (
+  namespace,
+  name,
+  dataset_id,
+  created_at
+) values (
+  $1, $2, $3, $4
+)
+on conflict (namespace, name) do nothing
+`
+
+type CreateDatasetAliasIfNotExistsParams struct {
+	Namespace string
+	Name      string
+	DatasetID int64
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateDatasetAliasIfNotExists(ctx context.Context, arg CreateDatasetAliasIfNotExistsParams) error {
+	_, err := q.db.ExecContext(ctx, createDatasetAliasIfNotExists,
+		arg.Namespace,
+		arg.Name,
+		arg.DatasetID,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const createDatasetNamespace = `-- name: CreateDatasetNamespace :one
 insert into lineage.dataset_namespaces (
   name,
@@ -86,11 +292,12 @@ insert into lineage.dataset_versions (
   dataset_id,
   namespace_id,
   name,
-  created_at
+  created_at,
+  version_uuid
 ) values (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5
 )
-returning id, dataset_id, namespace_id, name, created_at, updated_at
+returning id, dataset_id, namespace_id, name, created_at, updated_at, schema_recorded, version_uuid
 `
 
 type CreateDatasetVersionParams struct {
@@ -98,6 +305,7 @@ type CreateDatasetVersionParams struct {
 	NamespaceID int64
 	Name        string
 	CreatedAt   time.Time
+	VersionUuid uuid.UUID
 }
 
 func (q *Queries) CreateDatasetVersion(ctx context.Context, arg CreateDatasetVersionParams) (LineageDatasetVersion, error) {
@@ -106,6 +314,7 @@ func (q *Queries) CreateDatasetVersion(ctx context.Context, arg CreateDatasetVer
 		arg.NamespaceID,
 		arg.Name,
 		arg.CreatedAt,
+		arg.VersionUuid,
 	)
 	var i LineageDatasetVersion
 	err := row.Scan(
@@ -115,6 +324,51 @@ func (q *Queries) CreateDatasetVersion(ctx context.Context, arg CreateDatasetVer
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SchemaRecorded,
+		&i.VersionUuid,
+	)
+	return i, err
+}
+
+const createFailedEvent = `-- name: CreateFailedEvent :one
+insert into lineage.failed_events (
+  payload, producer, error_message, stacktrace, request_id, created_at
+) values (
+  $1, $2, $3, $4, $5,
+  $6
+)
+returning id, payload, producer, error_message, stacktrace, request_id, attempts, created_at, updated_at
+`
+
+type CreateFailedEventParams struct {
+	Payload      json.RawMessage
+	Producer     sql.NullString
+	ErrorMessage string
+	Stacktrace   sql.NullString
+	RequestID    sql.NullInt64
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateFailedEvent(ctx context.Context, arg CreateFailedEventParams) (LineageFailedEvent, error) {
+	row := q.db.QueryRowContext(ctx, createFailedEvent,
+		arg.Payload,
+		arg.Producer,
+		arg.ErrorMessage,
+		arg.Stacktrace,
+		arg.RequestID,
+		arg.CreatedAt,
+	)
+	var i LineageFailedEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Payload,
+		&i.Producer,
+		&i.ErrorMessage,
+		&i.Stacktrace,
+		&i.RequestID,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -236,11 +490,12 @@ insert into lineage.job_versions (
   namespace_id,
   name,
   facets,
-  created_at
+  created_at,
+  version_uuid
 ) values (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6
 )
-returning id, job_id, namespace_id, name, facets, created_at, updated_at
+returning id, job_id, namespace_id, name, facets, created_at, updated_at, version_uuid
 `
 
 type CreateJobVersionParams struct {
@@ -249,6 +504,7 @@ type CreateJobVersionParams struct {
 	Name        string
 	Facets      pqtype.NullRawMessage
 	CreatedAt   time.Time
+	VersionUuid uuid.UUID
 }
 
 func (q *Queries) CreateJobVersion(ctx context.Context, arg CreateJobVersionParams) (LineageJobVersion, error) {
@@ -258,6 +514,7 @@ func (q *Queries) CreateJobVersion(ctx context.Context, arg CreateJobVersionPara
 		arg.Name,
 		arg.Facets,
 		arg.CreatedAt,
+		arg.VersionUuid,
 	)
 	var i LineageJobVersion
 	err := row.Scan(
@@ -268,6 +525,47 @@ func (q *Queries) CreateJobVersion(ctx context.Context, arg CreateJobVersionPara
 		&i.Facets,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VersionUuid,
+	)
+	return i, err
+}
+
+const createJobVersionIODataset = `-- name: CreateJobVersionIODataset :one
+insert into lineage.job_version_io_datasets (
+  job_version_id,
+  dataset_id,
+  io_type,
+  io_facets,
+  created_at
+) values (
+  $1, $2, $3, $4, $5
+)
+returning job_version_id, dataset_id, io_type, io_facets, created_at
+`
+
+type CreateJobVersionIODatasetParams struct {
+	JobVersionID int64
+	DatasetID    int64
+	IoType       int32
+	IoFacets     pqtype.NullRawMessage
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateJobVersionIODataset(ctx context.Context, arg CreateJobVersionIODatasetParams) (LineageJobVersionIoDataset, error) {
+	row := q.db.QueryRowContext(ctx, createJobVersionIODataset,
+		arg.JobVersionID,
+		arg.DatasetID,
+		arg.IoType,
+		arg.IoFacets,
+		arg.CreatedAt,
+	)
+	var i LineageJobVersionIoDataset
+	err := row.Scan(
+		&i.JobVersionID,
+		&i.DatasetID,
+		&i.IoType,
+		&i.IoFacets,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -275,36 +573,36 @@ func (q *Queries) CreateJobVersion(ctx context.Context, arg CreateJobVersionPara
 const createLifecycleStateChange = `-- name: CreateLifecycleStateChange :one
 insert into lineage.lifecycle_state_changes (
   dataset_id,
+  run_id,
+  change,
   namespace,
   name,
-  data_type,
-  change,
-  updated_at,
+  previous_dataset_id,
   created_at
 ) values (
   $1, $2, $3, $4, $5, $6, $7
 )
-returning id, dataset_id, change, namespace, name, data_type, created_at, updated_at
+returning id, dataset_id, change, namespace, name, created_at, updated_at, run_id, previous_dataset_id
 `
 
 type CreateLifecycleStateChangeParams struct {
-	DatasetID int64
-	Namespace sql.NullString
-	Name      sql.NullString
-	DataType  string
-	Change    sql.NullString
-	UpdatedAt sql.NullTime
-	CreatedAt time.Time
+	DatasetID         int64
+	RunID             sql.NullInt64
+	Change            sql.NullString
+	Namespace         sql.NullString
+	Name              sql.NullString
+	PreviousDatasetID sql.NullInt64
+	CreatedAt         time.Time
 }
 
 func (q *Queries) CreateLifecycleStateChange(ctx context.Context, arg CreateLifecycleStateChangeParams) (LineageLifecycleStateChange, error) {
 	row := q.db.QueryRowContext(ctx, createLifecycleStateChange,
 		arg.DatasetID,
+		arg.RunID,
+		arg.Change,
 		arg.Namespace,
 		arg.Name,
-		arg.DataType,
-		arg.Change,
-		arg.UpdatedAt,
+		arg.PreviousDatasetID,
 		arg.CreatedAt,
 	)
 	var i LineageLifecycleStateChange
@@ -314,9 +612,10 @@ func (q *Queries) CreateLifecycleStateChange(ctx context.Context, arg CreateLife
 		&i.Change,
 		&i.Namespace,
 		&i.Name,
-		&i.DataType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RunID,
+		&i.PreviousDatasetID,
 	)
 	return i, err
 }
@@ -324,25 +623,80 @@ func (q *Queries) CreateLifecycleStateChange(ctx context.Context, arg CreateLife
 const createRequest = `-- name: CreateRequest :one
 INSERT INTO lineage.requests (
   payload,
-  created_at
+  created_at,
+  status,
+  attempts,
+  next_attempt_at,
+  idempotency_key,
+  run_event_id,
+  processed_at,
+  event_time
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, payload, created_at
+RETURNING id, payload, created_at, status, attempts, next_attempt_at, error_message, idempotency_key, run_event_id, processed_at, event_time
 `
 
 type CreateRequestParams struct {
-	Payload   json.RawMessage
-	CreatedAt time.Time
+	Payload        json.RawMessage
+	CreatedAt      time.Time
+	Status         int32
+	Attempts       int32
+	NextAttemptAt  sql.NullTime
+	IdempotencyKey sql.NullString
+	RunEventID     sql.NullInt64
+	ProcessedAt    sql.NullTime
+	EventTime      sql.NullTime
 }
 
 func (q *Queries) CreateRequest(ctx context.Context, arg CreateRequestParams) (LineageRequest, error) {
-	row := q.db.QueryRowContext(ctx, createRequest, arg.Payload, arg.CreatedAt)
+	row := q.db.QueryRowContext(ctx, createRequest,
+		arg.Payload,
+		arg.CreatedAt,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.IdempotencyKey,
+		arg.RunEventID,
+		arg.ProcessedAt,
+		arg.EventTime,
+	)
 	var i LineageRequest
-	err := row.Scan(&i.ID, &i.Payload, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ErrorMessage,
+		&i.IdempotencyKey,
+		&i.RunEventID,
+		&i.ProcessedAt,
+		&i.EventTime,
+	)
 	return i, err
 }
 
+const createRequestPrune = `-- name: CreateRequestPrune :exec
+insert into lineage.request_prunes (
+  created_before, requests, created_at
+) values (
+  $1, $2, $3
+)
+`
+
+type CreateRequestPruneParams struct {
+	CreatedBefore time.Time
+	Requests      int64
+	CreatedAt     time.Time
+}
+
+func (q *Queries) CreateRequestPrune(ctx context.Context, arg CreateRequestPruneParams) error {
+	_, err := q.db.ExecContext(ctx, createRequestPrune, arg.CreatedBefore, arg.Requests, arg.CreatedAt)
+	return err
+}
+
 const createRun = `-- name: CreateRun :one
 INSERT INTO lineage.runs (
   run_uuid,
@@ -356,11 +710,12 @@ INSERT INTO lineage.runs (
   error_message,
   programming_language,
   stacktrace,
-  created_at
+  created_at,
+  is_placeholder
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 )
-RETURNING id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at
+RETURNING id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time
 `
 
 type CreateRunParams struct {
@@ -376,6 +731,7 @@ type CreateRunParams struct {
 	ProgrammingLanguage sql.NullString
 	Stacktrace          sql.NullString
 	CreatedAt           time.Time
+	IsPlaceholder       bool
 }
 
 func (q *Queries) CreateRun(ctx context.Context, arg CreateRunParams) (LineageRun, error) {
@@ -392,6 +748,7 @@ func (q *Queries) CreateRun(ctx context.Context, arg CreateRunParams) (LineageRu
 		arg.ProgrammingLanguage,
 		arg.Stacktrace,
 		arg.CreatedAt,
+		arg.IsPlaceholder,
 	)
 	var i LineageRun
 	err := row.Scan(
@@ -410,6 +767,9 @@ func (q *Queries) CreateRun(ctx context.Context, arg CreateRunParams) (LineageRu
 		&i.Stacktrace,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsPlaceholder,
+		&i.State,
+		&i.LastEventTime,
 	)
 	return i, err
 }
@@ -421,11 +781,13 @@ insert into lineage.run_dataset_versions (
   io_type,
   dataset_facets,
   io_facets,
+  first_event_type,
+  last_event_type,
   created_at
 ) values (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
-returning run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at
+returning run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at, first_event_type, last_event_type, updated_at
 `
 
 type CreateRunDatasetVersionParams struct {
@@ -434,6 +796,8 @@ type CreateRunDatasetVersionParams struct {
 	IoType           int32
 	DatasetFacets    pqtype.NullRawMessage
 	IoFacets         pqtype.NullRawMessage
+	FirstEventType   int32
+	LastEventType    int32
 	CreatedAt        time.Time
 }
 
@@ -444,6 +808,8 @@ func (q *Queries) CreateRunDatasetVersion(ctx context.Context, arg CreateRunData
 		arg.IoType,
 		arg.DatasetFacets,
 		arg.IoFacets,
+		arg.FirstEventType,
+		arg.LastEventType,
 		arg.CreatedAt,
 	)
 	var i LineageRunDatasetVersion
@@ -454,6 +820,9 @@ func (q *Queries) CreateRunDatasetVersion(ctx context.Context, arg CreateRunData
 		&i.DatasetFacets,
 		&i.IoFacets,
 		&i.CreatedAt,
+		&i.FirstEventType,
+		&i.LastEventType,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -464,19 +833,23 @@ INSERT INTO lineage.run_events (
   event_type,
   event_time,
   facets,
-  created_at
+  created_at,
+  payload_hash,
+  idempotency_key
 ) VALUES (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, run_id, event_type, event_time, facets, created_at, updated_at
+RETURNING id, run_id, event_type, event_time, facets, created_at, updated_at, payload_hash, idempotency_key
 `
 
 type CreateRunEventParams struct {
-	RunID     int64
-	EventType int32
-	EventTime time.Time
-	Facets    pqtype.NullRawMessage
-	CreatedAt time.Time
+	RunID          int64
+	EventType      int32
+	EventTime      time.Time
+	Facets         pqtype.NullRawMessage
+	CreatedAt      time.Time
+	PayloadHash    sql.NullString
+	IdempotencyKey sql.NullString
 }
 
 func (q *Queries) CreateRunEvent(ctx context.Context, arg CreateRunEventParams) (LineageRunEvent, error) {
@@ -486,6 +859,8 @@ func (q *Queries) CreateRunEvent(ctx context.Context, arg CreateRunEventParams)
 		arg.EventTime,
 		arg.Facets,
 		arg.CreatedAt,
+		arg.PayloadHash,
+		arg.IdempotencyKey,
 	)
 	var i LineageRunEvent
 	err := row.Scan(
@@ -496,164 +871,535 @@ func (q *Queries) CreateRunEvent(ctx context.Context, arg CreateRunEventParams)
 		&i.Facets,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PayloadHash,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
 
-const getDatasetByID = `-- name: GetDatasetByID :one
-select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.datasets
-where id = $1 limit 1
+const createSchemaChange = `-- name: CreateSchemaChange :one
+insert into lineage.schema_changes (
+  dataset_version_id,
+  previous_version_id,
+  change_type,
+  field_name,
+  previous_data_type,
+  data_type,
+  previous_description,
+  description,
+  created_at
+) values (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+returning id, dataset_version_id, previous_version_id, change_type, field_name, previous_data_type, data_type, previous_description, description, created_at
 `
 
-func (q *Queries) GetDatasetByID(ctx context.Context, id int64) (LineageDataset, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetByID, id)
-	var i LineageDataset
+type CreateSchemaChangeParams struct {
+	DatasetVersionID    int64
+	PreviousVersionID   int64
+	ChangeType          int32
+	FieldName           string
+	PreviousDataType    sql.NullString
+	DataType            sql.NullString
+	PreviousDescription sql.NullString
+	Description         sql.NullString
+	CreatedAt           time.Time
+}
+
+func (q *Queries) CreateSchemaChange(ctx context.Context, arg CreateSchemaChangeParams) (LineageSchemaChange, error) {
+	row := q.db.QueryRowContext(ctx, createSchemaChange,
+		arg.DatasetVersionID,
+		arg.PreviousVersionID,
+		arg.ChangeType,
+		arg.FieldName,
+		arg.PreviousDataType,
+		arg.DataType,
+		arg.PreviousDescription,
+		arg.Description,
+		arg.CreatedAt,
+	)
+	var i LineageSchemaChange
 	err := row.Scan(
 		&i.ID,
-		&i.CurrentVersionID,
-		&i.NamespaceID,
-		&i.Name,
-		&i.Facets,
+		&i.DatasetVersionID,
+		&i.PreviousVersionID,
+		&i.ChangeType,
+		&i.FieldName,
+		&i.PreviousDataType,
+		&i.DataType,
+		&i.PreviousDescription,
+		&i.Description,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getDatasetByNamespaceIDAndName = `-- name: GetDatasetByNamespaceIDAndName :one
-select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.datasets
-where namespace_id = $1 and name = $2 limit 1
+const createSearchDocument = `-- name: CreateSearchDocument :exec
+insert into lineage.search_documents (
+  entity_type, entity_id, field, content, created_at
+) values (
+  $1, $2, $3, $4, $5
+)
 `
 
-type GetDatasetByNamespaceIDAndNameParams struct {
-	NamespaceID int64
-	Name        string
+type CreateSearchDocumentParams struct {
+	EntityType int32
+	EntityID   int64
+	Field      string
+	Content    string
+	CreatedAt  time.Time
 }
 
-func (q *Queries) GetDatasetByNamespaceIDAndName(ctx context.Context, arg GetDatasetByNamespaceIDAndNameParams) (LineageDataset, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetByNamespaceIDAndName, arg.NamespaceID, arg.Name)
-	var i LineageDataset
-	err := row.Scan(
-		&i.ID,
-		&i.CurrentVersionID,
-		&i.NamespaceID,
-		&i.Name,
-		&i.Facets,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+func (q *Queries) CreateSearchDocument(ctx context.Context, arg CreateSearchDocumentParams) error {
+	_, err := q.db.ExecContext(ctx, createSearchDocument,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Field,
+		arg.Content,
+		arg.CreatedAt,
 	)
-	return i, err
+	return err
 }
 
-const getDatasetNamespaceByID = `-- name: GetDatasetNamespaceByID :one
-select id, name, created_at, updated_at from lineage.dataset_namespaces
-where id = $1 limit 1
+const deleteAPIToken = `-- name: DeleteAPIToken :execrows
+delete from lineage.api_tokens
+where id = $1
 `
 
-func (q *Queries) GetDatasetNamespaceByID(ctx context.Context, id int64) (LineageDatasetNamespace, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetNamespaceByID, id)
-	var i LineageDatasetNamespace
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) DeleteAPIToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAPIToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getDatasetNamespaceByName = `-- name: GetDatasetNamespaceByName :one
-select id, name, created_at, updated_at from lineage.dataset_namespaces
-where name = $1 limit 1
+const deleteDatasetAlias = `-- name: DeleteDatasetAlias :exec
+delete from lineage.dataset_aliases
+where namespace = $1 and name = $2
 `
 
-func (q *Queries) GetDatasetNamespaceByName(ctx context.Context, name string) (LineageDatasetNamespace, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetNamespaceByName, name)
-	var i LineageDatasetNamespace
+type DeleteDatasetAliasParams struct {
+	Namespace string
+	Name      string
+}
+
+func (q *Queries) DeleteDatasetAlias(ctx context.Context, arg DeleteDatasetAliasParams) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasetAlias, arg.Namespace, arg.Name)
+	return err
+}
+
+const deleteDatasetAliases = `-- name: DeleteDatasetAliases :exec
+delete from lineage.dataset_aliases
+`
+
+func (q *Queries) DeleteDatasetAliases(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasetAliases)
+	return err
+}
+
+const deleteDatasetNamespaces = `-- name: DeleteDatasetNamespaces :exec
+delete from lineage.dataset_namespaces
+`
+
+func (q *Queries) DeleteDatasetNamespaces(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasetNamespaces)
+	return err
+}
+
+const deleteDatasetVersion = `-- name: DeleteDatasetVersion :exec
+delete from lineage.dataset_versions
+where id = $1
+`
+
+func (q *Queries) DeleteDatasetVersion(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasetVersion, id)
+	return err
+}
+
+const deleteDatasetVersions = `-- name: DeleteDatasetVersions :exec
+delete from lineage.dataset_versions
+`
+
+func (q *Queries) DeleteDatasetVersions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasetVersions)
+	return err
+}
+
+const deleteDatasets = `-- name: DeleteDatasets :exec
+delete from lineage.datasets
+`
+
+func (q *Queries) DeleteDatasets(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteDatasets)
+	return err
+}
+
+const deleteFacets = `-- name: DeleteFacets :exec
+delete from lineage.facets
+`
+
+func (q *Queries) DeleteFacets(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteFacets)
+	return err
+}
+
+const deleteFacetsForOldRuns = `-- name: DeleteFacetsForOldRuns :exec
+delete from lineage.facets
+where entity_type = 2
+  and entity_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) DeleteFacetsForOldRuns(ctx context.Context, runsPerJob int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFacetsForOldRuns, runsPerJob)
+	return err
+}
+
+const deleteFailedEvent = `-- name: DeleteFailedEvent :exec
+delete from lineage.failed_events
+where id = $1
+`
+
+func (q *Queries) DeleteFailedEvent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFailedEvent, id)
+	return err
+}
+
+const deleteFields = `-- name: DeleteFields :exec
+delete from lineage.fields
+`
+
+func (q *Queries) DeleteFields(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteFields)
+	return err
+}
+
+const deleteFieldsByDatasetVersionID = `-- name: DeleteFieldsByDatasetVersionID :exec
+delete from lineage.fields
+where dataset_version_id = $1
+`
+
+func (q *Queries) DeleteFieldsByDatasetVersionID(ctx context.Context, datasetVersionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFieldsByDatasetVersionID, datasetVersionID)
+	return err
+}
+
+const deleteJobNamespaces = `-- name: DeleteJobNamespaces :exec
+delete from lineage.job_namespaces
+`
+
+func (q *Queries) DeleteJobNamespaces(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteJobNamespaces)
+	return err
+}
+
+const deleteJobVersionIODatasets = `-- name: DeleteJobVersionIODatasets :exec
+delete from lineage.job_version_io_datasets
+`
+
+func (q *Queries) DeleteJobVersionIODatasets(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteJobVersionIODatasets)
+	return err
+}
+
+const deleteJobVersions = `-- name: DeleteJobVersions :exec
+delete from lineage.job_versions
+`
+
+func (q *Queries) DeleteJobVersions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteJobVersions)
+	return err
+}
+
+const deleteJobs = `-- name: DeleteJobs :exec
+delete from lineage.jobs
+`
+
+func (q *Queries) DeleteJobs(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteJobs)
+	return err
+}
+
+const deleteLifecycleStateChanges = `-- name: DeleteLifecycleStateChanges :exec
+delete from lineage.lifecycle_state_changes
+`
+
+func (q *Queries) DeleteLifecycleStateChanges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteLifecycleStateChanges)
+	return err
+}
+
+const deleteOldRuns = `-- name: DeleteOldRuns :execrows
+delete from lineage.runs
+where id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) DeleteOldRuns(ctx context.Context, runsPerJob int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldRuns, runsPerJob)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRequestPrunes = `-- name: DeleteRequestPrunes :exec
+delete from lineage.request_prunes
+`
+
+func (q *Queries) DeleteRequestPrunes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteRequestPrunes)
+	return err
+}
+
+const deleteRequestsCreatedBefore = `-- name: DeleteRequestsCreatedBefore :execrows
+delete from lineage.requests
+where created_at < $1
+  and status <> 1
+`
+
+func (q *Queries) DeleteRequestsCreatedBefore(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRequestsCreatedBefore, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRunDatasetVersions = `-- name: DeleteRunDatasetVersions :exec
+delete from lineage.run_dataset_versions
+`
+
+func (q *Queries) DeleteRunDatasetVersions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteRunDatasetVersions)
+	return err
+}
+
+const deleteRunDatasetVersionsForOldRuns = `-- name: DeleteRunDatasetVersionsForOldRuns :exec
+delete from lineage.run_dataset_versions
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) DeleteRunDatasetVersionsForOldRuns(ctx context.Context, runsPerJob int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRunDatasetVersionsForOldRuns, runsPerJob)
+	return err
+}
+
+const deleteRunEvents = `-- name: DeleteRunEvents :exec
+delete from lineage.run_events
+`
+
+func (q *Queries) DeleteRunEvents(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteRunEvents)
+	return err
+}
+
+const deleteRunEventsForOldRuns = `-- name: DeleteRunEventsForOldRuns :execrows
+delete from lineage.run_events
+where run_id in (
+  select id from (
+    select r.id, row_number() over (partition by v.job_id order by coalesce(r.started_at, r.last_event_time, r.created_at) desc, r.id desc) as run_rank
+    from lineage.runs r
+    join lineage.job_versions v on v.id = r.job_version_id
+  ) ranked
+  where run_rank > $1
+)
+`
+
+func (q *Queries) DeleteRunEventsForOldRuns(ctx context.Context, runsPerJob int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRunEventsForOldRuns, runsPerJob)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRuns = `-- name: DeleteRuns :exec
+delete from lineage.runs
+`
+
+func (q *Queries) DeleteRuns(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteRuns)
+	return err
+}
+
+const deleteSchemaChanges = `-- name: DeleteSchemaChanges :exec
+delete from lineage.schema_changes
+`
+
+func (q *Queries) DeleteSchemaChanges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteSchemaChanges)
+	return err
+}
+
+const deleteSchemaChangesByDatasetVersionID = `-- name: DeleteSchemaChangesByDatasetVersionID :exec
+delete from lineage.schema_changes
+where dataset_version_id = $1
+`
+
+func (q *Queries) DeleteSchemaChangesByDatasetVersionID(ctx context.Context, datasetVersionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSchemaChangesByDatasetVersionID, datasetVersionID)
+	return err
+}
+
+const deleteSearchDocuments = `-- name: DeleteSearchDocuments :exec
+delete from lineage.search_documents
+`
+
+func (q *Queries) DeleteSearchDocuments(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteSearchDocuments)
+	return err
+}
+
+const deleteSearchDocumentsByEntity = `-- name: DeleteSearchDocumentsByEntity :exec
+delete from lineage.search_documents
+where entity_type = $1 and entity_id = $2
+`
+
+type DeleteSearchDocumentsByEntityParams struct {
+	EntityType int32
+	EntityID   int64
+}
+
+func (q *Queries) DeleteSearchDocumentsByEntity(ctx context.Context, arg DeleteSearchDocumentsByEntityParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSearchDocumentsByEntity, arg.EntityType, arg.EntityID)
+	return err
+}
+
+const fillPlaceholderRun = `-- name: FillPlaceholderRun :one
+UPDATE lineage.runs SET 
+  job_version_id = $2,
+  facets = $3,
+  parent_run_id = $4,
+  started_at = $5,
+  nominal_started_at = $6,
+  nominal_ended_at = $7,
+  updated_at = $8,
+  is_placeholder = false
+WHERE id = $1
+RETURNING id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time
+`
+
+type FillPlaceholderRunParams struct {
+	ID               int64
+	JobVersionID     int64
+	Facets           pqtype.NullRawMessage
+	ParentRunID      sql.NullInt64
+	StartedAt        sql.NullTime
+	NominalStartedAt sql.NullTime
+	NominalEndedAt   sql.NullTime
+	UpdatedAt        sql.NullTime
+}
+
+func (q *Queries) FillPlaceholderRun(ctx context.Context, arg FillPlaceholderRunParams) (LineageRun, error) {
+	row := q.db.QueryRowContext(ctx, fillPlaceholderRun,
+		arg.ID,
+		arg.JobVersionID,
+		arg.Facets,
+		arg.ParentRunID,
+		arg.StartedAt,
+		arg.NominalStartedAt,
+		arg.NominalEndedAt,
+		arg.UpdatedAt,
+	)
+	var i LineageRun
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
+		&i.RunUuid,
+		&i.JobVersionID,
+		&i.ParentRunID,
+		&i.LastEventType,
+		&i.Facets,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.NominalStartedAt,
+		&i.NominalEndedAt,
+		&i.ErrorMessage,
+		&i.ProgrammingLanguage,
+		&i.Stacktrace,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsPlaceholder,
+		&i.State,
+		&i.LastEventTime,
 	)
 	return i, err
 }
 
-const getDatasetVersionByID = `-- name: GetDatasetVersionByID :one
-select id, dataset_id, namespace_id, name, created_at, updated_at from lineage.dataset_versions
-where id = $1 limit 1
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+select id, name, token_hash, token_prefix, producer, namespace, created_at, last_used_at from lineage.api_tokens
+where token_hash = $1 limit 1
 `
 
-func (q *Queries) GetDatasetVersionByID(ctx context.Context, id int64) (LineageDatasetVersion, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetVersionByID, id)
-	var i LineageDatasetVersion
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (LineageApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPITokenByHash, tokenHash)
+	var i LineageApiToken
 	err := row.Scan(
 		&i.ID,
-		&i.DatasetID,
-		&i.NamespaceID,
 		&i.Name,
+		&i.TokenHash,
+		&i.TokenPrefix,
+		&i.Producer,
+		&i.Namespace,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
-const getDatasetWithNamespace = `-- name: GetDatasetWithNamespace :one
-select 
-  d.id, 
-  d.current_version_id,
-  d.name, 
-  d.namespace_id, 
-  d.facets,
-  d.updated_at,
-  d.created_at,
-  ns.name as namespace_name,
-  ns.updated_at as namespace_updated_at,
-  ns.created_at as namespace_created_at
-from lineage.datasets d
-join lineage.dataset_namespaces ns on ns.id = d.namespace_id
-where d.id = $1
+const getDatasetAliasByName = `-- name: GetDatasetAliasByName :one
+select id, namespace, name, dataset_id, is_manual, created_at, updated_at from lineage.dataset_aliases
+where namespace = $1 and name = $2
 `
 
-type GetDatasetWithNamespaceRow struct {
-	ID                 int64
-	CurrentVersionID   sql.NullInt64
-	Name               string
-	NamespaceID        int64
-	Facets             pqtype.NullRawMessage
-	UpdatedAt          sql.NullTime
-	CreatedAt          time.Time
-	NamespaceName      string
-	NamespaceUpdatedAt sql.NullTime
-	NamespaceCreatedAt time.Time
+type GetDatasetAliasByNameParams struct {
+	Namespace string
+	Name      string
 }
 
-func (q *Queries) GetDatasetWithNamespace(ctx context.Context, id int64) (GetDatasetWithNamespaceRow, error) {
-	row := q.db.QueryRowContext(ctx, getDatasetWithNamespace, id)
-	var i GetDatasetWithNamespaceRow
+func (q *Queries) GetDatasetAliasByName(ctx context.Context, arg GetDatasetAliasByNameParams) (LineageDatasetAlias, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetAliasByName, arg.Namespace, arg.Name)
+	var i LineageDatasetAlias
 	err := row.Scan(
 		&i.ID,
-		&i.CurrentVersionID,
+		&i.Namespace,
 		&i.Name,
-		&i.NamespaceID,
-		&i.Facets,
-		&i.UpdatedAt,
+		&i.DatasetID,
+		&i.IsManual,
 		&i.CreatedAt,
-		&i.NamespaceName,
-		&i.NamespaceUpdatedAt,
-		&i.NamespaceCreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getJobByID = `-- name: GetJobByID :one
-select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
+const getDatasetByID = `-- name: GetDatasetByID :one
+select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.datasets
 where id = $1 limit 1
 `
 
-func (q *Queries) GetJobByID(ctx context.Context, id int64) (LineageJob, error) {
-	row := q.db.QueryRowContext(ctx, getJobByID, id)
-	var i LineageJob
+func (q *Queries) GetDatasetByID(ctx context.Context, id int64) (LineageDataset, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetByID, id)
+	var i LineageDataset
 	err := row.Scan(
 		&i.ID,
 		&i.CurrentVersionID,
@@ -666,19 +1412,19 @@ func (q *Queries) GetJobByID(ctx context.Context, id int64) (LineageJob, error)
 	return i, err
 }
 
-const getJobByNamespaceIDAndName = `-- name: GetJobByNamespaceIDAndName :one
-select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
+const getDatasetByNamespaceIDAndName = `-- name: GetDatasetByNamespaceIDAndName :one
+select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.datasets
 where namespace_id = $1 and name = $2 limit 1
 `
 
-type GetJobByNamespaceIDAndNameParams struct {
+type GetDatasetByNamespaceIDAndNameParams struct {
 	NamespaceID int64
 	Name        string
 }
 
-func (q *Queries) GetJobByNamespaceIDAndName(ctx context.Context, arg GetJobByNamespaceIDAndNameParams) (LineageJob, error) {
-	row := q.db.QueryRowContext(ctx, getJobByNamespaceIDAndName, arg.NamespaceID, arg.Name)
-	var i LineageJob
+func (q *Queries) GetDatasetByNamespaceIDAndName(ctx context.Context, arg GetDatasetByNamespaceIDAndNameParams) (LineageDataset, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetByNamespaceIDAndName, arg.NamespaceID, arg.Name)
+	var i LineageDataset
 	err := row.Scan(
 		&i.ID,
 		&i.CurrentVersionID,
@@ -691,14 +1437,14 @@ func (q *Queries) GetJobByNamespaceIDAndName(ctx context.Context, arg GetJobByNa
 	return i, err
 }
 
-const getJobNamespaceByID = `-- name: GetJobNamespaceByID :one
-select id, name, created_at, updated_at from lineage.job_namespaces
+const getDatasetNamespaceByID = `-- name: GetDatasetNamespaceByID :one
+select id, name, created_at, updated_at from lineage.dataset_namespaces
 where id = $1 limit 1
 `
 
-func (q *Queries) GetJobNamespaceByID(ctx context.Context, id int64) (LineageJobNamespace, error) {
-	row := q.db.QueryRowContext(ctx, getJobNamespaceByID, id)
-	var i LineageJobNamespace
+func (q *Queries) GetDatasetNamespaceByID(ctx context.Context, id int64) (LineageDatasetNamespace, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetNamespaceByID, id)
+	var i LineageDatasetNamespace
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
@@ -708,14 +1454,14 @@ func (q *Queries) GetJobNamespaceByID(ctx context.Context, id int64) (LineageJob
 	return i, err
 }
 
-const getJobNamespaceByName = `-- name: GetJobNamespaceByName :one
-select id, name, created_at, updated_at from lineage.job_namespaces
+const getDatasetNamespaceByName = `-- name: GetDatasetNamespaceByName :one
+select id, name, created_at, updated_at from lineage.dataset_namespaces
 where name = $1 limit 1
 `
 
-func (q *Queries) GetJobNamespaceByName(ctx context.Context, name string) (LineageJobNamespace, error) {
-	row := q.db.QueryRowContext(ctx, getJobNamespaceByName, name)
-	var i LineageJobNamespace
+func (q *Queries) GetDatasetNamespaceByName(ctx context.Context, name string) (LineageDatasetNamespace, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetNamespaceByName, name)
+	var i LineageDatasetNamespace
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
@@ -725,44 +1471,88 @@ func (q *Queries) GetJobNamespaceByName(ctx context.Context, name string) (Linea
 	return i, err
 }
 
-const getJobVersionByID = `-- name: GetJobVersionByID :one
-select id, job_id, namespace_id, name, facets, created_at, updated_at from lineage.job_versions
-where id = $1 limit 1
+const getDatasetVersionByDatasetIDAndVersionUuid = `-- name: GetDatasetVersionByDatasetIDAndVersionUuid :one
+select id, dataset_id, namespace_id, name, created_at, updated_at, schema_recorded, version_uuid from lineage.dataset_versions
+where dataset_id = $1 and version_uuid = $2
+limit 1
 `
 
-func (q *Queries) GetJobVersionByID(ctx context.Context, id int64) (LineageJobVersion, error) {
-	row := q.db.QueryRowContext(ctx, getJobVersionByID, id)
-	var i LineageJobVersion
-	err := row.Scan(
-		&i.ID,
-		&i.JobID,
+type GetDatasetVersionByDatasetIDAndVersionUuidParams struct {
+	DatasetID   int64
+	VersionUuid uuid.UUID
+}
+
+func (q *Queries) GetDatasetVersionByDatasetIDAndVersionUuid(ctx context.Context, arg GetDatasetVersionByDatasetIDAndVersionUuidParams) (LineageDatasetVersion, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetVersionByDatasetIDAndVersionUuid, arg.DatasetID, arg.VersionUuid)
+	var i LineageDatasetVersion
+	err := row.Scan(
+		&i.ID,
+		&i.DatasetID,
 		&i.NamespaceID,
 		&i.Name,
-		&i.Facets,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SchemaRecorded,
+		&i.VersionUuid,
 	)
 	return i, err
 }
 
-const getJobWithNamespace = `-- name: GetJobWithNamespace :one
+const getDatasetVersionByID = `-- name: GetDatasetVersionByID :one
+select id, dataset_id, namespace_id, name, created_at, updated_at, schema_recorded, version_uuid from lineage.dataset_versions
+where id = $1 limit 1
+`
+
+func (q *Queries) GetDatasetVersionByID(ctx context.Context, id int64) (LineageDatasetVersion, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetVersionByID, id)
+	var i LineageDatasetVersion
+	err := row.Scan(
+		&i.ID,
+		&i.DatasetID,
+		&i.NamespaceID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SchemaRecorded,
+		&i.VersionUuid,
+	)
+	return i, err
+}
+
+const getDatasetVersionCreatedByRunUUID = `-- name: GetDatasetVersionCreatedByRunUUID :one
+select r.run_uuid from lineage.run_dataset_versions rdv
+join lineage.runs r on r.id = rdv.run_id
+where rdv.dataset_version_id = $1
+  and rdv.io_type = 2
+order by rdv.created_at, r.id
+limit 1
+`
+
+func (q *Queries) GetDatasetVersionCreatedByRunUUID(ctx context.Context, datasetVersionID int64) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetVersionCreatedByRunUUID, datasetVersionID)
+	var run_uuid uuid.UUID
+	err := row.Scan(&run_uuid)
+	return run_uuid, err
+}
+
+const getDatasetWithNamespace = `-- name: GetDatasetWithNamespace :one
 select 
-  j.id, 
-  j.current_version_id, 
-  j.name, 
-  j.namespace_id, 
-  j.facets,
-  j.updated_at,
-  j.created_at,
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
   ns.name as namespace_name,
   ns.updated_at as namespace_updated_at,
   ns.created_at as namespace_created_at
-from lineage.jobs j
-join lineage.job_namespaces ns on ns.id = j.namespace_id
-where j.id = $1
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where d.id = $1
 `
 
-type GetJobWithNamespaceRow struct {
+type GetDatasetWithNamespaceRow struct {
 	ID                 int64
 	CurrentVersionID   sql.NullInt64
 	Name               string
@@ -775,9 +1565,9 @@ type GetJobWithNamespaceRow struct {
 	NamespaceCreatedAt time.Time
 }
 
-func (q *Queries) GetJobWithNamespace(ctx context.Context, id int64) (GetJobWithNamespaceRow, error) {
-	row := q.db.QueryRowContext(ctx, getJobWithNamespace, id)
-	var i GetJobWithNamespaceRow
+func (q *Queries) GetDatasetWithNamespace(ctx context.Context, id int64) (GetDatasetWithNamespaceRow, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetWithNamespace, id)
+	var i GetDatasetWithNamespaceRow
 	err := row.Scan(
 		&i.ID,
 		&i.CurrentVersionID,
@@ -793,183 +1583,2677 @@ func (q *Queries) GetJobWithNamespace(ctx context.Context, id int64) (GetJobWith
 	return i, err
 }
 
-const getLatestRunDatasetVersionByDatasetVersionID = `-- name: GetLatestRunDatasetVersionByDatasetVersionID :one
-select run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at from lineage.run_dataset_versions
-where dataset_version_id = $1 order by created_at desc limit 1
+const getDatasetWithNamespaceByName = `-- name: GetDatasetWithNamespaceByName :one
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = $1 and d.name = $2
 `
 
-func (q *Queries) GetLatestRunDatasetVersionByDatasetVersionID(ctx context.Context, datasetVersionID int64) (LineageRunDatasetVersion, error) {
-	row := q.db.QueryRowContext(ctx, getLatestRunDatasetVersionByDatasetVersionID, datasetVersionID)
-	var i LineageRunDatasetVersion
+type GetDatasetWithNamespaceByNameParams struct {
+	NamespaceName string
+	Name          string
+}
+
+type GetDatasetWithNamespaceByNameRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) GetDatasetWithNamespaceByName(ctx context.Context, arg GetDatasetWithNamespaceByNameParams) (GetDatasetWithNamespaceByNameRow, error) {
+	row := q.db.QueryRowContext(ctx, getDatasetWithNamespaceByName, arg.NamespaceName, arg.Name)
+	var i GetDatasetWithNamespaceByNameRow
 	err := row.Scan(
-		&i.RunID,
-		&i.DatasetVersionID,
-		&i.IoType,
-		&i.DatasetFacets,
-		&i.IoFacets,
+		&i.ID,
+		&i.CurrentVersionID,
+		&i.Name,
+		&i.NamespaceID,
+		&i.Facets,
+		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.NamespaceName,
+		&i.NamespaceUpdatedAt,
+		&i.NamespaceCreatedAt,
 	)
 	return i, err
 }
 
-const getRunByID = `-- name: GetRunByID :one
-select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at from lineage.runs
+const getFailedEventByID = `-- name: GetFailedEventByID :one
+select id, payload, producer, error_message, stacktrace, request_id, attempts, created_at, updated_at from lineage.failed_events
 where id = $1 limit 1
 `
 
-func (q *Queries) GetRunByID(ctx context.Context, id int64) (LineageRun, error) {
-	row := q.db.QueryRowContext(ctx, getRunByID, id)
-	var i LineageRun
+func (q *Queries) GetFailedEventByID(ctx context.Context, id int64) (LineageFailedEvent, error) {
+	row := q.db.QueryRowContext(ctx, getFailedEventByID, id)
+	var i LineageFailedEvent
 	err := row.Scan(
 		&i.ID,
-		&i.RunUuid,
-		&i.JobVersionID,
-		&i.ParentRunID,
-		&i.LastEventType,
-		&i.Facets,
-		&i.StartedAt,
-		&i.EndedAt,
-		&i.NominalStartedAt,
-		&i.NominalEndedAt,
+		&i.Payload,
+		&i.Producer,
 		&i.ErrorMessage,
-		&i.ProgrammingLanguage,
 		&i.Stacktrace,
+		&i.RequestID,
+		&i.Attempts,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getRunByUUID = `-- name: GetRunByUUID :one
-select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at from lineage.runs
-where run_uuid = $1 limit 1
+const getJobByID = `-- name: GetJobByID :one
+select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
+where id = $1 limit 1
 `
 
-func (q *Queries) GetRunByUUID(ctx context.Context, runUuid uuid.UUID) (LineageRun, error) {
-	row := q.db.QueryRowContext(ctx, getRunByUUID, runUuid)
-	var i LineageRun
+func (q *Queries) GetJobByID(ctx context.Context, id int64) (LineageJob, error) {
+	row := q.db.QueryRowContext(ctx, getJobByID, id)
+	var i LineageJob
 	err := row.Scan(
 		&i.ID,
-		&i.RunUuid,
-		&i.JobVersionID,
-		&i.ParentRunID,
-		&i.LastEventType,
+		&i.CurrentVersionID,
+		&i.NamespaceID,
+		&i.Name,
 		&i.Facets,
-		&i.StartedAt,
-		&i.EndedAt,
-		&i.NominalStartedAt,
-		&i.NominalEndedAt,
-		&i.ErrorMessage,
-		&i.ProgrammingLanguage,
-		&i.Stacktrace,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getRunDatasetVersionByRunIDAndDatasetVersionID = `-- name: GetRunDatasetVersionByRunIDAndDatasetVersionID :one
-select run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at from lineage.run_dataset_versions
-where run_id = $1 and dataset_version_id = $2 limit 1
+const getJobByNamespaceIDAndName = `-- name: GetJobByNamespaceIDAndName :one
+select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
+where namespace_id = $1 and name = $2 limit 1
 `
 
-type GetRunDatasetVersionByRunIDAndDatasetVersionIDParams struct {
-	RunID            int64
-	DatasetVersionID int64
+type GetJobByNamespaceIDAndNameParams struct {
+	NamespaceID int64
+	Name        string
 }
 
-func (q *Queries) GetRunDatasetVersionByRunIDAndDatasetVersionID(ctx context.Context, arg GetRunDatasetVersionByRunIDAndDatasetVersionIDParams) (LineageRunDatasetVersion, error) {
-	row := q.db.QueryRowContext(ctx, getRunDatasetVersionByRunIDAndDatasetVersionID, arg.RunID, arg.DatasetVersionID)
-	var i LineageRunDatasetVersion
+func (q *Queries) GetJobByNamespaceIDAndName(ctx context.Context, arg GetJobByNamespaceIDAndNameParams) (LineageJob, error) {
+	row := q.db.QueryRowContext(ctx, getJobByNamespaceIDAndName, arg.NamespaceID, arg.Name)
+	var i LineageJob
 	err := row.Scan(
-		&i.RunID,
-		&i.DatasetVersionID,
-		&i.IoType,
-		&i.DatasetFacets,
-		&i.IoFacets,
+		&i.ID,
+		&i.CurrentVersionID,
+		&i.NamespaceID,
+		&i.Name,
+		&i.Facets,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getRunEvent = `-- name: GetRunEvent :one
-SELECT id, run_id, event_type, event_time, facets, created_at, updated_at FROM lineage.run_events
-WHERE id = $1 LIMIT 1
+const getJobNamespaceByID = `-- name: GetJobNamespaceByID :one
+select id, name, created_at, updated_at from lineage.job_namespaces
+where id = $1 limit 1
 `
 
-func (q *Queries) GetRunEvent(ctx context.Context, id int64) (LineageRunEvent, error) {
-	row := q.db.QueryRowContext(ctx, getRunEvent, id)
-	var i LineageRunEvent
+func (q *Queries) GetJobNamespaceByID(ctx context.Context, id int64) (LineageJobNamespace, error) {
+	row := q.db.QueryRowContext(ctx, getJobNamespaceByID, id)
+	var i LineageJobNamespace
 	err := row.Scan(
 		&i.ID,
-		&i.RunID,
-		&i.EventType,
-		&i.EventTime,
-		&i.Facets,
+		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const listDatasetNamespaces = `-- name: ListDatasetNamespaces :many
-select id, name, created_at, updated_at from lineage.dataset_namespaces
-order by name
+const getJobNamespaceByName = `-- name: GetJobNamespaceByName :one
+select id, name, created_at, updated_at from lineage.job_namespaces
+where name = $1 limit 1
 `
 
-func (q *Queries) ListDatasetNamespaces(ctx context.Context) ([]LineageDatasetNamespace, error) {
-	rows, err := q.db.QueryContext(ctx, listDatasetNamespaces)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []LineageDatasetNamespace
-	for rows.Next() {
-		var i LineageDatasetNamespace
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetJobNamespaceByName(ctx context.Context, name string) (LineageJobNamespace, error) {
+	row := q.db.QueryRowContext(ctx, getJobNamespaceByName, name)
+	var i LineageJobNamespace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const listDatasetVersionsByDatasetID = `-- name: ListDatasetVersionsByDatasetID :many
-select id, dataset_id, namespace_id, name, created_at, updated_at from lineage.dataset_versions
-where dataset_id = $1 
-order by created_at desc
+const getJobVersionByID = `-- name: GetJobVersionByID :one
+select id, job_id, namespace_id, name, facets, created_at, updated_at, version_uuid from lineage.job_versions
+where id = $1 limit 1
 `
 
-func (q *Queries) ListDatasetVersionsByDatasetID(ctx context.Context, datasetID int64) ([]LineageDatasetVersion, error) {
-	rows, err := q.db.QueryContext(ctx, listDatasetVersionsByDatasetID, datasetID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []LineageDatasetVersion
-	for rows.Next() {
-		var i LineageDatasetVersion
-		if err := rows.Scan(
-			&i.ID,
-			&i.DatasetID,
-			&i.NamespaceID,
-			&i.Name,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
+func (q *Queries) GetJobVersionByID(ctx context.Context, id int64) (LineageJobVersion, error) {
+	row := q.db.QueryRowContext(ctx, getJobVersionByID, id)
+	var i LineageJobVersion
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.NamespaceID,
+		&i.Name,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VersionUuid,
+	)
+	return i, err
+}
+
+const getJobVersionByJobIDAndVersionUuid = `-- name: GetJobVersionByJobIDAndVersionUuid :one
+select id, job_id, namespace_id, name, facets, created_at, updated_at, version_uuid from lineage.job_versions
+where job_id = $1 and version_uuid = $2
+limit 1
+`
+
+type GetJobVersionByJobIDAndVersionUuidParams struct {
+	JobID       int64
+	VersionUuid uuid.UUID
+}
+
+func (q *Queries) GetJobVersionByJobIDAndVersionUuid(ctx context.Context, arg GetJobVersionByJobIDAndVersionUuidParams) (LineageJobVersion, error) {
+	row := q.db.QueryRowContext(ctx, getJobVersionByJobIDAndVersionUuid, arg.JobID, arg.VersionUuid)
+	var i LineageJobVersion
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.NamespaceID,
+		&i.Name,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VersionUuid,
+	)
+	return i, err
+}
+
+const getJobWithNamespace = `-- name: GetJobWithNamespace :one
+select 
+  j.id, 
+  j.current_version_id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where j.id = $1
+`
+
+type GetJobWithNamespaceRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) GetJobWithNamespace(ctx context.Context, id int64) (GetJobWithNamespaceRow, error) {
+	row := q.db.QueryRowContext(ctx, getJobWithNamespace, id)
+	var i GetJobWithNamespaceRow
+	err := row.Scan(
+		&i.ID,
+		&i.CurrentVersionID,
+		&i.Name,
+		&i.NamespaceID,
+		&i.Facets,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.NamespaceName,
+		&i.NamespaceUpdatedAt,
+		&i.NamespaceCreatedAt,
+	)
+	return i, err
+}
+
+const getJobWithNamespaceByName = `-- name: GetJobWithNamespaceByName :one
+select 
+  j.id, 
+  j.current_version_id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = $1 and j.name = $2
+`
+
+type GetJobWithNamespaceByNameParams struct {
+	NamespaceName string
+	Name          string
+}
+
+type GetJobWithNamespaceByNameRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) GetJobWithNamespaceByName(ctx context.Context, arg GetJobWithNamespaceByNameParams) (GetJobWithNamespaceByNameRow, error) {
+	row := q.db.QueryRowContext(ctx, getJobWithNamespaceByName, arg.NamespaceName, arg.Name)
+	var i GetJobWithNamespaceByNameRow
+	err := row.Scan(
+		&i.ID,
+		&i.CurrentVersionID,
+		&i.Name,
+		&i.NamespaceID,
+		&i.Facets,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.NamespaceName,
+		&i.NamespaceUpdatedAt,
+		&i.NamespaceCreatedAt,
+	)
+	return i, err
+}
+
+const getLastRequestPrune = `-- name: GetLastRequestPrune :one
+select id, created_before, requests, created_at from lineage.request_prunes
+order by id desc
+limit 1
+`
+
+func (q *Queries) GetLastRequestPrune(ctx context.Context) (LineageRequestPrune, error) {
+	row := q.db.QueryRowContext(ctx, getLastRequestPrune)
+	var i LineageRequestPrune
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedBefore,
+		&i.Requests,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestLifecycleStateChangeByDatasetID = `-- name: GetLatestLifecycleStateChangeByDatasetID :one
+select id, dataset_id, change, namespace, name, created_at, updated_at, run_id, previous_dataset_id from lineage.lifecycle_state_changes
+where dataset_id = $1
+order by id desc
+limit 1
+`
+
+func (q *Queries) GetLatestLifecycleStateChangeByDatasetID(ctx context.Context, datasetID int64) (LineageLifecycleStateChange, error) {
+	row := q.db.QueryRowContext(ctx, getLatestLifecycleStateChangeByDatasetID, datasetID)
+	var i LineageLifecycleStateChange
+	err := row.Scan(
+		&i.ID,
+		&i.DatasetID,
+		&i.Change,
+		&i.Namespace,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RunID,
+		&i.PreviousDatasetID,
+	)
+	return i, err
+}
+
+const getLatestRunDatasetVersionByDatasetVersionID = `-- name: GetLatestRunDatasetVersionByDatasetVersionID :one
+select run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at, first_event_type, last_event_type, updated_at from lineage.run_dataset_versions
+where dataset_version_id = $1 order by created_at desc limit 1
+`
+
+func (q *Queries) GetLatestRunDatasetVersionByDatasetVersionID(ctx context.Context, datasetVersionID int64) (LineageRunDatasetVersion, error) {
+	row := q.db.QueryRowContext(ctx, getLatestRunDatasetVersionByDatasetVersionID, datasetVersionID)
+	var i LineageRunDatasetVersion
+	err := row.Scan(
+		&i.RunID,
+		&i.DatasetVersionID,
+		&i.IoType,
+		&i.DatasetFacets,
+		&i.IoFacets,
+		&i.CreatedAt,
+		&i.FirstEventType,
+		&i.LastEventType,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getMaxRequestID = `-- name: GetMaxRequestID :one
+select coalesce(max(id), 0) as max_id from lineage.requests
+`
+
+func (q *Queries) GetMaxRequestID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaxRequestID)
+	var max_id int64
+	err := row.Scan(&max_id)
+	return max_id, err
+}
+
+const getNextDatasetVersionID = `-- name: GetNextDatasetVersionID :one
+select n.id from lineage.dataset_versions v
+join lineage.dataset_versions n on n.dataset_id = v.dataset_id and n.id > v.id
+where v.id = $1
+order by n.id
+limit 1
+`
+
+func (q *Queries) GetNextDatasetVersionID(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getNextDatasetVersionID, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getPreviousDatasetVersionID = `-- name: GetPreviousDatasetVersionID :one
+select p.id from lineage.dataset_versions v
+join lineage.dataset_versions p on p.dataset_id = v.dataset_id and p.id < v.id
+where v.id = $1
+order by p.id desc
+limit 1
+`
+
+func (q *Queries) GetPreviousDatasetVersionID(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousDatasetVersionID, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getRequestByID = `-- name: GetRequestByID :one
+select id, payload, created_at, status, attempts, next_attempt_at, error_message, idempotency_key, run_event_id, processed_at, event_time from lineage.requests
+where id = $1 limit 1
+`
+
+func (q *Queries) GetRequestByID(ctx context.Context, id int64) (LineageRequest, error) {
+	row := q.db.QueryRowContext(ctx, getRequestByID, id)
+	var i LineageRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ErrorMessage,
+		&i.IdempotencyKey,
+		&i.RunEventID,
+		&i.ProcessedAt,
+		&i.EventTime,
+	)
+	return i, err
+}
+
+const getRunByID = `-- name: GetRunByID :one
+select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time from lineage.runs
+where id = $1 limit 1
+`
+
+func (q *Queries) GetRunByID(ctx context.Context, id int64) (LineageRun, error) {
+	row := q.db.QueryRowContext(ctx, getRunByID, id)
+	var i LineageRun
+	err := row.Scan(
+		&i.ID,
+		&i.RunUuid,
+		&i.JobVersionID,
+		&i.ParentRunID,
+		&i.LastEventType,
+		&i.Facets,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.NominalStartedAt,
+		&i.NominalEndedAt,
+		&i.ErrorMessage,
+		&i.ProgrammingLanguage,
+		&i.Stacktrace,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsPlaceholder,
+		&i.State,
+		&i.LastEventTime,
+	)
+	return i, err
+}
+
+const getRunByUUID = `-- name: GetRunByUUID :one
+select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time from lineage.runs
+where run_uuid = $1 limit 1
+`
+
+func (q *Queries) GetRunByUUID(ctx context.Context, runUuid uuid.UUID) (LineageRun, error) {
+	row := q.db.QueryRowContext(ctx, getRunByUUID, runUuid)
+	var i LineageRun
+	err := row.Scan(
+		&i.ID,
+		&i.RunUuid,
+		&i.JobVersionID,
+		&i.ParentRunID,
+		&i.LastEventType,
+		&i.Facets,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.NominalStartedAt,
+		&i.NominalEndedAt,
+		&i.ErrorMessage,
+		&i.ProgrammingLanguage,
+		&i.Stacktrace,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsPlaceholder,
+		&i.State,
+		&i.LastEventTime,
+	)
+	return i, err
+}
+
+const getRunDatasetVersionByRunIDAndDatasetVersionID = `-- name: GetRunDatasetVersionByRunIDAndDatasetVersionID :one
+select run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at, first_event_type, last_event_type, updated_at from lineage.run_dataset_versions
+where run_id = $1 and dataset_version_id = $2 limit 1
+`
+
+type GetRunDatasetVersionByRunIDAndDatasetVersionIDParams struct {
+	RunID            int64
+	DatasetVersionID int64
+}
+
+func (q *Queries) GetRunDatasetVersionByRunIDAndDatasetVersionID(ctx context.Context, arg GetRunDatasetVersionByRunIDAndDatasetVersionIDParams) (LineageRunDatasetVersion, error) {
+	row := q.db.QueryRowContext(ctx, getRunDatasetVersionByRunIDAndDatasetVersionID, arg.RunID, arg.DatasetVersionID)
+	var i LineageRunDatasetVersion
+	err := row.Scan(
+		&i.RunID,
+		&i.DatasetVersionID,
+		&i.IoType,
+		&i.DatasetFacets,
+		&i.IoFacets,
+		&i.CreatedAt,
+		&i.FirstEventType,
+		&i.LastEventType,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRunEvent = `-- name: GetRunEvent :one
+SELECT id, run_id, event_type, event_time, facets, created_at, updated_at, payload_hash, idempotency_key FROM lineage.run_events
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetRunEvent(ctx context.Context, id int64) (LineageRunEvent, error) {
+	row := q.db.QueryRowContext(ctx, getRunEvent, id)
+	var i LineageRunEvent
+	err := row.Scan(
+		&i.ID,
+		&i.RunID,
+		&i.EventType,
+		&i.EventTime,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PayloadHash,
+		&i.IdempotencyKey,
+	)
+	return i, err
+}
+
+const getRunEventByIdempotencyKey = `-- name: GetRunEventByIdempotencyKey :one
+select id, run_id, event_type, event_time, facets, created_at, updated_at, payload_hash, idempotency_key from lineage.run_events
+where idempotency_key = $1
+limit 1
+`
+
+func (q *Queries) GetRunEventByIdempotencyKey(ctx context.Context, idempotencyKey sql.NullString) (LineageRunEvent, error) {
+	row := q.db.QueryRowContext(ctx, getRunEventByIdempotencyKey, idempotencyKey)
+	var i LineageRunEvent
+	err := row.Scan(
+		&i.ID,
+		&i.RunID,
+		&i.EventType,
+		&i.EventTime,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PayloadHash,
+		&i.IdempotencyKey,
+	)
+	return i, err
+}
+
+const getRunEventByPayloadHash = `-- name: GetRunEventByPayloadHash :one
+select e.id, e.run_id, e.event_type, e.event_time, e.facets, e.created_at, e.updated_at, e.payload_hash, e.idempotency_key from lineage.run_events e
+join lineage.runs r on r.id = e.run_id
+where r.run_uuid = $1
+  and e.event_type = $2
+  and e.event_time = $3
+  and e.payload_hash = $4
+limit 1
+`
+
+type GetRunEventByPayloadHashParams struct {
+	RunUuid     uuid.UUID
+	EventType   int32
+	EventTime   time.Time
+	PayloadHash sql.NullString
+}
+
+func (q *Queries) GetRunEventByPayloadHash(ctx context.Context, arg GetRunEventByPayloadHashParams) (LineageRunEvent, error) {
+	row := q.db.QueryRowContext(ctx, getRunEventByPayloadHash,
+		arg.RunUuid,
+		arg.EventType,
+		arg.EventTime,
+		arg.PayloadHash,
+	)
+	var i LineageRunEvent
+	err := row.Scan(
+		&i.ID,
+		&i.RunID,
+		&i.EventType,
+		&i.EventTime,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PayloadHash,
+		&i.IdempotencyKey,
+	)
+	return i, err
+}
+
+const listAPITokens = `-- name: ListAPITokens :many
+select id, name, token_hash, token_prefix, producer, namespace, created_at, last_used_at from lineage.api_tokens
+order by id
+`
+
+func (q *Queries) ListAPITokens(ctx context.Context) ([]LineageApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, listAPITokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageApiToken
+	for rows.Next() {
+		var i LineageApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.TokenHash,
+			&i.TokenPrefix,
+			&i.Producer,
+			&i.Namespace,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAliasedDatasetIDs = `-- name: ListAliasedDatasetIDs :many
+select d.id
+from lineage.dataset_aliases a
+join lineage.dataset_namespaces ns on ns.name = a.namespace
+join lineage.datasets d on d.namespace_id = ns.id and d.name = a.name
+where a.dataset_id = $1 and d.id <> $1
+`
+
+func (q *Queries) ListAliasedDatasetIDs(ctx context.Context, datasetID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listAliasedDatasetIDs, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCurrentFieldsByDatasetIDs = `-- name: ListCurrentFieldsByDatasetIDs :many
+select
+  d.id as dataset_id,
+  f.id,
+  f.name,
+  f.data_type,
+  f.description,
+  f.created_at,
+  f.updated_at
+from lineage.datasets d
+join lineage.fields f on f.dataset_version_id = d.current_version_id
+where d.id in (select cast(value as bigint) from json_array_elements_text($1))
+order by d.id, f.name
+`
+
+type ListCurrentFieldsByDatasetIDsRow struct {
+	DatasetID   int64
+	ID          int64
+	Name        string
+	DataType    string
+	Description sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+}
+
+func (q *Queries) ListCurrentFieldsByDatasetIDs(ctx context.Context, ids json.RawMessage) ([]ListCurrentFieldsByDatasetIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrentFieldsByDatasetIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCurrentFieldsByDatasetIDsRow
+	for rows.Next() {
+		var i ListCurrentFieldsByDatasetIDsRow
+		if err := rows.Scan(
+			&i.DatasetID,
+			&i.ID,
+			&i.Name,
+			&i.DataType,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCurrentFieldsByNamespacePage = `-- name: ListCurrentFieldsByNamespacePage :many
+select
+  p.id as dataset_id,
+  f.id,
+  f.name,
+  f.data_type,
+  f.description,
+  f.created_at,
+  f.updated_at
+from (
+  select d.id, d.current_version_id from lineage.datasets d
+  join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+  where ns.name = $1 and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  order by d.name, d.id
+  limit $2 offset $3
+) p
+join lineage.fields f on f.dataset_version_id = p.current_version_id
+order by p.id, f.name
+`
+
+type ListCurrentFieldsByNamespacePageParams struct {
+	NamespaceName string
+	RowLimit      int32
+	RowOffset     int32
+}
+
+type ListCurrentFieldsByNamespacePageRow struct {
+	DatasetID   int64
+	ID          int64
+	Name        string
+	DataType    string
+	Description sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+}
+
+func (q *Queries) ListCurrentFieldsByNamespacePage(ctx context.Context, arg ListCurrentFieldsByNamespacePageParams) ([]ListCurrentFieldsByNamespacePageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrentFieldsByNamespacePage, arg.NamespaceName, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCurrentFieldsByNamespacePageRow
+	for rows.Next() {
+		var i ListCurrentFieldsByNamespacePageRow
+		if err := rows.Scan(
+			&i.DatasetID,
+			&i.ID,
+			&i.Name,
+			&i.DataType,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetAliasesByDatasetID = `-- name: ListDatasetAliasesByDatasetID :many
+select id, namespace, name, dataset_id, is_manual, created_at, updated_at from lineage.dataset_aliases
+where dataset_id = $1
+order by namespace, name
+`
+
+func (q *Queries) ListDatasetAliasesByDatasetID(ctx context.Context, datasetID int64) ([]LineageDatasetAlias, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetAliasesByDatasetID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageDatasetAlias
+	for rows.Next() {
+		var i LineageDatasetAlias
+		if err := rows.Scan(
+			&i.ID,
+			&i.Namespace,
+			&i.Name,
+			&i.DatasetID,
+			&i.IsManual,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetEdgesByJobID = `-- name: ListDatasetEdgesByJobID :many
+select distinct dv.dataset_id, rdv.io_type
+from lineage.run_dataset_versions rdv
+join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+join lineage.runs r on r.id = rdv.run_id
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+union
+select jvio.dataset_id, jvio.io_type
+from lineage.job_version_io_datasets jvio
+join lineage.jobs j on j.current_version_id = jvio.job_version_id
+where j.id = $1
+`
+
+type ListDatasetEdgesByJobIDRow struct {
+	DatasetID int64
+	IoType    int32
+}
+
+func (q *Queries) ListDatasetEdgesByJobID(ctx context.Context, jobID int64) ([]ListDatasetEdgesByJobIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetEdgesByJobID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetEdgesByJobIDRow
+	for rows.Next() {
+		var i ListDatasetEdgesByJobIDRow
+		if err := rows.Scan(&i.DatasetID, &i.IoType); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetEdgesByJobIDs = `-- name: ListDatasetEdgesByJobIDs :many
+with page as (
+  select j.id, j.current_version_id from lineage.jobs j
+  where j.id in (select cast(value as bigint) from json_array_elements_text($1))
+), edges as (
+  select distinct jv.job_id, dv.dataset_id, rdv.io_type
+  from page p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+  join lineage.run_dataset_versions rdv on rdv.run_id = r.id
+  join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+  union
+  select p.id, jvio.dataset_id, jvio.io_type
+  from page p
+  join lineage.job_version_io_datasets jvio on jvio.job_version_id = p.current_version_id
+)
+select distinct
+  e.job_id,
+  e.io_type,
+  cns.name as namespace_name,
+  cd.name as dataset_name
+from edges e
+join lineage.datasets d on d.id = e.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+left join lineage.dataset_aliases a on a.namespace = ns.name and a.name = d.name
+join lineage.datasets cd on cd.id = coalesce(a.dataset_id, d.id)
+join lineage.dataset_namespaces cns on cns.id = cd.namespace_id
+order by e.job_id, cns.name, cd.name
+`
+
+type ListDatasetEdgesByJobIDsRow struct {
+	JobID         int64
+	IoType        int32
+	NamespaceName string
+	DatasetName   string
+}
+
+func (q *Queries) ListDatasetEdgesByJobIDs(ctx context.Context, ids json.RawMessage) ([]ListDatasetEdgesByJobIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetEdgesByJobIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetEdgesByJobIDsRow
+	for rows.Next() {
+		var i ListDatasetEdgesByJobIDsRow
+		if err := rows.Scan(
+			&i.JobID,
+			&i.IoType,
+			&i.NamespaceName,
+			&i.DatasetName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetEdgesByNamespacePage = `-- name: ListDatasetEdgesByNamespacePage :many
+with page as (
+  select j.id, j.current_version_id from lineage.jobs j
+  join lineage.job_namespaces ns on ns.id = j.namespace_id
+  where ns.name = $1
+  order by j.name, j.id
+  limit $2 offset $3
+), edges as (
+  select distinct jv.job_id, dv.dataset_id, rdv.io_type
+  from page p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+  join lineage.run_dataset_versions rdv on rdv.run_id = r.id
+  join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+  union
+  select p.id, jvio.dataset_id, jvio.io_type
+  from page p
+  join lineage.job_version_io_datasets jvio on jvio.job_version_id = p.current_version_id
+)
+select distinct
+  e.job_id,
+  e.io_type,
+  cns.name as namespace_name,
+  cd.name as dataset_name
+from edges e
+join lineage.datasets d on d.id = e.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+left join lineage.dataset_aliases a on a.namespace = ns.name and a.name = d.name
+join lineage.datasets cd on cd.id = coalesce(a.dataset_id, d.id)
+join lineage.dataset_namespaces cns on cns.id = cd.namespace_id
+order by e.job_id, cns.name, cd.name
+`
+
+type ListDatasetEdgesByNamespacePageParams struct {
+	NamespaceName string
+	RowLimit      int32
+	RowOffset     int32
+}
+
+type ListDatasetEdgesByNamespacePageRow struct {
+	JobID         int64
+	IoType        int32
+	NamespaceName string
+	DatasetName   string
+}
+
+func (q *Queries) ListDatasetEdgesByNamespacePage(ctx context.Context, arg ListDatasetEdgesByNamespacePageParams) ([]ListDatasetEdgesByNamespacePageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetEdgesByNamespacePage, arg.NamespaceName, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetEdgesByNamespacePageRow
+	for rows.Next() {
+		var i ListDatasetEdgesByNamespacePageRow
+		if err := rows.Scan(
+			&i.JobID,
+			&i.IoType,
+			&i.NamespaceName,
+			&i.DatasetName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetIDs = `-- name: ListDatasetIDs :many
+select id from lineage.datasets
+order by id
+`
+
+func (q *Queries) ListDatasetIDs(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetNamespaces = `-- name: ListDatasetNamespaces :many
+select id, name, created_at, updated_at from lineage.dataset_namespaces
+order by name
+`
+
+func (q *Queries) ListDatasetNamespaces(ctx context.Context) ([]LineageDatasetNamespace, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageDatasetNamespace
+	for rows.Next() {
+		var i LineageDatasetNamespace
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetVersionsByDatasetID = `-- name: ListDatasetVersionsByDatasetID :many
+select id, dataset_id, namespace_id, name, created_at, updated_at, schema_recorded, version_uuid from lineage.dataset_versions
+where dataset_id = $1 
+order by created_at desc
+`
+
+func (q *Queries) ListDatasetVersionsByDatasetID(ctx context.Context, datasetID int64) ([]LineageDatasetVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetVersionsByDatasetID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageDatasetVersion
+	for rows.Next() {
+		var i LineageDatasetVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.DatasetID,
+			&i.NamespaceID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SchemaRecorded,
+			&i.VersionUuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsPageByName = `-- name: ListDatasetsPageByName :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where (ns.name = $1 or $1 = '')
+  and d.name ilike $2 escape '\'
+  and coalesce(d.updated_at, d.created_at) >= $3
+  and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  and (ns.name, d.name, d.id) > ($4, $5, $6)
+order by ns.name, d.name, d.id
+limit $7
+`
+
+type ListDatasetsPageByNameParams struct {
+	Namespace      string
+	NamePattern    string
+	UpdatedSince   time.Time
+	AfterNamespace string
+	AfterName      string
+	AfterID        int64
+	RowLimit       int32
+}
+
+type ListDatasetsPageByNameRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsPageByName(ctx context.Context, arg ListDatasetsPageByNameParams) ([]ListDatasetsPageByNameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsPageByName,
+		arg.Namespace,
+		arg.NamePattern,
+		arg.UpdatedSince,
+		arg.AfterNamespace,
+		arg.AfterName,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsPageByNameRow
+	for rows.Next() {
+		var i ListDatasetsPageByNameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsPageByUpdated = `-- name: ListDatasetsPageByUpdated :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where (ns.name = $1 or $1 = '')
+  and d.name ilike $2 escape '\'
+  and coalesce(d.updated_at, d.created_at) >= $3
+  and not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  and (coalesce(d.updated_at, d.created_at), d.id) < ($4, $5)
+order by coalesce(d.updated_at, d.created_at) desc, d.id desc
+limit $6
+`
+
+type ListDatasetsPageByUpdatedParams struct {
+	Namespace       string
+	NamePattern     string
+	UpdatedSince    time.Time
+	BeforeUpdatedAt time.Time
+	BeforeID        int64
+	RowLimit        int32
+}
+
+type ListDatasetsPageByUpdatedRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsPageByUpdated(ctx context.Context, arg ListDatasetsPageByUpdatedParams) ([]ListDatasetsPageByUpdatedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsPageByUpdated,
+		arg.Namespace,
+		arg.NamePattern,
+		arg.UpdatedSince,
+		arg.BeforeUpdatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsPageByUpdatedRow
+	for rows.Next() {
+		var i ListDatasetsPageByUpdatedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsWithNamespaces = `-- name: ListDatasetsWithNamespaces :many
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+`
+
+type ListDatasetsWithNamespacesRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsWithNamespaces(ctx context.Context) ([]ListDatasetsWithNamespacesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsWithNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsWithNamespacesRow
+	for rows.Next() {
+		var i ListDatasetsWithNamespacesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsWithNamespacesByIDs = `-- name: ListDatasetsWithNamespacesByIDs :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where d.id in (select cast(value as bigint) from json_array_elements_text($1))
+order by d.id
+`
+
+type ListDatasetsWithNamespacesByIDsRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsWithNamespacesByIDs(ctx context.Context, ids json.RawMessage) ([]ListDatasetsWithNamespacesByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsWithNamespacesByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsWithNamespacesByIDsRow
+	for rows.Next() {
+		var i ListDatasetsWithNamespacesByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsWithNamespacesByNamespace = `-- name: ListDatasetsWithNamespacesByNamespace :many
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = $1 and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+order by d.name
+`
+
+type ListDatasetsWithNamespacesByNamespaceRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsWithNamespacesByNamespace(ctx context.Context, namespaceName string) ([]ListDatasetsWithNamespacesByNamespaceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsWithNamespacesByNamespace, namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsWithNamespacesByNamespaceRow
+	for rows.Next() {
+		var i ListDatasetsWithNamespacesByNamespaceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasetsWithNamespacesByNamespacePage = `-- name: ListDatasetsWithNamespacesByNamespacePage :many
+select
+  d.id,
+  d.current_version_id,
+  d.name,
+  d.namespace_id,
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where ns.name = $1 and not exists (
+  select 1 from lineage.dataset_aliases a
+  where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+)
+order by d.name, d.id
+limit $2 offset $3
+`
+
+type ListDatasetsWithNamespacesByNamespacePageParams struct {
+	NamespaceName string
+	RowLimit      int32
+	RowOffset     int32
+}
+
+type ListDatasetsWithNamespacesByNamespacePageRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListDatasetsWithNamespacesByNamespacePage(ctx context.Context, arg ListDatasetsWithNamespacesByNamespacePageParams) ([]ListDatasetsWithNamespacesByNamespacePageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasetsWithNamespacesByNamespacePage, arg.NamespaceName, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasetsWithNamespacesByNamespacePageRow
+	for rows.Next() {
+		var i ListDatasetsWithNamespacesByNamespacePageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFacetsByEntity = `-- name: ListFacetsByEntity :many
+select id, entity_type, entity_id, name, producer, schema_url, payload, created_at, updated_at from lineage.facets
+where entity_type = $1 and entity_id = $2
+order by name
+`
+
+type ListFacetsByEntityParams struct {
+	EntityType int32
+	EntityID   int64
+}
+
+func (q *Queries) ListFacetsByEntity(ctx context.Context, arg ListFacetsByEntityParams) ([]LineageFacet, error) {
+	rows, err := q.db.QueryContext(ctx, listFacetsByEntity, arg.EntityType, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageFacet
+	for rows.Next() {
+		var i LineageFacet
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Name,
+			&i.Producer,
+			&i.SchemaUrl,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedEventsPage = `-- name: ListFailedEventsPage :many
+select id, payload, producer, error_message, stacktrace, request_id, attempts, created_at, updated_at from lineage.failed_events
+where created_at >= $1
+  and id < $2
+order by id desc
+limit $3
+`
+
+type ListFailedEventsPageParams struct {
+	CreatedSince time.Time
+	BeforeID     int64
+	RowLimit     int32
+}
+
+func (q *Queries) ListFailedEventsPage(ctx context.Context, arg ListFailedEventsPageParams) ([]LineageFailedEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listFailedEventsPage, arg.CreatedSince, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageFailedEvent
+	for rows.Next() {
+		var i LineageFailedEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Payload,
+			&i.Producer,
+			&i.ErrorMessage,
+			&i.Stacktrace,
+			&i.RequestID,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFieldsByDatasetVersionID = `-- name: ListFieldsByDatasetVersionID :many
+select id, dataset_version_id, name, data_type, description, created_at, updated_at from lineage.fields
+where dataset_version_id = $1 order by name
+`
+
+func (q *Queries) ListFieldsByDatasetVersionID(ctx context.Context, datasetVersionID int64) ([]LineageField, error) {
+	rows, err := q.db.QueryContext(ctx, listFieldsByDatasetVersionID, datasetVersionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageField
+	for rows.Next() {
+		var i LineageField
+		if err := rows.Scan(
+			&i.ID,
+			&i.DatasetVersionID,
+			&i.Name,
+			&i.DataType,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobEdgesByDatasetID = `-- name: ListJobEdgesByDatasetID :many
+select distinct jv.job_id, rdv.io_type
+from lineage.run_dataset_versions rdv
+join lineage.dataset_versions dv on dv.id = rdv.dataset_version_id
+join lineage.runs r on r.id = rdv.run_id
+join lineage.job_versions jv on jv.id = r.job_version_id
+where dv.dataset_id = $1
+union
+select j.id, jvio.io_type
+from lineage.job_version_io_datasets jvio
+join lineage.jobs j on j.current_version_id = jvio.job_version_id
+where jvio.dataset_id = $1
+`
+
+type ListJobEdgesByDatasetIDRow struct {
+	JobID  int64
+	IoType int32
+}
+
+func (q *Queries) ListJobEdgesByDatasetID(ctx context.Context, datasetID int64) ([]ListJobEdgesByDatasetIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobEdgesByDatasetID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobEdgesByDatasetIDRow
+	for rows.Next() {
+		var i ListJobEdgesByDatasetIDRow
+		if err := rows.Scan(&i.JobID, &i.IoType); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobIDs = `-- name: ListJobIDs :many
+select id from lineage.jobs
+order by id
+`
+
+func (q *Queries) ListJobIDs(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listJobIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobNamespaces = `-- name: ListJobNamespaces :many
+select id, name, created_at, updated_at from lineage.job_namespaces
+order by name
+`
+
+func (q *Queries) ListJobNamespaces(ctx context.Context) ([]LineageJobNamespace, error) {
+	rows, err := q.db.QueryContext(ctx, listJobNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageJobNamespace
+	for rows.Next() {
+		var i LineageJobNamespace
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobVersionIODatasetsByJobVersionID = `-- name: ListJobVersionIODatasetsByJobVersionID :many
+select 
+  jvio.job_version_id, jvio.dataset_id, jvio.io_type, jvio.io_facets, jvio.created_at,
+  d.name as dataset_name,
+  n.name as namespace_name
+from lineage.job_version_io_datasets jvio
+join lineage.datasets d on d.id = jvio.dataset_id
+join lineage.dataset_namespaces n on n.id = d.namespace_id
+where jvio.job_version_id = $1
+order by jvio.io_type, n.name, d.name
+`
+
+type ListJobVersionIODatasetsByJobVersionIDRow struct {
+	JobVersionID  int64
+	DatasetID     int64
+	IoType        int32
+	IoFacets      pqtype.NullRawMessage
+	CreatedAt     time.Time
+	DatasetName   string
+	NamespaceName string
+}
+
+func (q *Queries) ListJobVersionIODatasetsByJobVersionID(ctx context.Context, jobVersionID int64) ([]ListJobVersionIODatasetsByJobVersionIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobVersionIODatasetsByJobVersionID, jobVersionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobVersionIODatasetsByJobVersionIDRow
+	for rows.Next() {
+		var i ListJobVersionIODatasetsByJobVersionIDRow
+		if err := rows.Scan(
+			&i.JobVersionID,
+			&i.DatasetID,
+			&i.IoType,
+			&i.IoFacets,
+			&i.CreatedAt,
+			&i.DatasetName,
+			&i.NamespaceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobVersionsByJobID = `-- name: ListJobVersionsByJobID :many
+select id, job_id, namespace_id, name, facets, created_at, updated_at, version_uuid from lineage.job_versions
+where job_id = $1 
+order by created_at desc
+`
+
+func (q *Queries) ListJobVersionsByJobID(ctx context.Context, jobID int64) ([]LineageJobVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listJobVersionsByJobID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageJobVersion
+	for rows.Next() {
+		var i LineageJobVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.NamespaceID,
+			&i.Name,
+			&i.Facets,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VersionUuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobs = `-- name: ListJobs :many
+select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
+order by name
+`
+
+func (q *Queries) ListJobs(ctx context.Context) ([]LineageJob, error) {
+	rows, err := q.db.QueryContext(ctx, listJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageJob
+	for rows.Next() {
+		var i LineageJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.NamespaceID,
+			&i.Name,
+			&i.Facets,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsPageByName = `-- name: ListJobsPageByName :many
+select
+  j.id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = $1 or $1 = '')
+  and j.name ilike $2 escape '\'
+  and coalesce(j.updated_at, j.created_at) >= $3
+  and (ns.name, j.name, j.id) > ($4, $5, $6)
+order by ns.name, j.name, j.id
+limit $7
+`
+
+type ListJobsPageByNameParams struct {
+	Namespace      string
+	NamePattern    string
+	UpdatedSince   time.Time
+	AfterNamespace string
+	AfterName      string
+	AfterID        int64
+	RowLimit       int32
+}
+
+type ListJobsPageByNameRow struct {
+	ID                 int64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsPageByName(ctx context.Context, arg ListJobsPageByNameParams) ([]ListJobsPageByNameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsPageByName,
+		arg.Namespace,
+		arg.NamePattern,
+		arg.UpdatedSince,
+		arg.AfterNamespace,
+		arg.AfterName,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsPageByNameRow
+	for rows.Next() {
+		var i ListJobsPageByNameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsPageByUpdated = `-- name: ListJobsPageByUpdated :many
+select
+  j.id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = $1 or $1 = '')
+  and j.name ilike $2 escape '\'
+  and coalesce(j.updated_at, j.created_at) >= $3
+  and (coalesce(j.updated_at, j.created_at), j.id) < ($4, $5)
+order by coalesce(j.updated_at, j.created_at) desc, j.id desc
+limit $6
+`
+
+type ListJobsPageByUpdatedParams struct {
+	Namespace       string
+	NamePattern     string
+	UpdatedSince    time.Time
+	BeforeUpdatedAt time.Time
+	BeforeID        int64
+	RowLimit        int32
+}
+
+type ListJobsPageByUpdatedRow struct {
+	ID                 int64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsPageByUpdated(ctx context.Context, arg ListJobsPageByUpdatedParams) ([]ListJobsPageByUpdatedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsPageByUpdated,
+		arg.Namespace,
+		arg.NamePattern,
+		arg.UpdatedSince,
+		arg.BeforeUpdatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsPageByUpdatedRow
+	for rows.Next() {
+		var i ListJobsPageByUpdatedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsWithNamespaces = `-- name: ListJobsWithNamespaces :many
+select 
+  j.id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+`
+
+type ListJobsWithNamespacesRow struct {
+	ID                 int64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsWithNamespaces(ctx context.Context) ([]ListJobsWithNamespacesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsWithNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsWithNamespacesRow
+	for rows.Next() {
+		var i ListJobsWithNamespacesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsWithNamespacesByIDs = `-- name: ListJobsWithNamespacesByIDs :many
+select
+  j.id,
+  j.current_version_id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where j.id in (select cast(value as bigint) from json_array_elements_text($1))
+order by j.id
+`
+
+type ListJobsWithNamespacesByIDsRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsWithNamespacesByIDs(ctx context.Context, ids json.RawMessage) ([]ListJobsWithNamespacesByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsWithNamespacesByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsWithNamespacesByIDsRow
+	for rows.Next() {
+		var i ListJobsWithNamespacesByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsWithNamespacesByNamespace = `-- name: ListJobsWithNamespacesByNamespace :many
+select 
+  j.id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = $1
+order by j.name
+`
+
+type ListJobsWithNamespacesByNamespaceRow struct {
+	ID                 int64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsWithNamespacesByNamespace(ctx context.Context, namespaceName string) ([]ListJobsWithNamespacesByNamespaceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsWithNamespacesByNamespace, namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsWithNamespacesByNamespaceRow
+	for rows.Next() {
+		var i ListJobsWithNamespacesByNamespaceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobsWithNamespacesByNamespacePage = `-- name: ListJobsWithNamespacesByNamespacePage :many
+select
+  j.id,
+  j.current_version_id,
+  j.name,
+  j.namespace_id,
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where ns.name = $1
+order by j.name, j.id
+limit $2 offset $3
+`
+
+type ListJobsWithNamespacesByNamespacePageParams struct {
+	NamespaceName string
+	RowLimit      int32
+	RowOffset     int32
+}
+
+type ListJobsWithNamespacesByNamespacePageRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) ListJobsWithNamespacesByNamespacePage(ctx context.Context, arg ListJobsWithNamespacesByNamespacePageParams) ([]ListJobsWithNamespacesByNamespacePageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsWithNamespacesByNamespacePage, arg.NamespaceName, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobsWithNamespacesByNamespacePageRow
+	for rows.Next() {
+		var i ListJobsWithNamespacesByNamespacePageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
+			&i.Facets,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLatestRunsByJobIDs = `-- name: ListLatestRunsByJobIDs :many
+select
+  r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time,
+  latest.job_id
+from (
+  select r.id, jv.job_id, row_number() over (partition by jv.job_id order by r.created_at desc, r.id desc) as run_rank
+  from lineage.job_versions jv
+  join lineage.runs r on r.job_version_id = jv.id
+  where jv.job_id in (select cast(value as bigint) from json_array_elements_text($1))
+) latest
+join lineage.runs r on r.id = latest.id
+where latest.run_rank = 1
+`
+
+type ListLatestRunsByJobIDsRow struct {
+	ID                  int64
+	RunUuid             uuid.UUID
+	JobVersionID        int64
+	ParentRunID         sql.NullInt64
+	LastEventType       int32
+	Facets              pqtype.NullRawMessage
+	StartedAt           sql.NullTime
+	EndedAt             sql.NullTime
+	NominalStartedAt    sql.NullTime
+	NominalEndedAt      sql.NullTime
+	ErrorMessage        sql.NullString
+	ProgrammingLanguage sql.NullString
+	Stacktrace          sql.NullString
+	CreatedAt           time.Time
+	UpdatedAt           sql.NullTime
+	IsPlaceholder       bool
+	State               int32
+	LastEventTime       sql.NullTime
+	JobID               int64
+}
+
+func (q *Queries) ListLatestRunsByJobIDs(ctx context.Context, ids json.RawMessage) ([]ListLatestRunsByJobIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLatestRunsByJobIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLatestRunsByJobIDsRow
+	for rows.Next() {
+		var i ListLatestRunsByJobIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
+			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
+			&i.JobID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLatestRunsByNamespacePage = `-- name: ListLatestRunsByNamespacePage :many
+select
+  r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time,
+  latest.job_id
+from (
+  select r.id, jv.job_id, row_number() over (partition by jv.job_id order by r.created_at desc, r.id desc) as run_rank
+  from (
+    select j.id, j.current_version_id from lineage.jobs j
+    join lineage.job_namespaces ns on ns.id = j.namespace_id
+    where ns.name = $1
+    order by j.name, j.id
+    limit $2 offset $3
+  ) p
+  join lineage.job_versions jv on jv.job_id = p.id
+  join lineage.runs r on r.job_version_id = jv.id
+) latest
+join lineage.runs r on r.id = latest.id
+where latest.run_rank = 1
+`
+
+type ListLatestRunsByNamespacePageParams struct {
+	NamespaceName string
+	RowLimit      int32
+	RowOffset     int32
+}
+
+type ListLatestRunsByNamespacePageRow struct {
+	ID                  int64
+	RunUuid             uuid.UUID
+	JobVersionID        int64
+	ParentRunID         sql.NullInt64
+	LastEventType       int32
+	Facets              pqtype.NullRawMessage
+	StartedAt           sql.NullTime
+	EndedAt             sql.NullTime
+	NominalStartedAt    sql.NullTime
+	NominalEndedAt      sql.NullTime
+	ErrorMessage        sql.NullString
+	ProgrammingLanguage sql.NullString
+	Stacktrace          sql.NullString
+	CreatedAt           time.Time
+	UpdatedAt           sql.NullTime
+	IsPlaceholder       bool
+	State               int32
+	LastEventTime       sql.NullTime
+	JobID               int64
+}
+
+func (q *Queries) ListLatestRunsByNamespacePage(ctx context.Context, arg ListLatestRunsByNamespacePageParams) ([]ListLatestRunsByNamespacePageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLatestRunsByNamespacePage, arg.NamespaceName, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLatestRunsByNamespacePageRow
+	for rows.Next() {
+		var i ListLatestRunsByNamespacePageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
+			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
+			&i.JobID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLifecycleStateChangesByDatasetID = `-- name: ListLifecycleStateChangesByDatasetID :many
+select c.id, c.dataset_id, c.change, c.namespace, c.name, c.created_at, c.updated_at, c.run_id, c.previous_dataset_id,
+  d.name as dataset_name, n.name as namespace_name, r.run_uuid
+from lineage.lifecycle_state_changes c
+join lineage.datasets d on d.id = c.dataset_id
+join lineage.dataset_namespaces n on n.id = d.namespace_id
+left join lineage.runs r on r.id = c.run_id
+where c.dataset_id = $1 or c.previous_dataset_id = $1
+order by c.created_at, c.id
+`
+
+type ListLifecycleStateChangesByDatasetIDRow struct {
+	ID                int64
+	DatasetID         int64
+	Change            sql.NullString
+	Namespace         sql.NullString
+	Name              sql.NullString
+	CreatedAt         time.Time
+	UpdatedAt         sql.NullTime
+	RunID             sql.NullInt64
+	PreviousDatasetID sql.NullInt64
+	DatasetName       string
+	NamespaceName     string
+	RunUuid           uuid.NullUUID
+}
+
+func (q *Queries) ListLifecycleStateChangesByDatasetID(ctx context.Context, datasetID int64) ([]ListLifecycleStateChangesByDatasetIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLifecycleStateChangesByDatasetID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLifecycleStateChangesByDatasetIDRow
+	for rows.Next() {
+		var i ListLifecycleStateChangesByDatasetIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DatasetID,
+			&i.Change,
+			&i.Namespace,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RunID,
+			&i.PreviousDatasetID,
+			&i.DatasetName,
+			&i.NamespaceName,
+			&i.RunUuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listManualDatasetAliases = `-- name: ListManualDatasetAliases :many
+select a.namespace, a.name, ns.name as dataset_namespace, d.name as dataset_name
+from lineage.dataset_aliases a
+join lineage.datasets d on d.id = a.dataset_id
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where a.is_manual
+order by a.id
+`
+
+type ListManualDatasetAliasesRow struct {
+	Namespace        string
+	Name             string
+	DatasetNamespace string
+	DatasetName      string
+}
+
+func (q *Queries) ListManualDatasetAliases(ctx context.Context) ([]ListManualDatasetAliasesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listManualDatasetAliases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListManualDatasetAliasesRow
+	for rows.Next() {
+		var i ListManualDatasetAliasesRow
+		if err := rows.Scan(
+			&i.Namespace,
+			&i.Name,
+			&i.DatasetNamespace,
+			&i.DatasetName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRequestsForReplay = `-- name: ListRequestsForReplay :many
+select id, payload, created_at, status, attempts, next_attempt_at, error_message, idempotency_key, run_event_id, processed_at, event_time from lineage.requests
+where status <> 1 and id <= $1
+  and (coalesce(event_time, created_at), id) > ($2, $3)
+order by coalesce(event_time, created_at), id
+limit $4
+`
+
+type ListRequestsForReplayParams struct {
+	MaxID          int64
+	AfterEventTime time.Time
+	AfterID        int64
+	RowLimit       int32
+}
+
+func (q *Queries) ListRequestsForReplay(ctx context.Context, arg ListRequestsForReplayParams) ([]LineageRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listRequestsForReplay,
+		arg.MaxID,
+		arg.AfterEventTime,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageRequest
+	for rows.Next() {
+		var i LineageRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ErrorMessage,
+			&i.IdempotencyKey,
+			&i.RunEventID,
+			&i.ProcessedAt,
+			&i.EventTime,
+		); err != nil {
+			return nil, err
+		}
 		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
@@ -981,52 +4265,193 @@ func (q *Queries) ListDatasetVersionsByDatasetID(ctx context.Context, datasetID
 	return items, nil
 }
 
-const listDatasetsWithNamespaces = `-- name: ListDatasetsWithNamespaces :many
+const listRequestsPage = `-- name: ListRequestsPage :many
+select id, payload, created_at, status, attempts, next_attempt_at, error_message, idempotency_key, run_event_id, processed_at, event_time from lineage.requests
+where created_at >= $1
+  and id < $2
+order by id desc
+limit $3
+`
+
+type ListRequestsPageParams struct {
+	CreatedSince time.Time
+	BeforeID     int64
+	RowLimit     int32
+}
+
+func (q *Queries) ListRequestsPage(ctx context.Context, arg ListRequestsPageParams) ([]LineageRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listRequestsPage, arg.CreatedSince, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageRequest
+	for rows.Next() {
+		var i LineageRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ErrorMessage,
+			&i.IdempotencyKey,
+			&i.RunEventID,
+			&i.ProcessedAt,
+			&i.EventTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunDatasetVersionsByJobIDPage = `-- name: ListRunDatasetVersionsByJobIDPage :many
+select
+  rdv.run_id,
+  rdv.io_type,
+  n.name as namespace_name,
+  v.name as dataset_name
+from (
+  select r.id from lineage.runs r
+  join lineage.job_versions jv on jv.id = r.job_version_id
+  where jv.job_id = $1
+  order by r.created_at desc, r.id desc
+  limit $2 offset $3
+) p
+join lineage.run_dataset_versions rdv on rdv.run_id = p.id
+join lineage.dataset_versions v on v.id = rdv.dataset_version_id
+join lineage.dataset_namespaces n on n.id = v.namespace_id
+order by rdv.run_id, n.name, v.name
+`
+
+type ListRunDatasetVersionsByJobIDPageParams struct {
+	JobID     int64
+	RowLimit  int32
+	RowOffset int32
+}
+
+type ListRunDatasetVersionsByJobIDPageRow struct {
+	RunID         int64
+	IoType        int32
+	NamespaceName string
+	DatasetName   string
+}
+
+func (q *Queries) ListRunDatasetVersionsByJobIDPage(ctx context.Context, arg ListRunDatasetVersionsByJobIDPageParams) ([]ListRunDatasetVersionsByJobIDPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRunDatasetVersionsByJobIDPage, arg.JobID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRunDatasetVersionsByJobIDPageRow
+	for rows.Next() {
+		var i ListRunDatasetVersionsByJobIDPageRow
+		if err := rows.Scan(
+			&i.RunID,
+			&i.IoType,
+			&i.NamespaceName,
+			&i.DatasetName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunDatasetVersionsWithRelationshipsByRunID = `-- name: ListRunDatasetVersionsWithRelationshipsByRunID :many
 select 
-  d.id, 
-  d.current_version_id,
-  d.name, 
-  d.namespace_id, 
-  d.facets,
-  d.updated_at,
-  d.created_at,
-  ns.name as namespace_name,
-  ns.updated_at as namespace_updated_at,
-  ns.created_at as namespace_created_at
-from lineage.datasets d
-join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+  r.run_id, 
+  r.dataset_version_id,
+  r.io_type,
+  r.dataset_facets,
+  r.io_facets,
+  r.first_event_type,
+  r.last_event_type,
+  r.created_at,
+  r.updated_at,
+  v.id as version_id, 
+  v.dataset_id as version_dataset_id, 
+  v.name as version_name, 
+  v.namespace_id as version_namespace_id, 
+  v.updated_at as version_updated_at,
+  v.created_at as version_created_at,
+  v.version_uuid,
+  n.id as namespace_id, 
+  n.name as namespace_name,
+  n.updated_at as namespace_updated_at,
+  n.created_at as namespace_created_at
+from lineage.run_dataset_versions r
+join lineage.dataset_versions v on v.id = r.dataset_version_id
+join lineage.dataset_namespaces n on n.id = v.namespace_id
+where r.run_id = $1
 `
 
-type ListDatasetsWithNamespacesRow struct {
-	ID                 int64
-	CurrentVersionID   sql.NullInt64
-	Name               string
-	NamespaceID        int64
-	Facets             pqtype.NullRawMessage
-	UpdatedAt          sql.NullTime
+type ListRunDatasetVersionsWithRelationshipsByRunIDRow struct {
+	RunID              int64
+	DatasetVersionID   int64
+	IoType             int32
+	DatasetFacets      pqtype.NullRawMessage
+	IoFacets           pqtype.NullRawMessage
+	FirstEventType     int32
+	LastEventType      int32
 	CreatedAt          time.Time
+	UpdatedAt          sql.NullTime
+	VersionID          int64
+	VersionDatasetID   int64
+	VersionName        string
+	VersionNamespaceID int64
+	VersionUpdatedAt   sql.NullTime
+	VersionCreatedAt   time.Time
+	VersionUuid        uuid.UUID
+	NamespaceID        int64
 	NamespaceName      string
 	NamespaceUpdatedAt sql.NullTime
 	NamespaceCreatedAt time.Time
 }
 
-func (q *Queries) ListDatasetsWithNamespaces(ctx context.Context) ([]ListDatasetsWithNamespacesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listDatasetsWithNamespaces)
+func (q *Queries) ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Context, runID int64) ([]ListRunDatasetVersionsWithRelationshipsByRunIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRunDatasetVersionsWithRelationshipsByRunID, runID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListDatasetsWithNamespacesRow
+	var items []ListRunDatasetVersionsWithRelationshipsByRunIDRow
 	for rows.Next() {
-		var i ListDatasetsWithNamespacesRow
+		var i ListRunDatasetVersionsWithRelationshipsByRunIDRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CurrentVersionID,
-			&i.Name,
-			&i.NamespaceID,
-			&i.Facets,
-			&i.UpdatedAt,
+			&i.RunID,
+			&i.DatasetVersionID,
+			&i.IoType,
+			&i.DatasetFacets,
+			&i.IoFacets,
+			&i.FirstEventType,
+			&i.LastEventType,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VersionID,
+			&i.VersionDatasetID,
+			&i.VersionName,
+			&i.VersionNamespaceID,
+			&i.VersionUpdatedAt,
+			&i.VersionCreatedAt,
+			&i.VersionUuid,
+			&i.NamespaceID,
 			&i.NamespaceName,
 			&i.NamespaceUpdatedAt,
 			&i.NamespaceCreatedAt,
@@ -1044,28 +4469,127 @@ func (q *Queries) ListDatasetsWithNamespaces(ctx context.Context) ([]ListDataset
 	return items, nil
 }
 
-const listFieldsByDatasetVersionID = `-- name: ListFieldsByDatasetVersionID :many
-select id, dataset_version_id, name, data_type, description, created_at, updated_at from lineage.fields
-where dataset_version_id = $1 order by name
+const listRunEventsByRunID = `-- name: ListRunEventsByRunID :many
+SELECT id, run_id, event_type, event_time, facets, created_at, updated_at, payload_hash, idempotency_key FROM lineage.run_events
+WHERE run_id = $1
+ORDER BY created_at asc
 `
 
-func (q *Queries) ListFieldsByDatasetVersionID(ctx context.Context, datasetVersionID int64) ([]LineageField, error) {
-	rows, err := q.db.QueryContext(ctx, listFieldsByDatasetVersionID, datasetVersionID)
+func (q *Queries) ListRunEventsByRunID(ctx context.Context, runID int64) ([]LineageRunEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listRunEventsByRunID, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageRunEvent
+	for rows.Next() {
+		var i LineageRunEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunID,
+			&i.EventType,
+			&i.EventTime,
+			&i.Facets,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PayloadHash,
+			&i.IdempotencyKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRuns = `-- name: ListRuns :many
+select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time from lineage.runs
+order by job_version_id, id
+`
+
+func (q *Queries) ListRuns(ctx context.Context) ([]LineageRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LineageRun
+	for rows.Next() {
+		var i LineageRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
+			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunsByJobID = `-- name: ListRunsByJobID :many
+select r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+order by r.created_at desc
+`
+
+func (q *Queries) ListRunsByJobID(ctx context.Context, jobID int64) ([]LineageRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsByJobID, jobID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageField
+	var items []LineageRun
 	for rows.Next() {
-		var i LineageField
+		var i LineageRun
 		if err := rows.Scan(
 			&i.ID,
-			&i.DatasetVersionID,
-			&i.Name,
-			&i.DataType,
-			&i.Description,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
+			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
 		); err != nil {
 			return nil, err
 		}
@@ -1080,25 +4604,48 @@ func (q *Queries) ListFieldsByDatasetVersionID(ctx context.Context, datasetVersi
 	return items, nil
 }
 
-const listJobNamespaces = `-- name: ListJobNamespaces :many
-select id, name, created_at, updated_at from lineage.job_namespaces
-order by name
+const listRunsByJobIDPage = `-- name: ListRunsByJobIDPage :many
+select r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+order by r.created_at desc, r.id desc
+limit $2 offset $3
 `
 
-func (q *Queries) ListJobNamespaces(ctx context.Context) ([]LineageJobNamespace, error) {
-	rows, err := q.db.QueryContext(ctx, listJobNamespaces)
+type ListRunsByJobIDPageParams struct {
+	JobID     int64
+	RowLimit  int32
+	RowOffset int32
+}
+
+func (q *Queries) ListRunsByJobIDPage(ctx context.Context, arg ListRunsByJobIDPageParams) ([]LineageRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsByJobIDPage, arg.JobID, arg.RowLimit, arg.RowOffset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageJobNamespace
+	var items []LineageRun
 	for rows.Next() {
-		var i LineageJobNamespace
+		var i LineageRun
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
+			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
 		); err != nil {
 			return nil, err
 		}
@@ -1113,29 +4660,40 @@ func (q *Queries) ListJobNamespaces(ctx context.Context) ([]LineageJobNamespace,
 	return items, nil
 }
 
-const listJobVersionsByJobID = `-- name: ListJobVersionsByJobID :many
-select id, job_id, namespace_id, name, facets, created_at, updated_at from lineage.job_versions
-where job_id = $1 
-order by created_at desc
+const listRunsByParentRunID = `-- name: ListRunsByParentRunID :many
+select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time from lineage.runs
+where parent_run_id = $1
+order by created_at
 `
 
-func (q *Queries) ListJobVersionsByJobID(ctx context.Context, jobID int64) ([]LineageJobVersion, error) {
-	rows, err := q.db.QueryContext(ctx, listJobVersionsByJobID, jobID)
+func (q *Queries) ListRunsByParentRunID(ctx context.Context, parentRunID sql.NullInt64) ([]LineageRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsByParentRunID, parentRunID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageJobVersion
+	var items []LineageRun
 	for rows.Next() {
-		var i LineageJobVersion
+		var i LineageRun
 		if err := rows.Scan(
 			&i.ID,
-			&i.JobID,
-			&i.NamespaceID,
-			&i.Name,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
 			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
 		); err != nil {
 			return nil, err
 		}
@@ -1150,28 +4708,98 @@ func (q *Queries) ListJobVersionsByJobID(ctx context.Context, jobID int64) ([]Li
 	return items, nil
 }
 
-const listJobs = `-- name: ListJobs :many
-select id, current_version_id, namespace_id, name, facets, created_at, updated_at from lineage.jobs
-order by name
+const listRunsPage = `-- name: ListRunsPage :many
+select
+  r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time,
+  j.id as job_id,
+  j.name as job_name,
+  ns.name as namespace_name
+from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+join lineage.jobs j on j.id = jv.job_id
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where (ns.name = $1 or $1 = '')
+  and (r.state = $2 or $2 = 0)
+  and coalesce(r.updated_at, r.created_at) >= $3
+  and coalesce(r.updated_at, r.created_at) < $4
+  and (r.created_at, r.id) < ($5, $6)
+order by r.created_at desc, r.id desc
+limit $7
 `
 
-func (q *Queries) ListJobs(ctx context.Context) ([]LineageJob, error) {
-	rows, err := q.db.QueryContext(ctx, listJobs)
+type ListRunsPageParams struct {
+	Namespace       string
+	State           int32
+	UpdatedSince    time.Time
+	UpdatedBefore   time.Time
+	BeforeCreatedAt time.Time
+	BeforeID        int64
+	RowLimit        int32
+}
+
+type ListRunsPageRow struct {
+	ID                  int64
+	RunUuid             uuid.UUID
+	JobVersionID        int64
+	ParentRunID         sql.NullInt64
+	LastEventType       int32
+	Facets              pqtype.NullRawMessage
+	StartedAt           sql.NullTime
+	EndedAt             sql.NullTime
+	NominalStartedAt    sql.NullTime
+	NominalEndedAt      sql.NullTime
+	ErrorMessage        sql.NullString
+	ProgrammingLanguage sql.NullString
+	Stacktrace          sql.NullString
+	CreatedAt           time.Time
+	UpdatedAt           sql.NullTime
+	IsPlaceholder       bool
+	State               int32
+	LastEventTime       sql.NullTime
+	JobID               int64
+	JobName             string
+	NamespaceName       string
+}
+
+func (q *Queries) ListRunsPage(ctx context.Context, arg ListRunsPageParams) ([]ListRunsPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsPage,
+		arg.Namespace,
+		arg.State,
+		arg.UpdatedSince,
+		arg.UpdatedBefore,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageJob
+	var items []ListRunsPageRow
 	for rows.Next() {
-		var i LineageJob
+		var i ListRunsPageRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CurrentVersionID,
-			&i.NamespaceID,
-			&i.Name,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
 			&i.Facets,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
+			&i.JobID,
+			&i.JobName,
+			&i.NamespaceName,
 		); err != nil {
 			return nil, err
 		}
@@ -1186,52 +4814,67 @@ func (q *Queries) ListJobs(ctx context.Context) ([]LineageJob, error) {
 	return items, nil
 }
 
-const listJobsWithNamespaces = `-- name: ListJobsWithNamespaces :many
-select 
-  j.id, 
-  j.name, 
-  j.namespace_id, 
-  j.facets,
-  j.updated_at,
-  j.created_at,
-  ns.name as namespace_name,
-  ns.updated_at as namespace_updated_at,
-  ns.created_at as namespace_created_at
-from lineage.jobs j
-join lineage.job_namespaces ns on ns.id = j.namespace_id
+const listRunsPageByJobID = `-- name: ListRunsPageByJobID :many
+select r.id, r.run_uuid, r.job_version_id, r.parent_run_id, r.last_event_type, r.facets, r.started_at, r.ended_at, r.nominal_started_at, r.nominal_ended_at, r.error_message, r.programming_language, r.stacktrace, r.created_at, r.updated_at, r.is_placeholder, r.state, r.last_event_time from lineage.runs r
+join lineage.job_versions jv on jv.id = r.job_version_id
+where jv.job_id = $1
+  and (r.job_version_id = $2 or $2 = 0)
+  and (r.state = $3 or $3 = 0)
+  and coalesce(r.updated_at, r.created_at) >= $4
+  and coalesce(r.updated_at, r.created_at) < $5
+  and (r.created_at, r.id) < ($6, $7)
+order by r.created_at desc, r.id desc
+limit $8
 `
 
-type ListJobsWithNamespacesRow struct {
-	ID                 int64
-	Name               string
-	NamespaceID        int64
-	Facets             pqtype.NullRawMessage
-	UpdatedAt          sql.NullTime
-	CreatedAt          time.Time
-	NamespaceName      string
-	NamespaceUpdatedAt sql.NullTime
-	NamespaceCreatedAt time.Time
+type ListRunsPageByJobIDParams struct {
+	JobID           int64
+	JobVersionID    int64
+	State           int32
+	UpdatedSince    time.Time
+	UpdatedBefore   time.Time
+	BeforeCreatedAt time.Time
+	BeforeID        int64
+	RowLimit        int32
 }
 
-func (q *Queries) ListJobsWithNamespaces(ctx context.Context) ([]ListJobsWithNamespacesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listJobsWithNamespaces)
+func (q *Queries) ListRunsPageByJobID(ctx context.Context, arg ListRunsPageByJobIDParams) ([]LineageRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsPageByJobID,
+		arg.JobID,
+		arg.JobVersionID,
+		arg.State,
+		arg.UpdatedSince,
+		arg.UpdatedBefore,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListJobsWithNamespacesRow
+	var items []LineageRun
 	for rows.Next() {
-		var i ListJobsWithNamespacesRow
+		var i LineageRun
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.NamespaceID,
+			&i.RunUuid,
+			&i.JobVersionID,
+			&i.ParentRunID,
+			&i.LastEventType,
 			&i.Facets,
-			&i.UpdatedAt,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.NominalStartedAt,
+			&i.NominalEndedAt,
+			&i.ErrorMessage,
+			&i.ProgrammingLanguage,
+			&i.Stacktrace,
 			&i.CreatedAt,
-			&i.NamespaceName,
-			&i.NamespaceUpdatedAt,
-			&i.NamespaceCreatedAt,
+			&i.UpdatedAt,
+			&i.IsPlaceholder,
+			&i.State,
+			&i.LastEventTime,
 		); err != nil {
 			return nil, err
 		}
@@ -1246,29 +4889,32 @@ func (q *Queries) ListJobsWithNamespaces(ctx context.Context) ([]ListJobsWithNam
 	return items, nil
 }
 
-const listLifecycleStateChangesByDatasetID = `-- name: ListLifecycleStateChangesByDatasetID :many
-select id, dataset_id, change, namespace, name, data_type, created_at, updated_at from lineage.lifecycle_state_changes
-where dataset_id = $1 order by created_at
+const listSchemaChangesByDatasetVersionID = `-- name: ListSchemaChangesByDatasetVersionID :many
+select id, dataset_version_id, previous_version_id, change_type, field_name, previous_data_type, data_type, previous_description, description, created_at from lineage.schema_changes
+where dataset_version_id = $1
+order by field_name
 `
 
-func (q *Queries) ListLifecycleStateChangesByDatasetID(ctx context.Context, datasetID int64) ([]LineageLifecycleStateChange, error) {
-	rows, err := q.db.QueryContext(ctx, listLifecycleStateChangesByDatasetID, datasetID)
+func (q *Queries) ListSchemaChangesByDatasetVersionID(ctx context.Context, datasetVersionID int64) ([]LineageSchemaChange, error) {
+	rows, err := q.db.QueryContext(ctx, listSchemaChangesByDatasetVersionID, datasetVersionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageLifecycleStateChange
+	var items []LineageSchemaChange
 	for rows.Next() {
-		var i LineageLifecycleStateChange
+		var i LineageSchemaChange
 		if err := rows.Scan(
 			&i.ID,
-			&i.DatasetID,
-			&i.Change,
-			&i.Namespace,
-			&i.Name,
+			&i.DatasetVersionID,
+			&i.PreviousVersionID,
+			&i.ChangeType,
+			&i.FieldName,
+			&i.PreviousDataType,
 			&i.DataType,
+			&i.PreviousDescription,
+			&i.Description,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1283,21 +4929,32 @@ func (q *Queries) ListLifecycleStateChangesByDatasetID(ctx context.Context, data
 	return items, nil
 }
 
-const listRequests = `-- name: ListRequests :many
-select id, payload, created_at from lineage.requests
-order by created_at
+const listUnreferencedDatasetVersions = `-- name: ListUnreferencedDatasetVersions :many
+select v.id, v.dataset_id, v.namespace_id, v.name, v.created_at, v.updated_at, v.schema_recorded, v.version_uuid from lineage.dataset_versions v
+where not exists (select 1 from lineage.run_dataset_versions rdv where rdv.dataset_version_id = v.id)
+  and not exists (select 1 from lineage.datasets d where d.current_version_id = v.id)
+order by v.id
 `
 
-func (q *Queries) ListRequests(ctx context.Context) ([]LineageRequest, error) {
-	rows, err := q.db.QueryContext(ctx, listRequests)
+func (q *Queries) ListUnreferencedDatasetVersions(ctx context.Context) ([]LineageDatasetVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listUnreferencedDatasetVersions)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageRequest
+	var items []LineageDatasetVersion
 	for rows.Next() {
-		var i LineageRequest
-		if err := rows.Scan(&i.ID, &i.Payload, &i.CreatedAt); err != nil {
+		var i LineageDatasetVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.DatasetID,
+			&i.NamespaceID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SchemaRecorded,
+			&i.VersionUuid,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1311,75 +4968,121 @@ func (q *Queries) ListRequests(ctx context.Context) ([]LineageRequest, error) {
 	return items, nil
 }
 
-const listRunDatasetVersionsWithRelationshipsByRunID = `-- name: ListRunDatasetVersionsWithRelationshipsByRunID :many
-select 
-  r.run_id, 
-  r.dataset_version_id,
-  r.io_type,
-  r.dataset_facets,
-  r.io_facets,
+const repointDatasetAliases = `-- name: RepointDatasetAliases :exec
+update lineage.dataset_aliases
+set dataset_id = $1, updated_at = $2
+where dataset_id = $3
+`
+
+type RepointDatasetAliasesParams struct {
+	DatasetID         int64
+	UpdatedAt         sql.NullTime
+	PreviousDatasetID int64
+}
+
+func (q *Queries) RepointDatasetAliases(ctx context.Context, arg RepointDatasetAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, repointDatasetAliases, arg.DatasetID, arg.UpdatedAt, arg.PreviousDatasetID)
+	return err
+}
+
+const searchDatasetDocuments = `-- name: SearchDatasetDocuments :many
+with matches as (
+  select
+    sd.id,
+    sd.entity_id,
+    sd.field,
+    sd.content,
+    case sd.field
+      when 'name' then 0
+      when 'namespace' then 1
+      when 'field' then 2
+      when 'description' then 3
+      when 'owner' then 4
+      else 5
+    end as field_rank
+  from lineage.search_documents sd
+  where sd.entity_type = $1
+    and sd.content ilike $2 escape '\'
+), ranked as (
+  select
+    d.id,
+    d.name,
+    ns.name as namespace_name,
+    d.created_at,
+    d.updated_at,
+    min(m.field_rank * 1000000 + length(m.content)) as score
+  from matches m
+  join lineage.datasets d on d.id = m.entity_id
+  join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+  where not exists (
+    select 1 from lineage.dataset_aliases a
+    where a.namespace = ns.name and a.name = d.name and a.dataset_id <> d.id
+  )
+  group by d.id, d.name, ns.name, d.created_at, d.updated_at
+  order by score, d.name, d.id
+  limit $3
+)
+select
+  r.id,
+  r.name,
+  r.namespace_name,
   r.created_at,
-  v.id as version_id, 
-  v.dataset_id as version_dataset_id, 
-  v.name as version_name, 
-  v.namespace_id as version_namespace_id, 
-  v.updated_at as version_updated_at,
-  v.created_at as version_created_at,
-  n.id as namespace_id, 
-  n.name as namespace_name,
-  n.updated_at as namespace_updated_at,
-  n.created_at as namespace_created_at
-from lineage.run_dataset_versions r
-join lineage.dataset_versions v on v.id = r.dataset_version_id
-join lineage.dataset_namespaces n on n.id = v.namespace_id
-where r.run_id = $1
+  r.updated_at,
+  r.score,
+  m.field,
+  m.content
+from ranked r
+join (
+  select
+    matches.*,
+    row_number() over (partition by matches.entity_id order by matches.field_rank, matches.id) as match_number
+  from matches
+) m on m.entity_id = r.id
+where m.match_number <= $4
+order by r.score, r.name, r.id, m.field_rank, m.id
 `
 
-type ListRunDatasetVersionsWithRelationshipsByRunIDRow struct {
-	RunID              int64
-	DatasetVersionID   int64
-	IoType             int32
-	DatasetFacets      pqtype.NullRawMessage
-	IoFacets           pqtype.NullRawMessage
-	CreatedAt          time.Time
-	VersionID          int64
-	VersionDatasetID   int64
-	VersionName        string
-	VersionNamespaceID int64
-	VersionUpdatedAt   sql.NullTime
-	VersionCreatedAt   time.Time
-	NamespaceID        int64
-	NamespaceName      string
-	NamespaceUpdatedAt sql.NullTime
-	NamespaceCreatedAt time.Time
+type SearchDatasetDocumentsParams struct {
+	EntityType       int32
+	Pattern          string
+	RowLimit         int32
+	MatchesPerResult int32
 }
 
-func (q *Queries) ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Context, runID int64) ([]ListRunDatasetVersionsWithRelationshipsByRunIDRow, error) {
-	rows, err := q.db.QueryContext(ctx, listRunDatasetVersionsWithRelationshipsByRunID, runID)
+type SearchDatasetDocumentsRow struct {
+	ID            int64
+	Name          string
+	NamespaceName string
+	CreatedAt     time.Time
+	UpdatedAt     sql.NullTime
+	Score         int64
+	Field         string
+	Content       string
+}
+
+func (q *Queries) SearchDatasetDocuments(ctx context.Context, arg SearchDatasetDocumentsParams) ([]SearchDatasetDocumentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchDatasetDocuments,
+		arg.EntityType,
+		arg.Pattern,
+		arg.RowLimit,
+		arg.MatchesPerResult,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListRunDatasetVersionsWithRelationshipsByRunIDRow
+	var items []SearchDatasetDocumentsRow
 	for rows.Next() {
-		var i ListRunDatasetVersionsWithRelationshipsByRunIDRow
+		var i SearchDatasetDocumentsRow
 		if err := rows.Scan(
-			&i.RunID,
-			&i.DatasetVersionID,
-			&i.IoType,
-			&i.DatasetFacets,
-			&i.IoFacets,
-			&i.CreatedAt,
-			&i.VersionID,
-			&i.VersionDatasetID,
-			&i.VersionName,
-			&i.VersionNamespaceID,
-			&i.VersionUpdatedAt,
-			&i.VersionCreatedAt,
-			&i.NamespaceID,
+			&i.ID,
+			&i.Name,
 			&i.NamespaceName,
-			&i.NamespaceUpdatedAt,
-			&i.NamespaceCreatedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Score,
+			&i.Field,
+			&i.Content,
 		); err != nil {
 			return nil, err
 		}
@@ -1394,29 +5097,63 @@ func (q *Queries) ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Con
 	return items, nil
 }
 
-const listRunEventsByRunID = `-- name: ListRunEventsByRunID :many
-SELECT id, run_id, event_type, event_time, facets, created_at, updated_at FROM lineage.run_events
-WHERE run_id = $1
-ORDER BY created_at asc
+const searchDatasetsWithNamespaces = `-- name: SearchDatasetsWithNamespaces :many
+select 
+  d.id, 
+  d.current_version_id,
+  d.name, 
+  d.namespace_id, 
+  d.facets,
+  d.updated_at,
+  d.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.datasets d
+join lineage.dataset_namespaces ns on ns.id = d.namespace_id
+where d.name ilike $1 or ns.name ilike $1
+order by d.name
+limit $2
 `
 
-func (q *Queries) ListRunEventsByRunID(ctx context.Context, runID int64) ([]LineageRunEvent, error) {
-	rows, err := q.db.QueryContext(ctx, listRunEventsByRunID, runID)
+type SearchDatasetsWithNamespacesParams struct {
+	Pattern  string
+	RowLimit int32
+}
+
+type SearchDatasetsWithNamespacesRow struct {
+	ID                 int64
+	CurrentVersionID   sql.NullInt64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) SearchDatasetsWithNamespaces(ctx context.Context, arg SearchDatasetsWithNamespacesParams) ([]SearchDatasetsWithNamespacesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchDatasetsWithNamespaces, arg.Pattern, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageRunEvent
+	var items []SearchDatasetsWithNamespacesRow
 	for rows.Next() {
-		var i LineageRunEvent
+		var i SearchDatasetsWithNamespacesRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.RunID,
-			&i.EventType,
-			&i.EventTime,
+			&i.CurrentVersionID,
+			&i.Name,
+			&i.NamespaceID,
 			&i.Facets,
-			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1431,36 +5168,100 @@ func (q *Queries) ListRunEventsByRunID(ctx context.Context, runID int64) ([]Line
 	return items, nil
 }
 
-const listRuns = `-- name: ListRuns :many
-select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at from lineage.runs
-order by job_version_id, id
+const searchJobDocuments = `-- name: SearchJobDocuments :many
+with matches as (
+  select
+    sd.id,
+    sd.entity_id,
+    sd.field,
+    sd.content,
+    case sd.field
+      when 'name' then 0
+      when 'namespace' then 1
+      when 'field' then 2
+      when 'description' then 3
+      when 'owner' then 4
+      else 5
+    end as field_rank
+  from lineage.search_documents sd
+  where sd.entity_type = $1
+    and sd.content ilike $2 escape '\'
+), ranked as (
+  select
+    j.id,
+    j.name,
+    ns.name as namespace_name,
+    j.created_at,
+    j.updated_at,
+    min(m.field_rank * 1000000 + length(m.content)) as score
+  from matches m
+  join lineage.jobs j on j.id = m.entity_id
+  join lineage.job_namespaces ns on ns.id = j.namespace_id
+  group by j.id, j.name, ns.name, j.created_at, j.updated_at
+  order by score, j.name, j.id
+  limit $3
+)
+select
+  r.id,
+  r.name,
+  r.namespace_name,
+  r.created_at,
+  r.updated_at,
+  r.score,
+  m.field,
+  m.content
+from ranked r
+join (
+  select
+    matches.*,
+    row_number() over (partition by matches.entity_id order by matches.field_rank, matches.id) as match_number
+  from matches
+) m on m.entity_id = r.id
+where m.match_number <= $4
+order by r.score, r.name, r.id, m.field_rank, m.id
 `
 
-func (q *Queries) ListRuns(ctx context.Context) ([]LineageRun, error) {
-	rows, err := q.db.QueryContext(ctx, listRuns)
+type SearchJobDocumentsParams struct {
+	EntityType       int32
+	Pattern          string
+	RowLimit         int32
+	MatchesPerResult int32
+}
+
+type SearchJobDocumentsRow struct {
+	ID            int64
+	Name          string
+	NamespaceName string
+	CreatedAt     time.Time
+	UpdatedAt     sql.NullTime
+	Score         int64
+	Field         string
+	Content       string
+}
+
+func (q *Queries) SearchJobDocuments(ctx context.Context, arg SearchJobDocumentsParams) ([]SearchJobDocumentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchJobDocuments,
+		arg.EntityType,
+		arg.Pattern,
+		arg.RowLimit,
+		arg.MatchesPerResult,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageRun
+	var items []SearchJobDocumentsRow
 	for rows.Next() {
-		var i LineageRun
+		var i SearchJobDocumentsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.RunUuid,
-			&i.JobVersionID,
-			&i.ParentRunID,
-			&i.LastEventType,
-			&i.Facets,
-			&i.StartedAt,
-			&i.EndedAt,
-			&i.NominalStartedAt,
-			&i.NominalEndedAt,
-			&i.ErrorMessage,
-			&i.ProgrammingLanguage,
-			&i.Stacktrace,
+			&i.Name,
+			&i.NamespaceName,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Score,
+			&i.Field,
+			&i.Content,
 		); err != nil {
 			return nil, err
 		}
@@ -1475,37 +5276,60 @@ func (q *Queries) ListRuns(ctx context.Context) ([]LineageRun, error) {
 	return items, nil
 }
 
-const listRunsByJobVersionID = `-- name: ListRunsByJobVersionID :many
-select id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at from lineage.runs
-where job_version_id = $1
-order by created_at desc
+const searchJobsWithNamespaces = `-- name: SearchJobsWithNamespaces :many
+select 
+  j.id, 
+  j.name, 
+  j.namespace_id, 
+  j.facets,
+  j.updated_at,
+  j.created_at,
+  ns.name as namespace_name,
+  ns.updated_at as namespace_updated_at,
+  ns.created_at as namespace_created_at
+from lineage.jobs j
+join lineage.job_namespaces ns on ns.id = j.namespace_id
+where j.name ilike $1 or ns.name ilike $1
+order by j.name
+limit $2
 `
 
-func (q *Queries) ListRunsByJobVersionID(ctx context.Context, jobVersionID int64) ([]LineageRun, error) {
-	rows, err := q.db.QueryContext(ctx, listRunsByJobVersionID, jobVersionID)
+type SearchJobsWithNamespacesParams struct {
+	Pattern  string
+	RowLimit int32
+}
+
+type SearchJobsWithNamespacesRow struct {
+	ID                 int64
+	Name               string
+	NamespaceID        int64
+	Facets             pqtype.NullRawMessage
+	UpdatedAt          sql.NullTime
+	CreatedAt          time.Time
+	NamespaceName      string
+	NamespaceUpdatedAt sql.NullTime
+	NamespaceCreatedAt time.Time
+}
+
+func (q *Queries) SearchJobsWithNamespaces(ctx context.Context, arg SearchJobsWithNamespacesParams) ([]SearchJobsWithNamespacesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchJobsWithNamespaces, arg.Pattern, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []LineageRun
+	var items []SearchJobsWithNamespacesRow
 	for rows.Next() {
-		var i LineageRun
+		var i SearchJobsWithNamespacesRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.RunUuid,
-			&i.JobVersionID,
-			&i.ParentRunID,
-			&i.LastEventType,
+			&i.Name,
+			&i.NamespaceID,
 			&i.Facets,
-			&i.StartedAt,
-			&i.EndedAt,
-			&i.NominalStartedAt,
-			&i.NominalEndedAt,
-			&i.ErrorMessage,
-			&i.ProgrammingLanguage,
-			&i.Stacktrace,
-			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.NamespaceName,
+			&i.NamespaceUpdatedAt,
+			&i.NamespaceCreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1520,6 +5344,32 @@ func (q *Queries) ListRunsByJobVersionID(ctx context.Context, jobVersionID int64
 	return items, nil
 }
 
+const setDatasetVersionSchemaRecorded = `-- name: SetDatasetVersionSchemaRecorded :exec
+update lineage.dataset_versions set schema_recorded = true
+where id = $1
+`
+
+func (q *Queries) SetDatasetVersionSchemaRecorded(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, setDatasetVersionSchemaRecorded, id)
+	return err
+}
+
+const updateAPITokenLastUsedAt = `-- name: UpdateAPITokenLastUsedAt :exec
+update lineage.api_tokens set
+  last_used_at = $1
+where id = $2
+`
+
+type UpdateAPITokenLastUsedAtParams struct {
+	LastUsedAt sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) UpdateAPITokenLastUsedAt(ctx context.Context, arg UpdateAPITokenLastUsedAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateAPITokenLastUsedAt, arg.LastUsedAt, arg.ID)
+	return err
+}
+
 const updateCurrentDatasetVersion = `-- name: UpdateCurrentDatasetVersion :one
 update lineage.datasets set current_version_id = $1, updated_at = $2 
 where id = $3
@@ -1603,6 +5453,103 @@ func (q *Queries) UpdateDataset(ctx context.Context, arg UpdateDatasetParams) (L
 	return i, err
 }
 
+const updateFailedEvent = `-- name: UpdateFailedEvent :exec
+update lineage.failed_events set
+  payload = $1,
+  producer = $2,
+  error_message = $3,
+  stacktrace = $4,
+  attempts = $5,
+  updated_at = $6
+where id = $7
+`
+
+type UpdateFailedEventParams struct {
+	Payload      json.RawMessage
+	Producer     sql.NullString
+	ErrorMessage string
+	Stacktrace   sql.NullString
+	Attempts     int32
+	UpdatedAt    sql.NullTime
+	ID           int64
+}
+
+func (q *Queries) UpdateFailedEvent(ctx context.Context, arg UpdateFailedEventParams) error {
+	_, err := q.db.ExecContext(ctx, updateFailedEvent,
+		arg.Payload,
+		arg.Producer,
+		arg.ErrorMessage,
+		arg.Stacktrace,
+		arg.Attempts,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateJob = `-- name: UpdateJob :one
+update lineage.jobs set 
+  facets = $1,
+  updated_at = $2 
+where id = $3
+returning id, current_version_id, namespace_id, name, facets, created_at, updated_at
+`
+
+type UpdateJobParams struct {
+	Facets    pqtype.NullRawMessage
+	UpdatedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) UpdateJob(ctx context.Context, arg UpdateJobParams) (LineageJob, error) {
+	row := q.db.QueryRowContext(ctx, updateJob, arg.Facets, arg.UpdatedAt, arg.ID)
+	var i LineageJob
+	err := row.Scan(
+		&i.ID,
+		&i.CurrentVersionID,
+		&i.NamespaceID,
+		&i.Name,
+		&i.Facets,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateRequestStatus = `-- name: UpdateRequestStatus :exec
+update lineage.requests set
+  status = $1,
+  attempts = $2,
+  next_attempt_at = $3,
+  error_message = $4,
+  run_event_id = $5,
+  processed_at = $6
+where id = $7
+`
+
+type UpdateRequestStatusParams struct {
+	Status        int32
+	Attempts      int32
+	NextAttemptAt sql.NullTime
+	ErrorMessage  sql.NullString
+	RunEventID    sql.NullInt64
+	ProcessedAt   sql.NullTime
+	ID            int64
+}
+
+func (q *Queries) UpdateRequestStatus(ctx context.Context, arg UpdateRequestStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateRequestStatus,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.ErrorMessage,
+		arg.RunEventID,
+		arg.ProcessedAt,
+		arg.ID,
+	)
+	return err
+}
+
 const updateRun = `-- name: UpdateRun :one
 UPDATE lineage.runs SET 
   facets = $2,
@@ -1610,10 +5557,13 @@ UPDATE lineage.runs SET
   error_message = $4,
   programming_language = $5,
   stacktrace = $6,
-  ended_at = $7,
-  updated_at = $8
+  started_at = $7,
+  ended_at = $8,
+  updated_at = $9,
+  state = $10,
+  last_event_time = $11
 WHERE id = $1
-RETURNING id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at
+RETURNING id, run_uuid, job_version_id, parent_run_id, last_event_type, facets, started_at, ended_at, nominal_started_at, nominal_ended_at, error_message, programming_language, stacktrace, created_at, updated_at, is_placeholder, state, last_event_time
 `
 
 type UpdateRunParams struct {
@@ -1623,8 +5573,11 @@ type UpdateRunParams struct {
 	ErrorMessage        sql.NullString
 	ProgrammingLanguage sql.NullString
 	Stacktrace          sql.NullString
+	StartedAt           sql.NullTime
 	EndedAt             sql.NullTime
 	UpdatedAt           sql.NullTime
+	State               int32
+	LastEventTime       sql.NullTime
 }
 
 func (q *Queries) UpdateRun(ctx context.Context, arg UpdateRunParams) (LineageRun, error) {
@@ -1635,8 +5588,11 @@ func (q *Queries) UpdateRun(ctx context.Context, arg UpdateRunParams) (LineageRu
 		arg.ErrorMessage,
 		arg.ProgrammingLanguage,
 		arg.Stacktrace,
+		arg.StartedAt,
 		arg.EndedAt,
 		arg.UpdatedAt,
+		arg.State,
+		arg.LastEventTime,
 	)
 	var i LineageRun
 	err := row.Scan(
@@ -1655,6 +5611,151 @@ func (q *Queries) UpdateRun(ctx context.Context, arg UpdateRunParams) (LineageRu
 		&i.Stacktrace,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsPlaceholder,
+		&i.State,
+		&i.LastEventTime,
+	)
+	return i, err
+}
+
+const updateRunDatasetVersion = `-- name: UpdateRunDatasetVersion :one
+update lineage.run_dataset_versions set
+  dataset_facets = $3,
+  io_facets = $4,
+  last_event_type = $5,
+  updated_at = $6
+where run_id = $1 and dataset_version_id = $2
+returning run_id, dataset_version_id, io_type, dataset_facets, io_facets, created_at, first_event_type, last_event_type, updated_at
+`
+
+type UpdateRunDatasetVersionParams struct {
+	RunID            int64
+	DatasetVersionID int64
+	DatasetFacets    pqtype.NullRawMessage
+	IoFacets         pqtype.NullRawMessage
+	LastEventType    int32
+	UpdatedAt        sql.NullTime
+}
+
+func (q *Queries) UpdateRunDatasetVersion(ctx context.Context, arg UpdateRunDatasetVersionParams) (LineageRunDatasetVersion, error) {
+	row := q.db.QueryRowContext(ctx, updateRunDatasetVersion,
+		arg.RunID,
+		arg.DatasetVersionID,
+		arg.DatasetFacets,
+		arg.IoFacets,
+		arg.LastEventType,
+		arg.UpdatedAt,
+	)
+	var i LineageRunDatasetVersion
+	err := row.Scan(
+		&i.RunID,
+		&i.DatasetVersionID,
+		&i.IoType,
+		&i.DatasetFacets,
+		&i.IoFacets,
+		&i.CreatedAt,
+		&i.FirstEventType,
+		&i.LastEventType,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertFacet = `-- name: UpsertFacet :one
+insert into lineage.facets (
+  entity_type,
+  entity_id,
+  name,
+  producer,
+  schema_url,
+  payload,
+  created_at
+) values (
+  $1, $2, $3, $4, $5, $6, $7
+)
+on conflict (entity_type, entity_id, name) do update set
+  producer = excluded.producer,
+  schema_url = excluded.schema_url,
+  payload = excluded.payload,
+  updated_at = excluded.created_at
+returning id, entity_type, entity_id, name, producer, schema_url, payload, created_at, updated_at
+`
+
+type UpsertFacetParams struct {
+	EntityType int32
+	EntityID   int64
+	Name       string
+	Producer   sql.NullString
+	SchemaUrl  sql.NullString
+	Payload    json.RawMessage
+	CreatedAt  time.Time
+}
+
+func (q *Queries) UpsertFacet(ctx context.Context, arg UpsertFacetParams) (LineageFacet, error) {
+	row := q.db.QueryRowContext(ctx, upsertFacet,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Name,
+		arg.Producer,
+		arg.SchemaUrl,
+		arg.Payload,
+		arg.CreatedAt,
+	)
+	var i LineageFacet
+	err := row.Scan(
+		&i.ID,
+		&i.EntityType,
+		&i.EntityID,
+		&i.Name,
+		&i.Producer,
+		&i.SchemaUrl,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertManualDatasetAlias = `-- name: UpsertManualDatasetAlias :one
+insert into lineage.dataset_aliases (
+  namespace,
+  name,
+  dataset_id,
+  is_manual,
+  created_at
+) values (
+  $1, $2, $3, true, $4
+)
+on conflict (namespace, name) do update set
+  dataset_id = excluded.dataset_id,
+  is_manual = true,
+  updated_at = excluded.created_at
+returning id, namespace, name, dataset_id, is_manual, created_at, updated_at
+`
+
+type UpsertManualDatasetAliasParams struct {
+	Namespace string
+	Name      string
+	DatasetID int64
+	CreatedAt time.Time
+}
+
+func (q *Queries) UpsertManualDatasetAlias(ctx context.Context, arg UpsertManualDatasetAliasParams) (LineageDatasetAlias, error) {
+	row := q.db.QueryRowContext(ctx, upsertManualDatasetAlias,
+		arg.Namespace,
+		arg.Name,
+		arg.DatasetID,
+		arg.CreatedAt,
+	)
+	var i LineageDatasetAlias
+	err := row.Scan(
+		&i.ID,
+		&i.Namespace,
+		&i.Name,
+		&i.DatasetID,
+		&i.IsManual,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -2,13 +2,17 @@ package datasets
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"oplin/internal/lineage"
 	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/htmx/facets"
+	"oplin/internal/lineage/htmx/graph"
 	"oplin/internal/lineage/ops"
 	"oplin/internal/openlineage"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -56,11 +60,47 @@ type Breadcrumb struct {
 var TabItems = []TabItem{
 	{Key: "fields", Text: "Fields", Href: "/lineage/datasets/%d/fields"},
 	{Key: "lineage", Text: "Lineage", Href: "/lineage/datasets/%d/lineage"},
+	{Key: "graph", Text: "Graph", Href: "/lineage/datasets/%d/graph"},
 	{Key: "ownership", Text: "Ownership", Href: "/lineage/datasets/%d/ownership"},
 	{Key: "quality", Text: "Quality", Href: "/lineage/datasets/%d/quality"},
+	{Key: "history", Text: "History", Href: "/lineage/datasets/%d/history"},
 	{Key: "more", Text: "More...", Href: "/lineage/datasets/%d/more"},
 }
 
+// HistoryItem is a lifecycle state change shown in the History tab. A rename
+// links to the dataset on the other side of it.
+type HistoryItem struct {
+	Change     string
+	CreatedAt  time.Time
+	RunHref    string
+	RunText    string
+	Detail     string
+	DetailHref string
+}
+
+func buildHistoryItems(dsID int64, changes []lineage.LifecycleStateChange) []HistoryItem {
+	var res []HistoryItem
+	for _, c := range changes {
+		it := HistoryItem{Change: c.Change, CreatedAt: c.CreatedAt}
+		if c.RunID > 0 {
+			it.RunHref = fmt.Sprintf("/lineage/runs/%d", c.RunID)
+			it.RunText = c.RunUUID.String()
+		}
+		switch {
+		case c.DatasetID != dsID:
+			it.Detail = fmt.Sprintf("Renamed to %s.%s", c.DatasetNamespace, c.DatasetName)
+			it.DetailHref = fmt.Sprintf("/lineage/datasets/%d", c.DatasetID)
+		case c.PreviousName != "":
+			it.Detail = fmt.Sprintf("Previously %s.%s", c.PreviousNamespace, c.PreviousName)
+			if c.PreviousDatasetID > 0 {
+				it.DetailHref = fmt.Sprintf("/lineage/datasets/%d", c.PreviousDatasetID)
+			}
+		}
+		res = append(res, it)
+	}
+	return res
+}
+
 func buildDatasetBreadcrumbs(dsID int64, text string) []Breadcrumb {
 	return []Breadcrumb{
 		{Href: "/lineage/datasets", Text: "Datasets"},
@@ -123,14 +163,27 @@ func MakeListDatasets(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
-		dss, err := ops.ListDatasetsWithNamespaces(ctx, deps)
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		page, err := ops.ListDatasetsPage(ctx, deps, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
 		if err != nil {
 			htmx.InternalServerError(c, err)
 			return
 		}
+		filters := htmx.BuildListFilters(c, "/lineage/datasets")
+		filters.Names = true
 		c.HTML(http.StatusOK, "lineage/datasets-list.html", gin.H{
 			"Title":     "Datasets",
-			"Datasets":  dss,
+			"Datasets":  page.Datasets,
+			"Filters":   filters,
+			"Pager":     htmx.BuildPager(c, "/lineage/datasets", page.Next),
 			"MenuItems": htmx.BuildMenuItems("datasets"),
 		})
 	}
@@ -162,12 +215,19 @@ func MakeGetDataset(deps htmx.Deps) gin.HandlerFunc {
 			return
 		}
 
+		changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, ds.Dataset.CurrentVersionID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
 		title := fmt.Sprintf("%s %s", ds.DatasetNamespace.Name, ds.Dataset.Name)
 
 		c.HTML(http.StatusOK, "lineage/datasets-detail.html", gin.H{
 			"Title":                title,
 			"DatasetWithNamespace": ds,
 			"Fields":               fields,
+			"SchemaChanges":        changes,
 			"VersionID":            ds.Dataset.CurrentVersionID,
 			"Versions":             vs,
 			"TabItems":             buildTabItems("fields", ds.Dataset.ID),
@@ -202,11 +262,17 @@ func MakeGetDatasetFields(deps htmx.Deps) gin.HandlerFunc {
 			htmx.InternalServerError(c, err)
 			return
 		}
+		changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, ds.Dataset.CurrentVersionID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
 		c.HTML(http.StatusOK, "lineage/datasets-fields.html", gin.H{
-			"Fields":    fields,
-			"VersionID": ds.Dataset.CurrentVersionID,
-			"Versions":  vs,
-			"TabItems":  buildTabItems("fields", ds.Dataset.ID),
+			"Fields":        fields,
+			"SchemaChanges": changes,
+			"VersionID":     ds.Dataset.CurrentVersionID,
+			"Versions":      vs,
+			"TabItems":      buildTabItems("fields", ds.Dataset.ID),
 		})
 	}
 }
@@ -236,11 +302,17 @@ func MakeGetDatasetVersionFields(deps htmx.Deps) gin.HandlerFunc {
 			htmx.InternalServerError(c, err)
 			return
 		}
+		changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, dsv.ID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
 		c.HTML(http.StatusOK, "lineage/datasets-fields.html", gin.H{
-			"Fields":    fields,
-			"VersionID": dsv.ID,
-			"Versions":  vs,
-			"TabItems":  buildTabItems("fields", dsv.DatasetID),
+			"Fields":        fields,
+			"SchemaChanges": changes,
+			"VersionID":     dsv.ID,
+			"Versions":      vs,
+			"TabItems":      buildTabItems("fields", dsv.DatasetID),
 		})
 	}
 }
@@ -329,6 +401,36 @@ func MakeGetDatasetVersionLineage(deps htmx.Deps) gin.HandlerFunc {
 	}
 }
 
+func MakeGetDatasetGraph(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		s := c.Param("id")
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		ds, err := ops.GetDatasetWithNamespace(ctx, deps, id)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+		nodeID := lineage.NodeID{Type: lineage.NodeTypeDataset, Namespace: ds.DatasetNamespace.Name, Name: ds.Dataset.Name}
+		g, err := graph.Build(ctx, deps, nodeID, graph.Depth(c, "upstream"), graph.Depth(c, "downstream"),
+			fmt.Sprintf("/lineage/datasets/%d/graph", id))
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/datasets-graph.html", gin.H{
+			"Graph":    g,
+			"TabItems": buildTabItems("graph", ds.Dataset.ID),
+		})
+	}
+}
+
 func buildFieldLineages(f *openlineage.DatasetFacets, ds *lineage.DatasetWithNamespace) []FieldLineage {
 	var fl []FieldLineage
 	for name, fields := range f.ColumnLineage.Fields {
@@ -392,6 +494,35 @@ func MakeGetDatasetQuality(deps htmx.Deps) gin.HandlerFunc {
 	}
 }
 
+func MakeGetDatasetHistory(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		s := c.Param("id")
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		ds, err := ops.GetDatasetWithNamespace(ctx, deps, id)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		changes, err := ops.ListLifecycleStateChanges(ctx, deps, ds.Dataset.ID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+		c.HTML(http.StatusOK, "lineage/datasets-history.html", gin.H{
+			"DatasetWithNamespace": ds,
+			"History":              buildHistoryItems(ds.Dataset.ID, changes),
+			"TabItems":             buildTabItems("history", ds.Dataset.ID),
+		})
+	}
+}
+
 func MakeGetDatasetMore(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
@@ -407,8 +538,21 @@ func MakeGetDatasetMore(deps htmx.Deps) gin.HandlerFunc {
 			htmx.InternalServerError(c, err)
 			return
 		}
+		fs, err := ops.ListFacets(ctx, deps, lineage.FacetEntityTypeDataset, ds.Dataset.ID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		aliases, err := ops.ListDatasetAliases(ctx, deps, ds.Dataset.ID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
 		c.HTML(http.StatusOK, "lineage/datasets-more.html", gin.H{
 			"DatasetWithNamespace": ds,
+			"Facets":               facets.BuildItems(fs),
+			"Aliases":              aliases,
 			"TabItems":             buildTabItems("more", ds.Dataset.ID),
 		})
 	}
@@ -0,0 +1,100 @@
+package datasets_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/wiring"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSuite(tb testing.TB) (*gin.Engine, *ops.TestDeps) {
+	deps := &ops.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(context.Background(), deps)
+	if err != nil {
+		tb.Fatalf("Failed to initialize test db[%v]", err)
+	}
+	r := wiring.NewGinEngine()
+	wiring.SetupRouter(r, deps)
+	return r, deps
+}
+
+func get(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDatasetPages(t *testing.T) {
+	t.Parallel()
+	r, deps := setupSuite(t)
+
+	ev := openlineage.RunEvent{}
+	ev.EventTime = time.Now().UTC()
+	ev.EventType = "COMPLETE"
+	ev.Job = openlineage.NewJob("airflow", "orders.monthly_summary", nil)
+	ev.Run = openlineage.NewRun(uuid.New())
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("postgres://db:5432", "public.monthly_summary", nil)}}
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(context.Background(), deps, &ev)
+	assert.Nil(t, err)
+
+	w := get(r, "/lineage/datasets")
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "public.monthly_summary")
+
+	for _, path := range []string{"/lineage/datasets/1", "/lineage/datasets/1/history", "/lineage/datasets/1/more"} {
+		w = get(r, path)
+		assert.Equal(t, 200, w.Code, path)
+	}
+
+	w = get(r, "/lineage/datasets/1000")
+	assert.NotEqual(t, 200, w.Code)
+}
+
+func TestDatasetListPages(t *testing.T) {
+	t.Parallel()
+	r, deps := setupSuite(t)
+
+	ev := openlineage.RunEvent{}
+	ev.EventTime = time.Now().UTC()
+	ev.EventType = "COMPLETE"
+	ev.Job = openlineage.NewJob("airflow", "orders.rollup", nil)
+	ev.Run = openlineage.NewRun(uuid.New())
+	for _, name := range []string{"public.a_orders", "public.b_orders", "public.c_orders"} {
+		ev.Outputs = append(ev.Outputs, openlineage.OutputDataset{Dataset: *openlineage.NewDataset("postgres://db:5432", name, nil)})
+	}
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(context.Background(), deps, &ev)
+	assert.Nil(t, err)
+
+	page, err := ops.ListDatasetsPage(context.Background(), deps, lineage.ListOptions{Limit: 2})
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(page.Datasets))
+	assert.Equal(t, "public.a_orders", page.Datasets[0].Dataset.Name)
+	require.NotEqual(t, "", page.Next)
+
+	w := get(r, "/lineage/datasets?limit=2&after="+page.Next)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "public.c_orders")
+	assert.NotContains(t, w.Body.String(), "public.a_orders")
+	assert.NotContains(t, w.Body.String(), ">Next<")
+
+	w = get(r, "/lineage/datasets?prefix=public.b")
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "public.b_orders")
+	assert.NotContains(t, w.Body.String(), "public.c_orders")
+
+	w = get(r, "/lineage/datasets?after=not-a-cursor")
+	assert.Equal(t, 400, w.Code)
+}
@@ -1,7 +1,7 @@
 package htmx
 
-import "database/sql"
+import "oplin/internal/lineage/store"
 
 type Deps interface {
-	GetDB() *sql.DB
+	GetStore() store.Store
 }
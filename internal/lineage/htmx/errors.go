@@ -19,3 +19,8 @@ func InternalServerError(c *gin.Context, err error) {
 	c.Error(&Err{E: err})
 	c.HTML(http.StatusInternalServerError, "lineage/error.html", gin.H{})
 }
+
+func BadRequest(c *gin.Context, err error) {
+	c.Error(&Err{E: err})
+	c.HTML(http.StatusBadRequest, "lineage/error.html", gin.H{})
+}
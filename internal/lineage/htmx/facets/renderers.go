@@ -0,0 +1,142 @@
+package facets
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"oplin/internal/lineage"
+	"sync"
+)
+
+// Renderer renders a facet for the "More" pages of datasets, jobs and runs
+type Renderer func(f lineage.Facet) (template.HTML, error)
+
+var (
+	mu        sync.RWMutex
+	renderers = map[string]Renderer{}
+)
+
+// Register sets the renderer of the facets with the given name, replacing any
+// previous one. Facets without a renderer are shown as indented JSON.
+func Register(name string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	renderers[name] = r
+}
+
+func lookup(name string) (Renderer, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// Render renders the facet with its registered renderer falling back to the
+// JSON one when there is none or it fails
+func Render(f lineage.Facet) template.HTML {
+	if r, ok := lookup(f.Name); ok {
+		if res, err := r(f); err == nil {
+			return res
+		}
+	}
+	res, err := RenderJSON(f)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(string(f.Payload)))
+	}
+	return res
+}
+
+// Item is a facet ready to be shown by the lineage/facets.html template
+type Item struct {
+	Name      string
+	Producer  string
+	SchemaURL string
+	HTML      template.HTML
+}
+
+func BuildItems(facets []lineage.Facet) []Item {
+	var res []Item
+	for _, f := range facets {
+		res = append(res, Item{Name: f.Name, Producer: f.Producer, SchemaURL: f.SchemaURL, HTML: Render(f)})
+	}
+	return res
+}
+
+var jsonTemplate = template.Must(template.New("json").Parse(`<pre><code>{{ . }}</code></pre>`))
+
+// RenderJSON renders the facet as indented JSON
+func RenderJSON(f lineage.Facet) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, f.Payload, "", "  "); err != nil {
+		return "", err
+	}
+	return execute(jsonTemplate, buf.String())
+}
+
+func execute(t *template.Template, data interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+var documentationTemplate = template.Must(template.New("documentation").Parse(
+	`<p>{{ .Description }}</p>`))
+
+func renderDocumentation(f lineage.Facet) (template.HTML, error) {
+	var v struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(f.Payload, &v); err != nil {
+		return "", err
+	}
+	return execute(documentationTemplate, v)
+}
+
+var sqlTemplate = template.Must(template.New("sql").Parse(
+	`<pre><code class="language-sql">{{ .Query }}</code></pre>`))
+
+func renderSQL(f lineage.Facet) (template.HTML, error) {
+	var v struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(f.Payload, &v); err != nil {
+		return "", err
+	}
+	return execute(sqlTemplate, v)
+}
+
+var sourceCodeLocationTemplate = template.Must(template.New("sourceCodeLocation").Parse(`<table role="grid">
+  <tbody>
+    <tr><th>Type</th><td>{{ .Type }}</td></tr>
+    <tr><th>URL</th><td>{{ .URL }}</td></tr>
+    <tr><th>Repo URL</th><td>{{ .RepoURL }}</td></tr>
+    <tr><th>Path</th><td>{{ .Path }}</td></tr>
+    <tr><th>Version</th><td>{{ .Version }}</td></tr>
+    <tr><th>Tag</th><td>{{ .Tag }}</td></tr>
+    <tr><th>Branch</th><td>{{ .Branch }}</td></tr>
+  </tbody>
+</table>`))
+
+func renderSourceCodeLocation(f lineage.Facet) (template.HTML, error) {
+	var v struct {
+		Type    string `json:"type"`
+		URL     string `json:"url"`
+		RepoURL string `json:"repoUrl"`
+		Path    string `json:"path"`
+		Version string `json:"version"`
+		Tag     string `json:"tag"`
+		Branch  string `json:"branch"`
+	}
+	if err := json.Unmarshal(f.Payload, &v); err != nil {
+		return "", err
+	}
+	return execute(sourceCodeLocationTemplate, v)
+}
+
+func init() {
+	Register("documentation", renderDocumentation)
+	Register("sql", renderSQL)
+	Register("sourceCodeLocation", renderSourceCodeLocation)
+}
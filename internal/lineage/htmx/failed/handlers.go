@@ -0,0 +1,130 @@
+package failed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"oplin/internal/lineage/auth"
+	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MakeListFailedEvents(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		page, err := ops.ListFailedEvents(ctx, deps, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/failed-list.html", gin.H{
+			"FailedEvents": page.FailedEvents,
+			"Filters":      htmx.BuildListFilters(c, "/lineage/failed"),
+			"Pager":        htmx.BuildPager(c, "/lineage/failed", page.Next),
+			"MenuItems":    htmx.BuildMenuItems("failed"),
+		})
+	}
+}
+
+func MakeGetFailedEvent(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+
+		ev, err := ops.GetFailedEvent(ctx, deps, id)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/failed-event.html", gin.H{
+			"FailedEvent": ev,
+			"Payload":     string(ev.Payload),
+			"CSRFToken":   auth.CSRFToken(c),
+			"MenuItems":   htmx.BuildMenuItems("failed"),
+		})
+	}
+}
+
+// MakeResubmitFailedEvent records the payload posted from the failed event
+// page. A payload that does not match the spec is shown again with the
+// fields at fault, one that fails again keeps the failed event with the new
+// error.
+func MakeResubmitFailedEvent(deps htmx.Deps, validator *openlineage.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		ev, err := ops.GetFailedEvent(ctx, deps, id)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		payload := []byte(c.PostForm("payload"))
+		var fieldErrs []openlineage.FieldError
+		kind, err := openlineage.DetectEventKind(payload)
+		if err != nil {
+			fieldErrs = []openlineage.FieldError{{Message: err.Error()}}
+		} else {
+			fieldErrs = validator.Validate(kind, payload)
+		}
+		if len(fieldErrs) > 0 {
+			c.HTML(http.StatusBadRequest, "lineage/failed-event.html", gin.H{
+				"FailedEvent": ev,
+				"Payload":     string(payload),
+				"FieldErrors": fieldErrs,
+				"CSRFToken":   auth.CSRFToken(c),
+				"MenuItems":   htmx.BuildMenuItems("failed"),
+			})
+			return
+		}
+
+		if err := ol_ops.ResubmitFailedEvent(ctx, deps, id, payload); err != nil {
+			c.Error(&htmx.Err{E: err})
+			c.Redirect(http.StatusSeeOther, fmt.Sprintf("/lineage/failed/%d", id))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/lineage/failed")
+	}
+}
+
+func MakeDiscardFailedEvent(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		if err := ol_ops.DiscardFailedEvent(ctx, deps, id); err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/lineage/failed")
+	}
+}
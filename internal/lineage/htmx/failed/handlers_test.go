@@ -0,0 +1,86 @@
+package failed_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/wiring"
+	"oplin/internal/openlineage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSuite(tb testing.TB) (*gin.Engine, *ops.TestDeps) {
+	deps := &ops.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(context.Background(), deps)
+	if err != nil {
+		tb.Fatalf("Failed to initialize test db[%v]", err)
+	}
+	r := wiring.NewGinEngine()
+	wiring.SetupRouter(r, deps)
+	return r, deps
+}
+
+func get(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func post(r *gin.Engine, path string, form url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFailedEventPages(t *testing.T) {
+	t.Parallel()
+	r, deps := setupSuite(t)
+	ctx := context.Background()
+
+	ev := openlineage.RunEvent{}
+	ev.EventTime = time.Now().UTC()
+	ev.EventType = "unknown"
+	ev.Producer = "https://example.com/misconfigured"
+	ev.Job = openlineage.NewJob("airflow", "orders.monthly_summary", nil)
+	ev.Run = openlineage.NewRun(uuid.New())
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	require.NotNil(t, err)
+
+	w := get(r, "/lineage/failed")
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/misconfigured")
+
+	page, err := ops.ListFailedEvents(ctx, deps, lineage.ListOptions{})
+	require.Nil(t, err)
+	id := page.FailedEvents[0].ID
+	path := fmt.Sprintf("/lineage/failed/%d", id)
+	w = get(r, path)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "orders.monthly_summary")
+
+	// a payload that is not an event is shown again
+	w = post(r, path+"/resubmit", url.Values{"payload": {`{"eventType": "START"}`}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "field-errors")
+
+	w = post(r, path+"/discard", url.Values{})
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	_, err = ops.GetFailedEvent(ctx, deps, id)
+	assert.NotNil(t, err)
+}
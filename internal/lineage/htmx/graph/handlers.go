@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/ops"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Node struct {
+	DomID     string
+	Type      string
+	Namespace string
+	Name      string
+	Href      string
+	GraphHref string
+	IsCenter  bool
+}
+
+type Column struct {
+	Nodes []Node
+}
+
+type Line struct {
+	Origin      string
+	Destination string
+}
+
+type Graph struct {
+	NodeID     string
+	Href       string
+	Upstream   int
+	Downstream int
+	Depths     []int
+	Columns    []Column
+	Lines      []Line
+}
+
+// Depth reads the upstream or downstream query parameter
+func Depth(c *gin.Context, key string) int {
+	v, err := strconv.Atoi(c.Query(key))
+	if err != nil || v < 0 {
+		return ops.DefaultLineageDepth
+	}
+	return v
+}
+
+// levels returns how many hops each node is from start, negative upstream
+// and positive downstream
+func levels(g *lineage.LineageGraph, start lineage.NodeID) map[lineage.NodeID]int {
+	res := map[lineage.NodeID]int{start: 0}
+	for _, step := range []int{-1, 1} {
+		frontier := []lineage.NodeID{start}
+		for len(frontier) > 0 {
+			var next []lineage.NodeID
+			for _, n := range frontier {
+				for _, e := range g.Edges {
+					from, to := e.Origin, e.Destination
+					if step < 0 {
+						from, to = to, from
+					}
+					if from != n {
+						continue
+					}
+					if _, ok := res[to]; !ok {
+						res[to] = res[n] + step
+						next = append(next, to)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+	return res
+}
+
+// Build walks the lineage graph around start and lays it out in columns,
+// upstream nodes to the left and downstream nodes to the right. href is the
+// url the depth controls reload.
+func Build(ctx context.Context, deps htmx.Deps, start lineage.NodeID, upstream int, downstream int, href string) (*Graph, error) {
+	g, err := ops.GetLineageGraph(ctx, deps, start, upstream, downstream)
+	if err != nil {
+		return nil, err
+	}
+
+	res := Graph{
+		NodeID:     start.String(),
+		Href:       href,
+		Upstream:   upstream,
+		Downstream: downstream,
+	}
+	for i := 0; i <= ops.MaxLineageDepth; i++ {
+		res.Depths = append(res.Depths, i)
+	}
+
+	lvls := levels(g, start)
+	min, max := 0, 0
+	for _, l := range lvls {
+		if l < min {
+			min = l
+		}
+		if l > max {
+			max = l
+		}
+	}
+	res.Columns = make([]Column, max-min+1)
+
+	domIDs := map[lineage.NodeID]string{}
+	for i, n := range g.Nodes {
+		l, ok := lvls[n.NodeID]
+		if !ok {
+			continue
+		}
+		domID := fmt.Sprintf("node-%d", i)
+		domIDs[n.NodeID] = domID
+
+		href := fmt.Sprintf("/lineage/datasets/%d", n.ID)
+		if n.NodeID.Type == lineage.NodeTypeJob {
+			href = fmt.Sprintf("/lineage/jobs/%d", n.ID)
+		}
+		col := &res.Columns[l-min]
+		col.Nodes = append(col.Nodes, Node{
+			DomID:     domID,
+			Type:      n.NodeID.Type.String(),
+			Namespace: n.NodeID.Namespace,
+			Name:      n.NodeID.Name,
+			Href:      href,
+			GraphHref: "/lineage/graph?nodeId=" + url.QueryEscape(n.NodeID.String()),
+			IsCenter:  n.NodeID == start,
+		})
+	}
+	for _, col := range res.Columns {
+		sort.Slice(col.Nodes, func(i, j int) bool { return col.Nodes[i].Name < col.Nodes[j].Name })
+	}
+	for _, e := range g.Edges {
+		res.Lines = append(res.Lines, Line{Origin: domIDs[e.Origin], Destination: domIDs[e.Destination]})
+	}
+	return &res, nil
+}
+
+// MakeGetGraph renders the lineage graph around the nodeId query parameter.
+// Requests made by htmx get just the graph so the depth controls can swap it.
+func MakeGetGraph(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		nodeID, err := lineage.NodeIDFromString(c.Query("nodeId"))
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		g, err := Build(ctx, deps, nodeID, Depth(c, "upstream"), Depth(c, "downstream"), "/lineage/graph")
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		template := "lineage/graph.html"
+		if c.GetHeader("HX-Request") == "true" {
+			template = "lineage/graph-content.html"
+		}
+		c.HTML(http.StatusOK, template, gin.H{
+			"Title":     nodeID.String(),
+			"Graph":     g,
+			"MenuItems": htmx.BuildMenuItems(""),
+		})
+	}
+}
@@ -2,9 +2,13 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"oplin/internal/lineage"
 	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/htmx/facets"
+	"oplin/internal/lineage/htmx/graph"
 	"oplin/internal/lineage/ops"
 	"strconv"
 
@@ -26,8 +30,11 @@ type Breadcrumb struct {
 
 var TabItems = []TabItem{
 	{Key: "runs", Text: "Runs", Href: "/lineage/jobs/%d/runs"},
+	{Key: "versions", Text: "Versions", Href: "/lineage/jobs/%d/versions"},
+	{Key: "graph", Text: "Graph", Href: "/lineage/jobs/%d/graph"},
 	{Key: "ownership", Text: "Ownership", Href: "/lineage/jobs/%d/ownership"},
 	{Key: "sourcecode", Text: "Source Code", Href: "/lineage/jobs/%d/sourcecode"},
+	{Key: "more", Text: "More...", Href: "/lineage/jobs/%d/more"},
 }
 
 func buildBreadcrumbs(jobID int64, text string) []Breadcrumb {
@@ -53,14 +60,27 @@ func MakeListJobs(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
-		jss, err := ops.ListJobsWithNamespaces(ctx, deps)
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		page, err := ops.ListJobsPage(ctx, deps, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
 		if err != nil {
 			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
 			return
 		}
+		filters := htmx.BuildListFilters(c, "/lineage/jobs")
+		filters.Names = true
 		c.HTML(http.StatusOK, "lineage/jobs-list.html", gin.H{
 			"Title":     "Jobs",
-			"Jobs":      jss,
+			"Jobs":      page.Jobs,
+			"Filters":   filters,
+			"Pager":     htmx.BuildPager(c, "/lineage/jobs", page.Next),
 			"MenuItems": htmx.BuildMenuItems("jobs"),
 		})
 	}
@@ -81,11 +101,26 @@ func MakeGetJob(deps htmx.Deps) gin.HandlerFunc {
 			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
 			return
 		}
-		runs, err := ops.ListRunsByJobVersionID(ctx, deps, jns.Job.CurrentVersionID)
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		runs, err := ops.ListRuns(ctx, deps, jns.Job.ID, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
 		if err != nil {
 			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
 			return
 		}
+		runsHref := fmt.Sprintf("/lineage/jobs/%d/runs", jns.Job.ID)
+		filters := htmx.BuildListFilters(c, runsHref)
+		filters.Target = "#content"
+		filters.States = htmx.RunStates
+		pager := htmx.BuildPager(c, runsHref, runs.Next)
+		pager.Target = "#content"
 
 		title := fmt.Sprintf("%s %s", jns.JobNamespace.Name, jns.Job.Name)
 
@@ -93,7 +128,9 @@ func MakeGetJob(deps htmx.Deps) gin.HandlerFunc {
 			"Breadcrumbs":      buildBreadcrumbs(jns.Job.ID, title),
 			"Title":            title,
 			"JobWithNamespace": jns,
-			"Runs":             runs,
+			"Runs":             runs.Runs,
+			"Filters":          filters,
+			"Pager":            pager,
 			"TabItems":         buildTabItems("runs", jns.Job.ID),
 			"MenuItems":        htmx.BuildMenuItems("jobs"),
 		})
@@ -115,11 +152,26 @@ func MakeGetJobRuns(deps htmx.Deps) gin.HandlerFunc {
 			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
 			return
 		}
-		runs, err := ops.ListRunsByJobVersionID(ctx, deps, jns.Job.CurrentVersionID)
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		runs, err := ops.ListRuns(ctx, deps, jns.Job.ID, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
 		if err != nil {
 			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
 			return
 		}
+		runsHref := fmt.Sprintf("/lineage/jobs/%d/runs", jns.Job.ID)
+		filters := htmx.BuildListFilters(c, runsHref)
+		filters.Target = "#content"
+		filters.States = htmx.RunStates
+		pager := htmx.BuildPager(c, runsHref, runs.Next)
+		pager.Target = "#content"
 
 		title := fmt.Sprintf("%s %s", jns.JobNamespace.Name, jns.Job.Name)
 
@@ -127,12 +179,96 @@ func MakeGetJobRuns(deps htmx.Deps) gin.HandlerFunc {
 			"Breadcrumbs":      buildBreadcrumbs(jns.Job.ID, title),
 			"Title":            title,
 			"JobWithNamespace": jns,
-			"Runs":             runs,
+			"Runs":             runs.Runs,
+			"Filters":          filters,
+			"Pager":            pager,
 			"TabItems":         buildTabItems("runs", jns.Job.ID),
 		})
 	}
 }
 
+func MakeGetJobVersions(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		s := c.Param("id")
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		jns, err := ops.GetJobWithNamespace(ctx, deps, id)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+		vs, err := ops.ListJobVersions(ctx, deps, jns.Job.ID)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		// default to the current version when none is chosen
+		versionID := jns.Job.CurrentVersionID
+		if v := c.Query("version"); v != "" {
+			versionID, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+				return
+			}
+		}
+		diffs, err := ops.DiffJobVersion(ctx, deps, versionID)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+		declared, err := ops.ListDeclaredDatasetsForJobVersion(ctx, deps, versionID)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/jobs-versions.html", gin.H{
+			"VersionID":    versionID,
+			"Versions":     vs,
+			"VersionsHref": fmt.Sprintf("/lineage/jobs/%d/versions", jns.Job.ID),
+			"Diffs":        diffs,
+			"Declared":     declared,
+			"TabItems":     buildTabItems("versions", jns.Job.ID),
+		})
+	}
+}
+
+func MakeGetJobGraph(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		s := c.Param("id")
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		jns, err := ops.GetJobWithNamespace(ctx, deps, id)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+		nodeID := lineage.NodeID{Type: lineage.NodeTypeJob, Namespace: jns.JobNamespace.Name, Name: jns.Job.Name}
+		g, err := graph.Build(ctx, deps, nodeID, graph.Depth(c, "upstream"), graph.Depth(c, "downstream"),
+			fmt.Sprintf("/lineage/jobs/%d/graph", id))
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/jobs-graph.html", gin.H{
+			"Graph":    g,
+			"TabItems": buildTabItems("graph", jns.Job.ID),
+		})
+	}
+}
+
 func MakeGetJobOwnership(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
@@ -186,3 +322,37 @@ func MakeGetJobSourceCode(deps htmx.Deps) gin.HandlerFunc {
 		})
 	}
 }
+
+func MakeGetJobMore(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		s := c.Param("id")
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		jns, err := ops.GetJobWithNamespace(ctx, deps, id)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		fs, err := ops.ListFacets(ctx, deps, lineage.FacetEntityTypeJob, jns.Job.ID)
+		if err != nil {
+			c.HTML(http.StatusOK, "lineage/error.html", gin.H{})
+			return
+		}
+
+		title := fmt.Sprintf("%s %s", jns.JobNamespace.Name, jns.Job.Name)
+
+		c.HTML(http.StatusOK, "lineage/jobs-more.html", gin.H{
+			"Breadcrumbs":      buildBreadcrumbs(jns.Job.ID, title),
+			"Title":            title,
+			"JobWithNamespace": jns,
+			"Facets":           facets.BuildItems(fs),
+			"TabItems":         buildTabItems("more", jns.Job.ID),
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package htmx
+
+import (
+	"oplin/internal/lineage"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+const sinceLayout = "2006-01-02"
+
+// RunStates are the states a list of runs can be filtered on
+var RunStates = runStateNames()
+
+func runStateNames() []string {
+	var res []string
+	for _, s := range lineage.RunStates {
+		res = append(res, s.String())
+	}
+	return res
+}
+
+// ListFilters fill in the filter form of a list. Lists inside tabs set
+// Target so the form replaces the tab instead of loading a page.
+type ListFilters struct {
+	Href      string
+	Target    string
+	Namespace string
+	Prefix    string
+	Since     string
+	Until     string
+	Sort      string
+	State     string
+	// Names shows the namespace and name filters and the sort
+	Names bool
+	// ByNamespace shows the namespace filter on its own
+	ByNamespace bool
+	// States are the run states to filter on and show the end of the time
+	// window, none for lists of other rows
+	States []string
+}
+
+// Pager links the first and the next page of a list keeping its filters,
+// First is empty on the first page and Next on the last
+type Pager struct {
+	Target string
+	First  string
+	Next   string
+}
+
+// ParseListOptions reads the sort, filters and page of a list from the query
+// string
+func ParseListOptions(c *gin.Context) (lineage.ListOptions, error) {
+	opts := lineage.ListOptions{
+		Sort:       lineage.ListSort(c.Query("sort")),
+		Namespace:  c.Query("namespace"),
+		NamePrefix: c.Query("prefix"),
+		After:      c.Query("after"),
+	}
+	if s := c.Query("since"); s != "" {
+		t, err := time.Parse(sinceLayout, s)
+		if err != nil {
+			return opts, eris.Wrapf(err, "invalid since[%s]", s)
+		}
+		opts.UpdatedSince = t
+	}
+	if s := c.Query("until"); s != "" {
+		t, err := time.Parse(sinceLayout, s)
+		if err != nil {
+			return opts, eris.Wrapf(err, "invalid until[%s]", s)
+		}
+		// the window includes the day it ends on
+		opts.UpdatedBefore = t.AddDate(0, 0, 1)
+	}
+	if s := c.Query("state"); s != "" {
+		state, err := lineage.RunStateFromString(s)
+		if err != nil {
+			return opts, eris.Wrapf(err, "invalid state[%s]", s)
+		}
+		opts.State = state
+	}
+	if s := c.Query("job_version"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return opts, eris.Wrapf(err, "invalid job_version[%s]", s)
+		}
+		opts.JobVersionID = id
+	}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			return opts, eris.Wrapf(err, "invalid limit[%s]", s)
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+// BuildListFilters fills in the filter form of the list at href from the
+// query string
+func BuildListFilters(c *gin.Context, href string) ListFilters {
+	return ListFilters{
+		Href:      href,
+		Namespace: c.Query("namespace"),
+		Prefix:    c.Query("prefix"),
+		Since:     c.Query("since"),
+		Until:     c.Query("until"),
+		Sort:      c.Query("sort"),
+		State:     c.Query("state"),
+	}
+}
+
+// BuildPager links the pages of the list at href, next is the cursor of the
+// next page
+func BuildPager(c *gin.Context, href string, next string) Pager {
+	q := c.Request.URL.Query()
+	var res Pager
+	if q.Get("after") != "" {
+		q.Del("after")
+		res.First = href + "?" + q.Encode()
+	}
+	if next != "" {
+		q.Set("after", next)
+		res.Next = href + "?" + q.Encode()
+	}
+	return res
+}
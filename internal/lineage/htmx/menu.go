@@ -11,7 +11,10 @@ type MenuItem struct {
 var MenuItems = []MenuItem{
 	{Key: "datasets", Text: "Datasets", Href: "/lineage/datasets", Icon: "table"},
 	{Key: "events", Text: "Events", Href: "/lineage/requests", Icon: "list-alt"},
+	{Key: "failed", Text: "Failed events", Href: "/lineage/failed", Icon: "exclamation-triangle"},
 	{Key: "jobs", Text: "Jobs", Href: "/lineage/jobs", Icon: "cogs"},
+	{Key: "runs", Text: "Runs", Href: "/lineage/runs", Icon: "play"},
+	{Key: "tokens", Text: "API tokens", Href: "/lineage/tokens", Icon: "key"},
 }
 
 func BuildMenuItems(chosenKey string) []MenuItem {
@@ -2,6 +2,7 @@ package requests
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"oplin/internal/lineage/htmx"
 	"oplin/internal/lineage/ops"
@@ -13,14 +14,25 @@ func MakeGetRequests(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
-		reqs, err := ops.ListRequests(ctx, deps)
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		page, err := ops.ListRequests(ctx, deps, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
 		if err != nil {
 			htmx.InternalServerError(c, err)
 			return
 		}
 
 		c.HTML(http.StatusOK, "lineage/requests-list.html", gin.H{
-			"Requests":  reqs,
+			"Requests":  page.Requests,
+			"Filters":   htmx.BuildListFilters(c, "/lineage/requests"),
+			"Pager":     htmx.BuildPager(c, "/lineage/requests", page.Next),
 			"MenuItems": htmx.BuildMenuItems("events"),
 		})
 	}
@@ -2,11 +2,15 @@ package runs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"oplin/internal/lineage"
 	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/htmx/facets"
 	"oplin/internal/lineage/ops"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +29,32 @@ func buildBreadcrumbs(jobID int64, jobText string, runID int64, runText string)
 	}
 }
 
+// TreeItem is a run in the tree of runs shown on the run page
+type TreeItem struct {
+	Href        string
+	Text        string
+	State       string
+	StartedAt   time.Time
+	Placeholder bool
+	Current     bool
+	Children    []TreeItem
+}
+
+func buildTreeItem(node lineage.RunTreeNode, currentID int64) TreeItem {
+	item := TreeItem{
+		Href:        fmt.Sprintf("/lineage/runs/%d", node.Run.ID),
+		Text:        fmt.Sprintf("%s %s", node.NamespaceName, node.JobName),
+		State:       node.Run.State.String(),
+		StartedAt:   node.Run.StartedAt,
+		Placeholder: node.Run.IsPlaceholder,
+		Current:     node.Run.ID == currentID,
+	}
+	for _, child := range node.Children {
+		item.Children = append(item.Children, buildTreeItem(child, currentID))
+	}
+	return item
+}
+
 func MakeGetRun(deps htmx.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
@@ -65,6 +95,18 @@ func MakeGetRun(deps htmx.Deps) gin.HandlerFunc {
 			return
 		}
 
+		tree, err := ops.GetRunTree(ctx, deps, id)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		fs, err := ops.ListFacets(ctx, deps, lineage.FacetEntityTypeRun, run.ID)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
 		jobTitle := fmt.Sprintf("%s %s", jns.JobNamespace.Name, jns.Job.Name)
 
 		c.HTML(http.StatusOK, "lineage/jobs-runs-events.html", gin.H{
@@ -72,7 +114,42 @@ func MakeGetRun(deps htmx.Deps) gin.HandlerFunc {
 			"Run":         run,
 			"Events":      events,
 			"IODatasets":  ioDatasets,
+			"RunTree":     buildTreeItem(*tree, run.ID),
+			"Facets":      facets.BuildItems(fs),
 			"MenuItems":   htmx.BuildMenuItems("jobs"),
 		})
 	}
 }
+
+// MakeListRuns lists the runs of every job filtered by namespace, state and
+// time window
+func MakeListRuns(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		opts, err := htmx.ParseListOptions(c)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		page, err := ops.ListRunsWithJobs(ctx, deps, opts)
+		if errors.Is(err, ops.ErrInvalidCursor) {
+			htmx.BadRequest(c, err)
+			return
+		}
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		filters := htmx.BuildListFilters(c, "/lineage/runs")
+		filters.ByNamespace = true
+		filters.States = htmx.RunStates
+		c.HTML(http.StatusOK, "lineage/runs-list.html", gin.H{
+			"Runs":      page.Runs,
+			"Filters":   filters,
+			"Pager":     htmx.BuildPager(c, "/lineage/runs", page.Next),
+			"MenuItems": htmx.BuildMenuItems("runs"),
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/ops"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const searchLimit = 50
+
+// MakeSearch lists the datasets and jobs matching the query of the search
+// box in the menu
+func MakeSearch(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		q := strings.TrimSpace(c.Query("q"))
+
+		var results []lineage.SearchResult
+		if q != "" {
+			var err error
+			results, err = ops.FullTextSearch(ctx, deps, q, lineage.NodeTypeUnknown, searchLimit)
+			if err != nil {
+				htmx.InternalServerError(c, err)
+				return
+			}
+		}
+
+		c.HTML(http.StatusOK, "lineage/search.html", gin.H{
+			"Query":     q,
+			"Results":   results,
+			"MenuItems": htmx.BuildMenuItems(""),
+		})
+	}
+}
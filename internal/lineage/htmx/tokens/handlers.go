@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"oplin/internal/lineage/auth"
+	"oplin/internal/lineage/htmx"
+	"oplin/internal/lineage/ops"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MakeListAPITokens(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		tokens, err := ops.ListAPITokens(ctx, deps)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		c.HTML(http.StatusOK, "lineage/tokens-list.html", gin.H{
+			"Tokens":    tokens,
+			"CSRFToken": auth.CSRFToken(c),
+			"MenuItems": htmx.BuildMenuItems("tokens"),
+		})
+	}
+}
+
+// MakeCreateAPIToken creates a token from the form of the tokens page and
+// shows it, it cannot be shown again
+func MakeCreateAPIToken(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+
+		name := strings.TrimSpace(c.PostForm("name"))
+		producer := strings.TrimSpace(c.PostForm("producer"))
+		namespace := strings.TrimSpace(c.PostForm("namespace"))
+		tok, secret, err := ops.CreateAPIToken(ctx, deps, name, producer, namespace)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		tokens, err := ops.ListAPITokens(ctx, deps)
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+
+		c.HTML(http.StatusCreated, "lineage/tokens-list.html", gin.H{
+			"Tokens":    tokens,
+			"Created":   tok,
+			"Secret":    secret,
+			"CSRFToken": auth.CSRFToken(c),
+			"MenuItems": htmx.BuildMenuItems("tokens"),
+		})
+	}
+}
+
+func MakeRevokeAPIToken(deps htmx.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			htmx.BadRequest(c, err)
+			return
+		}
+		err = ops.RevokeAPIToken(ctx, deps, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			htmx.BadRequest(c, err)
+			return
+		}
+		if err != nil {
+			htmx.InternalServerError(c, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/lineage/tokens")
+	}
+}
@@ -0,0 +1,69 @@
+package tokens_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/wiring"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSuite(tb testing.TB) (*gin.Engine, *ops.TestDeps) {
+	deps := &ops.TestDeps{Store: store.GetTestStore()}
+	err := ops.InitializeTestDB(context.Background(), deps)
+	if err != nil {
+		tb.Fatalf("Failed to initialize test db[%v]", err)
+	}
+	r := wiring.NewGinEngine()
+	wiring.SetupRouter(r, deps)
+	return r, deps
+}
+
+func post(r *gin.Engine, path string, form url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPITokenPages(t *testing.T) {
+	r, deps := setupSuite(t)
+	ctx := context.Background()
+
+	w := post(r, "/lineage/tokens", url.Values{"name": {"airflow"}, "namespace": {"warehouse"}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "created-token")
+
+	tokens, err := ops.ListAPITokens(ctx, deps)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tokens))
+	assert.Equal(t, "warehouse", tokens[0].Namespace)
+	assert.Contains(t, w.Body.String(), tokens[0].Prefix)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/lineage/tokens", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "airflow")
+	assert.NotContains(t, w.Body.String(), "created-token")
+
+	w = post(r, "/lineage/tokens", url.Values{"name": {""}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	path := fmt.Sprintf("/lineage/tokens/%d/revoke", tokens[0].ID)
+	assert.Equal(t, http.StatusSeeOther, post(r, path, url.Values{}).Code)
+	assert.Equal(t, http.StatusBadRequest, post(r, path, url.Values{}).Code)
+	tokens, err = ops.ListAPITokens(ctx, deps)
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(tokens))
+}
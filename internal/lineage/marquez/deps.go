@@ -0,0 +1,15 @@
+package marquez
+
+import "oplin/internal/lineage/store"
+
+type Deps interface {
+	GetStore() store.Store
+}
+
+type TestDeps struct {
+	Store store.Store
+}
+
+func (d *TestDeps) GetStore() store.Store {
+	return d.Store
+}
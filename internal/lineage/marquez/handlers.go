@@ -0,0 +1,477 @@
+package marquez
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/ops"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+)
+
+// writeError writes an error in the Marquez {code, message} shape
+func writeError(c *gin.Context, httpStatus int, err error) {
+	c.Error(err)
+	fmt.Println(eris.ToString(err, true))
+
+	c.JSON(httpStatus, gin.H{
+		"code":    httpStatus,
+		"message": err.Error(),
+	})
+}
+
+// writeLookupError writes a 404 when the resource does not exist and a 500
+// for everything else
+func writeLookupError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(c, http.StatusNotFound, err)
+	} else {
+		writeError(c, http.StatusInternalServerError, err)
+	}
+}
+
+// queryInt reads an integer query parameter falling back to def when it is
+// missing or invalid
+func queryInt(c *gin.Context, key string, def int) int {
+	v, err := strconv.Atoi(c.Query(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+// page reads the Marquez limit and offset query parameters
+func page(c *gin.Context) (int, int) {
+	return queryInt(c, "limit", 100), queryInt(c, "offset", 0)
+}
+
+// MakeListNamespaces lists the job and dataset namespaces
+func MakeListNamespaces(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		rows, err := ops.ListNamespaces(ctx, deps)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []Namespace{}
+		for _, ns := range rows {
+			res = append(res, toNamespace(ns))
+		}
+		c.JSON(http.StatusOK, gin.H{"namespaces": res})
+	}
+}
+
+// MakeGetNamespace gets a namespace by name
+func MakeGetNamespace(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		rows, err := ops.ListNamespaces(ctx, deps)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for _, ns := range rows {
+			if ns.Name == c.Param("namespace") {
+				c.JSON(http.StatusOK, toNamespace(ns))
+				return
+			}
+		}
+		writeError(c, http.StatusNotFound, eris.Errorf("Namespace[%s] not found", c.Param("namespace")))
+	}
+}
+
+func buildDataset(ctx context.Context, deps Deps, ds lineage.DatasetWithNamespace) (Dataset, error) {
+	var fields []lineage.Field
+	if ds.Dataset.CurrentVersionID > 0 {
+		var err error
+		fields, err = ops.ListFieldsForDatasetVersion(ctx, deps, ds.Dataset.CurrentVersionID)
+		if err != nil {
+			return Dataset{}, err
+		}
+	}
+	return toDataset(ds, fields), nil
+}
+
+// MakeListDatasets lists the datasets in a namespace
+func MakeListDatasets(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		namespace := c.Param("namespace")
+		limit, offset := page(c)
+		total, err := ops.CountDatasetsByNamespace(ctx, deps, namespace)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		rows, err := ops.ListDatasetsWithNamespacesByNamespacePage(ctx, deps, namespace, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		fields, err := ops.ListCurrentFieldsByNamespacePage(ctx, deps, namespace, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []Dataset{}
+		for _, row := range rows {
+			res = append(res, toDataset(row, fields[row.Dataset.ID]))
+		}
+		c.JSON(http.StatusOK, gin.H{"totalCount": total, "datasets": res})
+	}
+}
+
+// MakeGetDataset gets a dataset by namespace and name
+func MakeGetDataset(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("dataset"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		res, err := buildDataset(ctx, deps, *ds)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// buildJobs fills in the inputs, outputs and latest run of the jobs, by
+// job id
+func buildJobs(ctx context.Context, deps Deps, jobs []lineage.JobWithNamespace) (map[int64]Job, error) {
+	ids := make([]int64, 0, len(jobs))
+	for _, jns := range jobs {
+		ids = append(ids, jns.Job.ID)
+	}
+	edges, err := ops.ListDatasetEdgesByJobIDs(ctx, deps, ids)
+	if err != nil {
+		return nil, err
+	}
+	latestRuns, err := ops.ListLatestRunsByJobIDs(ctx, deps, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, outputs := datasetIDs(edges)
+	res := map[int64]Job{}
+	for _, jns := range jobs {
+		res[jns.Job.ID] = toJobWithIO(jns, inputs, outputs, latestRuns)
+	}
+	return res, nil
+}
+
+// toJobWithIO is the job with its inputs, outputs and latest run out of
+// those of a list of jobs
+func toJobWithIO(jns lineage.JobWithNamespace, inputs map[int64][]DatasetID, outputs map[int64][]DatasetID, latestRuns map[int64]lineage.Run) Job {
+	job := toJob(jns)
+	job.Inputs = append(job.Inputs, inputs[jns.Job.ID]...)
+	job.Outputs = append(job.Outputs, outputs[jns.Job.ID]...)
+	if run, ok := latestRuns[jns.Job.ID]; ok {
+		r := toRun(run, job.ID)
+		job.LatestRun = &r
+	}
+	return job
+}
+
+// datasetIDs splits the dataset edges of jobs or runs into the inputs and
+// the outputs of each
+func datasetIDs(edges []lineage.DatasetEdge) (map[int64][]DatasetID, map[int64][]DatasetID) {
+	inputs := map[int64][]DatasetID{}
+	outputs := map[int64][]DatasetID{}
+	for _, e := range edges {
+		id := DatasetID{Namespace: e.NamespaceName, Name: e.DatasetName}
+		switch e.IOType {
+		case lineage.IOTypeInput:
+			inputs[e.ID] = append(inputs[e.ID], id)
+		case lineage.IOTypeOutput:
+			outputs[e.ID] = append(outputs[e.ID], id)
+		}
+	}
+	return inputs, outputs
+}
+
+// MakeListJobs lists the jobs in a namespace
+func MakeListJobs(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		namespace := c.Param("namespace")
+		limit, offset := page(c)
+		total, err := ops.CountJobsByNamespace(ctx, deps, namespace)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		rows, err := ops.ListJobsWithNamespacesByNamespacePage(ctx, deps, namespace, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		edges, err := ops.ListDatasetEdgesByNamespacePage(ctx, deps, namespace, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		latestRuns, err := ops.ListLatestRunsByNamespacePage(ctx, deps, namespace, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		inputs, outputs := datasetIDs(edges)
+		res := []Job{}
+		for _, row := range rows {
+			res = append(res, toJobWithIO(row, inputs, outputs, latestRuns))
+		}
+		c.JSON(http.StatusOK, gin.H{"totalCount": total, "jobs": res})
+	}
+}
+
+// MakeGetJob gets a job by namespace and name
+func MakeGetJob(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		res, err := buildJobs(ctx, deps, []lineage.JobWithNamespace{*jns})
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, res[jns.Job.ID])
+	}
+}
+
+// buildRun fills in the job and the input and output datasets of a run
+func buildRun(ctx context.Context, deps Deps, run lineage.Run) (Run, error) {
+	jv, err := ops.GetJobVersionByID(ctx, deps, run.JobVersionID)
+	if err != nil {
+		return Run{}, err
+	}
+	jns, err := ops.GetJobWithNamespace(ctx, deps, jv.JobID)
+	if err != nil {
+		return Run{}, err
+	}
+
+	res := toRun(run, JobID{Namespace: jns.JobNamespace.Name, Name: jns.Job.Name})
+	datasets, err := ops.ListRunDatasetVersionsWithRelationshipsByRunID(ctx, deps, run.ID)
+	if err != nil {
+		return res, err
+	}
+	for _, ds := range datasets {
+		id := DatasetID{Namespace: ds.DatasetNamespace.Name, Name: ds.DatasetVersion.Name}
+		switch ds.RunIODataset.IOType {
+		case lineage.IOTypeInput:
+			res.InputVersions = append(res.InputVersions, id)
+		case lineage.IOTypeOutput:
+			res.OutputVersions = append(res.OutputVersions, id)
+		}
+	}
+	return res, nil
+}
+
+// MakeListJobRuns lists the runs of every version of a job, newest first
+func MakeListJobRuns(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		jns, err := ops.GetJobWithNamespaceByName(ctx, deps, c.Param("namespace"), c.Param("job"))
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		limit, offset := page(c)
+		total, err := ops.CountRunsByJobID(ctx, deps, jns.Job.ID)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		runs, err := ops.ListRunsByJobIDPage(ctx, deps, jns.Job.ID, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		edges, err := ops.ListRunDatasetVersionsByJobIDPage(ctx, deps, jns.Job.ID, limit, offset)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		job := JobID{Namespace: jns.JobNamespace.Name, Name: jns.Job.Name}
+		inputs, outputs := datasetIDs(edges)
+		res := []Run{}
+		for _, run := range runs {
+			r := toRun(run, job)
+			r.InputVersions = append(r.InputVersions, inputs[run.ID]...)
+			r.OutputVersions = append(r.OutputVersions, outputs[run.ID]...)
+			res = append(res, r)
+		}
+		c.JSON(http.StatusOK, gin.H{"totalCount": total, "runs": res})
+	}
+}
+
+// MakeGetRun gets a run by its uuid
+func MakeGetRun(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		runUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		run, err := ops.GetRunWithUUID(ctx, deps, runUUID)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+		res, err := buildRun(ctx, deps, *run)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// buildNodeData returns the datasets and jobs the nodes of a lineage graph
+// refer to, by id
+func buildNodeData(ctx context.Context, deps Deps, nodes []lineage.LineageNode) (map[int64]Dataset, map[int64]Job, error) {
+	var datasetIDs, jobIDs []int64
+	for _, n := range nodes {
+		if n.NodeID.Type == lineage.NodeTypeDataset {
+			datasetIDs = append(datasetIDs, n.ID)
+		} else {
+			jobIDs = append(jobIDs, n.ID)
+		}
+	}
+	rows, err := ops.ListDatasetsWithNamespacesByIDs(ctx, deps, datasetIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, err := ops.ListCurrentFieldsByDatasetIDs(ctx, deps, datasetIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	datasets := map[int64]Dataset{}
+	for _, ds := range rows {
+		datasets[ds.Dataset.ID] = toDataset(ds, fields[ds.Dataset.ID])
+	}
+
+	jobRows, err := ops.ListJobsWithNamespacesByIDs(ctx, deps, jobIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobs, err := buildJobs(ctx, deps, jobRows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datasets, jobs, nil
+}
+
+// MakeGetLineage returns the lineage graph around the nodeId query parameter
+func MakeGetLineage(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		nodeID, err := lineage.NodeIDFromString(c.Query("nodeId"))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		depth := queryInt(c, "depth", 20)
+
+		graph, err := ops.GetLineageGraph(ctx, deps, nodeID, depth, depth)
+		if err != nil {
+			writeLookupError(c, err)
+			return
+		}
+
+		datasets, jobs, err := buildNodeData(ctx, deps, graph.Nodes)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		nodes := map[lineage.NodeID]*Node{}
+		res := []*Node{}
+		for _, n := range graph.Nodes {
+			var data interface{} = jobs[n.ID]
+			if n.NodeID.Type == lineage.NodeTypeDataset {
+				data = datasets[n.ID]
+			}
+			node := &Node{
+				ID:       n.NodeID.String(),
+				Type:     n.NodeID.Type.String(),
+				Data:     data,
+				InEdges:  []Edge{},
+				OutEdges: []Edge{},
+			}
+			nodes[n.NodeID] = node
+			res = append(res, node)
+		}
+		for _, e := range graph.Edges {
+			edge := Edge{Origin: e.Origin.String(), Destination: e.Destination.String()}
+			nodes[e.Origin].OutEdges = append(nodes[e.Origin].OutEdges, edge)
+			nodes[e.Destination].InEdges = append(nodes[e.Destination].InEdges, edge)
+		}
+		c.JSON(http.StatusOK, gin.H{"graph": res})
+	}
+}
+
+var searchFilters = map[string]lineage.NodeType{
+	"":        lineage.NodeTypeUnknown,
+	"dataset": lineage.NodeTypeDataset,
+	"job":     lineage.NodeTypeJob,
+}
+
+// MakeSearch searches datasets and jobs by name, namespace, fields,
+// descriptions, owners or SQL, the best matches first
+func MakeSearch(deps Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		q := c.Query("q")
+		if q == "" {
+			writeError(c, http.StatusBadRequest, eris.New("Query parameter q is required"))
+			return
+		}
+		nodeType, ok := searchFilters[c.Query("filter")]
+		if !ok {
+			writeError(c, http.StatusBadRequest, eris.Errorf("Unknown filter[%s]", c.Query("filter")))
+			return
+		}
+
+		rows, err := ops.FullTextSearch(ctx, deps, q, nodeType, queryInt(c, "limit", 10))
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if c.Query("sort") == "updated_at" {
+			sort.SliceStable(rows, func(i, j int) bool { return rows[i].UpdatedAt.After(rows[j].UpdatedAt) })
+		}
+
+		res := []SearchResult{}
+		for _, row := range rows {
+			res = append(res, SearchResult{
+				Type:      row.NodeID.Type.String(),
+				Name:      row.NodeID.Name,
+				UpdatedAt: optionalTime(row.UpdatedAt),
+				Namespace: row.NodeID.Namespace,
+				NodeID:    row.NodeID.String(),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"totalCount": len(res), "results": res})
+	}
+}
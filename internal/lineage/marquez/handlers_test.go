@@ -0,0 +1,186 @@
+package marquez_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"oplin/internal/lineage/auth"
+	"oplin/internal/lineage/marquez"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/wiring"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSuite(tb testing.TB) (*gin.Engine, func(tb testing.TB)) {
+	ctx := context.Background()
+	st := store.GetTestStore()
+
+	deps := marquez.TestDeps{Store: st}
+	err := ops.InitializeTestDB(ctx, &deps)
+	if err != nil {
+		log.Fatalf("Failed to initialize test db[%v]", err)
+	}
+	r := wiring.NewGinEngine()
+	wiring.SetupMarquezRouter(r.Group("/marquez"), &deps, auth.NoAuth())
+	return r, func(tb testing.TB) {
+	}
+}
+
+func do(t *testing.T, r http.Handler, method string, path string, body string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	r.ServeHTTP(w, req)
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return w.Code, res
+}
+
+func TestMarquezAPI(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	payload := `
+	{"run": {"runId": "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a10"},
+	"job": {"namespace": "airflow", "name": "etl"},
+	"inputs": [{"namespace": "postgres://db:5432", "name": "raw"}],
+	"outputs": [{"namespace": "postgres://db:5432", "name": "clean"}],
+	"eventType": "COMPLETE",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"producer": "test",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+	`
+	code, _ := do(t, r, "POST", "/marquez/api/v1/lineage", payload)
+	require.Equal(t, 200, code)
+
+	code, body := do(t, r, "GET", "/marquez/api/v1/namespaces", "")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["namespaces"], 2)
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/namespaces/postgres:%2F%2Fdb:5432/datasets", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(2), body["totalCount"])
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/namespaces/airflow/jobs/etl", "")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["inputs"], 1)
+	assert.Len(t, body["outputs"], 1)
+	assert.Equal(t, "COMPLETED", body["latestRun"].(map[string]interface{})["state"])
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/jobs/runs/5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a10", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "COMPLETED", body["state"])
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/lineage?nodeId=dataset:postgres://db:5432:raw", "")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["graph"], 3)
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/search?q=cle", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(1), body["totalCount"])
+
+	code, _ = do(t, r, "GET", "/marquez/api/v1/lineage?nodeId=dataset:nope:missing", "")
+	assert.Equal(t, 404, code)
+}
+
+// postJobRuns records three jobs reading db.raw, which has two fields, and
+// writing out_a, out_b and out_c, etl_a runs twice
+func postJobRuns(t *testing.T, r http.Handler) {
+	post := func(job string, runID string, output string) {
+		payload := fmt.Sprintf(`
+		{"run": {"runId": "%s"},
+		"job": {"namespace": "airflow", "name": "%s"},
+		"inputs": [{"namespace": "db", "name": "raw", "facets": {"schema": {
+			"_producer": "test",
+			"_schemaURL": "https://openlineage.io/spec/facets/1-0-0/SchemaDatasetFacet.json",
+			"fields": [{"name": "id", "type": "int"}, {"name": "value", "type": "text"}]
+		}}}],
+		"outputs": [{"namespace": "db", "name": "%s"}],
+		"eventType": "COMPLETE",
+		"eventTime": "2023-02-05T15:48:28.660754+02:00",
+		"producer": "test",
+		"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"}
+		`, runID, job, output)
+		code, _ := do(t, r, "POST", "/marquez/api/v1/lineage", payload)
+		require.Equal(t, 200, code)
+	}
+	post("etl_a", "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a01", "out_a")
+	post("etl_b", "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a02", "out_b")
+	post("etl_c", "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a03", "out_c")
+	post("etl_a", "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a04", "out_a")
+}
+
+func TestMarquezListPages(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	postJobRuns(t, r)
+
+	code, body := do(t, r, "GET", "/marquez/api/v1/namespaces/db/datasets?limit=2&offset=2", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(4), body["totalCount"])
+	datasets := body["datasets"].([]interface{})
+	require.Len(t, datasets, 2)
+	assert.Equal(t, "out_c", datasets[0].(map[string]interface{})["name"])
+	assert.Len(t, datasets[0].(map[string]interface{})["fields"], 0)
+	assert.Equal(t, "raw", datasets[1].(map[string]interface{})["name"])
+	assert.Len(t, datasets[1].(map[string]interface{})["fields"], 2)
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/namespaces/airflow/jobs?limit=2", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(3), body["totalCount"])
+	jobs := body["jobs"].([]interface{})
+	require.Len(t, jobs, 2)
+	job := jobs[0].(map[string]interface{})
+	assert.Equal(t, "etl_a", job["name"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"namespace": "db", "name": "raw"}}, job["inputs"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"namespace": "db", "name": "out_a"}}, job["outputs"])
+	assert.Equal(t, "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a04", job["latestRun"].(map[string]interface{})["id"])
+	assert.Equal(t, "etl_b", jobs[1].(map[string]interface{})["name"])
+
+	code, body = do(t, r, "GET", "/marquez/api/v1/namespaces/airflow/jobs/etl_a/runs?limit=1&offset=1", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(2), body["totalCount"])
+	runs := body["runs"].([]interface{})
+	require.Len(t, runs, 1)
+	run := runs[0].(map[string]interface{})
+	assert.Equal(t, "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a01", run["id"])
+	assert.Len(t, run["inputVersions"], 1)
+	assert.Len(t, run["outputVersions"], 1)
+}
+
+func TestMarquezLineageAndSearch(t *testing.T) {
+	r, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	postJobRuns(t, r)
+
+	code, body := do(t, r, "GET", "/marquez/api/v1/lineage?nodeId=dataset:db:raw&depth=2", "")
+	assert.Equal(t, 200, code)
+	nodes := map[string]map[string]interface{}{}
+	for _, n := range body["graph"].([]interface{}) {
+		node := n.(map[string]interface{})
+		nodes[node["id"].(string)] = node["data"].(map[string]interface{})
+	}
+	require.Len(t, nodes, 7)
+	assert.Len(t, nodes["dataset:db:raw"]["fields"], 2)
+	assert.Equal(t, "out_c", nodes["dataset:db:out_c"]["name"])
+	job := nodes["job:airflow:etl_a"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"namespace": "db", "name": "raw"}}, job["inputs"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"namespace": "db", "name": "out_a"}}, job["outputs"])
+	assert.Equal(t, "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a04", job["latestRun"].(map[string]interface{})["id"])
+	assert.Equal(t, "5f0c3d3a-1a3b-4d8e-9f44-3b0e0b4f5a02", nodes["job:airflow:etl_b"]["latestRun"].(map[string]interface{})["id"])
+
+	// a field name only matches the full text search
+	code, body = do(t, r, "GET", "/marquez/api/v1/search?q=value&filter=dataset", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(1), body["totalCount"])
+	assert.Equal(t, "raw", body["results"].([]interface{})[0].(map[string]interface{})["name"])
+}
@@ -0,0 +1,202 @@
+// Package marquez serves the subset of the Marquez REST API used by existing
+// Marquez clients and UIs, mapped onto the lineage domain types
+package marquez
+
+import (
+	"time"
+
+	"oplin/internal/lineage"
+	"oplin/internal/openlineage"
+
+	"github.com/google/uuid"
+)
+
+// The response shapes below follow the Marquez API. Fields Oplin does not
+// track, such as tags and version uuids, are returned empty.
+
+type Namespace struct {
+	Name        string     `json:"name"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+	OwnerName   string     `json:"ownerName"`
+	Description *string    `json:"description"`
+	IsHidden    bool       `json:"isHidden"`
+}
+
+type DatasetID struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type JobID struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type Field struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Tags        []string `json:"tags"`
+	Description *string  `json:"description"`
+}
+
+type Dataset struct {
+	ID             DatasetID                 `json:"id"`
+	Type           string                    `json:"type"`
+	Name           string                    `json:"name"`
+	PhysicalName   string                    `json:"physicalName"`
+	CreatedAt      time.Time                 `json:"createdAt"`
+	UpdatedAt      *time.Time                `json:"updatedAt"`
+	Namespace      string                    `json:"namespace"`
+	SourceName     string                    `json:"sourceName"`
+	Fields         []Field                   `json:"fields"`
+	Tags           []string                  `json:"tags"`
+	LastModifiedAt *time.Time                `json:"lastModifiedAt"`
+	Description    *string                   `json:"description"`
+	Facets         openlineage.DatasetFacets `json:"facets"`
+	Deleted        bool                      `json:"deleted"`
+}
+
+type Job struct {
+	ID            JobID                 `json:"id"`
+	Type          string                `json:"type"`
+	Name          string                `json:"name"`
+	SimpleName    string                `json:"simpleName"`
+	ParentJobName *string               `json:"parentJobName"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	UpdatedAt     *time.Time            `json:"updatedAt"`
+	Namespace     string                `json:"namespace"`
+	Inputs        []DatasetID           `json:"inputs"`
+	Outputs       []DatasetID           `json:"outputs"`
+	Location      *string               `json:"location"`
+	Description   *string               `json:"description"`
+	LatestRun     *Run                  `json:"latestRun"`
+	Facets        openlineage.JobFacets `json:"facets"`
+}
+
+type Run struct {
+	ID               uuid.UUID             `json:"id"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        *time.Time            `json:"updatedAt"`
+	NominalStartTime *time.Time            `json:"nominalStartTime"`
+	NominalEndTime   *time.Time            `json:"nominalEndTime"`
+	State            string                `json:"state"`
+	StartedAt        *time.Time            `json:"startedAt"`
+	EndedAt          *time.Time            `json:"endedAt"`
+	DurationMs       *int64                `json:"durationMs"`
+	Args             map[string]string     `json:"args"`
+	JobVersion       JobID                 `json:"jobVersion"`
+	InputVersions    []DatasetID           `json:"inputVersions"`
+	OutputVersions   []DatasetID           `json:"outputVersions"`
+	Facets           openlineage.RunFacets `json:"facets"`
+}
+
+type Edge struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+}
+
+type Node struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	Data     interface{} `json:"data"`
+	InEdges  []Edge      `json:"inEdges"`
+	OutEdges []Edge      `json:"outEdges"`
+}
+
+type SearchResult struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+	Namespace string     `json:"namespace"`
+	NodeID    string     `json:"nodeId"`
+}
+
+// optionalTime returns nil for the zero time so it is serialized as null
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toNamespace(ns lineage.Namespace) Namespace {
+	return Namespace{
+		Name:      ns.Name,
+		CreatedAt: ns.CreatedAt,
+		UpdatedAt: optionalTime(ns.UpdatedAt),
+	}
+}
+
+func toDataset(ds lineage.DatasetWithNamespace, fields []lineage.Field) Dataset {
+	res := Dataset{
+		ID:             DatasetID{Namespace: ds.DatasetNamespace.Name, Name: ds.Dataset.Name},
+		Type:           "DB_TABLE",
+		Name:           ds.Dataset.Name,
+		PhysicalName:   ds.Dataset.Name,
+		CreatedAt:      ds.Dataset.CreatedAt,
+		UpdatedAt:      optionalTime(ds.Dataset.UpdatedAt),
+		Namespace:      ds.DatasetNamespace.Name,
+		SourceName:     ds.DatasetNamespace.Name,
+		Fields:         []Field{},
+		Tags:           []string{},
+		LastModifiedAt: optionalTime(ds.Dataset.UpdatedAt),
+		Facets:         ds.Dataset.Facets,
+	}
+	for _, f := range fields {
+		res.Fields = append(res.Fields, Field{
+			Name:        f.Name,
+			Type:        f.DataType,
+			Tags:        []string{},
+			Description: optionalString(f.Description),
+		})
+	}
+	return res
+}
+
+func toJob(jns lineage.JobWithNamespace) Job {
+	return Job{
+		ID:          JobID{Namespace: jns.JobNamespace.Name, Name: jns.Job.Name},
+		Type:        "BATCH",
+		Name:        jns.Job.Name,
+		SimpleName:  jns.Job.Name,
+		CreatedAt:   jns.Job.CreatedAt,
+		UpdatedAt:   optionalTime(jns.Job.UpdatedAt),
+		Namespace:   jns.JobNamespace.Name,
+		Inputs:      []DatasetID{},
+		Outputs:     []DatasetID{},
+		Location:    optionalString(jns.Job.Facets.SourceCodeLocation.URL),
+		Description: optionalString(jns.Job.Facets.Documentation.Description),
+		Facets:      jns.Job.Facets,
+	}
+}
+
+func toRun(run lineage.Run, job JobID) Run {
+	res := Run{
+		ID:               run.RunUUID,
+		CreatedAt:        run.CreatedAt,
+		UpdatedAt:        optionalTime(run.UpdatedAt),
+		NominalStartTime: optionalTime(run.NominalStartedAt),
+		NominalEndTime:   optionalTime(run.NominalEndedAt),
+		State:            run.State.String(),
+		StartedAt:        optionalTime(run.StartedAt),
+		EndedAt:          optionalTime(run.EndedAt),
+		Args:             map[string]string{},
+		JobVersion:       job,
+		InputVersions:    []DatasetID{},
+		OutputVersions:   []DatasetID{},
+		Facets:           run.Facets,
+	}
+	if run.State.IsFinished() {
+		ms := run.Duration().Milliseconds()
+		res.DurationMs = &ms
+	}
+	return res
+}
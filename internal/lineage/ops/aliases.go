@@ -0,0 +1,208 @@
+package ops
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// ErrMergeIntoItself is returned when merging a dataset into the logical
+// dataset it already is part of
+var ErrMergeIntoItself = errors.New("dataset is already part of the dataset it is merged into")
+
+// ListDatasetAliases lists the other names of a dataset
+func ListDatasetAliases(ctx context.Context, deps Deps, dsID int64) ([]lineage.DatasetAlias, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetAliasesByDatasetID(ctx, dsID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list aliases for dsID[%d]", dsID)
+	}
+	var res []lineage.DatasetAlias
+	for _, row := range rows {
+		res = append(res, lineage.DatasetAlias{
+			ID:        row.ID,
+			Namespace: row.Namespace,
+			Name:      row.Name,
+			DatasetID: row.DatasetID,
+			IsManual:  row.IsManual,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+func getDatasetByName(ctx context.Context, qtx db.Querier, namespace string, name string) (*db.GetDatasetWithNamespaceByNameRow, error) {
+	row, err := qtx.GetDatasetWithNamespaceByName(ctx, db.GetDatasetWithNamespaceByNameParams{
+		NamespaceName: namespace,
+		Name:          name,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get dataset[%s] in namespace[%s]", name, namespace)
+	}
+	return &row, nil
+}
+
+// MergeDatasets makes the dataset an alias of the logical dataset into names
+// so both are shown as one, the aliases of the dataset move along with it.
+// Manual aliases are never replaced by symlinks.
+func MergeDatasets(ctx context.Context, deps Deps, namespace string, name string, intoNamespace string, intoName string) (*lineage.DatasetAlias, error) {
+	var res *lineage.DatasetAlias
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		var err error
+		res, err = mergeDatasets(ctx, qtx, namespace, name, intoNamespace, intoName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func mergeDatasets(ctx context.Context, qtx db.Querier, namespace string, name string, intoNamespace string, intoName string) (*lineage.DatasetAlias, error) {
+	ds, err := getDatasetByName(ctx, qtx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var target int64
+	alias, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: intoNamespace, Name: intoName})
+	if err != nil && !utils.IsNoRowsError(err) {
+		return nil, eris.Wrapf(err, "Failed to get dataset alias[%s] in namespace[%s]", intoName, intoNamespace)
+	}
+	if err == nil {
+		target = alias.DatasetID
+	} else {
+		into, err := getDatasetByName(ctx, qtx, intoNamespace, intoName)
+		if err != nil {
+			return nil, err
+		}
+		target = into.ID
+	}
+	if target == ds.ID {
+		return nil, ErrMergeIntoItself
+	}
+	current, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: namespace, Name: name})
+	if err != nil && !utils.IsNoRowsError(err) {
+		return nil, eris.Wrapf(err, "Failed to get dataset alias[%s] in namespace[%s]", name, namespace)
+	}
+	if err == nil && current.DatasetID == target {
+		return nil, ErrMergeIntoItself
+	}
+
+	row, err := qtx.UpsertManualDatasetAlias(ctx, db.UpsertManualDatasetAliasParams{
+		Namespace: namespace,
+		Name:      name,
+		DatasetID: target,
+		CreatedAt: utils.NowUTC(),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to merge dataset[%d] into dataset[%d]", ds.ID, target)
+	}
+	err = qtx.RepointDatasetAliases(ctx, db.RepointDatasetAliasesParams{
+		DatasetID:         target,
+		UpdatedAt:         utils.NowUTCAsNullTime(),
+		PreviousDatasetID: ds.ID,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to repoint aliases of dataset[%d]", ds.ID)
+	}
+
+	return toDatasetAlias(row), nil
+}
+
+// SplitDataset takes a name out of the logical dataset it is an alias of.
+// A dataset recorded under the name becomes a dataset of its own again and
+// symlinks will not merge it back.
+func SplitDataset(ctx context.Context, deps Deps, namespace string, name string) error {
+	return deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		return splitDataset(ctx, qtx, namespace, name)
+	})
+}
+
+func splitDataset(ctx context.Context, qtx db.Querier, namespace string, name string) error {
+	_, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: namespace, Name: name})
+	if err != nil {
+		return eris.Wrapf(err, "Failed to get dataset alias[%s] in namespace[%s]", name, namespace)
+	}
+
+	ds, err := getDatasetByName(ctx, qtx, namespace, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		_, err = qtx.UpsertManualDatasetAlias(ctx, db.UpsertManualDatasetAliasParams{
+			Namespace: namespace,
+			Name:      name,
+			DatasetID: ds.ID,
+			CreatedAt: utils.NowUTC(),
+		})
+	} else {
+		err = qtx.DeleteDatasetAlias(ctx, db.DeleteDatasetAliasParams{Namespace: namespace, Name: name})
+	}
+	if err != nil {
+		return eris.Wrapf(err, "Failed to split dataset[%s] in namespace[%s]", name, namespace)
+	}
+	return nil
+}
+
+// RestoreDatasetAlias makes the name a manual alias of the dataset recorded
+// under datasetNamespace and datasetName again, the ids of both are new after
+// a replay. False is returned when the dataset is no longer recorded.
+func RestoreDatasetAlias(ctx context.Context, qtx db.Querier, namespace string, name string, datasetNamespace string, datasetName string) (bool, error) {
+	target, err := getDatasetByName(ctx, qtx, datasetNamespace, datasetName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = qtx.UpsertManualDatasetAlias(ctx, db.UpsertManualDatasetAliasParams{
+		Namespace: namespace,
+		Name:      name,
+		DatasetID: target.ID,
+		CreatedAt: utils.NowUTC(),
+	})
+	if err != nil {
+		return false, eris.Wrapf(err, "Failed to restore alias[%s] in namespace[%s]", name, namespace)
+	}
+	if namespace == datasetNamespace && name == datasetName {
+		return true, nil
+	}
+
+	// aliases of a dataset recorded under the name follow it like on a merge
+	ds, err := getDatasetByName(ctx, qtx, namespace, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = qtx.RepointDatasetAliases(ctx, db.RepointDatasetAliasesParams{
+		DatasetID:         target.ID,
+		UpdatedAt:         utils.NowUTCAsNullTime(),
+		PreviousDatasetID: ds.ID,
+	})
+	if err != nil {
+		return false, eris.Wrapf(err, "Failed to repoint aliases of dataset[%d]", ds.ID)
+	}
+	return true, nil
+}
+
+func toDatasetAlias(row db.LineageDatasetAlias) *lineage.DatasetAlias {
+	return &lineage.DatasetAlias{
+		ID:        row.ID,
+		Namespace: row.Namespace,
+		Name:      row.Name,
+		DatasetID: row.DatasetID,
+		IsManual:  row.IsManual,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,126 @@
+package ops
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/utils"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// ErrInvalidAPIToken is returned for a token that does not exist or was
+// revoked
+var ErrInvalidAPIToken = errors.New("invalid api token")
+
+// apiTokenPrefix starts every token so it is easy to spot in configs
+const apiTokenPrefix = "oplin_"
+
+// apiTokenShownPrefix is how much of a token is kept to tell tokens apart
+const apiTokenShownPrefix = 12
+
+// apiTokenTouchInterval is how stale the last use of a token may be before
+// it is written again, every event would write it otherwise
+const apiTokenTouchInterval = time.Minute
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken creates a token for a producer, the producer and namespace
+// scope it when set. The token is only returned here, only its hash is kept.
+func CreateAPIToken(
+	ctx context.Context, deps Deps, name string, producer string, namespace string,
+) (*lineage.APIToken, string, error) {
+	if name == "" {
+		return nil, "", eris.New("api token has no name")
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", eris.Wrap(err, "could not generate api token")
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	params := db.CreateAPITokenParams{
+		Name:        name,
+		TokenHash:   hashAPIToken(token),
+		TokenPrefix: token[:apiTokenShownPrefix],
+		Producer:    sql.NullString{String: producer, Valid: producer != ""},
+		Namespace:   sql.NullString{String: namespace, Valid: namespace != ""},
+		CreatedAt:   utils.NowUTC(),
+	}
+	row, err := deps.GetStore().Queries().CreateAPIToken(ctx, params)
+	if err != nil {
+		return nil, "", eris.Wrapf(err, "Failed to create api token[%s]", name)
+	}
+	return toAPIToken(row), token, nil
+}
+
+// ListAPITokens lists the tokens oldest first
+func ListAPITokens(ctx context.Context, deps Deps) ([]lineage.APIToken, error) {
+	rows, err := deps.GetStore().Queries().ListAPITokens(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to list api tokens")
+	}
+	var res []lineage.APIToken
+	for _, row := range rows {
+		res = append(res, *toAPIToken(row))
+	}
+	return res, nil
+}
+
+// RevokeAPIToken deletes the token, events sent with it are refused from
+// then on
+func RevokeAPIToken(ctx context.Context, deps Deps, id int64) error {
+	n, err := deps.GetStore().Queries().DeleteAPIToken(ctx, id)
+	if err != nil {
+		return eris.Wrapf(err, "Failed to revoke api token[%d]", id)
+	}
+	if n == 0 {
+		return eris.Wrapf(sql.ErrNoRows, "no api token[%d]", id)
+	}
+	return nil
+}
+
+// AuthenticateAPIToken returns the stored token matching the token sent by
+// a producer and records its use
+func AuthenticateAPIToken(ctx context.Context, deps Deps, token string) (*lineage.APIToken, error) {
+	qtx := deps.GetStore().Queries()
+	row, err := qtx.GetAPITokenByHash(ctx, hashAPIToken(token))
+	if utils.IsNoRowsError(err) {
+		return nil, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to get api token")
+	}
+
+	now := utils.NowUTC()
+	if !row.LastUsedAt.Valid || now.Sub(row.LastUsedAt.Time) > apiTokenTouchInterval {
+		row.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		params := db.UpdateAPITokenLastUsedAtParams{ID: row.ID, LastUsedAt: row.LastUsedAt}
+		if err := qtx.UpdateAPITokenLastUsedAt(ctx, params); err != nil {
+			return nil, eris.Wrapf(err, "Failed to update api token[%d]", row.ID)
+		}
+	}
+	return toAPIToken(row), nil
+}
+
+func toAPIToken(row db.LineageApiToken) *lineage.APIToken {
+	return &lineage.APIToken{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.TokenPrefix,
+		Producer:   row.Producer.String,
+		Namespace:  row.Namespace.String,
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt.Time,
+	}
+}
@@ -6,13 +6,14 @@ import (
 	"oplin/internal/lineage"
 	"oplin/internal/lineage/db"
 	ol "oplin/internal/openlineage"
+	"oplin/internal/utils"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
 func ListDatasetsWithNamespaces(ctx context.Context, deps Deps) ([]lineage.DatasetWithNamespace, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListDatasetsWithNamespaces(ctx)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list datasets")
@@ -20,27 +21,94 @@ func ListDatasetsWithNamespaces(ctx context.Context, deps Deps) ([]lineage.Datas
 	var res []lineage.DatasetWithNamespace
 
 	for _, row := range rows {
-		res = append(res, lineage.DatasetWithNamespace{
-			Dataset: lineage.Dataset{
-				ID:                 row.ID,
-				CurrentVersionID:   row.CurrentVersionID.Int64,
-				DatasetNamespaceID: row.NamespaceID,
-				Name:               row.Name,
-				CreatedAt:          row.CreatedAt,
-				UpdatedAt:          row.UpdatedAt.Time},
-			DatasetNamespace: lineage.DatasetNamespace{
-				ID:        row.NamespaceID,
-				Name:      row.NamespaceName,
-				CreatedAt: row.NamespaceCreatedAt,
-				UpdatedAt: row.NamespaceUpdatedAt.Time},
+		res = append(res, fromDatasetListRow(row))
+	}
+	return res, nil
+}
+
+// ListDatasetsPage lists a page of datasets sorted and filtered by the
+// options
+func ListDatasetsPage(ctx context.Context, deps Deps, opts lineage.ListOptions) (*lineage.DatasetPage, error) {
+	qtx := deps.GetStore().Queries()
+	limit := pageLimit(opts)
+	var rows []db.ListDatasetsWithNamespacesRow
+	if opts.Sort == lineage.ListSortUpdated {
+		after, err := newestFirstCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		page, err := qtx.ListDatasetsPageByUpdated(ctx, db.ListDatasetsPageByUpdatedParams{
+			Namespace:       opts.Namespace,
+			NamePattern:     prefixPattern(opts.NamePrefix),
+			UpdatedSince:    opts.UpdatedSince,
+			BeforeUpdatedAt: after.Time,
+			BeforeID:        after.ID,
+			RowLimit:        int32(limit + 1),
+		})
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to list datasets")
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListDatasetsWithNamespacesRow(row))
+		}
+	} else {
+		after, err := decodeCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		page, err := qtx.ListDatasetsPageByName(ctx, db.ListDatasetsPageByNameParams{
+			Namespace:      opts.Namespace,
+			NamePattern:    prefixPattern(opts.NamePrefix),
+			UpdatedSince:   opts.UpdatedSince,
+			AfterNamespace: after.Namespace,
+			AfterName:      after.Name,
+			AfterID:        after.ID,
+			RowLimit:       int32(limit + 1),
 		})
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to list datasets")
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListDatasetsWithNamespacesRow(row))
+		}
+	}
+
+	res := &lineage.DatasetPage{}
+	for i, row := range rows {
+		if i == limit {
+			last := res.Datasets[limit-1]
+			res.Next = cursor{
+				Namespace: last.DatasetNamespace.Name,
+				Name:      last.Dataset.Name,
+				Time:      updatedOrCreated(last.Dataset.UpdatedAt, last.Dataset.CreatedAt),
+				ID:        last.Dataset.ID,
+			}.encode()
+			break
+		}
+		res.Datasets = append(res.Datasets, fromDatasetListRow(row))
 	}
 	return res, nil
 }
 
+func fromDatasetListRow(row db.ListDatasetsWithNamespacesRow) lineage.DatasetWithNamespace {
+	return lineage.DatasetWithNamespace{
+		Dataset: lineage.Dataset{
+			ID:                 row.ID,
+			CurrentVersionID:   row.CurrentVersionID.Int64,
+			DatasetNamespaceID: row.NamespaceID,
+			Name:               row.Name,
+			CreatedAt:          row.CreatedAt,
+			UpdatedAt:          row.UpdatedAt.Time},
+		DatasetNamespace: lineage.DatasetNamespace{
+			ID:        row.NamespaceID,
+			Name:      row.NamespaceName,
+			CreatedAt: row.NamespaceCreatedAt,
+			UpdatedAt: row.NamespaceUpdatedAt.Time},
+	}
+}
+
 func ListDatasetVersions(ctx context.Context, deps Deps, dsID int64) ([]lineage.DatasetVersion, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListDatasetVersionsByDatasetID(ctx, dsID)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list dataset versions")
@@ -50,6 +118,7 @@ func ListDatasetVersions(ctx context.Context, deps Deps, dsID int64) ([]lineage.
 	for _, row := range rows {
 		res = append(res, lineage.DatasetVersion{
 			ID:                 row.ID,
+			VersionUUID:        row.VersionUuid,
 			DatasetID:          row.DatasetID,
 			DatasetNamespaceID: row.NamespaceID,
 			Name:               row.Name,
@@ -61,8 +130,7 @@ func ListDatasetVersions(ctx context.Context, deps Deps, dsID int64) ([]lineage.
 }
 
 func ListFieldsForDatasetVersion(ctx context.Context, deps Deps, dsvID int64) ([]lineage.Field, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListFieldsByDatasetVersionID(ctx, dsvID)
 	if err != nil {
 		return nil, eris.Wrapf(err, "Failed to list fileds for dataset version[%d]", dsvID)
@@ -82,18 +150,99 @@ func ListFieldsForDatasetVersion(ctx context.Context, deps Deps, dsvID int64) ([
 	return res, nil
 }
 
+// ListSchemaChangesForDatasetVersion lists the fields that changed between a
+// dataset version and the version before it
+func ListSchemaChangesForDatasetVersion(ctx context.Context, deps Deps, dsvID int64) ([]lineage.SchemaChange, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListSchemaChangesByDatasetVersionID(ctx, dsvID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list schema changes for dsvID[%d]", dsvID)
+	}
+	var res []lineage.SchemaChange
+
+	for _, row := range rows {
+		res = append(res, lineage.SchemaChange{
+			ID:                  row.ID,
+			DatasetVersionID:    row.DatasetVersionID,
+			PreviousVersionID:   row.PreviousVersionID,
+			ChangeType:          lineage.SchemaChangeType(row.ChangeType),
+			FieldName:           row.FieldName,
+			PreviousDataType:    row.PreviousDataType.String,
+			DataType:            row.DataType.String,
+			PreviousDescription: row.PreviousDescription.String,
+			Description:         row.Description.String,
+			CreatedAt:           row.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// ListLifecycleStateChanges lists the lifecycle state changes of a dataset
+// oldest first, including the renames of other datasets from it
+func ListLifecycleStateChanges(ctx context.Context, deps Deps, dsID int64) ([]lineage.LifecycleStateChange, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListLifecycleStateChangesByDatasetID(ctx, dsID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list lifecycle state changes for dsID[%d]", dsID)
+	}
+	var res []lineage.LifecycleStateChange
+
+	for _, row := range rows {
+		res = append(res, lineage.LifecycleStateChange{
+			ID:                row.ID,
+			DatasetID:         row.DatasetID,
+			DatasetNamespace:  row.NamespaceName,
+			DatasetName:       row.DatasetName,
+			Change:            row.Change.String,
+			PreviousNamespace: row.Namespace.String,
+			PreviousName:      row.Name.String,
+			PreviousDatasetID: row.PreviousDatasetID.Int64,
+			RunID:             row.RunID.Int64,
+			RunUUID:           row.RunUuid.UUID,
+			CreatedAt:         row.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
 func GetDatasetWithNamespace(ctx context.Context, deps Deps, id int64) (*lineage.DatasetWithNamespace, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetDatasetWithNamespace(ctx, id)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to get dataset")
 	}
+	return toDatasetWithNamespace(row)
+}
 
-	f := ol.NewDatasetFacets()
-	err = json.Unmarshal(row.Facets.RawMessage, f)
+// GetDatasetWithNamespaceByName gets a dataset by any of its names, an alias
+// gets the dataset it is an alias of
+func GetDatasetWithNamespaceByName(ctx context.Context, deps Deps, namespace string, name string) (*lineage.DatasetWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	alias, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: namespace, Name: name})
+	if err != nil && !utils.IsNoRowsError(err) {
+		return nil, eris.Wrapf(err, "Failed to get dataset alias[%s] in namespace[%s]", name, namespace)
+	}
+	if err == nil {
+		return GetDatasetWithNamespace(ctx, deps, alias.DatasetID)
+	}
+
+	row, err := qtx.GetDatasetWithNamespaceByName(ctx, db.GetDatasetWithNamespaceByNameParams{
+		NamespaceName: namespace,
+		Name:          name,
+	})
 	if err != nil {
-		return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		return nil, eris.Wrapf(err, "Failed to get dataset[%s] in namespace[%s]", name, namespace)
+	}
+	return toDatasetWithNamespace(db.GetDatasetWithNamespaceRow(row))
+}
+
+func toDatasetWithNamespace(row db.GetDatasetWithNamespaceRow) (*lineage.DatasetWithNamespace, error) {
+	f := ol.NewDatasetFacets()
+	if len(row.Facets.RawMessage) > 0 {
+		err := json.Unmarshal(row.Facets.RawMessage, f)
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		}
 	}
 
 	res := lineage.DatasetWithNamespace{
@@ -114,10 +263,146 @@ func GetDatasetWithNamespace(ctx context.Context, deps Deps, id int64) (*lineage
 	return &res, nil
 }
 
+func ListDatasetsWithNamespacesByNamespace(ctx context.Context, deps Deps, namespace string) ([]lineage.DatasetWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetsWithNamespacesByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets in namespace[%s]", namespace)
+	}
+	var res []lineage.DatasetWithNamespace
+
+	for _, row := range rows {
+		res = append(res, fromDatasetListRow(db.ListDatasetsWithNamespacesRow(row)))
+	}
+	return res, nil
+}
+
+// ListDatasetsWithNamespacesByNamespacePage lists limit datasets of a
+// namespace by name, starting at offset
+func ListDatasetsWithNamespacesByNamespacePage(ctx context.Context, deps Deps, namespace string, limit int, offset int) ([]lineage.DatasetWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetsWithNamespacesByNamespacePage(ctx, db.ListDatasetsWithNamespacesByNamespacePageParams{
+		NamespaceName: namespace,
+		RowLimit:      int32(limit),
+		RowOffset:     int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets in namespace[%s]", namespace)
+	}
+	var res []lineage.DatasetWithNamespace
+
+	for _, row := range rows {
+		ds, err := toDatasetWithNamespace(db.GetDatasetWithNamespaceRow(row))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *ds)
+	}
+	return res, nil
+}
+
+// ListCurrentFieldsByNamespacePage lists the fields of the current versions
+// of the same page of datasets as ListDatasetsWithNamespacesByNamespacePage,
+// by dataset id
+func ListCurrentFieldsByNamespacePage(ctx context.Context, deps Deps, namespace string, limit int, offset int) (map[int64][]lineage.Field, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListCurrentFieldsByNamespacePage(ctx, db.ListCurrentFieldsByNamespacePageParams{
+		NamespaceName: namespace,
+		RowLimit:      int32(limit),
+		RowOffset:     int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list fields in namespace[%s]", namespace)
+	}
+	res := map[int64][]lineage.Field{}
+
+	for _, row := range rows {
+		res[row.DatasetID] = append(res[row.DatasetID], lineage.Field{
+			ID:          row.ID,
+			Name:        row.Name,
+			DataType:    row.DataType,
+			Description: row.Description.String,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+// ListDatasetsWithNamespacesByIDs lists the datasets with the ids
+func ListDatasetsWithNamespacesByIDs(ctx context.Context, deps Deps, ids []int64) ([]lineage.DatasetWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetsWithNamespacesByIDs(ctx, idList(ids))
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets%v", ids)
+	}
+	var res []lineage.DatasetWithNamespace
+
+	for _, row := range rows {
+		ds, err := toDatasetWithNamespace(db.GetDatasetWithNamespaceRow(row))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *ds)
+	}
+	return res, nil
+}
+
+// ListCurrentFieldsByDatasetIDs lists the fields of the current versions of
+// the datasets, by dataset id
+func ListCurrentFieldsByDatasetIDs(ctx context.Context, deps Deps, ids []int64) (map[int64][]lineage.Field, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListCurrentFieldsByDatasetIDs(ctx, idList(ids))
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list fields of datasets%v", ids)
+	}
+	res := map[int64][]lineage.Field{}
+
+	for _, row := range rows {
+		res[row.DatasetID] = append(res[row.DatasetID], lineage.Field{
+			ID:          row.ID,
+			Name:        row.Name,
+			DataType:    row.DataType,
+			Description: row.Description.String,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+// CountDatasetsByNamespace counts the datasets of a namespace, aliases are
+// not counted
+func CountDatasetsByNamespace(ctx context.Context, deps Deps, namespace string) (int, error) {
+	n, err := deps.GetStore().Queries().CountDatasetsByNamespace(ctx, namespace)
+	if err != nil {
+		return 0, eris.Wrapf(err, "Failed to count datasets in namespace[%s]", namespace)
+	}
+	return int(n), nil
+}
+
+func ListDatasetNamespaces(ctx context.Context, deps Deps) ([]lineage.DatasetNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetNamespaces(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to list dataset namespaces")
+	}
+	var res []lineage.DatasetNamespace
+
+	for _, row := range rows {
+		res = append(res, lineage.DatasetNamespace{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
 func GetLatestFacetsByDatasetVersionID(ctx context.Context, deps Deps, id int64) (*ol.DatasetFacets, error) {
 	f := ol.NewDatasetFacets()
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetLatestRunDatasetVersionByDatasetVersionID(ctx, id)
 	if err != nil {
 		return f, eris.Wrap(err, "Failed to get latest dataset version facets")
@@ -131,14 +416,14 @@ func GetLatestFacetsByDatasetVersionID(ctx context.Context, deps Deps, id int64)
 }
 
 func GetDatasetVersionByID(ctx context.Context, deps Deps, id int64) (*lineage.DatasetVersion, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetDatasetVersionByID(ctx, id)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to get latest dataset version facets")
 	}
 	dsv := lineage.DatasetVersion{
 		ID:                 row.ID,
+		VersionUUID:        row.VersionUuid,
 		DatasetID:          row.DatasetID,
 		DatasetNamespaceID: row.NamespaceID,
 		Name:               row.Name,
@@ -148,3 +433,36 @@ func GetDatasetVersionByID(ctx context.Context, deps Deps, id int64) (*lineage.D
 
 	return &dsv, nil
 }
+
+// GetDatasetVersionWithUUID gets a version of the dataset by its uuid, a
+// version of another dataset is not found
+func GetDatasetVersionWithUUID(ctx context.Context, deps Deps, dsID int64, version uuid.UUID) (*lineage.DatasetVersion, error) {
+	qtx := deps.GetStore().Queries()
+	params := db.GetDatasetVersionByDatasetIDAndVersionUuidParams{DatasetID: dsID, VersionUuid: version}
+	row, err := qtx.GetDatasetVersionByDatasetIDAndVersionUuid(ctx, params)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get dataset version[%v]", params)
+	}
+	return &lineage.DatasetVersion{
+		ID:                 row.ID,
+		VersionUUID:        row.VersionUuid,
+		DatasetID:          row.DatasetID,
+		DatasetNamespaceID: row.NamespaceID,
+		Name:               row.Name,
+		CreatedAt:          row.CreatedAt,
+		UpdatedAt:          row.UpdatedAt.Time,
+	}, nil
+}
+
+// GetDatasetVersionCreatedByRun returns the first run that wrote the dataset
+// version, nil when no run wrote it
+func GetDatasetVersionCreatedByRun(ctx context.Context, deps Deps, dsvID int64) (*uuid.UUID, error) {
+	runUUID, err := deps.GetStore().Queries().GetDatasetVersionCreatedByRunUUID(ctx, dsvID)
+	if utils.IsNoRowsError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get the run that created dataset version[%d]", dsvID)
+	}
+	return &runUUID, nil
+}
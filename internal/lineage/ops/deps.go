@@ -1,15 +1,15 @@
 package ops
 
-import "database/sql"
+import "oplin/internal/lineage/store"
 
 type Deps interface {
-	GetDB() *sql.DB
+	GetStore() store.Store
 }
 
 type TestDeps struct {
-	DB *sql.DB
+	Store store.Store
 }
 
-func (d *TestDeps) GetDB() *sql.DB {
-	return d.DB
+func (d *TestDeps) GetStore() store.Store {
+	return d.Store
 }
@@ -0,0 +1,91 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/utils"
+	"sort"
+
+	"github.com/rotisserie/eris"
+)
+
+func splitFacets(msg []byte) (map[string]json.RawMessage, error) {
+	res := map[string]json.RawMessage{}
+	if len(msg) == 0 {
+		return res, nil
+	}
+	if err := json.Unmarshal(msg, &res); err != nil {
+		return nil, eris.Wrapf(err, "could not unmarshall[%s]", msg)
+	}
+	return res, nil
+}
+
+func indentFacet(msg json.RawMessage) string {
+	if len(msg) == 0 {
+		return ""
+	}
+	b, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return string(msg)
+	}
+	return string(b)
+}
+
+// diffFacets returns the facets that were added, removed or changed between
+// two facet documents sorted by name
+func diffFacets(previous []byte, current []byte) ([]lineage.FacetDiff, error) {
+	prev, err := splitFacets(previous)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := splitFacets(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []lineage.FacetDiff
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok {
+			res = append(res, lineage.FacetDiff{Name: name, Change: lineage.FacetChangeAdded, Current: indentFacet(c)})
+		} else if !utils.FacetsEqual(p, c) {
+			res = append(res, lineage.FacetDiff{
+				Name: name, Change: lineage.FacetChangeChanged, Previous: indentFacet(p), Current: indentFacet(c),
+			})
+		}
+	}
+	for name, p := range prev {
+		if _, ok := cur[name]; !ok {
+			res = append(res, lineage.FacetDiff{Name: name, Change: lineage.FacetChangeRemoved, Previous: indentFacet(p)})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// ListFacets lists every facet stored for the job, run or dataset by name,
+// custom facets included
+func ListFacets(ctx context.Context, deps Deps, entityType lineage.FacetEntityType, id int64) ([]lineage.Facet, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListFacetsByEntity(ctx, db.ListFacetsByEntityParams{EntityType: int32(entityType), EntityID: id})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list facets of %s[%d]", entityType, id)
+	}
+	var res []lineage.Facet
+	for _, row := range rows {
+		res = append(res, lineage.Facet{
+			ID:         row.ID,
+			EntityType: lineage.FacetEntityType(row.EntityType),
+			EntityID:   row.EntityID,
+			Name:       row.Name,
+			Producer:   row.Producer.String,
+			SchemaURL:  row.SchemaUrl.String,
+			Payload:    row.Payload,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package ops
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+
+	"github.com/rotisserie/eris"
+)
+
+// ListFailedEvents lists a page of the events that could not be recorded
+// newest first, UpdatedSince filters on when they failed
+func ListFailedEvents(ctx context.Context, deps Deps, opts lineage.ListOptions) (*lineage.FailedEventPage, error) {
+	after, err := newestFirstCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+	limit := pageLimit(opts)
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListFailedEventsPage(ctx, db.ListFailedEventsPageParams{
+		CreatedSince: opts.UpdatedSince,
+		BeforeID:     after.ID,
+		RowLimit:     int32(limit + 1),
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to list failed events")
+	}
+	res := &lineage.FailedEventPage{}
+
+	for i, row := range rows {
+		if i == limit {
+			res.Next = cursor{ID: res.FailedEvents[limit-1].ID}.encode()
+			break
+		}
+		res.FailedEvents = append(res.FailedEvents, *toFailedEvent(row))
+	}
+	return res, nil
+}
+
+// GetFailedEvent returns the failed event with its error
+func GetFailedEvent(ctx context.Context, deps Deps, id int64) (*lineage.FailedEvent, error) {
+	row, err := deps.GetStore().Queries().GetFailedEventByID(ctx, id)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get failed event[%d]", id)
+	}
+	return toFailedEvent(row), nil
+}
+
+func toFailedEvent(row db.LineageFailedEvent) *lineage.FailedEvent {
+	return &lineage.FailedEvent{
+		ID:           row.ID,
+		Payload:      row.Payload,
+		Producer:     row.Producer.String,
+		ErrorMessage: row.ErrorMessage,
+		Stacktrace:   row.Stacktrace.String,
+		RequestID:    row.RequestID.Int64,
+		Attempts:     int(row.Attempts),
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt.Time,
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func InitializeDB(ctx context.Context, deps Deps) error {
-	err := db.InitializeDB(ctx, deps.GetDB())
+	_, err := deps.GetStore().MigrateUp(ctx)
 	if err != nil {
 		return eris.Wrap(err, "Failed to initialize db")
 	}
@@ -16,9 +16,36 @@ func InitializeDB(ctx context.Context, deps Deps) error {
 }
 
 func InitializeTestDB(ctx context.Context, deps Deps) error {
-	err := db.InitializeTestDB(ctx, deps.GetDB())
+	err := deps.GetStore().Reset(ctx)
 	if err != nil {
 		return eris.Wrap(err, "Failed to initialize test db")
 	}
 	return nil
 }
+
+// MigrateUp applies the pending migrations
+func MigrateUp(ctx context.Context, deps Deps) ([]db.Migration, error) {
+	res, err := deps.GetStore().MigrateUp(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to migrate up")
+	}
+	return res, nil
+}
+
+// MigrateDown reverts the last steps migrations
+func MigrateDown(ctx context.Context, deps Deps, steps int) ([]db.Migration, error) {
+	res, err := deps.GetStore().MigrateDown(ctx, steps)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to migrate down")
+	}
+	return res, nil
+}
+
+// ListMigrationStatuses lists the migrations and whether they are applied
+func ListMigrationStatuses(ctx context.Context, deps Deps) ([]db.MigrationStatus, error) {
+	res, err := deps.GetStore().MigrationStatuses(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to get migration status")
+	}
+	return res, nil
+}
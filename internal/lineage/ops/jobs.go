@@ -7,12 +7,12 @@ import (
 	"oplin/internal/lineage/db"
 	ol "oplin/internal/openlineage"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
 func ListJobsWithNamespaces(ctx context.Context, deps Deps) ([]lineage.JobWithNamespace, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListJobsWithNamespaces(ctx)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list jobs")
@@ -20,26 +20,92 @@ func ListJobsWithNamespaces(ctx context.Context, deps Deps) ([]lineage.JobWithNa
 	var res []lineage.JobWithNamespace
 
 	for _, row := range rows {
-		res = append(res, lineage.JobWithNamespace{
-			Job: lineage.Job{
-				ID:             row.ID,
-				JobNamespaceID: row.NamespaceID,
-				Name:           row.Name,
-				CreatedAt:      row.CreatedAt,
-				UpdatedAt:      row.UpdatedAt.Time},
-			JobNamespace: lineage.JobNamespace{
-				ID:        row.NamespaceID,
-				Name:      row.NamespaceName,
-				CreatedAt: row.NamespaceCreatedAt,
-				UpdatedAt: row.NamespaceUpdatedAt.Time},
+		res = append(res, fromJobListRow(row))
+	}
+	return res, nil
+}
+
+// ListJobsPage lists a page of jobs sorted and filtered by the options
+func ListJobsPage(ctx context.Context, deps Deps, opts lineage.ListOptions) (*lineage.JobPage, error) {
+	qtx := deps.GetStore().Queries()
+	limit := pageLimit(opts)
+	var rows []db.ListJobsWithNamespacesRow
+	if opts.Sort == lineage.ListSortUpdated {
+		after, err := newestFirstCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		page, err := qtx.ListJobsPageByUpdated(ctx, db.ListJobsPageByUpdatedParams{
+			Namespace:       opts.Namespace,
+			NamePattern:     prefixPattern(opts.NamePrefix),
+			UpdatedSince:    opts.UpdatedSince,
+			BeforeUpdatedAt: after.Time,
+			BeforeID:        after.ID,
+			RowLimit:        int32(limit + 1),
 		})
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to list jobs")
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListJobsWithNamespacesRow(row))
+		}
+	} else {
+		after, err := decodeCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		page, err := qtx.ListJobsPageByName(ctx, db.ListJobsPageByNameParams{
+			Namespace:      opts.Namespace,
+			NamePattern:    prefixPattern(opts.NamePrefix),
+			UpdatedSince:   opts.UpdatedSince,
+			AfterNamespace: after.Namespace,
+			AfterName:      after.Name,
+			AfterID:        after.ID,
+			RowLimit:       int32(limit + 1),
+		})
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to list jobs")
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListJobsWithNamespacesRow(row))
+		}
+	}
+
+	res := &lineage.JobPage{}
+	for i, row := range rows {
+		if i == limit {
+			last := res.Jobs[limit-1]
+			res.Next = cursor{
+				Namespace: last.JobNamespace.Name,
+				Name:      last.Job.Name,
+				Time:      updatedOrCreated(last.Job.UpdatedAt, last.Job.CreatedAt),
+				ID:        last.Job.ID,
+			}.encode()
+			break
+		}
+		res.Jobs = append(res.Jobs, fromJobListRow(row))
 	}
 	return res, nil
 }
 
+func fromJobListRow(row db.ListJobsWithNamespacesRow) lineage.JobWithNamespace {
+	return lineage.JobWithNamespace{
+		Job: lineage.Job{
+			ID:             row.ID,
+			JobNamespaceID: row.NamespaceID,
+			Name:           row.Name,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt.Time},
+		JobNamespace: lineage.JobNamespace{
+			ID:        row.NamespaceID,
+			Name:      row.NamespaceName,
+			CreatedAt: row.NamespaceCreatedAt,
+			UpdatedAt: row.NamespaceUpdatedAt.Time},
+	}
+}
+
 func ListJobVersions(ctx context.Context, deps Deps, jobID int64) ([]lineage.JobVersion, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListJobVersionsByJobID(ctx, jobID)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list dataset versions")
@@ -47,30 +113,63 @@ func ListJobVersions(ctx context.Context, deps Deps, jobID int64) ([]lineage.Job
 	var res []lineage.JobVersion
 
 	for _, row := range rows {
-		res = append(res, lineage.JobVersion{
-			ID:             row.ID,
-			JobID:          row.JobID,
-			JobNamespaceID: row.NamespaceID,
-			Name:           row.Name,
-			CreatedAt:      row.CreatedAt,
-			UpdatedAt:      row.UpdatedAt.Time,
-		})
+		jv, err := toJobVersion(row)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *jv)
 	}
 	return res, nil
 }
 
+func toJobVersion(row db.LineageJobVersion) (*lineage.JobVersion, error) {
+	f := &ol.JobFacets{}
+	if len(row.Facets.RawMessage) > 0 {
+		err := json.Unmarshal(row.Facets.RawMessage, f)
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		}
+	}
+	return &lineage.JobVersion{
+		ID:             row.ID,
+		VersionUUID:    row.VersionUuid,
+		JobID:          row.JobID,
+		JobNamespaceID: row.NamespaceID,
+		Name:           row.Name,
+		Facets:         *f,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt.Time,
+	}, nil
+}
+
 func GetJobWithNamespace(ctx context.Context, deps Deps, id int64) (*lineage.JobWithNamespace, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetJobWithNamespace(ctx, id)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to get job")
 	}
+	return toJobWithNamespace(row)
+}
 
-	f := &ol.JobFacets{}
-	err = json.Unmarshal(row.Facets.RawMessage, f)
+func GetJobWithNamespaceByName(ctx context.Context, deps Deps, namespace string, name string) (*lineage.JobWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	row, err := qtx.GetJobWithNamespaceByName(ctx, db.GetJobWithNamespaceByNameParams{
+		NamespaceName: namespace,
+		Name:          name,
+	})
 	if err != nil {
-		return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		return nil, eris.Wrapf(err, "Failed to get job[%s] in namespace[%s]", name, namespace)
+	}
+	return toJobWithNamespace(db.GetJobWithNamespaceRow(row))
+}
+
+func toJobWithNamespace(row db.GetJobWithNamespaceRow) (*lineage.JobWithNamespace, error) {
+	f := &ol.JobFacets{}
+	if len(row.Facets.RawMessage) > 0 {
+		err := json.Unmarshal(row.Facets.RawMessage, f)
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		}
 	}
 
 	res := lineage.JobWithNamespace{
@@ -91,20 +190,200 @@ func GetJobWithNamespace(ctx context.Context, deps Deps, id int64) (*lineage.Job
 	return &res, nil
 }
 
+func ListJobsWithNamespacesByNamespace(ctx context.Context, deps Deps, namespace string) ([]lineage.JobWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListJobsWithNamespacesByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list jobs in namespace[%s]", namespace)
+	}
+	var res []lineage.JobWithNamespace
+
+	for _, row := range rows {
+		res = append(res, fromJobListRow(db.ListJobsWithNamespacesRow(row)))
+	}
+	return res, nil
+}
+
+// ListJobsWithNamespacesByNamespacePage lists limit jobs of a namespace by
+// name, starting at offset
+func ListJobsWithNamespacesByNamespacePage(ctx context.Context, deps Deps, namespace string, limit int, offset int) ([]lineage.JobWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListJobsWithNamespacesByNamespacePage(ctx, db.ListJobsWithNamespacesByNamespacePageParams{
+		NamespaceName: namespace,
+		RowLimit:      int32(limit),
+		RowOffset:     int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list jobs in namespace[%s]", namespace)
+	}
+	var res []lineage.JobWithNamespace
+
+	for _, row := range rows {
+		jns, err := toJobWithNamespace(db.GetJobWithNamespaceRow(row))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *jns)
+	}
+	return res, nil
+}
+
+// ListDatasetEdgesByNamespacePage lists the datasets read and written by the
+// same page of jobs as ListJobsWithNamespacesByNamespacePage, under the name
+// of the dataset their names are an alias of
+func ListDatasetEdgesByNamespacePage(ctx context.Context, deps Deps, namespace string, limit int, offset int) ([]lineage.DatasetEdge, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetEdgesByNamespacePage(ctx, db.ListDatasetEdgesByNamespacePageParams{
+		NamespaceName: namespace,
+		RowLimit:      int32(limit),
+		RowOffset:     int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets of jobs in namespace[%s]", namespace)
+	}
+	var res []lineage.DatasetEdge
+
+	for _, row := range rows {
+		res = append(res, lineage.DatasetEdge{
+			ID:            row.JobID,
+			DatasetName:   row.DatasetName,
+			NamespaceName: row.NamespaceName,
+			IOType:        lineage.IOType(row.IoType),
+		})
+	}
+	return res, nil
+}
+
+// ListJobsWithNamespacesByIDs lists the jobs with the ids
+func ListJobsWithNamespacesByIDs(ctx context.Context, deps Deps, ids []int64) ([]lineage.JobWithNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListJobsWithNamespacesByIDs(ctx, idList(ids))
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list jobs%v", ids)
+	}
+	var res []lineage.JobWithNamespace
+
+	for _, row := range rows {
+		jns, err := toJobWithNamespace(db.GetJobWithNamespaceRow(row))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *jns)
+	}
+	return res, nil
+}
+
+// ListDatasetEdgesByJobIDs lists the datasets read and written by the jobs,
+// under the name of the dataset their names are an alias of
+func ListDatasetEdgesByJobIDs(ctx context.Context, deps Deps, jobIDs []int64) ([]lineage.DatasetEdge, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListDatasetEdgesByJobIDs(ctx, idList(jobIDs))
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets of jobs%v", jobIDs)
+	}
+	var res []lineage.DatasetEdge
+
+	for _, row := range rows {
+		res = append(res, lineage.DatasetEdge{
+			ID:            row.JobID,
+			DatasetName:   row.DatasetName,
+			NamespaceName: row.NamespaceName,
+			IOType:        lineage.IOType(row.IoType),
+		})
+	}
+	return res, nil
+}
+
+// CountJobsByNamespace counts the jobs of a namespace
+func CountJobsByNamespace(ctx context.Context, deps Deps, namespace string) (int, error) {
+	n, err := deps.GetStore().Queries().CountJobsByNamespace(ctx, namespace)
+	if err != nil {
+		return 0, eris.Wrapf(err, "Failed to count jobs in namespace[%s]", namespace)
+	}
+	return int(n), nil
+}
+
+func ListJobNamespaces(ctx context.Context, deps Deps) ([]lineage.JobNamespace, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListJobNamespaces(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to list job namespaces")
+	}
+	var res []lineage.JobNamespace
+
+	for _, row := range rows {
+		res = append(res, lineage.JobNamespace{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt.Time,
+		})
+	}
+	return res, nil
+}
+
 func GetJobVersionByID(ctx context.Context, deps Deps, id int64) (*lineage.JobVersion, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetJobVersionByID(ctx, id)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to get job")
 	}
-	res := lineage.JobVersion{
-		ID:             row.ID,
-		JobID:          row.JobID,
-		JobNamespaceID: row.NamespaceID,
-		Name:           row.Name,
-		CreatedAt:      row.CreatedAt,
-		UpdatedAt:      row.UpdatedAt.Time,
+	return toJobVersion(row)
+}
+
+// GetJobVersionWithUUID gets a version of the job by its uuid, a version of
+// another job is not found
+func GetJobVersionWithUUID(ctx context.Context, deps Deps, jobID int64, version uuid.UUID) (*lineage.JobVersion, error) {
+	qtx := deps.GetStore().Queries()
+	params := db.GetJobVersionByJobIDAndVersionUuidParams{JobID: jobID, VersionUuid: version}
+	row, err := qtx.GetJobVersionByJobIDAndVersionUuid(ctx, params)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get job version[%v]", params)
 	}
-	return &res, nil
+	return toJobVersion(row)
+}
+
+// DiffJobVersion compares the facets of a job version with those of the
+// version before it. The first version of a job shows every facet as added.
+func DiffJobVersion(ctx context.Context, deps Deps, id int64) ([]lineage.FacetDiff, error) {
+	qtx := deps.GetStore().Queries()
+	jv, err := qtx.GetJobVersionByID(ctx, id)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get job version[%d]", id)
+	}
+	rows, err := qtx.ListJobVersionsByJobID(ctx, jv.JobID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list versions of job[%d]", jv.JobID)
+	}
+
+	// versions are listed newest first so the previous one follows
+	var previous []byte
+	for i, row := range rows {
+		if row.ID == id && i+1 < len(rows) {
+			previous = rows[i+1].Facets.RawMessage
+		}
+	}
+	return diffFacets(previous, jv.Facets.RawMessage)
+}
+
+// ListDeclaredDatasetsForJobVersion lists the inputs and outputs declared for
+// a job version by job events
+func ListDeclaredDatasetsForJobVersion(ctx context.Context, deps Deps, id int64) ([]lineage.JobVersionIODataset, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListJobVersionIODatasetsByJobVersionID(ctx, id)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list declared datasets of job version[%d]", id)
+	}
+	var res []lineage.JobVersionIODataset
+	for _, row := range rows {
+		res = append(res, lineage.JobVersionIODataset{
+			JobVersionID:  row.JobVersionID,
+			DatasetID:     row.DatasetID,
+			DatasetName:   row.DatasetName,
+			NamespaceName: row.NamespaceName,
+			IOType:        lineage.IOType(row.IoType),
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+	return res, nil
 }
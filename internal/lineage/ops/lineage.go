@@ -0,0 +1,263 @@
+package ops
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/utils"
+	"sort"
+
+	"github.com/rotisserie/eris"
+)
+
+const (
+	// DefaultLineageDepth is the number of hops walked when none is given
+	DefaultLineageDepth = 2
+	// MaxLineageDepth bounds a walk so one request cannot traverse everything
+	MaxLineageDepth = 20
+)
+
+type nodeKey struct {
+	nodeType lineage.NodeType
+	id       int64
+}
+
+// graphWalker walks the job/dataset graph built from the inputs and outputs
+// recorded for every run. Datasets that are aliases of another dataset are
+// walked as that dataset.
+type graphWalker struct {
+	qtx       db.Querier
+	nodes     map[nodeKey]lineage.LineageNode
+	edges     map[lineage.LineageEdge]bool
+	canonical map[int64]int64
+}
+
+// GetLineageGraph returns the nodes and edges reachable from start by walking
+// up to upstream hops against the flow of data and downstream hops with it.
+// Both depths are capped at MaxLineageDepth.
+func GetLineageGraph(ctx context.Context, deps Deps, start lineage.NodeID, upstream int, downstream int) (*lineage.LineageGraph, error) {
+	if upstream > MaxLineageDepth {
+		upstream = MaxLineageDepth
+	}
+	if downstream > MaxLineageDepth {
+		downstream = MaxLineageDepth
+	}
+	w := &graphWalker{
+		qtx:       deps.GetStore().Queries(),
+		nodes:     map[nodeKey]lineage.LineageNode{},
+		edges:     map[lineage.LineageEdge]bool{},
+		canonical: map[int64]int64{},
+	}
+
+	node, err := w.find(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.walk(ctx, *node, upstream, true); err != nil {
+		return nil, err
+	}
+	if err := w.walk(ctx, *node, downstream, false); err != nil {
+		return nil, err
+	}
+
+	res := lineage.LineageGraph{}
+	for _, n := range w.nodes {
+		res.Nodes = append(res.Nodes, n)
+	}
+	for e := range w.edges {
+		res.Edges = append(res.Edges, e)
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		return res.Nodes[i].NodeID.String() < res.Nodes[j].NodeID.String()
+	})
+	sort.Slice(res.Edges, func(i, j int) bool {
+		a, b := res.Edges[i], res.Edges[j]
+		if a.Origin != b.Origin {
+			return a.Origin.String() < b.Origin.String()
+		}
+		return a.Destination.String() < b.Destination.String()
+	})
+	return &res, nil
+}
+
+func (w *graphWalker) find(ctx context.Context, nodeID lineage.NodeID) (*lineage.LineageNode, error) {
+	var node lineage.LineageNode
+	switch nodeID.Type {
+	case lineage.NodeTypeDataset:
+		alias, err := w.qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{
+			Namespace: nodeID.Namespace,
+			Name:      nodeID.Name,
+		})
+		if err != nil && !utils.IsNoRowsError(err) {
+			return nil, eris.Wrapf(err, "Failed to find alias[%s]", nodeID)
+		}
+		if err == nil {
+			node, err := w.load(ctx, nodeKey{lineage.NodeTypeDataset, alias.DatasetID})
+			if err != nil {
+				return nil, err
+			}
+			return &node, nil
+		}
+		row, err := w.qtx.GetDatasetWithNamespaceByName(ctx, db.GetDatasetWithNamespaceByNameParams{
+			NamespaceName: nodeID.Namespace,
+			Name:          nodeID.Name,
+		})
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to find node[%s]", nodeID)
+		}
+		node = lineage.LineageNode{NodeID: nodeID, ID: row.ID, UpdatedAt: row.UpdatedAt.Time}
+	case lineage.NodeTypeJob:
+		row, err := w.qtx.GetJobWithNamespaceByName(ctx, db.GetJobWithNamespaceByNameParams{
+			NamespaceName: nodeID.Namespace,
+			Name:          nodeID.Name,
+		})
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to find node[%s]", nodeID)
+		}
+		node = lineage.LineageNode{NodeID: nodeID, ID: row.ID, UpdatedAt: row.UpdatedAt.Time}
+	default:
+		return nil, eris.Errorf("Unknown type for node[%s]", nodeID)
+	}
+	w.nodes[nodeKey{nodeID.Type, node.ID}] = node
+	return &node, nil
+}
+
+func (w *graphWalker) load(ctx context.Context, key nodeKey) (lineage.LineageNode, error) {
+	if node, ok := w.nodes[key]; ok {
+		return node, nil
+	}
+
+	var node lineage.LineageNode
+	switch key.nodeType {
+	case lineage.NodeTypeDataset:
+		row, err := w.qtx.GetDatasetWithNamespace(ctx, key.id)
+		if err != nil {
+			return node, eris.Wrapf(err, "Failed to get dataset[%d]", key.id)
+		}
+		node = lineage.LineageNode{
+			NodeID:    lineage.NodeID{Type: lineage.NodeTypeDataset, Namespace: row.NamespaceName, Name: row.Name},
+			ID:        row.ID,
+			UpdatedAt: row.UpdatedAt.Time,
+		}
+	case lineage.NodeTypeJob:
+		row, err := w.qtx.GetJobWithNamespace(ctx, key.id)
+		if err != nil {
+			return node, eris.Wrapf(err, "Failed to get job[%d]", key.id)
+		}
+		node = lineage.LineageNode{
+			NodeID:    lineage.NodeID{Type: lineage.NodeTypeJob, Namespace: row.NamespaceName, Name: row.Name},
+			ID:        row.ID,
+			UpdatedAt: row.UpdatedAt.Time,
+		}
+	}
+	w.nodes[key] = node
+	return node, nil
+}
+
+// canonicalDatasetID returns the dataset the dataset's name is an alias of or
+// the dataset itself
+func (w *graphWalker) canonicalDatasetID(ctx context.Context, id int64) (int64, error) {
+	if res, ok := w.canonical[id]; ok {
+		return res, nil
+	}
+	row, err := w.qtx.GetDatasetWithNamespace(ctx, id)
+	if err != nil {
+		return 0, eris.Wrapf(err, "Failed to get dataset[%d]", id)
+	}
+	res := id
+	alias, err := w.qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: row.NamespaceName, Name: row.Name})
+	if err != nil && !utils.IsNoRowsError(err) {
+		return 0, eris.Wrapf(err, "Failed to get alias of dataset[%d]", id)
+	}
+	if err == nil {
+		res = alias.DatasetID
+	}
+	w.canonical[id] = res
+	return res, nil
+}
+
+// neighbours returns the nodes one hop from node. Upstream of a dataset are
+// the jobs that wrote it and upstream of a job are the datasets it read.
+func (w *graphWalker) neighbours(ctx context.Context, node lineage.LineageNode, upstream bool) ([]lineage.LineageNode, error) {
+	var keys []nodeKey
+	switch node.NodeID.Type {
+	case lineage.NodeTypeDataset:
+		want := lineage.IOTypeInput
+		if upstream {
+			want = lineage.IOTypeOutput
+		}
+		aliased, err := w.qtx.ListAliasedDatasetIDs(ctx, node.ID)
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to list aliases for dataset[%d]", node.ID)
+		}
+		for _, id := range append([]int64{node.ID}, aliased...) {
+			rows, err := w.qtx.ListJobEdgesByDatasetID(ctx, id)
+			if err != nil {
+				return nil, eris.Wrapf(err, "Failed to list jobs for dataset[%d]", id)
+			}
+			for _, row := range rows {
+				if lineage.IOType(row.IoType) == want {
+					keys = append(keys, nodeKey{lineage.NodeTypeJob, row.JobID})
+				}
+			}
+		}
+	case lineage.NodeTypeJob:
+		want := lineage.IOTypeOutput
+		if upstream {
+			want = lineage.IOTypeInput
+		}
+		rows, err := w.qtx.ListDatasetEdgesByJobID(ctx, node.ID)
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to list datasets for job[%d]", node.ID)
+		}
+		for _, row := range rows {
+			if lineage.IOType(row.IoType) == want {
+				id, err := w.canonicalDatasetID(ctx, row.DatasetID)
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, nodeKey{lineage.NodeTypeDataset, id})
+			}
+		}
+	}
+
+	var res []lineage.LineageNode
+	for _, key := range keys {
+		n, err := w.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// walk does a breadth first walk from start for at most depth hops in one
+// direction, recording every node and edge it passes
+func (w *graphWalker) walk(ctx context.Context, start lineage.LineageNode, depth int, upstream bool) error {
+	visited := map[lineage.NodeID]bool{start.NodeID: true}
+	frontier := []lineage.LineageNode{start}
+
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []lineage.LineageNode
+		for _, node := range frontier {
+			neighbours, err := w.neighbours(ctx, node, upstream)
+			if err != nil {
+				return err
+			}
+			for _, n := range neighbours {
+				if upstream {
+					w.edges[lineage.LineageEdge{Origin: n.NodeID, Destination: node.NodeID}] = true
+				} else {
+					w.edges[lineage.LineageEdge{Origin: node.NodeID, Destination: n.NodeID}] = true
+				}
+				if !visited[n.NodeID] {
+					visited[n.NodeID] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package ops
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	"sort"
+)
+
+// ListNamespaces lists the job and dataset namespaces merged by name
+func ListNamespaces(ctx context.Context, deps Deps) ([]lineage.Namespace, error) {
+	jns, err := ListJobNamespaces(ctx, deps)
+	if err != nil {
+		return nil, err
+	}
+	dns, err := ListDatasetNamespaces(ctx, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*lineage.Namespace{}
+	add := func(ns lineage.Namespace) {
+		existing, ok := byName[ns.Name]
+		if !ok {
+			byName[ns.Name] = &ns
+			return
+		}
+		if ns.CreatedAt.Before(existing.CreatedAt) {
+			existing.CreatedAt = ns.CreatedAt
+		}
+		if ns.UpdatedAt.After(existing.UpdatedAt) {
+			existing.UpdatedAt = ns.UpdatedAt
+		}
+	}
+	for _, ns := range jns {
+		add(lineage.Namespace{Name: ns.Name, CreatedAt: ns.CreatedAt, UpdatedAt: ns.UpdatedAt})
+	}
+	for _, ns := range dns {
+		add(lineage.Namespace{Name: ns.Name, CreatedAt: ns.CreatedAt, UpdatedAt: ns.UpdatedAt})
+	}
+
+	var res []lineage.Namespace
+	for _, ns := range byName {
+		res = append(res, *ns)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package openlineage
+
+import (
+	"context"
+	"encoding/json"
+	"oplin/internal/lineage/db"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// canonicalDatasetID returns the dataset the name is an alias of, or the
+// dataset itself when it is not one
+func canonicalDatasetID(ctx context.Context, qtx db.Querier, nsName string, ds *db.LineageDataset) (int64, error) {
+	alias, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: nsName, Name: ds.Name})
+	if utils.IsNoRowsError(err) {
+		return ds.ID, nil
+	}
+	if err != nil {
+		return 0, eris.Wrapf(err, "get dataset alias[%s.%s] failed", nsName, ds.Name)
+	}
+	return alias.DatasetID, nil
+}
+
+// handleSymlinks makes every identifier of the symlinks facet an alias of the
+// dataset so the same physical table reported under different names is one
+// logical dataset. An identifier that already is an alias keeps pointing
+// where it does, manual merges and splits win over symlinks.
+func handleSymlinks(
+	ctx context.Context, qtx db.Querier, nsName string, ds *db.LineageDataset, msg json.RawMessage,
+) error {
+	if !hasFacet(msg, "symlinks") {
+		return nil
+	}
+	fs := openlineage.NewDatasetFacets()
+	if err := json.Unmarshal(msg, fs); err != nil {
+		return eris.Wrapf(err, "could not unmarshall[%s]", msg)
+	}
+
+	target, err := canonicalDatasetID(ctx, qtx, nsName, ds)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range fs.Symlinks.Identifiers {
+		if id.Namespace == nsName && id.Name == ds.Name {
+			continue
+		}
+		params := db.CreateDatasetAliasIfNotExistsParams{
+			Namespace: id.Namespace,
+			Name:      id.Name,
+			DatasetID: target,
+			CreatedAt: utils.NowUTC(),
+		}
+		if err := qtx.CreateDatasetAliasIfNotExists(ctx, params); err != nil {
+			return eris.Wrapf(err, "create dataset alias[%v] failed", params)
+		}
+		alias, err := qtx.GetDatasetAliasByName(ctx, db.GetDatasetAliasByNameParams{Namespace: id.Namespace, Name: id.Name})
+		if err != nil {
+			return eris.Wrapf(err, "get dataset alias[%s.%s] failed", id.Namespace, id.Name)
+		}
+		if alias.DatasetID != target {
+			continue
+		}
+
+		// aliases of a dataset recorded under the identifier follow it
+		aliased, err := getDatasetByIdentifier(ctx, qtx, id)
+		if err != nil {
+			return err
+		}
+		if aliased != nil && aliased.ID != target {
+			err = qtx.RepointDatasetAliases(ctx, db.RepointDatasetAliasesParams{
+				DatasetID:         target,
+				UpdatedAt:         utils.NowUTCAsNullTime(),
+				PreviousDatasetID: aliased.ID,
+			})
+			if err != nil {
+				return eris.Wrapf(err, "repoint dataset aliases of dataset[%d] failed", aliased.ID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymlinksMergeDatasets(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// spark writes the path and says it is the warehouse.orders table
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("spark", "load_orders", nil)
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("s3://bucket", "/orders", []byte(
+		`{"symlinks": {"identifiers": [{"namespace": "hive://metastore", "name": "warehouse.orders", "type": "TABLE"}]}}`,
+	))}}
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	// hive reads the table
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("hive", "monthly_orders", nil)
+	ev.Inputs = []openlineage.InputDataset{{Dataset: *openlineage.NewDataset("hive://metastore", "warehouse.orders", nil)}}
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	path, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "s3://bucket", "/orders")
+	assert.Nil(t, err)
+	table, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "hive://metastore", "warehouse.orders")
+	assert.Nil(t, err)
+	assert.Equal(t, path.Dataset.ID, table.Dataset.ID)
+
+	datasets, err := ops.ListDatasetsWithNamespaces(ctx, deps)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(datasets))
+
+	start := lineage.NodeID{Type: lineage.NodeTypeJob, Namespace: "spark", Name: "load_orders"}
+	graph, err := ops.GetLineageGraph(ctx, deps, start, 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+
+	// split the table from the path
+	err = ops.SplitDataset(ctx, deps, "hive://metastore", "warehouse.orders")
+	assert.Nil(t, err)
+
+	graph, err = ops.GetLineageGraph(ctx, deps, start, 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(graph.Nodes))
+
+	datasets, err = ops.ListDatasetsWithNamespaces(ctx, deps)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(datasets))
+
+	// and merge it back by hand
+	_, err = ops.MergeDatasets(ctx, deps, "hive://metastore", "warehouse.orders", "s3://bucket", "/orders")
+	assert.Nil(t, err)
+
+	graph, err = ops.GetLineageGraph(ctx, deps, start, 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+
+	_, err = ops.MergeDatasets(ctx, deps, "hive://metastore", "warehouse.orders", "s3://bucket", "/orders")
+	assert.ErrorIs(t, err, ops.ErrMergeIntoItself)
+}
@@ -0,0 +1,94 @@
+package openlineage
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/store"
+	"oplin/internal/openlineage"
+
+	"github.com/rotisserie/eris"
+)
+
+// BatchChunkSize is the number of events recorded per transaction by
+// CreateWithOpenLineageRunEvents
+const BatchChunkSize = 500
+
+// BatchResult is the outcome of recording one event of a batch, either the
+// run event or the error that prevented it from being recorded
+type BatchResult struct {
+	RunEvent *lineage.RunEvent
+	Err      error
+}
+
+// CreateWithOpenLineageRunEvents records the events in order. Events are
+// recorded in chunks of BatchChunkSize per transaction with a savepoint per
+// event so a failing event does not undo the others. The results line up
+// with the events. An error is only returned when a chunk cannot be
+// committed, in which case the results of the events before it are kept.
+// keys holds the idempotency key of each event and payloads the body each
+// was received as, both may be nil.
+func CreateWithOpenLineageRunEvents(
+	ctx context.Context, deps Deps, evs []*openlineage.RunEvent, keys []string, payloads []json.RawMessage,
+) ([]BatchResult, error) {
+	res := make([]BatchResult, 0, len(evs))
+	for start := 0; start < len(evs); start += BatchChunkSize {
+		end := start + BatchChunkSize
+		if end > len(evs) {
+			end = len(evs)
+		}
+		var chunkKeys []string
+		if keys != nil {
+			chunkKeys = keys[start:end]
+		}
+		var chunkPayloads []json.RawMessage
+		if payloads != nil {
+			chunkPayloads = payloads[start:end]
+		}
+		chunk, err := createChunk(ctx, deps, evs[start:end], chunkKeys, chunkPayloads)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, chunk...)
+	}
+	return res, nil
+}
+
+func createChunk(
+	ctx context.Context, deps Deps, evs []*openlineage.RunEvent, keys []string, payloads []json.RawMessage,
+) ([]BatchResult, error) {
+	res := make([]BatchResult, 0, len(evs))
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		for i, ev := range evs {
+			var key string
+			if keys != nil {
+				key = keys[i]
+			}
+			var payload json.RawMessage
+			if payloads != nil {
+				payload = payloads[i]
+			}
+			var runEvent *lineage.RunEvent
+			err := qtx.Savepoint(ctx, func() error {
+				var err error
+				runEvent, err = createWithRunEvent(ctx, qtx, ev, key, payload)
+				return err
+			})
+			if err != nil {
+				ferr := qtx.Savepoint(ctx, func() error {
+					return saveFailure(ctx, qtx, ev, payload, err)
+				})
+				if ferr != nil {
+					log.Printf("could not save failed event[%v]", ferr)
+				}
+			}
+			res = append(res, BatchResult{RunEvent: runEvent, Err: err})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "commit batch failed")
+	}
+	return res, nil
+}
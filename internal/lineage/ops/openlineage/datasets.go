@@ -1,15 +1,16 @@
 package openlineage
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"oplin/internal/lineage"
 	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
 	"oplin/internal/openlineage"
 	"oplin/internal/utils"
 	"sort"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
@@ -19,7 +20,7 @@ type IODataset struct {
 	Type     lineage.IOType
 }
 
-func createDatasetNamespaceIfNotExists(ctx context.Context, qtx *db.Queries, name string) (*db.LineageDatasetNamespace, error) {
+func createDatasetNamespaceIfNotExists(ctx context.Context, qtx db.Querier, name string) (*db.LineageDatasetNamespace, error) {
 	ns, err := qtx.GetDatasetNamespaceByName(ctx, name)
 	if err != nil && !utils.IsNoRowsError(err) {
 		return nil, eris.Wrapf(err, "getting dataset namespace[%s] failed", name)
@@ -39,7 +40,7 @@ func createDatasetNamespaceIfNotExists(ctx context.Context, qtx *db.Queries, nam
 }
 
 func createDatasetIfNotExists(
-	ctx context.Context, qtx *db.Queries, nsID int64, datasetName string, msg json.RawMessage,
+	ctx context.Context, qtx db.Querier, nsID int64, datasetName string, msg json.RawMessage,
 ) (*db.LineageDataset, error) {
 	getParams := db.GetDatasetByNamespaceIDAndNameParams{
 		NamespaceID: nsID, Name: datasetName,
@@ -66,7 +67,7 @@ func createDatasetIfNotExists(
 }
 
 func updateDataset(
-	ctx context.Context, qtx *db.Queries, dsID int64, msg json.RawMessage,
+	ctx context.Context, qtx db.Querier, dsID int64, msg json.RawMessage,
 ) (*db.LineageDataset, error) {
 	params := db.UpdateDatasetParams{
 		ID:        dsID,
@@ -81,7 +82,7 @@ func updateDataset(
 }
 
 func createDatasetVersionIfNotExists(
-	ctx context.Context, qtx *db.Queries, ds *db.LineageDataset,
+	ctx context.Context, qtx db.Querier, ds *db.LineageDataset,
 ) (*db.LineageDatasetVersion, error) {
 
 	if ds.CurrentVersionID.Valid {
@@ -96,7 +97,7 @@ func createDatasetVersionIfNotExists(
 }
 
 func createDatasetVersion(
-	ctx context.Context, qtx *db.Queries, ds *db.LineageDataset,
+	ctx context.Context, qtx db.Querier, ds *db.LineageDataset,
 ) (*db.LineageDatasetVersion, error) {
 
 	params := db.CreateDatasetVersionParams{
@@ -104,6 +105,7 @@ func createDatasetVersion(
 		DatasetID:   ds.ID,
 		Name:        ds.Name,
 		CreatedAt:   utils.NowUTC(),
+		VersionUuid: uuid.New(),
 	}
 	dv, err := qtx.CreateDatasetVersion(ctx, params)
 	if err != nil {
@@ -112,36 +114,90 @@ func createDatasetVersion(
 	return &dv, nil
 }
 
-func equalStringSlices(a, b []string) bool {
-	sort.Strings(a)
-	sort.Strings(b)
-
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+// flattenFields flattens nested schema fields into dotted names, e.g. a
+// struct column address with a field city becomes address.city
+func flattenFields(fields []openlineage.SchemaField) []openlineage.SchemaField {
+	var res []openlineage.SchemaField
+	for _, f := range fields {
+		res = append(res, openlineage.SchemaField{Name: f.Name, Type: f.Type, Description: f.Description})
+		for _, nested := range flattenFields(f.Fields) {
+			nested.Name = f.Name + "." + nested.Name
+			res = append(res, nested)
 		}
 	}
-	return true
+	return res
 }
 
-func fieldNamesEqual(rows []db.LineageField, fields []openlineage.SchemaField) bool {
-	var a []string
-	var b []string
+// diffFields compares the stored fields of a version with the flattened
+// fields of a schema facet by name, type and description
+func diffFields(rows []db.LineageField, fields []openlineage.SchemaField) []lineage.SchemaChange {
+	byName := make(map[string]db.LineageField)
+	for _, row := range rows {
+		byName[row.Name] = row
+	}
 
-	for i := range rows {
-		a = append(a, rows[i].Name)
+	var res []lineage.SchemaChange
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		seen[f.Name] = true
+		row, ok := byName[f.Name]
+		if !ok {
+			res = append(res, lineage.SchemaChange{
+				ChangeType:  lineage.SchemaChangeTypeAdded,
+				FieldName:   f.Name,
+				DataType:    f.Type,
+				Description: f.Description,
+			})
+		} else if row.DataType != f.Type || row.Description.String != f.Description {
+			res = append(res, lineage.SchemaChange{
+				ChangeType:          lineage.SchemaChangeTypeChanged,
+				FieldName:           f.Name,
+				PreviousDataType:    row.DataType,
+				DataType:            f.Type,
+				PreviousDescription: row.Description.String,
+				Description:         f.Description,
+			})
+		}
+	}
+	for _, row := range rows {
+		if !seen[row.Name] {
+			res = append(res, lineage.SchemaChange{
+				ChangeType:          lineage.SchemaChangeTypeRemoved,
+				FieldName:           row.Name,
+				PreviousDataType:    row.DataType,
+				PreviousDescription: row.Description.String,
+			})
+		}
 	}
-	for i := range fields {
-		b = append(b, fields[i].Name)
+	sort.Slice(res, func(i, j int) bool { return res[i].FieldName < res[j].FieldName })
+	return res
+}
+
+func createSchemaChanges(
+	ctx context.Context, qtx db.Querier, dsvID int64, previousID int64, changes []lineage.SchemaChange,
+) error {
+	for _, c := range changes {
+		params := db.CreateSchemaChangeParams{
+			DatasetVersionID:    dsvID,
+			PreviousVersionID:   previousID,
+			ChangeType:          int32(c.ChangeType),
+			FieldName:           c.FieldName,
+			PreviousDataType:    utils.NullString(c.PreviousDataType),
+			DataType:            utils.NullString(c.DataType),
+			PreviousDescription: utils.NullString(c.PreviousDescription),
+			Description:         utils.NullString(c.Description),
+			CreatedAt:           utils.NowUTC(),
+		}
+		_, err := qtx.CreateSchemaChange(ctx, params)
+		if err != nil {
+			return eris.Wrapf(err, "could not create schema change[%v]", params)
+		}
 	}
-	return equalStringSlices(a, b)
+	return nil
 }
 
 func createFields(
-	ctx context.Context, qtx *db.Queries, dsvID int64, fields []openlineage.SchemaField,
+	ctx context.Context, qtx db.Querier, dsvID int64, fields []openlineage.SchemaField,
 ) ([]db.LineageField, error) {
 	var rows []db.LineageField
 	for _, f := range fields {
@@ -161,12 +217,12 @@ func createFields(
 	return rows, nil
 }
 
-func createRunDatasetVersionIfNotExists(
-	ctx context.Context, qtx *db.Queries, runID int64, versionID int64, ioMsg json.RawMessage, dsMsg json.RawMessage, io lineage.IOType,
+func createOrUpdateRunDatasetVersion(
+	ctx context.Context, qtx db.Querier, runEvent *db.LineageRunEvent, versionID int64, ioMsg json.RawMessage, dsMsg json.RawMessage, io lineage.IOType,
 ) (*db.LineageRunDatasetVersion, error) {
 
 	getParams := db.GetRunDatasetVersionByRunIDAndDatasetVersionIDParams{
-		RunID:            runID,
+		RunID:            runEvent.RunID,
 		DatasetVersionID: versionID,
 	}
 	rdv, err := qtx.GetRunDatasetVersionByRunIDAndDatasetVersionID(ctx, getParams)
@@ -174,15 +230,17 @@ func createRunDatasetVersionIfNotExists(
 		return nil, eris.Wrapf(err, "get run dataset version[%v] failed", getParams)
 	}
 	if err == nil {
-		return &rdv, nil
+		return updateRunDatasetVersion(ctx, qtx, &rdv, runEvent, ioMsg, dsMsg)
 	}
 
 	params := db.CreateRunDatasetVersionParams{
-		RunID:            runID,
+		RunID:            runEvent.RunID,
 		DatasetVersionID: versionID,
 		IoType:           int32(io),
 		IoFacets:         utils.ToPQRawMessageType(ioMsg),
 		DatasetFacets:    utils.ToPQRawMessageType(dsMsg),
+		FirstEventType:   runEvent.EventType,
+		LastEventType:    runEvent.EventType,
 		CreatedAt:        utils.NowUTC(),
 	}
 	rdv, err = qtx.CreateRunDatasetVersion(ctx, params)
@@ -192,7 +250,49 @@ func createRunDatasetVersionIfNotExists(
 	return &rdv, nil
 }
 
-func updateCurrentDatasetVersion(ctx context.Context, qtx *db.Queries, ds *db.LineageDataset, dsVersion *db.LineageDatasetVersion) (
+// updateRunDatasetVersion merges the facets of a later event into an existing
+// run dataset version and records the event type as the last one seen
+func updateRunDatasetVersion(
+	ctx context.Context, qtx db.Querier, rdv *db.LineageRunDatasetVersion, runEvent *db.LineageRunEvent, ioMsg json.RawMessage, dsMsg json.RawMessage,
+) (*db.LineageRunDatasetVersion, error) {
+	ioFacets, err := utils.MergeFacets(rdv.IoFacets.RawMessage, ioMsg)
+	if err != nil {
+		return nil, eris.Wrapf(err, "cannot merge io facets[%s], [%s]", rdv.IoFacets.RawMessage, ioMsg)
+	}
+	dsFacets, err := utils.MergeFacets(rdv.DatasetFacets.RawMessage, dsMsg)
+	if err != nil {
+		return nil, eris.Wrapf(err, "cannot merge dataset facets[%s], [%s]", rdv.DatasetFacets.RawMessage, dsMsg)
+	}
+
+	params := db.UpdateRunDatasetVersionParams{
+		RunID:            rdv.RunID,
+		DatasetVersionID: rdv.DatasetVersionID,
+		IoFacets:         utils.ToPQRawMessageType(ioFacets),
+		DatasetFacets:    utils.ToPQRawMessageType(dsFacets),
+		LastEventType:    runEvent.EventType,
+		UpdatedAt:        utils.NowUTCAsNullTime(),
+	}
+	row, err := qtx.UpdateRunDatasetVersion(ctx, params)
+	if err != nil {
+		return nil, eris.Wrapf(err, "update run dataset version[%v] failed", params)
+	}
+	return &row, nil
+}
+
+// hasFacet returns true if the facets message contains a facet with the given name
+func hasFacet(msg json.RawMessage, name string) bool {
+	if len(msg) == 0 {
+		return false
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return false
+	}
+	_, ok := m[name]
+	return ok
+}
+
+func updateCurrentDatasetVersion(ctx context.Context, qtx db.Querier, ds *db.LineageDataset, dsVersion *db.LineageDatasetVersion) (
 	*db.LineageDataset, error) {
 	row, err := qtx.UpdateCurrentDatasetVersion(ctx, db.UpdateCurrentDatasetVersionParams{
 		CurrentVersionID: utils.NullInt64(&dsVersion.ID),
@@ -205,27 +305,48 @@ func updateCurrentDatasetVersion(ctx context.Context, qtx *db.Queries, ds *db.Li
 	return &row, nil
 }
 
-func handleIO(
-	ctx context.Context, qtx *db.Queries, dsIO IODataset, runEvent *db.LineageRunEvent,
-) (*db.LineageRunDatasetVersion, error) {
-	ns, err := createDatasetNamespaceIfNotExists(ctx, qtx, dsIO.Namespace)
+// handleDataset creates or updates the dataset and, when the facets carry a
+// schema, its version and fields. It returns the version the facets refer to.
+func handleDataset(
+	ctx context.Context, qtx db.Querier, dataset openlineage.Dataset,
+) (*db.LineageDatasetVersion, error) {
+	ns, err := createDatasetNamespaceIfNotExists(ctx, qtx, dataset.Namespace)
 	if err != nil {
-		return nil, eris.Wrapf(err, "create dataset namespace[%v] failed", dsIO.Namespace)
+		return nil, eris.Wrapf(err, "create dataset namespace[%v] failed", dataset.Namespace)
 	}
 
-	ds, err := createDatasetIfNotExists(ctx, qtx, ns.ID, dsIO.Name, dsIO.Facets)
+	ds, err := createDatasetIfNotExists(ctx, qtx, ns.ID, dataset.Name, dataset.Facets)
 	if err != nil {
 		return nil, err
 	}
+	indexedFacets, indexedVersionID := ds.Facets.RawMessage, ds.CurrentVersionID
 
-	// only update when facets change
-	if !bytes.Equal(ds.Facets.RawMessage, dsIO.Facets) {
-		ds, err = updateDataset(ctx, qtx, ds.ID, dsIO.Facets)
+	// events only carry the facets the producer knows about at that point so
+	// merge them into the existing ones and only update when they change
+	if len(dataset.Facets) > 0 {
+		msg, err := utils.MergeFacets(ds.Facets.RawMessage, dataset.Facets)
 		if err != nil {
-			return nil, err
+			return nil, eris.Wrapf(err, "cannot merge dataset facets[%s], [%s]", ds.Facets.RawMessage, dataset.Facets)
+		}
+		if !utils.JSONEqual(ds.Facets.RawMessage, msg) {
+			ds, err = updateDataset(ctx, qtx, ds.ID, msg)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	err = saveFacets(ctx, qtx, lineage.FacetEntityTypeDataset, ds.ID, dataset.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	err = handleSymlinks(ctx, qtx, ns.Name, ds, dataset.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	newVersion := !ds.CurrentVersionID.Valid
 	dsVersion, err := createDatasetVersionIfNotExists(ctx, qtx, ds)
 	if err != nil {
 		return nil, err
@@ -239,39 +360,104 @@ func handleIO(
 		}
 	}
 
-	fs := openlineage.NewDatasetFacets()
-	err = json.Unmarshal(dsIO.Facets, fs)
+	// events without a schema facet (e.g. RUNNING) refer to the current version
+	if hasFacet(dataset.Facets, "schema") {
+		dsVersion, err = handleSchema(ctx, qtx, ds, dsVersion, newVersion, dataset.Facets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the index only changes with the fields of a new version or the facets
+	if newVersion || dsVersion.ID != indexedVersionID.Int64 || ops.SearchFacetsChanged(indexedFacets, ds.Facets.RawMessage) {
+		err = ops.IndexDataset(ctx, qtx, ds.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dsVersion, nil
+}
+
+func handleIO(
+	ctx context.Context, qtx db.Querier, dsIO IODataset, runEvent *db.LineageRunEvent,
+) (*db.LineageRunDatasetVersion, error) {
+	dsVersion, err := handleDataset(ctx, qtx, dsIO.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	err = handleLifecycleStateChange(ctx, qtx, dsVersion.DatasetID, utils.NullInt64(&runEvent.RunID), dsIO.Dataset.Facets)
 	if err != nil {
-		return nil, eris.Wrapf(err, "could not unmarshall[%s]", dsIO.Facets)
+		return nil, err
 	}
+	return createOrUpdateRunDatasetVersion(ctx, qtx, runEvent, dsVersion.ID, dsIO.IOFacets, dsIO.Dataset.Facets, dsIO.Type)
+}
 
-	rows, err := qtx.ListFieldsByDatasetVersionID(ctx, dsVersion.ID)
+// handleSchema creates the fields of a new dataset version or a new version
+// when the schema has changed, returning the version the schema belongs to.
+// An existing version whose schema was never recorded, because its events had
+// none or an empty one, has no fields to diff against: a schema with fields
+// gets a new version with every field added.
+func handleSchema(
+	ctx context.Context, qtx db.Querier, ds *db.LineageDataset, dsVersion *db.LineageDatasetVersion,
+	newVersion bool, msg json.RawMessage,
+) (*db.LineageDatasetVersion, error) {
+	fs := openlineage.NewDatasetFacets()
+	err := json.Unmarshal(msg, fs)
 	if err != nil {
-		return nil, eris.Wrapf(err, "Fethching fields failed for dsvID[%d]", dsVersion.ID)
+		return nil, eris.Wrapf(err, "could not unmarshall[%s]", msg)
 	}
 
-	if len(rows) == 0 {
-		rows, err = createFields(ctx, qtx, dsVersion.ID, fs.Schema.Fields)
-		if err != nil {
+	fields := flattenFields(fs.Schema.Fields)
+
+	if newVersion {
+		if _, err := createFields(ctx, qtx, dsVersion.ID, fields); err != nil {
 			return nil, err
 		}
+		return setSchemaRecorded(ctx, qtx, dsVersion)
 	}
 
-	// create a new version if the schema has changed
-	if !fieldNamesEqual(rows, fs.Schema.Fields) {
-		dsVersion, err = createDatasetVersion(ctx, qtx, ds)
-		if err != nil {
-			return nil, err
-		}
-		rows, err = createFields(ctx, qtx, dsVersion.ID, fs.Schema.Fields)
+	var rows []db.LineageField
+	if dsVersion.SchemaRecorded {
+		rows, err = qtx.ListFieldsByDatasetVersionID(ctx, dsVersion.ID)
 		if err != nil {
-			return nil, err
+			return nil, eris.Wrapf(err, "Fethching fields failed for dsvID[%d]", dsVersion.ID)
 		}
-		ds, err = updateCurrentDatasetVersion(ctx, qtx, ds, dsVersion)
-		if err != nil {
-			return nil, err
+	}
+
+	// create a new version and record what changed if the schema has changed
+	changes := diffFields(rows, fields)
+	if len(changes) == 0 {
+		if !dsVersion.SchemaRecorded {
+			return setSchemaRecorded(ctx, qtx, dsVersion)
 		}
+		return dsVersion, nil
+	}
+	previousID := dsVersion.ID
+	dsVersion, err = createDatasetVersion(ctx, qtx, ds)
+	if err != nil {
+		return nil, err
 	}
+	_, err = createFields(ctx, qtx, dsVersion.ID, fields)
+	if err != nil {
+		return nil, err
+	}
+	err = createSchemaChanges(ctx, qtx, dsVersion.ID, previousID, changes)
+	if err != nil {
+		return nil, err
+	}
+	_, err = updateCurrentDatasetVersion(ctx, qtx, ds, dsVersion)
+	if err != nil {
+		return nil, err
+	}
+	return setSchemaRecorded(ctx, qtx, dsVersion)
+}
 
-	return createRunDatasetVersionIfNotExists(ctx, qtx, runEvent.RunID, dsVersion.ID, dsIO.IOFacets, dsIO.Dataset.Facets, dsIO.Type)
+func setSchemaRecorded(
+	ctx context.Context, qtx db.Querier, dsVersion *db.LineageDatasetVersion,
+) (*db.LineageDatasetVersion, error) {
+	if err := qtx.SetDatasetVersionSchemaRecorded(ctx, dsVersion.ID); err != nil {
+		return nil, eris.Wrapf(err, "set schema recorded of dataset version[%d] failed", dsVersion.ID)
+	}
+	dsVersion.SchemaRecorded = true
+	return dsVersion, nil
 }
@@ -0,0 +1,87 @@
+package openlineage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key already
+// recorded a different event
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different event")
+
+// payloadHash returns the sha256 of the event as canonical JSON, the keys of
+// every object are sorted so a client re-serializing the event on a retry
+// does not change its hash
+func payloadHash(ev interface{}) (string, error) {
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		return "", eris.Wrap(err, "could not marshal event")
+	}
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", eris.Wrap(err, "could not decode event")
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return "", eris.Wrap(err, "could not marshal canonical event")
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// findDuplicate returns the run event already recorded for the event or nil
+// when the event is new. An event is a duplicate of the one recorded with the
+// same idempotency key, or of one with the same run, event type, event time
+// and payload hash.
+func findDuplicate(
+	ctx context.Context, qtx db.Querier, ev *openlineage.RunEvent, hash string, key string,
+) (*db.LineageRunEvent, error) {
+	if key != "" {
+		runEvent, err := qtx.GetRunEventByIdempotencyKey(ctx, toNullString(key))
+		if err == nil {
+			if runEvent.PayloadHash.String != hash {
+				return nil, eris.Wrapf(ErrIdempotencyKeyReused, "idempotency key[%s]", key)
+			}
+			return &runEvent, nil
+		}
+		if !utils.IsNoRowsError(err) {
+			return nil, eris.Wrapf(err, "get run event by idempotency key[%s] failed", key)
+		}
+	}
+
+	t, err := lineage.RunEventTypeFromString(ev.EventType)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not convert event type[%s]", ev.EventType)
+	}
+	params := db.GetRunEventByPayloadHashParams{
+		RunUuid:     ev.Run.ID,
+		EventType:   int32(t),
+		EventTime:   ev.EventTime,
+		PayloadHash: toNullString(hash),
+	}
+	runEvent, err := qtx.GetRunEventByPayloadHash(ctx, params)
+	if utils.IsNoRowsError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "get run event by payload hash[%v] failed", params)
+	}
+	return &runEvent, nil
+}
@@ -0,0 +1,49 @@
+package openlineage
+
+import (
+	"context"
+	"encoding/json"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// saveFacets stores every facet of the message by name, including those the
+// spec does not define. Facets missing from the message are kept.
+func saveFacets(
+	ctx context.Context, qtx db.Querier, entityType lineage.FacetEntityType, entityID int64, msg json.RawMessage,
+) error {
+	if len(msg) == 0 {
+		return nil
+	}
+	var facets map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &facets); err != nil {
+		return eris.Wrapf(err, "unmarshalling facets[%s] failed", msg)
+	}
+
+	for name, payload := range facets {
+		// every facet should carry the base facet fields but a custom one
+		// may not even be an object
+		var base struct {
+			Producer  string `json:"_producer"`
+			SchemaURL string `json:"_schemaURL"`
+		}
+		_ = json.Unmarshal(payload, &base)
+
+		params := db.UpsertFacetParams{
+			EntityType: int32(entityType),
+			EntityID:   entityID,
+			Name:       name,
+			Producer:   utils.NullString(base.Producer),
+			SchemaUrl:  utils.NullString(base.SchemaURL),
+			Payload:    payload,
+			CreatedAt:  utils.NowUTC(),
+		}
+		if _, err := qtx.UpsertFacet(ctx, params); err != nil {
+			return eris.Wrapf(err, "upsert facet[%s] of %s[%d] failed", name, entityType, entityID)
+		}
+	}
+	return nil
+}
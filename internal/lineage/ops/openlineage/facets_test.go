@@ -0,0 +1,51 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFacets(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	ev := openlineage.DatasetEvent{
+		EventTime: time.Now().UTC(),
+		Dataset: openlineage.NewDataset("food_delivery", "public.customers", []byte(`{
+			"acme_retention": {"_producer": "https://acme.com/producer", "_schemaURL": "https://acme.com/retention.json", "days": 30},
+			"storage": {"_producer": "https://acme.com/producer", "storageLayer": "iceberg", "fileFormat": "parquet"}
+		}`)),
+	}
+	dv, err := ol_ops.CreateWithOpenLineageDatasetEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+
+	facets, err := ops.ListFacets(ctx, deps, lineage.FacetEntityTypeDataset, dv.DatasetID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(facets))
+	assert.Equal(t, "acme_retention", facets[0].Name)
+	assert.Equal(t, "https://acme.com/producer", facets[0].Producer)
+	assert.Equal(t, "https://acme.com/retention.json", facets[0].SchemaURL)
+	assert.JSONEq(t,
+		`{"_producer": "https://acme.com/producer", "_schemaURL": "https://acme.com/retention.json", "days": 30}`,
+		string(facets[0].Payload))
+
+	// a later event replaces the facets it carries and keeps the others
+	ev.Dataset.Facets = []byte(`{"acme_retention": {"_producer": "https://acme.com/producer", "days": 60}}`)
+	_, err = ol_ops.CreateWithOpenLineageDatasetEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+
+	facets, err = ops.ListFacets(ctx, deps, lineage.FacetEntityTypeDataset, dv.DatasetID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(facets))
+	assert.JSONEq(t, `{"_producer": "https://acme.com/producer", "days": 60}`, string(facets[0].Payload))
+	assert.Equal(t, "", facets[0].SchemaURL)
+	assert.Equal(t, "storage", facets[1].Name)
+}
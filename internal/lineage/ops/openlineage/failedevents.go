@@ -0,0 +1,151 @@
+package openlineage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// NormalizeEventType records event types the spec does not define as OTHER,
+// they are only accepted when validation is lenient
+func NormalizeEventType(ev *openlineage.RunEvent) {
+	if _, err := lineage.RunEventTypeFromString(ev.EventType); err != nil {
+		ev.EventType = lineage.RunEventTypeOther.String()
+	}
+}
+
+// producerOf reads the producer of the payload, it is empty when the payload
+// is not an event
+func producerOf(payload []byte) sql.NullString {
+	var ev struct {
+		Producer string `json:"producer"`
+	}
+	_ = json.Unmarshal(payload, &ev)
+	return toNullString(ev.Producer)
+}
+
+// saveFailedEvent keeps the payload that could not be recorded with the
+// error, the request is set when it was queued
+func saveFailedEvent(
+	ctx context.Context, qtx db.Querier, payload []byte, err error, requestID sql.NullInt64,
+) error {
+	params := db.CreateFailedEventParams{
+		Payload:      payload,
+		Producer:     producerOf(payload),
+		ErrorMessage: err.Error(),
+		Stacktrace:   toNullString(eris.ToString(err, true)),
+		RequestID:    requestID,
+		CreatedAt:    utils.NowUTC(),
+	}
+	if _, err := qtx.CreateFailedEvent(ctx, params); err != nil {
+		return eris.Wrap(err, "create failed event failed")
+	}
+	return nil
+}
+
+// saveFailure keeps an event that could not be recorded, ev is any of the
+// OpenLineage event kinds and payload its body when known. A reused
+// idempotency key is the client's mistake and is not kept.
+func saveFailure(ctx context.Context, qtx db.Querier, ev interface{}, payload json.RawMessage, err error) error {
+	if errors.Is(err, ErrIdempotencyKeyReused) {
+		return nil
+	}
+	msg, merr := requestPayload(ev, payload)
+	if merr != nil {
+		return merr
+	}
+	return saveFailedEvent(ctx, qtx, msg, err, sql.NullInt64{})
+}
+
+// recordFailure keeps an event that could not be recorded, failing to keep
+// it is only logged as the error of the event is the one returned
+func recordFailure(ctx context.Context, qtx db.Querier, ev interface{}, payload json.RawMessage, err error) {
+	if ferr := saveFailure(ctx, qtx, ev, payload, err); ferr != nil {
+		log.Printf("could not save failed event[%v]", ferr)
+	}
+}
+
+// ResubmitFailedEvent records the failed event again with the given payload,
+// which is usually the stored one fixed by hand. The failed event is
+// discarded once recorded, otherwise it keeps the payload and the new error.
+func ResubmitFailedEvent(ctx context.Context, deps Deps, id int64, payload []byte) error {
+	qtx := deps.GetStore().Queries()
+	failed, err := qtx.GetFailedEventByID(ctx, id)
+	if err != nil {
+		return eris.Wrapf(err, "get failed event[%d] failed", id)
+	}
+	if !json.Valid(payload) {
+		return eris.New("payload is not JSON")
+	}
+
+	err = resubmit(ctx, deps, payload)
+	if err == nil {
+		return DiscardFailedEvent(ctx, deps, id)
+	}
+
+	params := db.UpdateFailedEventParams{
+		ID:           id,
+		Payload:      payload,
+		Producer:     producerOf(payload),
+		ErrorMessage: err.Error(),
+		Stacktrace:   toNullString(eris.ToString(err, true)),
+		Attempts:     failed.Attempts + 1,
+		UpdatedAt:    sql.NullTime{Time: utils.NowUTC(), Valid: true},
+	}
+	if uerr := qtx.UpdateFailedEvent(ctx, params); uerr != nil {
+		return eris.Wrapf(uerr, "update failed event[%d] failed", id)
+	}
+	return err
+}
+
+// resubmit records the payload as the event kind it matches
+func resubmit(ctx context.Context, deps Deps, payload []byte) error {
+	kind, err := openlineage.DetectEventKind(payload)
+	if err != nil {
+		return eris.Wrap(err, "could not detect the event kind")
+	}
+
+	switch kind {
+	case openlineage.EventKindDataset:
+		ev := &openlineage.DatasetEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return eris.Wrap(err, "could not parse dataset event")
+		}
+		_, err := createDatasetEventTx(ctx, deps, ev, payload)
+		return err
+	case openlineage.EventKindJob:
+		ev := &openlineage.JobEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return eris.Wrap(err, "could not parse job event")
+		}
+		_, err := createJobEventTx(ctx, deps, ev, payload)
+		return err
+	default:
+		ev := openlineage.NewRunEvent()
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return eris.Wrap(err, "could not parse run event")
+		}
+		if ev.Run == nil || ev.Job == nil {
+			return eris.New("run event has no run or job")
+		}
+		NormalizeEventType(ev)
+		_, err := createRunEventTx(ctx, deps, ev, "", payload)
+		return err
+	}
+}
+
+// DiscardFailedEvent deletes the failed event
+func DiscardFailedEvent(ctx context.Context, deps Deps, id int64) error {
+	if err := deps.GetStore().Queries().DeleteFailedEvent(ctx, id); err != nil {
+		return eris.Wrapf(err, "delete failed event[%d] failed", id)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package openlineage_test
+
+import (
+	"context"
+	"encoding/json"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedEvents(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// an event type the store does not know is kept as a failed event
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.EventType = "unknown"
+	_, createErr := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.NotNil(t, createErr)
+
+	page, err := ops.ListFailedEvents(ctx, deps, lineage.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.FailedEvents))
+	failed := page.FailedEvents[0]
+	assert.Equal(t, ev.Producer, failed.Producer)
+	assert.Equal(t, createErr.Error(), failed.ErrorMessage)
+	assert.NotEqual(t, "", failed.Stacktrace)
+	assert.Equal(t, 1, failed.Attempts)
+
+	// a payload that fails again keeps the failed event with the new error
+	err = ol_ops.ResubmitFailedEvent(ctx, deps, failed.ID, []byte(`{"eventType": "START", "producer": "p"}`))
+	assert.NotNil(t, err)
+	res, err := ops.GetFailedEvent(ctx, deps, failed.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "p", res.Producer)
+	assert.Equal(t, 2, res.Attempts)
+	page, err = ops.ListFailedEvents(ctx, deps, lineage.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.FailedEvents))
+
+	// a fixed payload is recorded and the failed event discarded
+	ev.EventType = "START"
+	msg, err := json.Marshal(ev)
+	assert.Nil(t, err)
+	err = ol_ops.ResubmitFailedEvent(ctx, deps, failed.ID, msg)
+	assert.Nil(t, err)
+	_, err = ops.GetFailedEvent(ctx, deps, failed.ID)
+	assert.NotNil(t, err)
+	runEvent, err := ol_ops.FindDuplicateRunEvent(ctx, deps, &ev, "")
+	assert.Nil(t, err)
+	assert.NotNil(t, runEvent)
+
+	// a queued request that fails for good is kept with its request
+	req, err := ol_ops.EnqueueRequest(ctx, deps, map[string]string{"eventType": "START"}, "", nil)
+	assert.Nil(t, err)
+	found, err := ol_ops.ProcessNextRequest(ctx, deps)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	page, err = ops.ListFailedEvents(ctx, deps, lineage.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.FailedEvents))
+	assert.Equal(t, req.ID, page.FailedEvents[0].RequestID)
+
+	err = ol_ops.DiscardFailedEvent(ctx, deps, page.FailedEvents[0].ID)
+	assert.Nil(t, err)
+	page, err = ops.ListFailedEvents(ctx, deps, lineage.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.FailedEvents))
+}
@@ -6,10 +6,11 @@ import (
 	"oplin/internal/lineage/db"
 	"oplin/internal/utils"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
-func createJobNamespaceIfNotExists(ctx context.Context, qtx *db.Queries, name string) (*db.LineageJobNamespace, error) {
+func createJobNamespaceIfNotExists(ctx context.Context, qtx db.Querier, name string) (*db.LineageJobNamespace, error) {
 	ns, err := qtx.GetJobNamespaceByName(ctx, name)
 	if err != nil && !utils.IsNoRowsError(err) {
 		return nil, eris.Wrapf(err, "getting job namespace[%s] failed", name)
@@ -29,7 +30,7 @@ func createJobNamespaceIfNotExists(ctx context.Context, qtx *db.Queries, name st
 }
 
 func createJobIfNotExists(
-	ctx context.Context, qtx *db.Queries, nsID int64, jobName string, msg json.RawMessage,
+	ctx context.Context, qtx db.Querier, nsID int64, jobName string, msg json.RawMessage,
 ) (*db.LineageJob, error) {
 	getParams := db.GetJobByNamespaceIDAndNameParams{
 		NamespaceID: nsID, Name: jobName,
@@ -55,24 +56,78 @@ func createJobIfNotExists(
 	return &job, nil
 }
 
+// updateJobFacets merges the facets of an event into the job's facets. Events
+// only carry the facets the producer knows about at that point so facets
+// missing from the event are kept.
+func updateJobFacets(
+	ctx context.Context, qtx db.Querier, job *db.LineageJob, msg json.RawMessage,
+) (*db.LineageJob, error) {
+	if len(msg) == 0 {
+		return job, nil
+	}
+	facets, err := utils.MergeFacets(job.Facets.RawMessage, msg)
+	if err != nil {
+		return nil, eris.Wrapf(err, "cannot merge job facets[%s], [%s]", job.Facets.RawMessage, msg)
+	}
+	if utils.JSONEqual(job.Facets.RawMessage, facets) {
+		return job, nil
+	}
+
+	params := db.UpdateJobParams{
+		ID:        job.ID,
+		Facets:    utils.ToPQRawMessageType(facets),
+		UpdatedAt: utils.NowUTCAsNullTime(),
+	}
+	row, err := qtx.UpdateJob(ctx, params)
+	if err != nil {
+		return nil, eris.Wrapf(err, "update job[%v] failed", params)
+	}
+	return &row, nil
+}
+
+// createJobVersionIfNotExists returns the current version of the job or a new
+// one when the job's facets differ from those of the current version
 func createJobVersionIfNotExists(
-	ctx context.Context, qtx *db.Queries, job *db.LineageJob,
+	ctx context.Context, qtx db.Querier, job *db.LineageJob,
 ) (*db.LineageJobVersion, error) {
 
+	var declared []declaredIO
 	if job.CurrentVersionID.Valid {
 		jv, err := qtx.GetJobVersionByID(ctx, job.CurrentVersionID.Int64)
 		if err != nil {
 			return nil, eris.Wrapf(err, "get job version[%v] failed", job.CurrentVersionID.Int64)
 		}
-		return &jv, err
+		if utils.FacetsEqual(jv.Facets.RawMessage, job.Facets.RawMessage) {
+			return &jv, err
+		}
+		// runs do not declare datasets so keep those declared by job events
+		declared, err = listDeclaredIO(ctx, qtx, jv.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	jv, err := createJobVersion(ctx, qtx, job)
+	if err != nil {
+		return nil, err
+	}
+	err = createDeclaredIO(ctx, qtx, jv.ID, declared)
+	if err != nil {
+		return nil, err
+	}
+	return jv, nil
+}
+
+func createJobVersion(
+	ctx context.Context, qtx db.Querier, job *db.LineageJob,
+) (*db.LineageJobVersion, error) {
 	params := db.CreateJobVersionParams{
 		NamespaceID: job.NamespaceID,
 		JobID:       job.ID,
 		Name:        job.Name,
 		Facets:      job.Facets,
 		CreatedAt:   utils.NowUTC(),
+		VersionUuid: uuid.New(),
 	}
 	jv, err := qtx.CreateJobVersion(ctx, params)
 	if err != nil {
@@ -80,3 +135,36 @@ func createJobVersionIfNotExists(
 	}
 	return &jv, nil
 }
+
+// createCurrentJobVersion gets or creates the job version matching the job's
+// facets and makes it the job's current version
+func createCurrentJobVersion(
+	ctx context.Context, qtx db.Querier, job *db.LineageJob,
+) (*db.LineageJobVersion, error) {
+	jobVersion, err := createJobVersionIfNotExists(ctx, qtx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobVersion.ID != job.CurrentVersionID.Int64 {
+		err := updateCurrentJobVersion(ctx, qtx, job, jobVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jobVersion, nil
+}
+
+func updateCurrentJobVersion(
+	ctx context.Context, qtx db.Querier, job *db.LineageJob, jobVersion *db.LineageJobVersion,
+) error {
+	_, err := qtx.UpdateCurrentJobVersion(ctx, db.UpdateCurrentJobVersionParams{
+		CurrentVersionID: utils.NullInt64(&jobVersion.ID),
+		UpdatedAt:        utils.NowUTCAsNullTime(),
+		ID:               job.ID,
+	})
+	if err != nil {
+		return eris.Wrapf(err, "update current job version[%v] failed", jobVersion.ID)
+	}
+	return nil
+}
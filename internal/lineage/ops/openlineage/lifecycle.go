@@ -0,0 +1,88 @@
+package openlineage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"oplin/internal/lineage/db"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// getDatasetByIdentifier returns nil when the dataset is not known
+func getDatasetByIdentifier(ctx context.Context, qtx db.Querier, id openlineage.Identifier) (*db.LineageDataset, error) {
+	ns, err := qtx.GetDatasetNamespaceByName(ctx, id.Namespace)
+	if utils.IsNoRowsError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "getting dataset namespace[%s] failed", id.Namespace)
+	}
+	ds, err := qtx.GetDatasetByNamespaceIDAndName(ctx, db.GetDatasetByNamespaceIDAndNameParams{
+		NamespaceID: ns.ID, Name: id.Name,
+	})
+	if utils.IsNoRowsError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "getting dataset[%s.%s] failed", id.Namespace, id.Name)
+	}
+	return &ds, nil
+}
+
+// handleLifecycleStateChange records the lifecycle state change facet of a
+// dataset. Every event of a run usually repeats the facet so a change equal
+// to the last one recorded for the dataset is skipped.
+func handleLifecycleStateChange(
+	ctx context.Context, qtx db.Querier, dsID int64, runID sql.NullInt64, msg json.RawMessage,
+) error {
+	if !hasFacet(msg, "lifecycleStateChange") {
+		return nil
+	}
+	fs := openlineage.NewDatasetFacets()
+	if err := json.Unmarshal(msg, fs); err != nil {
+		return eris.Wrapf(err, "could not unmarshall[%s]", msg)
+	}
+	facet := fs.LifecycleStateChange
+	change := strings.ToUpper(facet.LifecycleStateChange)
+	if change == "" {
+		return nil
+	}
+	previous := facet.PreviousIdentifier
+
+	last, err := qtx.GetLatestLifecycleStateChangeByDatasetID(ctx, dsID)
+	if err != nil && !utils.IsNoRowsError(err) {
+		return eris.Wrapf(err, "get latest lifecycle state change of dataset[%d] failed", dsID)
+	}
+	if err == nil && last.Change.String == change && last.RunID == runID &&
+		last.Namespace.String == previous.Namespace && last.Name.String == previous.Name {
+		return nil
+	}
+
+	params := db.CreateLifecycleStateChangeParams{
+		DatasetID: dsID,
+		RunID:     runID,
+		Change:    utils.NullString(change),
+		CreatedAt: utils.NowUTC(),
+	}
+	if previous.Namespace != "" || previous.Name != "" {
+		params.Namespace = utils.NullString(previous.Namespace)
+		params.Name = utils.NullString(previous.Name)
+
+		ds, err := getDatasetByIdentifier(ctx, qtx, previous)
+		if err != nil {
+			return err
+		}
+		if ds != nil && ds.ID != dsID {
+			params.PreviousDatasetID = utils.NullInt64(&ds.ID)
+		}
+	}
+	_, err = qtx.CreateLifecycleStateChange(ctx, params)
+	if err != nil {
+		return eris.Wrapf(err, "create lifecycle state change[%v] failed", params)
+	}
+	return nil
+}
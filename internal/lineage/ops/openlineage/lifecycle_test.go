@@ -0,0 +1,66 @@
+package openlineage_test
+
+import (
+	"context"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleStateChanges(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	runUUID := uuid.New()
+	ev := getRunEvent(runUUID, time.Now().UTC())
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("food_delivery", "public.orders", []byte(
+		`{"lifecycleStateChange": {"lifecycleStateChange": "CREATE"}}`,
+	))}}
+	runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	// the complete event repeats the facet
+	ev.EventType = "complete"
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	orders, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "food_delivery", "public.orders")
+	assert.Nil(t, err)
+	changes, err := ops.ListLifecycleStateChanges(ctx, deps, orders.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "CREATE", changes[0].Change)
+	assert.Equal(t, runEvent.RunID, changes[0].RunID)
+	assert.Equal(t, runUUID, changes[0].RunUUID)
+
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("food_delivery", "public.orders_v2", []byte(
+		`{"lifecycleStateChange": {
+			"lifecycleStateChange": "RENAME",
+			"previousIdentifier": {"namespace": "food_delivery", "name": "public.orders"}
+		}}`,
+	))}}
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	renamed, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "food_delivery", "public.orders_v2")
+	assert.Nil(t, err)
+	changes, err = ops.ListLifecycleStateChanges(ctx, deps, renamed.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "RENAME", changes[0].Change)
+	assert.Equal(t, "public.orders", changes[0].PreviousName)
+	assert.Equal(t, orders.Dataset.ID, changes[0].PreviousDatasetID)
+
+	// the rename shows up in the history of the old dataset as well
+	changes, err = ops.ListLifecycleStateChanges(ctx, deps, orders.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, renamed.Dataset.ID, changes[1].DatasetID)
+}
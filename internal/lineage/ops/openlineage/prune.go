@@ -0,0 +1,206 @@
+package openlineage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// RetentionPolicy is how much of the lineage Prune keeps, a zero value keeps
+// everything
+type RetentionPolicy struct {
+	// RequestDays drops the stored requests older than the number of days,
+	// queued requests are kept
+	RequestDays int
+	// RunsPerJob keeps the last runs of every job with their events, ordered
+	// by start time or, for a run that never started, its last event time so
+	// late events do not count as new runs
+	RunsPerJob int
+	// CollapseDatasetVersions drops the dataset versions no run reads or
+	// writes except the current one, the schema changes across a dropped
+	// version are merged
+	CollapseDatasetVersions bool
+}
+
+// IsZero is true when the policy keeps everything
+func (p RetentionPolicy) IsZero() bool {
+	return p == RetentionPolicy{}
+}
+
+// PruneResult counts what Prune deleted or, on a dry run, would delete
+type PruneResult struct {
+	Requests        int64
+	Runs            int64
+	RunEvents       int64
+	DatasetVersions int64
+}
+
+// errDryRun rolls back the deletes of a dry run
+var errDryRun = errors.New("dry run")
+
+// Prune deletes what the policy does not keep in one transaction. A dry run
+// rolls the transaction back so it only reports what would be deleted.
+func Prune(ctx context.Context, deps Deps, policy RetentionPolicy, dryRun bool) (*PruneResult, error) {
+	res := &PruneResult{}
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		var err error
+		if policy.RequestDays > 0 {
+			before := utils.NowUTC().AddDate(0, 0, -policy.RequestDays)
+			res.Requests, err = qtx.DeleteRequestsCreatedBefore(ctx, before)
+			if err != nil {
+				return eris.Wrapf(err, "delete requests created before[%v] failed", before)
+			}
+			if res.Requests > 0 {
+				params := db.CreateRequestPruneParams{CreatedBefore: before, Requests: res.Requests, CreatedAt: utils.NowUTC()}
+				if err := qtx.CreateRequestPrune(ctx, params); err != nil {
+					return eris.Wrapf(err, "create request prune[%v] failed", params)
+				}
+			}
+		}
+		if policy.RunsPerJob > 0 {
+			if err := pruneRuns(ctx, qtx, int64(policy.RunsPerJob), res); err != nil {
+				return err
+			}
+		}
+		if policy.CollapseDatasetVersions {
+			res.DatasetVersions, err = collapseDatasetVersions(ctx, qtx)
+			if err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// pruneRuns deletes the runs older than the last runsPerJob of their job,
+// what refers to them is cleared first
+func pruneRuns(ctx context.Context, qtx store.Tx, runsPerJob int64, res *PruneResult) error {
+	clears := []func(context.Context, int64) error{
+		qtx.ClearRequestRunEventsForOldRuns,
+		qtx.ClearLifecycleStateChangeRunsForOldRuns,
+		qtx.ClearParentRunsForOldRuns,
+		qtx.DeleteFacetsForOldRuns,
+		qtx.DeleteRunDatasetVersionsForOldRuns,
+	}
+	for _, f := range clears {
+		if err := f(ctx, runsPerJob); err != nil {
+			return eris.Wrapf(err, "clear old runs[%d] failed", runsPerJob)
+		}
+	}
+
+	var err error
+	res.RunEvents, err = qtx.DeleteRunEventsForOldRuns(ctx, runsPerJob)
+	if err != nil {
+		return eris.Wrapf(err, "delete run events of old runs[%d] failed", runsPerJob)
+	}
+	res.Runs, err = qtx.DeleteOldRuns(ctx, runsPerJob)
+	if err != nil {
+		return eris.Wrapf(err, "delete old runs[%d] failed", runsPerJob)
+	}
+	return nil
+}
+
+// collapseDatasetVersions deletes the versions no run refers to except the
+// current one of their dataset. The next version's schema changes are
+// recomputed from the version before the one deleted, or dropped when it was
+// the first. The versions around it are looked up by their order, a version
+// with the schema of the one before has no schema changes to find them by.
+func collapseDatasetVersions(ctx context.Context, qtx db.Querier) (int64, error) {
+	versions, err := qtx.ListUnreferencedDatasetVersions(ctx)
+	if err != nil {
+		return 0, eris.Wrap(err, "list unreferenced dataset versions failed")
+	}
+	for _, v := range versions {
+		if err := collapseDatasetVersion(ctx, qtx, v.ID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(versions)), nil
+}
+
+func collapseDatasetVersion(ctx context.Context, qtx db.Querier, dsvID int64) error {
+	previousID, err := qtx.GetPreviousDatasetVersionID(ctx, dsvID)
+	if err != nil && !utils.IsNoRowsError(err) {
+		return eris.Wrapf(err, "get previous dataset version of[%d] failed", dsvID)
+	}
+	hasPrevious := err == nil
+	nextID, err := qtx.GetNextDatasetVersionID(ctx, dsvID)
+	if err != nil && !utils.IsNoRowsError(err) {
+		return eris.Wrapf(err, "get next dataset version of[%d] failed", dsvID)
+	}
+	hasNext := err == nil
+
+	if hasNext {
+		if err := qtx.DeleteSchemaChangesByDatasetVersionID(ctx, nextID); err != nil {
+			return eris.Wrapf(err, "delete schema changes of dataset version[%d] failed", nextID)
+		}
+		if hasPrevious {
+			if err := rediffDatasetVersion(ctx, qtx, nextID, previousID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := qtx.DeleteSchemaChangesByDatasetVersionID(ctx, dsvID); err != nil {
+		return eris.Wrapf(err, "delete schema changes of dataset version[%d] failed", dsvID)
+	}
+	if err := qtx.DeleteFieldsByDatasetVersionID(ctx, dsvID); err != nil {
+		return eris.Wrapf(err, "delete fields of dataset version[%d] failed", dsvID)
+	}
+	if err := qtx.DeleteDatasetVersion(ctx, dsvID); err != nil {
+		return eris.Wrapf(err, "delete dataset version[%d] failed", dsvID)
+	}
+	return nil
+}
+
+// rediffDatasetVersion records the schema changes of the version from the
+// given previous version
+func rediffDatasetVersion(ctx context.Context, qtx db.Querier, dsvID int64, previousID int64) error {
+	previous, err := qtx.ListFieldsByDatasetVersionID(ctx, previousID)
+	if err != nil {
+		return eris.Wrapf(err, "list fields of dataset version[%d] failed", previousID)
+	}
+	rows, err := qtx.ListFieldsByDatasetVersionID(ctx, dsvID)
+	if err != nil {
+		return eris.Wrapf(err, "list fields of dataset version[%d] failed", dsvID)
+	}
+	var fields []openlineage.SchemaField
+	for _, row := range rows {
+		fields = append(fields, openlineage.SchemaField{
+			Name: row.Name, Type: row.DataType, Description: row.Description.String,
+		})
+	}
+	return createSchemaChanges(ctx, qtx, dsvID, previousID, diffFields(previous, fields))
+}
+
+// RunPruner prunes with the policy every interval until the context is done
+func RunPruner(ctx context.Context, deps Deps, policy RetentionPolicy, interval time.Duration) {
+	for {
+		res, err := Prune(ctx, deps, policy, false)
+		if err != nil {
+			log.Printf("prune failed[%v]", err)
+		} else {
+			log.Printf("pruned %d requests, %d runs, %d run events and %d dataset versions",
+				res.Requests, res.Runs, res.RunEvents, res.DatasetVersions)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
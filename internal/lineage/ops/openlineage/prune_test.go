@@ -0,0 +1,202 @@
+package openlineage_test
+
+import (
+	"context"
+	"fmt"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	start := time.Now().UTC().Add(-time.Hour)
+	dataset := func(idType string, desc string) openlineage.Dataset {
+		return openlineage.Dataset{
+			Namespace: "postgres://db",
+			Name:      "public.orders",
+			Facets: []byte(fmt.Sprintf(`{"schema": {"fields": [
+				{"name": "id", "type": "%s"}, {"name": "amount", "type": "int", "description": "%s"}
+			]}}`, idType, desc)),
+		}
+	}
+	send := func(i int, ev openlineage.RunEvent) {
+		ev.EventTime = start.Add(time.Duration(i) * time.Minute)
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+
+	// three runs of the job each writing a new version of the dataset, the
+	// first version is also read by another job
+	var runUUIDs []uuid.UUID
+	for i, ds := range []openlineage.Dataset{dataset("int", "cents"), dataset("bigint", "cents"), dataset("bigint", "euros")} {
+		runUUID := uuid.New()
+		runUUIDs = append(runUUIDs, runUUID)
+		ev := getRunEvent(runUUID, start)
+		ev.EventType = "COMPLETE"
+		ev.Outputs = []openlineage.OutputDataset{{Dataset: ds}}
+		send(i, ev)
+		if i == 0 {
+			ev := getRunEvent(uuid.New(), start)
+			ev.Job = openlineage.NewJob("airflow", "orders.report", nil)
+			ev.Inputs = []openlineage.InputDataset{{Dataset: ds}}
+			send(i, ev)
+		}
+	}
+	ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "postgres://db", "public.orders")
+	assert.Nil(t, err)
+	versions, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(versions))
+
+	// a request received long ago
+	_, err = deps.GetStore().Queries().CreateRequest(ctx, db.CreateRequestParams{
+		Payload:   []byte(`{"eventType": "START"}`),
+		CreatedAt: utils.NowUTC().AddDate(0, 0, -40),
+		Status:    int32(lineage.RequestStatusProcessed),
+	})
+	assert.Nil(t, err)
+
+	policy := ol_ops.RetentionPolicy{RequestDays: 30, RunsPerJob: 1, CollapseDatasetVersions: true}
+	dry, err := ol_ops.Prune(ctx, deps, policy, true)
+	assert.Nil(t, err)
+	assert.Equal(t, &ol_ops.PruneResult{Requests: 1, Runs: 2, RunEvents: 2, DatasetVersions: 1}, dry)
+
+	// a dry run deletes nothing
+	_, err = ops.GetRunWithUUID(ctx, deps, runUUIDs[0])
+	assert.Nil(t, err)
+
+	res, err := ol_ops.Prune(ctx, deps, policy, false)
+	assert.Nil(t, err)
+	assert.Equal(t, dry, res)
+
+	for _, runUUID := range runUUIDs[:2] {
+		_, err = ops.GetRunWithUUID(ctx, deps, runUUID)
+		assert.NotNil(t, err)
+	}
+	run, err := ops.GetRunWithUUID(ctx, deps, runUUIDs[2])
+	assert.Nil(t, err)
+	evs, err := ops.ListRunEventsByRunID(ctx, deps, run.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(evs))
+
+	// the middle version is collapsed into the last one
+	pruned, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{versions[0].ID, versions[2].ID}, []int64{pruned[0].ID, pruned[1].ID})
+	changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, versions[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, versions[2].ID, changes[0].PreviousVersionID)
+	assert.Equal(t, "amount", changes[0].FieldName)
+	assert.Equal(t, "id", changes[1].FieldName)
+
+	// nothing left to prune
+	res, err = ol_ops.Prune(ctx, deps, policy, false)
+	assert.Nil(t, err)
+	assert.Equal(t, &ol_ops.PruneResult{}, res)
+}
+
+func TestPruneKeepsLatestRunsByTime(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// the latest run is recorded first, an older run arrives late
+	latest, late := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	for _, r := range []struct {
+		runUUID   uuid.UUID
+		eventTime time.Time
+	}{{latest, now}, {late, now.Add(-2 * time.Hour)}} {
+		ev := getRunEvent(r.runUUID, r.eventTime)
+		ev.EventType = "START"
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+
+	res, err := ol_ops.Prune(ctx, deps, ol_ops.RetentionPolicy{RunsPerJob: 1}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), res.Runs)
+	_, err = ops.GetRunWithUUID(ctx, deps, latest)
+	assert.Nil(t, err)
+	_, err = ops.GetRunWithUUID(ctx, deps, late)
+	assert.NotNil(t, err)
+}
+
+func TestCollapseDatasetVersionWithUnchangedSchema(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+	qtx := deps.GetStore().Queries()
+
+	schema := func(names ...string) openlineage.Dataset {
+		var fields []string
+		for _, n := range names {
+			fields = append(fields, fmt.Sprintf(`{"name": "%s", "type": "int"}`, n))
+		}
+		return openlineage.Dataset{
+			Namespace: "postgres://db",
+			Name:      "public.orders",
+			Facets:    []byte(fmt.Sprintf(`{"schema": {"fields": [%s]}}`, strings.Join(fields, ", "))),
+		}
+	}
+	write := func(ds openlineage.Dataset) {
+		ev := getRunEvent(uuid.New(), time.Now().UTC())
+		ev.EventType = "COMPLETE"
+		ev.Outputs = []openlineage.OutputDataset{{Dataset: ds}}
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+
+	// v1(a,b) written by a run, v2(a,b) with the same schema and no schema
+	// changes that no run refers to, then v3(a,b,c) written by a run
+	write(schema("a", "b"))
+	ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "postgres://db", "public.orders")
+	assert.Nil(t, err)
+	v1 := ds.Dataset.CurrentVersionID
+	v2, err := qtx.CreateDatasetVersion(ctx, db.CreateDatasetVersionParams{
+		NamespaceID: ds.Dataset.DatasetNamespaceID, DatasetID: ds.Dataset.ID, Name: ds.Dataset.Name, CreatedAt: utils.NowUTC(), VersionUuid: uuid.New(),
+	})
+	assert.Nil(t, err)
+	for _, n := range []string{"a", "b"} {
+		_, err = qtx.CreateField(ctx, db.CreateFieldParams{DatasetVersionID: v2.ID, Name: n, DataType: "int", CreatedAt: utils.NowUTC()})
+		assert.Nil(t, err)
+	}
+	_, err = qtx.UpdateCurrentDatasetVersion(ctx, db.UpdateCurrentDatasetVersionParams{
+		CurrentVersionID: utils.NullInt64(&v2.ID), UpdatedAt: utils.NowUTCAsNullTime(), ID: ds.Dataset.ID,
+	})
+	assert.Nil(t, err)
+	write(schema("a", "b", "c"))
+
+	versions, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(versions))
+	v3 := versions[0].ID
+
+	res, err := ol_ops.Prune(ctx, deps, ol_ops.RetentionPolicy{CollapseDatasetVersions: true}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), res.DatasetVersions)
+
+	// v3 still records c added, now from v1
+	changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, v3)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(changes)) {
+		assert.Equal(t, v1, changes[0].PreviousVersionID)
+		assert.Equal(t, "c", changes[0].FieldName)
+	}
+	_, err = qtx.GetDatasetVersionByID(ctx, v2.ID)
+	assert.NotNil(t, err)
+}
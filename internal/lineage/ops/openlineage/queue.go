@@ -0,0 +1,212 @@
+package openlineage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// IngestMode is how the lineage API records the events it receives
+type IngestMode string
+
+const (
+	// IngestSync records events while the client waits
+	IngestSync IngestMode = "sync"
+	// IngestAsync queues events for the request workers and answers at once
+	IngestAsync IngestMode = "async"
+)
+
+func IngestModeFromString(str string) (IngestMode, error) {
+	mode := IngestMode(strings.ToLower(str))
+	if mode != IngestSync && mode != IngestAsync {
+		return "", fmt.Errorf("No ingest mode matching [%s]", str)
+	}
+	return mode, nil
+}
+
+// MaxRequestAttempts is how many times a worker tries to record a queued
+// request before marking it failed
+var MaxRequestAttempts int32 = 5
+
+// RequestRetryBackoff is the wait before the first retry of a queued request,
+// it doubles with every attempt up to MaxRequestRetryBackoff
+var RequestRetryBackoff = 30 * time.Second
+
+// MaxRequestRetryBackoff caps the wait between retries
+var MaxRequestRetryBackoff = time.Hour
+
+// errUnprocessable marks a queued request that no retry can record
+var errUnprocessable = errors.New("request cannot be processed")
+
+// EnqueueRequest queues the event for the request workers, ev is any of the
+// OpenLineage event kinds. The key is the idempotency key of a run event. The
+// payload is the body the event was received as, it is queued instead of the
+// event when given.
+func EnqueueRequest(ctx context.Context, deps Deps, ev interface{}, key string, payload json.RawMessage) (*lineage.Request, error) {
+	msg, err := requestPayload(ev, payload)
+	if err != nil {
+		return nil, err
+	}
+	now := utils.NowUTC()
+	params := db.CreateRequestParams{
+		Payload:        msg,
+		CreatedAt:      now,
+		Status:         int32(lineage.RequestStatusPending),
+		NextAttemptAt:  sql.NullTime{Time: now, Valid: true},
+		IdempotencyKey: toNullString(key),
+		EventTime:      requestEventTime(ev),
+	}
+	row, err := deps.GetStore().Queries().CreateRequest(ctx, params)
+	if err != nil {
+		return nil, eris.Wrap(err, "enqueue request failed")
+	}
+	return &lineage.Request{
+		ID:            row.ID,
+		Status:        lineage.RequestStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     row.CreatedAt,
+	}, nil
+}
+
+// ProcessNextRequest records the oldest queued request that is due and
+// returns false when there is none. The request stays locked until it is
+// recorded so workers on other replicas skip it. A request that fails is
+// retried later with a backoff until MaxRequestAttempts, then it is kept as a
+// failed event.
+func ProcessNextRequest(ctx context.Context, deps Deps) (bool, error) {
+	var found bool
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		req, err := qtx.ClaimPendingRequest(ctx, utils.NowUTC())
+		if utils.IsNoRowsError(err) {
+			return nil
+		}
+		if err != nil {
+			return eris.Wrap(err, "claim pending request failed")
+		}
+		found = true
+
+		var runEventID sql.NullInt64
+		err = qtx.Savepoint(ctx, func() error {
+			var err error
+			runEventID, err = processRequest(ctx, qtx, &req)
+			return err
+		})
+		params := requestOutcome(&req, runEventID, err)
+		if err := qtx.UpdateRequestStatus(ctx, params); err != nil {
+			return eris.Wrapf(err, "update request status[%v] failed", params)
+		}
+		if params.Status != int32(lineage.RequestStatusFailed) || errors.Is(err, ErrIdempotencyKeyReused) {
+			return nil
+		}
+		requestID := sql.NullInt64{Int64: req.ID, Valid: true}
+		return saveFailedEvent(ctx, qtx, req.Payload, err, requestID)
+	})
+	return found, err
+}
+
+// processRequest records the queued event and returns the run event of a
+// run event
+func processRequest(ctx context.Context, qtx db.Querier, req *db.LineageRequest) (sql.NullInt64, error) {
+	kind, err := openlineage.DetectEventKind(req.Payload)
+	if err != nil {
+		return sql.NullInt64{}, eris.Wrapf(errUnprocessable, "could not detect the event kind[%v]", err)
+	}
+
+	switch kind {
+	case openlineage.EventKindDataset:
+		ev := &openlineage.DatasetEvent{}
+		if err := json.Unmarshal(req.Payload, ev); err != nil || ev.Dataset == nil {
+			return sql.NullInt64{}, eris.Wrapf(errUnprocessable, "could not parse dataset event[%v]", err)
+		}
+		_, err := recordDatasetEvent(ctx, qtx, ev)
+		return sql.NullInt64{}, err
+	case openlineage.EventKindJob:
+		ev := &openlineage.JobEvent{}
+		if err := json.Unmarshal(req.Payload, ev); err != nil || ev.Job == nil {
+			return sql.NullInt64{}, eris.Wrapf(errUnprocessable, "could not parse job event[%v]", err)
+		}
+		_, err := recordJobEvent(ctx, qtx, ev)
+		return sql.NullInt64{}, err
+	default:
+		ev := openlineage.NewRunEvent()
+		if err := json.Unmarshal(req.Payload, ev); err != nil || ev.Run == nil || ev.Job == nil {
+			return sql.NullInt64{}, eris.Wrapf(errUnprocessable, "could not parse run event[%v]", err)
+		}
+		// the payload is stored as received
+		NormalizeEventType(ev)
+		runEvent, err := recordRunEvent(ctx, qtx, ev, req.IdempotencyKey.String)
+		if err != nil {
+			return sql.NullInt64{}, err
+		}
+		return sql.NullInt64{Int64: runEvent.ID, Valid: true}, nil
+	}
+}
+
+// requestOutcome is the status of the request after an attempt to record it
+func requestOutcome(req *db.LineageRequest, runEventID sql.NullInt64, err error) db.UpdateRequestStatusParams {
+	now := utils.NowUTC()
+	params := db.UpdateRequestStatusParams{
+		ID:         req.ID,
+		Attempts:   req.Attempts + 1,
+		RunEventID: runEventID,
+	}
+	if err == nil {
+		params.Status = int32(lineage.RequestStatusProcessed)
+		params.ProcessedAt = sql.NullTime{Time: now, Valid: true}
+		return params
+	}
+
+	params.ErrorMessage = toNullString(err.Error())
+	if params.Attempts >= MaxRequestAttempts || errors.Is(err, errUnprocessable) ||
+		errors.Is(err, ErrIdempotencyKeyReused) {
+		params.Status = int32(lineage.RequestStatusFailed)
+		params.ProcessedAt = sql.NullTime{Time: now, Valid: true}
+		return params
+	}
+	params.Status = int32(lineage.RequestStatusPending)
+	params.NextAttemptAt = sql.NullTime{Time: now.Add(retryBackoff(params.Attempts)), Valid: true}
+	return params
+}
+
+// retryBackoff is the wait after the given number of failed attempts
+func retryBackoff(attempts int32) time.Duration {
+	d := RequestRetryBackoff
+	for i := int32(1); i < attempts && d < MaxRequestRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxRequestRetryBackoff {
+		return MaxRequestRetryBackoff
+	}
+	return d
+}
+
+// RunRequestWorker records queued requests until the context is done. It
+// waits for poll whenever the queue has nothing due.
+func RunRequestWorker(ctx context.Context, deps Deps, poll time.Duration) {
+	for {
+		found, err := ProcessNextRequest(ctx, deps)
+		if err != nil {
+			log.Printf("request worker failed[%v]", err)
+		}
+		if found && err == nil && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessQueuedRequests(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	req, err := ol_ops.EnqueueRequest(ctx, deps, &ev, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusPending, req.Status)
+
+	dsEv := &openlineage.DatasetEvent{
+		Dataset:   &openlineage.Dataset{Namespace: "postgres://db", Name: "public.orders"},
+		EventTime: time.Now().UTC(),
+	}
+	dsReq, err := ol_ops.EnqueueRequest(ctx, deps, dsEv, "", nil)
+	assert.Nil(t, err)
+
+	for _, want := range []bool{true, true, false} {
+		found, err := ol_ops.ProcessNextRequest(ctx, deps)
+		assert.Nil(t, err)
+		assert.Equal(t, want, found)
+	}
+
+	req, err = ops.GetRequest(ctx, deps, req.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusProcessed, req.Status)
+	assert.Equal(t, 1, req.Attempts)
+	assert.False(t, req.ProcessedAt.IsZero())
+
+	runEvent, err := ol_ops.FindDuplicateRunEvent(ctx, deps, &ev, "")
+	assert.Nil(t, err)
+	assert.Equal(t, runEvent.ID, req.RunEventID)
+
+	dsReq, err = ops.GetRequest(ctx, deps, dsReq.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusProcessed, dsReq.Status)
+	ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "postgres://db", "public.orders")
+	assert.Nil(t, err)
+	assert.Equal(t, "public.orders", ds.Dataset.Name)
+}
+
+func TestQueuedRequestRetries(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// a facet that cannot be read fails every attempt
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("warehouse", "orders", []byte(
+		`{"lifecycleStateChange": {"lifecycleStateChange": 5}}`))}}
+	req, err := ol_ops.EnqueueRequest(ctx, deps, &ev, "", nil)
+	assert.Nil(t, err)
+
+	found, err := ol_ops.ProcessNextRequest(ctx, deps)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	req, err = ops.GetRequest(ctx, deps, req.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusPending, req.Status)
+	assert.Equal(t, 1, req.Attempts)
+	assert.NotEqual(t, "", req.ErrorMessage)
+	assert.True(t, req.NextAttemptAt.After(time.Now().UTC().Add(ol_ops.RequestRetryBackoff/2)))
+
+	// not due before the backoff
+	found, err = ol_ops.ProcessNextRequest(ctx, deps)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	// a payload that is not an event is not retried
+	req, err = ol_ops.EnqueueRequest(ctx, deps, map[string]string{"eventType": "START"}, "", nil)
+	assert.Nil(t, err)
+	found, err = ol_ops.ProcessNextRequest(ctx, deps)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	req, err = ops.GetRequest(ctx, deps, req.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusFailed, req.Status)
+	assert.Equal(t, 1, req.Attempts)
+}
@@ -0,0 +1,210 @@
+package openlineage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"oplin/internal/utils"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// ReplayPageSize is the number of requests read at a time by Replay
+const ReplayPageSize = 500
+
+// MaxReplayErrors is the number of errors a ReplayResult keeps, the others
+// are only counted
+const MaxReplayErrors = 100
+
+// ErrRequestsPruned is returned when a replay would lose the lineage recorded
+// from requests the retention deleted
+var ErrRequestsPruned = errors.New("requests were pruned, a replay would lose the lineage recorded from them")
+
+// ReplayError is a request that could not be recorded by a replay
+type ReplayError struct {
+	RequestID int64
+	Err       error
+}
+
+// ReplayResult counts the requests replayed and those that failed, and the
+// manual aliases restored and those whose dataset was not recorded again
+type ReplayResult struct {
+	Requests    int
+	Failed      int
+	Errors      []ReplayError
+	Aliases     int
+	LostAliases int
+}
+
+// deleteLineage deletes everything recorded from the requests, children
+// before their parents
+func deleteLineage(ctx context.Context, qtx db.Querier) error {
+	deletes := []func(context.Context) error{
+		qtx.DeleteSearchDocuments,
+		qtx.DeleteFacets,
+		qtx.DeleteDatasetAliases,
+		qtx.DeleteLifecycleStateChanges,
+		qtx.DeleteSchemaChanges,
+		qtx.DeleteFields,
+		qtx.DeleteJobVersionIODatasets,
+		qtx.DeleteRunDatasetVersions,
+		qtx.DeleteDatasetVersions,
+		qtx.DeleteDatasets,
+		qtx.DeleteDatasetNamespaces,
+		qtx.DeleteRunEvents,
+		qtx.DeleteRuns,
+		qtx.DeleteJobVersions,
+		qtx.DeleteJobs,
+		qtx.DeleteJobNamespaces,
+	}
+	for _, del := range deletes {
+		if err := del(ctx); err != nil {
+			return eris.Wrap(err, "delete lineage failed")
+		}
+	}
+	return nil
+}
+
+// CheckReplay returns ErrRequestsPruned when requests were pruned since the
+// lineage was last rebuilt
+func CheckReplay(ctx context.Context, deps Deps) error {
+	return checkPruned(ctx, deps.GetStore().Queries())
+}
+
+func checkPruned(ctx context.Context, q db.Querier) error {
+	prune, err := q.GetLastRequestPrune(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return eris.Wrap(err, "get last request prune failed")
+	}
+	return eris.Wrapf(ErrRequestsPruned, "requests created before %v", prune.CreatedBefore.Format(time.RFC3339))
+}
+
+// Replay rebuilds the lineage from the stored requests, for when the way
+// events are recorded has changed. Everything recorded from the requests is
+// deleted and the requests received so far are recorded again in event time
+// order, queued requests are left to the workers. The merges and splits made
+// by hand are kept by name and made again once the requests are recorded. A
+// request that fails is marked failed and the replay goes on. The replay is
+// one exclusive transaction so the lineage is never seen half rebuilt and no
+// event is recorded beside it, ingestion and the workers of every replica
+// wait until it is done. Once requests were pruned the replay returns
+// ErrRequestsPruned unless forced, a forced replay drops the lineage of the
+// pruned requests for good. progress, when given, is called after every
+// request with the number replayed and the total.
+func Replay(ctx context.Context, deps Deps, force bool, progress func(done int, total int)) (*ReplayResult, error) {
+	res := &ReplayResult{}
+	err := deps.GetStore().ExclusiveTx(ctx, func(qtx store.Tx) error {
+		if !force {
+			if err := checkPruned(ctx, qtx); err != nil {
+				return err
+			}
+		}
+		// the lineage is rebuilt from the requests left, later replays lose
+		// nothing more
+		if err := qtx.DeleteRequestPrunes(ctx); err != nil {
+			return eris.Wrap(err, "delete request prunes failed")
+		}
+		maxID, err := qtx.GetMaxRequestID(ctx)
+		if err != nil {
+			return eris.Wrap(err, "get max request id failed")
+		}
+		total, err := qtx.CountRequestsForReplay(ctx, maxID)
+		if err != nil {
+			return eris.Wrap(err, "count requests failed")
+		}
+		aliases, err := qtx.ListManualDatasetAliases(ctx)
+		if err != nil {
+			return eris.Wrap(err, "list manual dataset aliases failed")
+		}
+		if err := deleteLineage(ctx, qtx); err != nil {
+			return err
+		}
+
+		params := db.ListRequestsForReplayParams{MaxID: maxID, RowLimit: ReplayPageSize}
+		for {
+			reqs, err := qtx.ListRequestsForReplay(ctx, params)
+			if err != nil {
+				return eris.Wrapf(err, "list requests[%v] failed", params)
+			}
+			for i := range reqs {
+				if err := replayRequest(ctx, qtx, &reqs[i], res); err != nil {
+					return err
+				}
+				if progress != nil {
+					progress(res.Requests, int(total))
+				}
+			}
+			if len(reqs) < ReplayPageSize {
+				return restoreAliases(ctx, qtx, aliases, res)
+			}
+			last := reqs[len(reqs)-1]
+			params.AfterEventTime = last.CreatedAt
+			if last.EventTime.Valid {
+				params.AfterEventTime = last.EventTime.Time
+			}
+			params.AfterID = last.ID
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// restoreAliases makes the manual aliases again after the replay, symlinks
+// recorded again do not win over them
+func restoreAliases(ctx context.Context, qtx store.Tx, aliases []db.ListManualDatasetAliasesRow, res *ReplayResult) error {
+	for _, a := range aliases {
+		ok, err := ops.RestoreDatasetAlias(ctx, qtx, a.Namespace, a.Name, a.DatasetNamespace, a.DatasetName)
+		if err != nil {
+			return err
+		}
+		if ok {
+			res.Aliases++
+		} else {
+			res.LostAliases++
+		}
+	}
+	return nil
+}
+
+// replayRequest records the request again and updates its status, an error
+// is only returned when the status cannot be updated
+func replayRequest(ctx context.Context, qtx store.Tx, req *db.LineageRequest, res *ReplayResult) error {
+	var runEventID sql.NullInt64
+	err := qtx.Savepoint(ctx, func() error {
+		var err error
+		runEventID, err = processRequest(ctx, qtx, req)
+		return err
+	})
+
+	now := sql.NullTime{Time: utils.NowUTC(), Valid: true}
+	params := db.UpdateRequestStatusParams{
+		ID:          req.ID,
+		Status:      int32(lineage.RequestStatusProcessed),
+		Attempts:    req.Attempts,
+		RunEventID:  runEventID,
+		ProcessedAt: now,
+	}
+	res.Requests++
+	if err != nil {
+		params.Status = int32(lineage.RequestStatusFailed)
+		params.ErrorMessage = toNullString(err.Error())
+		res.Failed++
+		if len(res.Errors) < MaxReplayErrors {
+			res.Errors = append(res.Errors, ReplayError{RequestID: req.ID, Err: err})
+		}
+	}
+	if err := qtx.UpdateRequestStatus(ctx, params); err != nil {
+		return eris.Wrapf(err, "update request status[%v] failed", params)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// the complete arrives before the start
+	start := time.Now().UTC().Add(-time.Minute)
+	runUUID := uuid.New()
+	complete := getRunEvent(runUUID, start.Add(time.Second))
+	complete.EventType = "complete"
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &complete)
+	assert.Nil(t, err)
+	ev := getRunEvent(runUUID, start)
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	// a queued request is left to the workers
+	queued, err := ol_ops.EnqueueRequest(ctx, deps, &ev, "", nil)
+	assert.Nil(t, err)
+
+	// a stored request that is not an event
+	bad, err := deps.GetStore().Queries().CreateRequest(ctx, db.CreateRequestParams{
+		Payload:   []byte(`{"eventType": "START"}`),
+		CreatedAt: utils.NowUTC(),
+		Status:    int32(lineage.RequestStatusProcessed),
+	})
+	assert.Nil(t, err)
+
+	var progress []int
+	res, err := ol_ops.Replay(ctx, deps, false, func(done int, total int) {
+		assert.Equal(t, 3, total)
+		progress = append(progress, done)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.Requests)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, bad.ID, res.Errors[0].RequestID)
+	assert.Equal(t, []int{1, 2, 3}, progress)
+
+	run, err := ops.GetRunWithUUID(ctx, deps, runUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RunStateCompleted, run.State)
+	assertTimeEqual(t, start, run.StartedAt)
+	evs, err := ops.ListRunEventsByRunID(ctx, deps, run.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(evs))
+	assert.Equal(t, lineage.RunEventTypeStart, evs[0].EventType)
+
+	req, err := ops.GetRequest(ctx, deps, queued.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusPending, req.Status)
+	req, err = ops.GetRequest(ctx, deps, bad.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RequestStatusFailed, req.Status)
+	assert.NotEqual(t, "", req.ErrorMessage)
+}
+
+func TestReplayKeepsMergesAndSplits(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// spark writes the path and says it is the warehouse.orders table, hive
+	// reads the table and the orders are copied to a backup
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("spark", "load_orders", nil)
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("s3://bucket", "/orders", []byte(
+		`{"symlinks": {"identifiers": [{"namespace": "hive://metastore", "name": "warehouse.orders", "type": "TABLE"}]}}`,
+	))}}
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("hive", "monthly_orders", nil)
+	ev.Inputs = []openlineage.InputDataset{{Dataset: *openlineage.NewDataset("hive://metastore", "warehouse.orders", nil)}}
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("spark", "backup_orders", nil)
+	ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("s3://backup", "/orders", nil)}}
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	err = ops.SplitDataset(ctx, deps, "hive://metastore", "warehouse.orders")
+	assert.Nil(t, err)
+	_, err = ops.MergeDatasets(ctx, deps, "s3://backup", "/orders", "s3://bucket", "/orders")
+	assert.Nil(t, err)
+
+	res, err := ol_ops.Replay(ctx, deps, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.Failed)
+	assert.Equal(t, 2, res.Aliases)
+	assert.Equal(t, 0, res.LostAliases)
+
+	path, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "s3://bucket", "/orders")
+	assert.Nil(t, err)
+	table, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "hive://metastore", "warehouse.orders")
+	assert.Nil(t, err)
+	backup, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "s3://backup", "/orders")
+	assert.Nil(t, err)
+	assert.NotEqual(t, path.Dataset.ID, table.Dataset.ID)
+	assert.Equal(t, path.Dataset.ID, backup.Dataset.ID)
+
+	datasets, err := ops.ListDatasetsWithNamespaces(ctx, deps)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(datasets))
+}
+
+func TestReplayAfterPrune(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	runUUID := uuid.New()
+	ev := getRunEvent(runUUID, time.Now().UTC())
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	_, err = deps.GetStore().Queries().CreateRequest(ctx, db.CreateRequestParams{
+		Payload:   []byte(`{"eventType": "START"}`),
+		CreatedAt: utils.NowUTC().AddDate(0, 0, -40),
+		Status:    int32(lineage.RequestStatusProcessed),
+	})
+	assert.Nil(t, err)
+
+	// a dry run and a prune that deletes nothing leave no marker
+	policy := ol_ops.RetentionPolicy{RequestDays: 30}
+	_, err = ol_ops.Prune(ctx, deps, policy, true)
+	assert.Nil(t, err)
+	_, err = ol_ops.Prune(ctx, deps, ol_ops.RetentionPolicy{RequestDays: 60}, false)
+	assert.Nil(t, err)
+	assert.Nil(t, ol_ops.CheckReplay(ctx, deps))
+
+	res, err := ol_ops.Prune(ctx, deps, policy, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), res.Requests)
+	assert.ErrorIs(t, ol_ops.CheckReplay(ctx, deps), ol_ops.ErrRequestsPruned)
+
+	// the lineage is kept when the replay is refused
+	_, err = ol_ops.Replay(ctx, deps, false, nil)
+	assert.ErrorIs(t, err, ol_ops.ErrRequestsPruned)
+	_, err = ops.GetRunWithUUID(ctx, deps, runUUID)
+	assert.Nil(t, err)
+
+	replayed, err := ol_ops.Replay(ctx, deps, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, replayed.Requests)
+	assert.Nil(t, ol_ops.CheckReplay(ctx, deps))
+}
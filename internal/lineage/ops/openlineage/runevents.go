@@ -2,9 +2,12 @@ package openlineage
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"oplin/internal/lineage"
 	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
 	"oplin/internal/openlineage"
 	"oplin/internal/utils"
 	"time"
@@ -13,8 +16,8 @@ import (
 )
 
 func createRunEvent(
-	ctx context.Context, qtx *db.Queries, runID int64, eventTime time.Time, eventType string,
-	msg json.RawMessage,
+	ctx context.Context, qtx db.Querier, runID int64, eventTime time.Time, eventType string,
+	msg json.RawMessage, hash string, key string,
 ) (*db.LineageRunEvent, error) {
 	t, err := lineage.RunEventTypeFromString(eventType)
 	if err != nil {
@@ -22,11 +25,13 @@ func createRunEvent(
 	}
 
 	params := db.CreateRunEventParams{
-		RunID:     runID,
-		EventType: int32(t),
-		EventTime: eventTime,
-		Facets:    utils.ToPQRawMessageType(msg),
-		CreatedAt: utils.NowUTC(),
+		RunID:          runID,
+		EventType:      int32(t),
+		EventTime:      eventTime,
+		Facets:         utils.ToPQRawMessageType(msg),
+		CreatedAt:      utils.NowUTC(),
+		PayloadHash:    toNullString(hash),
+		IdempotencyKey: toNullString(key),
 	}
 	runEvent, err := qtx.CreateRunEvent(ctx, params)
 	if err != nil {
@@ -35,14 +40,54 @@ func createRunEvent(
 	return &runEvent, nil
 }
 
-func saveRequest(ctx context.Context, qtx *db.Queries, ev *openlineage.RunEvent) error {
+// requestEventTime is the event time of any of the OpenLineage event kinds,
+// requests are replayed in event time order
+func requestEventTime(ev interface{}) sql.NullTime {
+	var t time.Time
+	switch ev := ev.(type) {
+	case *openlineage.RunEvent:
+		t = ev.EventTime
+	case *openlineage.DatasetEvent:
+		t = ev.EventTime
+	case *openlineage.JobEvent:
+		t = ev.EventTime
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// requestPayload is the body the event was received as, ev is marshalled
+// when there is none
+func requestPayload(ev interface{}, payload json.RawMessage) (json.RawMessage, error) {
+	if len(payload) > 0 {
+		return payload, nil
+	}
 	msg, err := json.Marshal(ev)
 	if err != nil {
-		return eris.Wrap(err, "could not marshal request")
+		return nil, eris.Wrap(err, "could not marshal request")
+	}
+	return msg, nil
+}
+
+// saveRequest keeps the event as it was received, ev is any of the
+// OpenLineage event kinds and payload its body. The request is saved as
+// processed, runEventID is the run event recorded for a run event.
+func saveRequest(
+	ctx context.Context, qtx db.Querier, ev interface{}, payload json.RawMessage, key string, runEventID sql.NullInt64,
+) error {
+	msg, err := requestPayload(ev, payload)
+	if err != nil {
+		return err
 	}
+	now := utils.NowUTC()
 	params := db.CreateRequestParams{
-		Payload:   msg,
-		CreatedAt: utils.NowUTC(),
+		Payload:        msg,
+		CreatedAt:      now,
+		Status:         int32(lineage.RequestStatusProcessed),
+		Attempts:       1,
+		IdempotencyKey: toNullString(key),
+		RunEventID:     runEventID,
+		ProcessedAt:    sql.NullTime{Time: now, Valid: true},
+		EventTime:      requestEventTime(ev),
 	}
 	_, err = qtx.CreateRequest(ctx, params)
 	if err != nil {
@@ -53,18 +98,105 @@ func saveRequest(ctx context.Context, qtx *db.Queries, ev *openlineage.RunEvent)
 }
 
 func CreateWithOpenLineageRunEvent(ctx context.Context, deps Deps, ev *openlineage.RunEvent) (*lineage.RunEvent, error) {
-	pg := deps.GetDB()
-	tx, err := pg.Begin()
+	return CreateWithOpenLineageRunEventAndKey(ctx, deps, ev, "", nil)
+}
+
+// CreateWithOpenLineageRunEventAndKey records the run event unless it is a
+// duplicate of one already recorded, see findDuplicate, in which case the
+// recorded run event is returned marked as a duplicate. The key is optional,
+// the payload is the body the event was received as and is stored instead of
+// the event when given. An event that cannot be recorded is kept as a failed
+// event.
+func CreateWithOpenLineageRunEventAndKey(
+	ctx context.Context, deps Deps, ev *openlineage.RunEvent, key string, payload json.RawMessage,
+) (*lineage.RunEvent, error) {
+	res, err := createRunEventTx(ctx, deps, ev, key, payload)
+	if err != nil {
+		recordFailure(ctx, deps.GetStore().Queries(), ev, payload, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// createRunEventTx records the run event in its own transaction
+func createRunEventTx(
+	ctx context.Context, deps Deps, ev *openlineage.RunEvent, key string, payload json.RawMessage,
+) (*lineage.RunEvent, error) {
+	var res *lineage.RunEvent
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		var err error
+		res, err = createWithRunEvent(ctx, qtx, ev, key, payload)
+		return err
+	})
 	if err != nil {
-		return nil, eris.Wrap(err, "begin transaction failed")
+		// a retry recorded concurrently wins the unique indexes
+		if res, dupErr := FindDuplicateRunEvent(ctx, deps, ev, key); dupErr == nil && res != nil {
+			return res, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// FindDuplicateRunEvent returns the run event already recorded for the
+// event marked as a duplicate, or nil when the event is new
+func FindDuplicateRunEvent(
+	ctx context.Context, deps Deps, ev *openlineage.RunEvent, key string,
+) (*lineage.RunEvent, error) {
+	hash, err := payloadHash(ev)
+	if err != nil {
+		return nil, err
+	}
+	dup, err := findDuplicate(ctx, deps.GetStore().Queries(), ev, hash, key)
+	if err != nil || dup == nil {
+		return nil, err
+	}
+	return toDuplicateRunEvent(dup), nil
+}
+
+func toDuplicateRunEvent(runEvent *db.LineageRunEvent) *lineage.RunEvent {
+	return &lineage.RunEvent{
+		ID:        runEvent.ID,
+		EventType: lineage.RunEventType(runEvent.EventType),
+		EventTime: runEvent.EventTime,
+		RunID:     runEvent.RunID,
+		CreatedAt: runEvent.CreatedAt,
+		UpdatedAt: runEvent.UpdatedAt.Time,
+		Duplicate: true,
 	}
-	defer tx.Rollback()
-	qtx := db.New(tx).WithTx(tx)
+}
 
-	err = saveRequest(ctx, qtx, ev)
+// createWithRunEvent saves the request and records the run event using the
+// given queries, which are expected to run inside a transaction
+func createWithRunEvent(
+	ctx context.Context, qtx db.Querier, ev *openlineage.RunEvent, key string, payload json.RawMessage,
+) (*lineage.RunEvent, error) {
+	res, err := recordRunEvent(ctx, qtx, ev, key)
+	if err != nil || res.Duplicate {
+		return res, err
+	}
+	err = saveRequest(ctx, qtx, ev, payload, key, sql.NullInt64{Int64: res.ID, Valid: true})
 	if err != nil {
 		return nil, eris.Wrap(err, "could not save request")
 	}
+	return res, nil
+}
+
+// recordRunEvent records the run event unless it is a duplicate
+func recordRunEvent(
+	ctx context.Context, qtx db.Querier, ev *openlineage.RunEvent, key string,
+) (*lineage.RunEvent, error) {
+	hash, err := payloadHash(ev)
+	if err != nil {
+		return nil, err
+	}
+	dup, err := findDuplicate(ctx, qtx, ev, hash, key)
+	if err != nil {
+		return nil, err
+	}
+	if dup != nil {
+		return toDuplicateRunEvent(dup), nil
+	}
 
 	ns, err := createJobNamespaceIfNotExists(ctx, qtx, ev.Job.Namespace)
 	if err != nil {
@@ -76,19 +208,26 @@ func CreateWithOpenLineageRunEvent(ctx context.Context, deps Deps, ev *openlinea
 		return nil, err
 	}
 
-	jobVersion, err := createJobVersionIfNotExists(ctx, qtx, job)
+	job, err = updateJobFacets(ctx, qtx, job, ev.Job.Facets)
 	if err != nil {
 		return nil, err
 	}
 
+	err = saveFacets(ctx, qtx, lineage.FacetEntityTypeJob, job.ID, ev.Job.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	jobVersion, err := createCurrentJobVersion(ctx, qtx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	// a new job or new facets make a new version, only then the index changes
 	if jobVersion.ID != job.CurrentVersionID.Int64 {
-		_, err := qtx.UpdateCurrentJobVersion(ctx, db.UpdateCurrentJobVersionParams{
-			CurrentVersionID: utils.NullInt64(&jobVersion.ID),
-			UpdatedAt:        utils.NowUTCAsNullTime(),
-			ID:               job.ID,
-		})
+		err = ops.IndexJob(ctx, qtx, job.ID)
 		if err != nil {
-			return nil, eris.Wrapf(err, "update current job version[%v] failed", jobVersion.ID)
+			return nil, err
 		}
 	}
 
@@ -97,7 +236,7 @@ func CreateWithOpenLineageRunEvent(ctx context.Context, deps Deps, ev *openlinea
 		return nil, err
 	}
 
-	runEvent, err := createRunEvent(ctx, qtx, run.ID, ev.EventTime, ev.EventType, ev.Run.Facets)
+	runEvent, err := createRunEvent(ctx, qtx, run.ID, ev.EventTime, ev.EventType, ev.Run.Facets, hash, key)
 	if err != nil {
 		return nil, err
 	}
@@ -107,33 +246,34 @@ func CreateWithOpenLineageRunEvent(ctx context.Context, deps Deps, ev *openlinea
 		return nil, err
 	}
 
-	if runEvent.EventType == int32(lineage.RunEventTypeComplete) {
-		for _, dsInput := range ev.Inputs {
-			dsIO := IODataset{
-				Dataset:  dsInput.Dataset,
-				IOFacets: dsInput.InputFacets,
-				Type:     lineage.IOTypeInput,
-			}
-			_, err := handleIO(ctx, qtx, dsIO, runEvent)
-			if err != nil {
-				return nil, err
-			}
+	err = saveFacets(ctx, qtx, lineage.FacetEntityTypeRun, run.ID, ev.Run.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	// every event type can mention datasets, e.g. a failed run still touched
+	// its inputs and a running one is already reading them
+	for _, dsInput := range ev.Inputs {
+		dsIO := IODataset{
+			Dataset:  dsInput.Dataset,
+			IOFacets: dsInput.InputFacets,
+			Type:     lineage.IOTypeInput,
 		}
-		for _, dsOutput := range ev.Outputs {
-			dsIO := IODataset{
-				Dataset:  dsOutput.Dataset,
-				IOFacets: dsOutput.OutputFacets,
-				Type:     lineage.IOTypeOutput,
-			}
-			_, err := handleIO(ctx, qtx, dsIO, runEvent)
-			if err != nil {
-				return nil, err
-			}
+		_, err := handleIO(ctx, qtx, dsIO, runEvent)
+		if err != nil {
+			return nil, err
 		}
 	}
-
-	if err = tx.Commit(); err != nil {
-		return nil, err
+	for _, dsOutput := range ev.Outputs {
+		dsIO := IODataset{
+			Dataset:  dsOutput.Dataset,
+			IOFacets: dsOutput.OutputFacets,
+			Type:     lineage.IOTypeOutput,
+		}
+		_, err := handleIO(ctx, qtx, dsIO, runEvent)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &lineage.RunEvent{
@@ -7,8 +7,8 @@ import (
 	"oplin/internal/lineage"
 	ops "oplin/internal/lineage/ops"
 	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
 	"oplin/internal/openlineage"
-	"oplin/internal/utils"
 	"testing"
 	"time"
 
@@ -18,8 +18,8 @@ import (
 
 func setupSuite(tb testing.TB) (*ops.TestDeps, func(tb testing.TB)) {
 	ctx := context.Background()
-	db := utils.GetTestDB()
-	deps := &ops.TestDeps{DB: db}
+	st := store.GetTestStore()
+	deps := &ops.TestDeps{Store: st}
 
 	err := ops.InitializeTestDB(ctx, deps)
 	if err != nil {
@@ -242,3 +242,385 @@ func TestColumnLineageFacets(t *testing.T) {
 	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
 	assert.Nil(t, err)
 }
+
+func TestInputsAndOutputsForAllEventTypes(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	start := time.Now().UTC()
+	runUUID := uuid.New()
+
+	ev := getRunEvent(runUUID, start)
+	ev.Inputs = []openlineage.InputDataset{
+		{Dataset: openlineage.Dataset{Namespace: "food_delivery", Name: "public.orders"}},
+	}
+	runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	ios, err := ops.ListRunDatasetVersionsWithRelationshipsByRunID(ctx, deps, runEvent.RunID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ios))
+	assert.Equal(t, lineage.IOTypeInput, ios[0].RunIODataset.IOType)
+	assert.Equal(t, lineage.RunEventTypeStart, ios[0].RunIODataset.FirstEventType)
+	assert.Equal(t, lineage.RunEventTypeStart, ios[0].RunIODataset.LastEventType)
+
+	ev = getRunEvent(runUUID, start.Add(time.Second*5))
+	ev.EventType = "fail"
+	ev.Inputs = []openlineage.InputDataset{
+		{Dataset: openlineage.Dataset{Namespace: "food_delivery", Name: "public.orders"}},
+	}
+	ev.Outputs = []openlineage.OutputDataset{
+		{Dataset: openlineage.Dataset{Namespace: "food_delivery", Name: "public.orders_summary"}},
+	}
+	runEvent, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	ios, err = ops.ListRunDatasetVersionsWithRelationshipsByRunID(ctx, deps, runEvent.RunID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ios))
+	for _, io := range ios {
+		if io.RunIODataset.IOType == lineage.IOTypeInput {
+			assert.Equal(t, "public.orders", io.DatasetVersion.Name)
+			assert.Equal(t, lineage.RunEventTypeStart, io.RunIODataset.FirstEventType)
+			assert.Equal(t, lineage.RunEventTypeFail, io.RunIODataset.LastEventType)
+		} else {
+			assert.Equal(t, "public.orders_summary", io.DatasetVersion.Name)
+			assert.Equal(t, lineage.RunEventTypeFail, io.RunIODataset.FirstEventType)
+			assert.Equal(t, lineage.RunEventTypeFail, io.RunIODataset.LastEventType)
+		}
+	}
+}
+
+func TestJobVersionedWhenFacetsChange(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	sqlFacet := `{"sql": {"_producer": "%s", "query": "%s"}}`
+
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job.Facets = []byte(fmt.Sprintf(sqlFacet, "v1", "select 1"))
+	runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	run, err := ops.GetRunWithID(ctx, deps, runEvent.RunID)
+	assert.Nil(t, err)
+	jv, err := ops.GetJobVersionByID(ctx, deps, run.JobVersionID)
+	assert.Nil(t, err)
+
+	// a new producer alone is not a new version
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job.Facets = []byte(fmt.Sprintf(sqlFacet, "v2", "select 1"))
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	vs, err := ops.ListJobVersions(ctx, deps, jv.JobID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vs))
+
+	ev = getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job.Facets = []byte(fmt.Sprintf(sqlFacet, "v2", "select 2"))
+	_, err = ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	vs, err = ops.ListJobVersions(ctx, deps, jv.JobID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(vs))
+	assert.Equal(t, "select 2", vs[0].Facets.SQLJob.Query)
+
+	jns, err := ops.GetJobWithNamespace(ctx, deps, jv.JobID)
+	assert.Nil(t, err)
+	assert.Equal(t, vs[0].ID, jns.Job.CurrentVersionID)
+	assert.Equal(t, "select 2", jns.Job.Facets.SQLJob.Query)
+
+	diffs, err := ops.DiffJobVersion(ctx, deps, vs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(diffs))
+	assert.Equal(t, lineage.FacetChangeChanged, diffs[0].Change)
+
+	runs, err := ops.ListRunsByJobID(ctx, deps, jv.JobID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(runs))
+}
+
+func TestSchemaChangesCreateVersions(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	schema := `{"schema": {"fields": [
+		{"name": "id", "type": "%s"},
+		{"name": "address", "type": "struct", "fields": [{"name": "city", "type": "string", "description": "%s"}]}
+	]}}`
+	send := func(idType string, cityDesc string) {
+		ev := getRunEvent(uuid.New(), time.Now().UTC())
+		ev.Outputs = []openlineage.OutputDataset{
+			{Dataset: openlineage.Dataset{
+				Namespace: "food_delivery",
+				Name:      "public.customers",
+				Facets:    []byte(fmt.Sprintf(schema, idType, cityDesc)),
+			}},
+		}
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+
+	send("int", "the city")
+	send("int", "the city")
+
+	dss, err := ops.ListDatasetsWithNamespaces(ctx, deps)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dss))
+	dsID := dss[0].Dataset.ID
+
+	vs, err := ops.ListDatasetVersions(ctx, deps, dsID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vs))
+
+	fields, err := ops.ListFieldsForDatasetVersion(ctx, deps, vs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(fields))
+
+	send("bigint", "the city")
+	send("bigint", "the town")
+
+	vs, err = ops.ListDatasetVersions(ctx, deps, dsID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(vs))
+
+	changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, vs[1].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, lineage.SchemaChangeTypeChanged, changes[0].ChangeType)
+	assert.Equal(t, "id", changes[0].FieldName)
+	assert.Equal(t, "int", changes[0].PreviousDataType)
+	assert.Equal(t, "bigint", changes[0].DataType)
+
+	changes, err = ops.ListSchemaChangesForDatasetVersion(ctx, deps, vs[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "address.city", changes[0].FieldName)
+	assert.Equal(t, vs[1].ID, changes[0].PreviousVersionID)
+}
+
+func TestSchemaAfterVersionWithoutSchema(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	send := func(name string, facets string) {
+		ev := getRunEvent(uuid.New(), time.Now().UTC())
+		ev.Outputs = []openlineage.OutputDataset{
+			{Dataset: openlineage.Dataset{Namespace: "food_delivery", Name: name, Facets: []byte(facets)}},
+		}
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+	withFields := `{"schema": {"fields": [{"name": "id", "type": "int"}]}}`
+
+	// first recorded with no schema, then with an empty one
+	send("public.orders", `{}`)
+	send("public.customers", `{"schema": {"fields": []}}`)
+	send("public.orders", withFields)
+	send("public.customers", withFields)
+
+	for _, name := range []string{"public.orders", "public.customers"} {
+		ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "food_delivery", name)
+		assert.Nil(t, err)
+		vs, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(vs), name)
+
+		fields, err := ops.ListFieldsForDatasetVersion(ctx, deps, vs[1].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(fields), name)
+		changes, err := ops.ListSchemaChangesForDatasetVersion(ctx, deps, vs[0].ID)
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(changes), name) {
+			assert.Equal(t, lineage.SchemaChangeTypeAdded, changes[0].ChangeType)
+			assert.Equal(t, vs[1].ID, changes[0].PreviousVersionID)
+		}
+	}
+
+	// an empty schema for a version without one changes nothing
+	send("public.events", `{}`)
+	send("public.events", `{"schema": {"fields": []}}`)
+	ds, err := ops.GetDatasetWithNamespaceByName(ctx, deps, "food_delivery", "public.events")
+	assert.Nil(t, err)
+	vs, err := ops.ListDatasetVersions(ctx, deps, ds.Dataset.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vs))
+}
+
+func TestChildBeforeParent(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	parentUUID := uuid.New()
+	childUUID := uuid.New()
+
+	ev := getRunEvent(childUUID, time.Now().UTC())
+	ev.Job = openlineage.NewJob("airflow", "orders.monthly_summary.load", nil)
+	ev.Run.Facets = []byte(
+		fmt.Sprintf(`{"parent": {
+			"job": {"name": "orders.monthly_summary", "namespace": "airflow"},
+			"run": {"runId": "%s"}
+		}}`, parentUUID),
+	)
+	childEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	child, err := ops.GetRunWithID(ctx, deps, childEvent.RunID)
+	assert.Nil(t, err)
+	assert.False(t, child.IsPlaceholder)
+
+	parent, err := ops.GetRunWithUUID(ctx, deps, parentUUID)
+	assert.Nil(t, err)
+	assert.True(t, parent.IsPlaceholder)
+	assert.Equal(t, parent.ID, child.ParentRunID)
+
+	start := time.Now().UTC()
+	ev = getRunEvent(parentUUID, start)
+	parentEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	assert.Equal(t, parent.ID, parentEvent.RunID)
+
+	parent, err = ops.GetRunWithUUID(ctx, deps, parentUUID)
+	assert.Nil(t, err)
+	assert.False(t, parent.IsPlaceholder)
+	assertTimeEqual(t, start, parent.StartedAt)
+
+	tree, err := ops.GetRunTree(ctx, deps, child.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, parent.ID, tree.Run.ID)
+	assert.Equal(t, "orders.monthly_summary", tree.JobName)
+	assert.Equal(t, 1, len(tree.Children))
+	assert.Equal(t, child.ID, tree.Children[0].Run.ID)
+}
+
+func TestDuplicateRunEvents(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	assert.False(t, runEvent.Duplicate)
+
+	dup, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+	assert.True(t, dup.Duplicate)
+	assert.Equal(t, runEvent.ID, dup.ID)
+
+	// the same run, type and time with another payload is a new event
+	other := ev
+	other.Producer = "other"
+	created, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &other)
+	assert.Nil(t, err)
+	assert.False(t, created.Duplicate)
+
+	// duplicates within a batch and of recorded events
+	complete := ev
+	complete.EventType = "complete"
+	res, err := ol_ops.CreateWithOpenLineageRunEvents(
+		ctx, deps, []*openlineage.RunEvent{&ev, &complete, &complete}, []string{"", "k/1", "k/2"}, nil,
+	)
+	assert.Nil(t, err)
+	assert.True(t, res[0].RunEvent.Duplicate)
+	assert.False(t, res[1].RunEvent.Duplicate)
+	assert.True(t, res[2].RunEvent.Duplicate)
+	assert.Equal(t, res[1].RunEvent.ID, res[2].RunEvent.ID)
+
+	_, err = ol_ops.CreateWithOpenLineageRunEventAndKey(ctx, deps, &ev, "k/1", nil)
+	assert.ErrorIs(t, err, ol_ops.ErrIdempotencyKeyReused)
+
+	evs, err := ops.ListRunEventsByRunID(ctx, deps, runEvent.RunID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(evs))
+}
+
+func TestRequestKeepsThePayload(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	runUUID := uuid.New()
+	ev := getRunEvent(runUUID, time.Now().UTC())
+	ev.EventType = "Running"
+	payload := []byte(fmt.Sprintf(`{ "eventType": "Running", "run": {"runId": "%s"} }`, runUUID))
+	_, err := ol_ops.CreateWithOpenLineageRunEventAndKey(ctx, deps, &ev, "", payload)
+	assert.Nil(t, err)
+
+	page, err := ops.ListRequests(ctx, deps, lineage.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Requests))
+	assert.Equal(t, string(payload), string(page.Requests[0].Payload))
+}
+
+func TestSearchIndexOnlyFollowsChanges(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	send := func(description string) {
+		ev := getRunEvent(uuid.New(), time.Now().UTC())
+		ev.Job = openlineage.NewJob("airflow", "orders.monthly_summary", []byte(fmt.Sprintf(
+			`{"documentation": {"description": "%s"}}`, description)))
+		ev.Outputs = []openlineage.OutputDataset{{Dataset: *openlineage.NewDataset("warehouse", "orders", []byte(fmt.Sprintf(
+			`{"documentation": {"description": "%s"}, "dataSource": {"uri": "%s"}}`, description, uuid.New())))}}
+		_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		assert.Nil(t, err)
+	}
+	search := func(q string) int {
+		res, err := ops.FullTextSearch(ctx, deps, q, lineage.NodeTypeUnknown, 10)
+		assert.Nil(t, err)
+		return len(res)
+	}
+
+	send("monthly totals")
+	assert.Equal(t, 2, search("monthly totals"))
+
+	// the same indexed text leaves the index alone
+	assert.Nil(t, deps.GetStore().Queries().DeleteSearchDocuments(ctx))
+	send("monthly totals")
+	assert.Equal(t, 0, search("monthly totals"))
+
+	send("totals by month")
+	assert.Equal(t, 2, search("totals by month"))
+	assert.Equal(t, 0, search("monthly totals"))
+}
+
+func TestFullTextSearchRanksBeforeTheLimit(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	// datasets whose descriptions match sort before the name matches by name
+	ev := getRunEvent(uuid.New(), time.Now().UTC())
+	ev.Job = openlineage.NewJob("airflow", "orders", nil)
+	for i := 0; i < 15; i++ {
+		ev.Outputs = append(ev.Outputs, openlineage.OutputDataset{Dataset: *openlineage.NewDataset("warehouse", fmt.Sprintf("a_%02d", i), []byte(
+			`{"documentation": {"description": "all the orders"}}`))})
+	}
+	ev.Outputs = append(ev.Outputs, openlineage.OutputDataset{Dataset: *openlineage.NewDataset("warehouse", "z_orders", nil)})
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	assert.Nil(t, err)
+
+	res, err := ops.FullTextSearch(ctx, deps, "orders", lineage.NodeTypeUnknown, 3)
+	assert.Nil(t, err)
+	if assert.Equal(t, 3, len(res)) {
+		assert.Equal(t, lineage.NodeID{Type: lineage.NodeTypeJob, Namespace: "airflow", Name: "orders"}, res[0].NodeID)
+		assert.Equal(t, "z_orders", res[1].NodeID.Name)
+		assert.Equal(t, "a_00", res[2].NodeID.Name)
+		assert.Equal(t, lineage.SearchFieldDescription, res[2].Matches[0].Field)
+	}
+
+	res, err = ops.FullTextSearch(ctx, deps, "orders", lineage.NodeTypeDataset, 1)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(res)) {
+		assert.Equal(t, "z_orders", res[0].NodeID.Name)
+	}
+}
@@ -13,14 +13,18 @@ import (
 	"github.com/rotisserie/eris"
 )
 
+// createRunIfNotExists returns the run with the given uuid, creating it if it
+// has not been seen yet. A placeholder run, created earlier because a child
+// reported first, is filled in with what the run's own event says about it.
 func createRunIfNotExists(
-	ctx context.Context, qtx *db.Queries, runUUID uuid.UUID, eventTime time.Time, jobVersionID int64, msg json.RawMessage,
+	ctx context.Context, qtx db.Querier, runUUID uuid.UUID, eventTime time.Time, jobVersionID int64, msg json.RawMessage,
 ) (*db.LineageRun, error) {
 	run, err := qtx.GetRunByUUID(ctx, runUUID)
 	if err != nil && !utils.IsNoRowsError(err) {
 		return nil, eris.Wrapf(err, "get run[%s] failed", runUUID)
 	}
-	if err == nil {
+	found := err == nil
+	if found && !run.IsPlaceholder {
 		return &run, nil
 	}
 
@@ -35,15 +39,31 @@ func createRunIfNotExists(
 
 	var parentRunID *int64
 	if len(fs.Parent.ParentRun.RunID) > 0 {
-		u, err := uuid.Parse(fs.Parent.ParentRun.RunID)
+		parent, err := createParentRunIfNotExists(ctx, qtx, runUUID, fs.Parent)
 		if err != nil {
-			return nil, eris.Wrapf(err, "parent run id[%v] conversion to uuid failed", fs.Parent.ParentRun.RunID)
+			return nil, err
+		}
+		if parent != nil {
+			parentRunID = &parent.ID
+		}
+	}
+
+	if found {
+		params := db.FillPlaceholderRunParams{
+			ID:               run.ID,
+			JobVersionID:     jobVersionID,
+			Facets:           utils.ToPQRawMessageType(msg),
+			ParentRunID:      utils.NullInt64(parentRunID),
+			NominalStartedAt: utils.NullTime(fs.NominalTime.StartTime),
+			NominalEndedAt:   utils.NullTime(fs.NominalTime.EndTime),
+			StartedAt:        utils.NullTime(eventTime),
+			UpdatedAt:        utils.NowUTCAsNullTime(),
 		}
-		parent, err := qtx.GetRunByUUID(ctx, u)
+		run, err = qtx.FillPlaceholderRun(ctx, params)
 		if err != nil {
-			return nil, eris.Wrapf(err, "get parent by run uuid[%v] failed", u)
+			return nil, eris.Wrapf(err, "fill placeholder run[%v] failed", params)
 		}
-		parentRunID = &parent.ID
+		return &run, nil
 	}
 
 	params := db.CreateRunParams{
@@ -63,15 +83,126 @@ func createRunIfNotExists(
 	return &run, nil
 }
 
+// createParentRunIfNotExists returns the run named by the parent facet. Child
+// tasks often report before the run that started them, so when the parent
+// has not been seen yet a placeholder run is created for the facet's job.
+// A run naming itself as its parent has no parent.
+func createParentRunIfNotExists(
+	ctx context.Context, qtx db.Querier, runUUID uuid.UUID, parent openlineage.ParentRunFacet,
+) (*db.LineageRun, error) {
+	u, err := uuid.Parse(parent.ParentRun.RunID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "parent run id[%v] conversion to uuid failed", parent.ParentRun.RunID)
+	}
+	if u == runUUID {
+		return nil, nil
+	}
+
+	run, err := qtx.GetRunByUUID(ctx, u)
+	if err != nil && !utils.IsNoRowsError(err) {
+		return nil, eris.Wrapf(err, "get parent by run uuid[%v] failed", u)
+	}
+	if err == nil {
+		return &run, nil
+	}
+
+	if parent.ParentJob.Namespace == "" || parent.ParentJob.Name == "" {
+		return nil, eris.Errorf("parent run[%s] not found and the parent facet names no job", u)
+	}
+
+	ns, err := createJobNamespaceIfNotExists(ctx, qtx, parent.ParentJob.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := createJobIfNotExists(ctx, qtx, ns.ID, parent.ParentJob.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jobVersion, err := createCurrentJobVersion(ctx, qtx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.CreateRunParams{
+		RunUuid:       u,
+		JobVersionID:  jobVersion.ID,
+		CreatedAt:     utils.NowUTC(),
+		IsPlaceholder: true,
+	}
+	run, err = qtx.CreateRun(ctx, params)
+	if err != nil {
+		return nil, eris.Wrapf(err, "create placeholder run[%v] failed", params)
+	}
+	return &run, nil
+}
+
+// nextRunState is the state an event moves a run to. Runs do not leave a
+// finished state and OTHER events do not change the state.
+func nextRunState(state lineage.RunState, eventType lineage.RunEventType) lineage.RunState {
+	if state.IsFinished() {
+		return state
+	}
+	switch eventType {
+	case lineage.RunEventTypeStart, lineage.RunEventTypeRunning:
+		return lineage.RunStateRunning
+	case lineage.RunEventTypeComplete:
+		return lineage.RunStateCompleted
+	case lineage.RunEventTypeAbort:
+		return lineage.RunStateAborted
+	case lineage.RunEventTypeFail:
+		return lineage.RunStateFailed
+	}
+	if state == lineage.RunStateUnknown {
+		return lineage.RunStateNew
+	}
+	return state
+}
+
+// updateRun moves the run to the state of the event. Events can arrive out
+// of order, one older than the latest event of the run is stale and only
+// fills in what the run is missing, such as an earlier start.
 func updateRun(
-	ctx context.Context, qtx *db.Queries, run *db.LineageRun, runEvent *db.LineageRunEvent,
+	ctx context.Context, qtx db.Querier, run *db.LineageRun, runEvent *db.LineageRunEvent,
 ) (*db.LineageRun, error) {
-	endedAt := run.EndedAt.Time
-	if runEvent.EventType == int32(lineage.RunEventTypeComplete) {
-		endedAt = runEvent.EventTime
+	eventType := lineage.RunEventType(runEvent.EventType)
+	stale := run.LastEventTime.Valid && runEvent.EventTime.Before(run.LastEventTime.Time)
+
+	state := lineage.RunState(run.State)
+
+	// a run starts with its first event that is not OTHER or with an
+	// earlier one arriving late
+	startedAt := run.StartedAt
+	if eventType != lineage.RunEventTypeOther &&
+		(state <= lineage.RunStateNew || !startedAt.Valid || runEvent.EventTime.Before(startedAt.Time)) {
+		startedAt = utils.NullTime(runEvent.EventTime)
+	}
+
+	endedAt := run.EndedAt
+	lastEventType := run.LastEventType
+	lastEventTime := run.LastEventTime
+	if !stale {
+		state = nextRunState(state, eventType)
+		if state.IsFinished() && !lineage.RunState(run.State).IsFinished() {
+			endedAt = utils.NullTime(runEvent.EventTime)
+		}
+		lastEventType = runEvent.EventType
+		// OTHER events do not change the state so do not make the events
+		// that do stale
+		if eventType != lineage.RunEventTypeOther {
+			lastEventTime = utils.NullTime(runEvent.EventTime)
+		}
 	}
 
-	msg, err := utils.MergeFacets(run.Facets.RawMessage, runEvent.Facets.RawMessage)
+	// the facets of a stale event do not replace newer ones
+	var msg json.RawMessage
+	var err error
+	if stale {
+		msg, err = utils.MergeFacets(runEvent.Facets.RawMessage, run.Facets.RawMessage)
+	} else {
+		msg, err = utils.MergeFacets(run.Facets.RawMessage, runEvent.Facets.RawMessage)
+	}
 	if err != nil {
 		return nil, eris.Wrapf(err, "update run failed cannot merge facets[%v], [%v]", run.Facets.RawMessage, runEvent.Facets.RawMessage)
 	}
@@ -87,12 +218,15 @@ func updateRun(
 	r, err := qtx.UpdateRun(ctx, db.UpdateRunParams{
 		ID:                  run.ID,
 		Facets:              utils.ToPQRawMessageType(msg),
-		EndedAt:             utils.NullTime(endedAt),
-		LastEventType:       runEvent.EventType,
+		LastEventType:       lastEventType,
 		ErrorMessage:        utils.NullString(fs.ErrorMessage.Message),
 		ProgrammingLanguage: utils.NullString(fs.ErrorMessage.ProgrammingLanguage),
 		Stacktrace:          utils.NullString(fs.ErrorMessage.Stacktrace),
-		UpdatedAt:           utils.NullTime(time.Now()),
+		StartedAt:           startedAt,
+		EndedAt:             endedAt,
+		UpdatedAt:           utils.NowUTCAsNullTime(),
+		State:               int32(state),
+		LastEventTime:       lastEventTime,
 	})
 	if err != nil {
 		return nil, eris.Wrapf(err, "could not update run[%d]", run.ID)
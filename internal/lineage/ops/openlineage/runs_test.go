@@ -0,0 +1,94 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStates(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	runUUID := uuid.New()
+	send := func(eventType string, eventTime time.Time) *lineage.Run {
+		ev := getRunEvent(runUUID, eventTime)
+		ev.EventType = eventType
+		runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+		require.Nil(t, err)
+		run, err := ops.GetRunWithID(ctx, deps, runEvent.RunID)
+		require.Nil(t, err)
+		return run
+	}
+
+	run := send("other", start.Add(3*time.Second))
+	assert.Equal(t, lineage.RunStateNew, run.State)
+
+	run = send("running", start.Add(2*time.Second))
+	assert.Equal(t, lineage.RunStateRunning, run.State)
+
+	// a START sent before the RUNNING but arriving after it moves the start
+	// back without changing the state
+	run = send("start", start.Add(time.Second))
+	assert.Equal(t, lineage.RunStateRunning, run.State)
+	assert.Equal(t, lineage.RunEventTypeRunning, run.LastEventType)
+	assertTimeEqual(t, start.Add(time.Second), run.StartedAt)
+
+	run = send("fail", start.Add(10*time.Second))
+	assert.Equal(t, lineage.RunStateFailed, run.State)
+	assertTimeEqual(t, start.Add(10*time.Second), run.EndedAt)
+	assert.Equal(t, 9*time.Second, run.Duration())
+
+	// stale events do not move a failed run back to running
+	run = send("running", start.Add(5*time.Second))
+	assert.Equal(t, lineage.RunStateFailed, run.State)
+
+	// nor do later ones
+	run = send("complete", start.Add(20*time.Second))
+	assert.Equal(t, lineage.RunStateFailed, run.State)
+	assertTimeEqual(t, start.Add(10*time.Second), run.EndedAt)
+
+	page, err := ops.ListRunsWithJobs(ctx, deps, lineage.ListOptions{State: lineage.RunStateFailed, Namespace: "airflow"})
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(page.Runs))
+	assert.Equal(t, "orders.monthly_summary", page.Runs[0].JobName)
+
+	page, err = ops.ListRunsWithJobs(ctx, deps, lineage.ListOptions{State: lineage.RunStateRunning})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Runs))
+
+	page, err = ops.ListRunsWithJobs(ctx, deps, lineage.ListOptions{UpdatedBefore: start})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Runs))
+}
+
+func TestAbortedRunEnds(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	start := time.Now().UTC()
+	ev := getRunEvent(uuid.New(), start)
+	_, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	require.Nil(t, err)
+
+	ev.EventType = "abort"
+	ev.EventTime = start.Add(time.Minute)
+	runEvent, err := ol_ops.CreateWithOpenLineageRunEvent(ctx, deps, &ev)
+	require.Nil(t, err)
+
+	run, err := ops.GetRunWithID(ctx, deps, runEvent.RunID)
+	assert.Nil(t, err)
+	assert.Equal(t, lineage.RunStateAborted, run.State)
+	assertTimeEqual(t, start.Add(time.Minute), run.EndedAt)
+	assert.Equal(t, time.Minute, run.Duration())
+}
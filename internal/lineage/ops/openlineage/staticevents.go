@@ -0,0 +1,300 @@
+package openlineage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
+	"oplin/internal/lineage/store"
+	"oplin/internal/openlineage"
+	"oplin/internal/utils"
+
+	"github.com/rotisserie/eris"
+)
+
+// declaredIO is a dataset a job event declares as an input or output
+type declaredIO struct {
+	DatasetID int64
+	Type      lineage.IOType
+	IOFacets  json.RawMessage
+}
+
+type declaredIOKey struct {
+	DatasetID int64
+	Type      lineage.IOType
+}
+
+func listDeclaredIO(ctx context.Context, qtx db.Querier, jvID int64) ([]declaredIO, error) {
+	rows, err := qtx.ListJobVersionIODatasetsByJobVersionID(ctx, jvID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "list declared datasets of job version[%v] failed", jvID)
+	}
+	var res []declaredIO
+	for _, row := range rows {
+		res = append(res, declaredIO{
+			DatasetID: row.DatasetID,
+			Type:      lineage.IOType(row.IoType),
+			IOFacets:  row.IoFacets.RawMessage,
+		})
+	}
+	return res, nil
+}
+
+func createDeclaredIO(ctx context.Context, qtx db.Querier, jvID int64, declared []declaredIO) error {
+	for _, d := range declared {
+		params := db.CreateJobVersionIODatasetParams{
+			JobVersionID: jvID,
+			DatasetID:    d.DatasetID,
+			IoType:       int32(d.Type),
+			IoFacets:     utils.ToPQRawMessageType(d.IOFacets),
+			CreatedAt:    utils.NowUTC(),
+		}
+		_, err := qtx.CreateJobVersionIODataset(ctx, params)
+		if err != nil {
+			return eris.Wrapf(err, "create job version io dataset[%v] failed", params)
+		}
+	}
+	return nil
+}
+
+// sameDeclaredIO returns true if both declare the same datasets with the
+// same io types, io facets are not compared
+func sameDeclaredIO(a []declaredIO, b []declaredIO) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keys := make(map[declaredIOKey]bool)
+	for _, d := range a {
+		keys[declaredIOKey{d.DatasetID, d.Type}] = true
+	}
+	for _, d := range b {
+		if !keys[declaredIOKey{d.DatasetID, d.Type}] {
+			return false
+		}
+	}
+	return true
+}
+
+// createJobVersionWithIO returns the current version of the job when it has
+// the job's facets and declares the same datasets, otherwise a new version
+// declaring the datasets becomes the current one
+func createJobVersionWithIO(
+	ctx context.Context, qtx db.Querier, job *db.LineageJob, declared []declaredIO,
+) (*db.LineageJobVersion, error) {
+	if job.CurrentVersionID.Valid {
+		jv, err := qtx.GetJobVersionByID(ctx, job.CurrentVersionID.Int64)
+		if err != nil {
+			return nil, eris.Wrapf(err, "get job version[%v] failed", job.CurrentVersionID.Int64)
+		}
+		current, err := listDeclaredIO(ctx, qtx, jv.ID)
+		if err != nil {
+			return nil, err
+		}
+		if utils.FacetsEqual(jv.Facets.RawMessage, job.Facets.RawMessage) && sameDeclaredIO(current, declared) {
+			return &jv, nil
+		}
+	}
+
+	jv, err := createJobVersion(ctx, qtx, job)
+	if err != nil {
+		return nil, err
+	}
+	err = createDeclaredIO(ctx, qtx, jv.ID, declared)
+	if err != nil {
+		return nil, err
+	}
+
+	err = updateCurrentJobVersion(ctx, qtx, job, jv)
+	if err != nil {
+		return nil, err
+	}
+	return jv, nil
+}
+
+// CreateWithOpenLineageDatasetEvent records the dataset, its facets and its
+// schema from a dataset event, one that cannot be recorded is kept as a
+// failed event. The payload is the body the event was received as, it is
+// stored instead of the event when given.
+func CreateWithOpenLineageDatasetEvent(
+	ctx context.Context, deps Deps, ev *openlineage.DatasetEvent, payload json.RawMessage,
+) (*lineage.DatasetVersion, error) {
+	res, err := createDatasetEventTx(ctx, deps, ev, payload)
+	if err != nil {
+		recordFailure(ctx, deps.GetStore().Queries(), ev, payload, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// createDatasetEventTx records the dataset event in its own transaction
+func createDatasetEventTx(
+	ctx context.Context, deps Deps, ev *openlineage.DatasetEvent, payload json.RawMessage,
+) (*lineage.DatasetVersion, error) {
+	if ev.Dataset == nil {
+		return nil, eris.New("dataset event has no dataset")
+	}
+
+	var res *lineage.DatasetVersion
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		var err error
+		res, err = createWithDatasetEvent(ctx, qtx, ev, payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func createWithDatasetEvent(
+	ctx context.Context, qtx db.Querier, ev *openlineage.DatasetEvent, payload json.RawMessage,
+) (*lineage.DatasetVersion, error) {
+	err := saveRequest(ctx, qtx, ev, payload, "", sql.NullInt64{})
+	if err != nil {
+		return nil, eris.Wrap(err, "could not save request")
+	}
+	return recordDatasetEvent(ctx, qtx, ev)
+}
+
+func recordDatasetEvent(ctx context.Context, qtx db.Querier, ev *openlineage.DatasetEvent) (*lineage.DatasetVersion, error) {
+	dsVersion, err := handleDataset(ctx, qtx, *ev.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	err = handleLifecycleStateChange(ctx, qtx, dsVersion.DatasetID, sql.NullInt64{}, ev.Dataset.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lineage.DatasetVersion{
+		ID:                 dsVersion.ID,
+		VersionUUID:        dsVersion.VersionUuid,
+		DatasetID:          dsVersion.DatasetID,
+		DatasetNamespaceID: dsVersion.NamespaceID,
+		Name:               dsVersion.Name,
+		CreatedAt:          dsVersion.CreatedAt,
+		UpdatedAt:          dsVersion.UpdatedAt.Time,
+	}, nil
+}
+
+// CreateWithOpenLineageJobEvent records the job and the datasets it declares
+// as inputs and outputs from a job event. Declaring different datasets
+// creates a new job version. An event that cannot be recorded is kept as a
+// failed event. The payload is stored like for a dataset event.
+func CreateWithOpenLineageJobEvent(
+	ctx context.Context, deps Deps, ev *openlineage.JobEvent, payload json.RawMessage,
+) (*lineage.JobVersion, error) {
+	res, err := createJobEventTx(ctx, deps, ev, payload)
+	if err != nil {
+		recordFailure(ctx, deps.GetStore().Queries(), ev, payload, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// createJobEventTx records the job event in its own transaction
+func createJobEventTx(
+	ctx context.Context, deps Deps, ev *openlineage.JobEvent, payload json.RawMessage,
+) (*lineage.JobVersion, error) {
+	if ev.Job == nil {
+		return nil, eris.New("job event has no job")
+	}
+
+	var res *lineage.JobVersion
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		var err error
+		res, err = createWithJobEvent(ctx, qtx, ev, payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func createWithJobEvent(
+	ctx context.Context, qtx db.Querier, ev *openlineage.JobEvent, payload json.RawMessage,
+) (*lineage.JobVersion, error) {
+	err := saveRequest(ctx, qtx, ev, payload, "", sql.NullInt64{})
+	if err != nil {
+		return nil, eris.Wrap(err, "could not save request")
+	}
+	return recordJobEvent(ctx, qtx, ev)
+}
+
+func recordJobEvent(ctx context.Context, qtx db.Querier, ev *openlineage.JobEvent) (*lineage.JobVersion, error) {
+	ns, err := createJobNamespaceIfNotExists(ctx, qtx, ev.Job.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := createJobIfNotExists(ctx, qtx, ns.ID, ev.Job.Name, ev.Job.Facets)
+	if err != nil {
+		return nil, err
+	}
+	indexedFacets := job.Facets.RawMessage
+
+	job, err = updateJobFacets(ctx, qtx, job, ev.Job.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	err = saveFacets(ctx, qtx, lineage.FacetEntityTypeJob, job.ID, ev.Job.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	// a job without a version is new
+	if !job.CurrentVersionID.Valid || ops.SearchFacetsChanged(indexedFacets, job.Facets.RawMessage) {
+		err = ops.IndexJob(ctx, qtx, job.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var declared []declaredIO
+	seen := make(map[declaredIOKey]bool)
+	addDeclared := func(ds openlineage.Dataset, msg json.RawMessage, t lineage.IOType) error {
+		dsVersion, err := handleDataset(ctx, qtx, ds)
+		if err != nil {
+			return err
+		}
+		err = handleLifecycleStateChange(ctx, qtx, dsVersion.DatasetID, sql.NullInt64{}, ds.Facets)
+		if err != nil {
+			return err
+		}
+		key := declaredIOKey{dsVersion.DatasetID, t}
+		if !seen[key] {
+			seen[key] = true
+			declared = append(declared, declaredIO{DatasetID: dsVersion.DatasetID, Type: t, IOFacets: msg})
+		}
+		return nil
+	}
+	for _, in := range ev.Inputs {
+		if err := addDeclared(in.Dataset, in.InputFacets, lineage.IOTypeInput); err != nil {
+			return nil, err
+		}
+	}
+	for _, out := range ev.Outputs {
+		if err := addDeclared(out.Dataset, out.OutputFacets, lineage.IOTypeOutput); err != nil {
+			return nil, err
+		}
+	}
+
+	jv, err := createJobVersionWithIO(ctx, qtx, job, declared)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lineage.JobVersion{
+		ID:             jv.ID,
+		VersionUUID:    jv.VersionUuid,
+		JobID:          jv.JobID,
+		JobNamespaceID: jv.NamespaceID,
+		Name:           jv.Name,
+		CreatedAt:      jv.CreatedAt,
+		UpdatedAt:      jv.UpdatedAt.Time,
+	}, nil
+}
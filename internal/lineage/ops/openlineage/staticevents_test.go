@@ -0,0 +1,80 @@
+package openlineage_test
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	ops "oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/openlineage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetEvent(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	ev := openlineage.DatasetEvent{
+		EventTime: time.Now().UTC(),
+		Dataset: openlineage.NewDataset("food_delivery", "public.customers", []byte(
+			`{"schema": {"fields": [{"name": "id", "type": "int"}, {"name": "name", "type": "string"}]}}`,
+		)),
+	}
+	dv, err := ol_ops.CreateWithOpenLineageDatasetEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+
+	fields, err := ops.ListFieldsForDatasetVersion(ctx, deps, dv.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(fields))
+
+	// no run or job is needed
+	jobs, err := ops.ListJobsWithNamespaces(ctx, deps)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func TestJobEvent(t *testing.T) {
+	deps, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	ev := openlineage.JobEvent{
+		EventTime: time.Now().UTC(),
+		Job:       openlineage.NewJob("warehouse", "public.customers_view", nil),
+		Inputs: []openlineage.InputDataset{
+			{Dataset: *openlineage.NewDataset("warehouse", "public.customers", nil)},
+		},
+		Outputs: []openlineage.OutputDataset{
+			{Dataset: *openlineage.NewDataset("warehouse", "public.customers_view", nil)},
+		},
+	}
+	jv, err := ol_ops.CreateWithOpenLineageJobEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+
+	declared, err := ops.ListDeclaredDatasetsForJobVersion(ctx, deps, jv.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(declared))
+	assert.Equal(t, lineage.IOTypeInput, declared[0].IOType)
+	assert.Equal(t, "public.customers", declared[0].DatasetName)
+
+	// the same declaration keeps the version
+	same, err := ol_ops.CreateWithOpenLineageJobEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, jv.ID, same.ID)
+
+	// declared datasets are part of the lineage graph
+	graph, err := ops.GetLineageGraph(ctx, deps, lineage.NodeID{
+		Type: lineage.NodeTypeJob, Namespace: "warehouse", Name: "public.customers_view",
+	}, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+	assert.Equal(t, 2, len(graph.Edges))
+
+	ev.Outputs = nil
+	changed, err := ol_ops.CreateWithOpenLineageJobEvent(ctx, deps, &ev, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, jv.ID, changed.ID)
+}
@@ -0,0 +1,108 @@
+package ops
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"oplin/internal/lineage"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// ErrInvalidCursor is returned for an After that is not a cursor of the list
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// endOfTime is later than every row so the first page of a newest first list
+// starts at the top
+var endOfTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// cursor is the sort key of the last row of a page, only the fields of the
+// sort of the list are set
+type cursor struct {
+	Namespace string    `json:"ns,omitempty"`
+	Name      string    `json:"n,omitempty"`
+	Time      time.Time `json:"t,omitempty"`
+	ID        int64     `json:"id"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes the cursor of the list options, the zero cursor is
+// returned for the first page
+func decodeCursor(opts lineage.ListOptions) (cursor, error) {
+	var c cursor
+	if opts.After == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(opts.After)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// newestFirstCursor decodes the cursor of a newest first list, the first
+// page starts before every row
+func newestFirstCursor(opts lineage.ListOptions) (cursor, error) {
+	if opts.After == "" {
+		return cursor{Time: endOfTime, ID: math.MaxInt64}, nil
+	}
+	return decodeCursor(opts)
+}
+
+// updatedBefore is the end of the time window of the list options, the
+// window is open when none is given
+func updatedBefore(opts lineage.ListOptions) time.Time {
+	if opts.UpdatedBefore.IsZero() {
+		return endOfTime
+	}
+	return opts.UpdatedBefore
+}
+
+// pageLimit is the number of rows on a page, one more row is queried to
+// know if there is a next page
+func pageLimit(opts lineage.ListOptions) int {
+	if opts.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if opts.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return opts.Limit
+}
+
+// prefixPattern is the like pattern matching names that start with prefix
+func prefixPattern(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
+
+// idList encodes ids as the JSON array the queries by ids take
+func idList(ids []int64) json.RawMessage {
+	if len(ids) == 0 {
+		return json.RawMessage("[]")
+	}
+	b, _ := json.Marshal(ids)
+	return b
+}
+
+// updatedOrCreated is the time a row was last written, the key of newest
+// first lists
+func updatedOrCreated(updatedAt time.Time, createdAt time.Time) time.Time {
+	if updatedAt.IsZero() {
+		return createdAt
+	}
+	return updatedAt
+}
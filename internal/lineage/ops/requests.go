@@ -8,21 +8,54 @@ import (
 	"github.com/rotisserie/eris"
 )
 
-func ListRequests(ctx context.Context, deps Deps) ([]lineage.Request, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
-	rows, err := qtx.ListRequests(ctx)
+// ListRequests lists a page of the raw requests newest first, UpdatedSince
+// filters on when they were received
+func ListRequests(ctx context.Context, deps Deps, opts lineage.ListOptions) (*lineage.RequestPage, error) {
+	after, err := newestFirstCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+	limit := pageLimit(opts)
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRequestsPage(ctx, db.ListRequestsPageParams{
+		CreatedSince: opts.UpdatedSince,
+		BeforeID:     after.ID,
+		RowLimit:     int32(limit + 1),
+	})
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list requests")
 	}
-	var res []lineage.Request
+	res := &lineage.RequestPage{}
 
-	for _, row := range rows {
-		res = append(res, lineage.Request{
-			ID:        row.ID,
-			Payload:   row.Payload,
-			CreatedAt: row.CreatedAt,
-		})
+	for i, row := range rows {
+		if i == limit {
+			res.Next = cursor{ID: res.Requests[limit-1].ID}.encode()
+			break
+		}
+		res.Requests = append(res.Requests, *toRequest(row))
 	}
 	return res, nil
 }
+
+// GetRequest returns the request with its status in the ingestion queue
+func GetRequest(ctx context.Context, deps Deps, id int64) (*lineage.Request, error) {
+	row, err := deps.GetStore().Queries().GetRequestByID(ctx, id)
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to get request[%d]", id)
+	}
+	return toRequest(row), nil
+}
+
+func toRequest(row db.LineageRequest) *lineage.Request {
+	return &lineage.Request{
+		ID:            row.ID,
+		Payload:       row.Payload,
+		Status:        lineage.RequestStatus(row.Status),
+		Attempts:      int(row.Attempts),
+		NextAttemptAt: row.NextAttemptAt.Time,
+		ErrorMessage:  row.ErrorMessage.String,
+		RunEventID:    row.RunEventID.Int64,
+		CreatedAt:     row.CreatedAt,
+		ProcessedAt:   row.ProcessedAt.Time,
+	}
+}
@@ -8,12 +8,12 @@ import (
 	ol "oplin/internal/openlineage"
 	"oplin/internal/utils"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
 func GetRunWithID(ctx context.Context, deps Deps, runID int64) (*lineage.Run, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	row, err := qtx.GetRunByID(ctx, runID)
 	if utils.IsNoRowsError(err) {
 		return nil, eris.Wrapf(err, "could not find run with id[%d]", runID)
@@ -21,19 +21,39 @@ func GetRunWithID(ctx context.Context, deps Deps, runID int64) (*lineage.Run, er
 	if err != nil {
 		return nil, err
 	}
+	return toRun(row)
+}
 
-	f := &ol.RunFacets{}
-	err = json.Unmarshal(row.Facets.RawMessage, f)
+func GetRunWithUUID(ctx context.Context, deps Deps, runUUID uuid.UUID) (*lineage.Run, error) {
+	qtx := deps.GetStore().Queries()
+	row, err := qtx.GetRunByUUID(ctx, runUUID)
+	if utils.IsNoRowsError(err) {
+		return nil, eris.Wrapf(err, "could not find run with uuid[%s]", runUUID)
+	}
 	if err != nil {
-		return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		return nil, err
+	}
+	return toRun(row)
+}
+
+func toRun(row db.LineageRun) (*lineage.Run, error) {
+	f := &ol.RunFacets{}
+	if len(row.Facets.RawMessage) > 0 {
+		err := json.Unmarshal(row.Facets.RawMessage, f)
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not unmarshall[%s]", row.Facets.RawMessage)
+		}
 	}
 
 	return &lineage.Run{
 		ID:                  row.ID,
+		RunUUID:             row.RunUuid,
 		JobVersionID:        row.JobVersionID,
 		Facets:              *f,
 		ParentRunID:         row.ParentRunID.Int64,
 		LastEventType:       lineage.RunEventType(row.LastEventType),
+		State:               lineage.RunState(row.State),
+		LastEventTime:       row.LastEventTime.Time,
 		NominalStartedAt:    row.NominalStartedAt.Time,
 		NominalEndedAt:      row.NominalEndedAt.Time,
 		StartedAt:           row.StartedAt.Time,
@@ -43,42 +63,251 @@ func GetRunWithID(ctx context.Context, deps Deps, runID int64) (*lineage.Run, er
 		Stacktrace:          row.Stacktrace.String,
 		CreatedAt:           row.CreatedAt,
 		UpdatedAt:           row.UpdatedAt.Time,
+		IsPlaceholder:       row.IsPlaceholder,
 	}, nil
 }
 
-func ListRunsByJobVersionID(ctx context.Context, deps Deps, jvID int64) ([]lineage.Run, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
-	rows, err := qtx.ListRunsByJobVersionID(ctx, jvID)
+// ListRuns lists a page of the runs of a job newest first, filtered by the
+// version of the job and the state of the run
+func ListRuns(ctx context.Context, deps Deps, jobID int64, opts lineage.ListOptions) (*lineage.RunPage, error) {
+	after, err := newestFirstCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+	limit := pageLimit(opts)
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRunsPageByJobID(ctx, db.ListRunsPageByJobIDParams{
+		JobID:           jobID,
+		JobVersionID:    opts.JobVersionID,
+		State:           int32(opts.State),
+		UpdatedSince:    opts.UpdatedSince,
+		UpdatedBefore:   updatedBefore(opts),
+		BeforeCreatedAt: after.Time,
+		BeforeID:        after.ID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list runs for job[%d]", jobID)
+	}
+	res := &lineage.RunPage{}
+
+	for i, row := range rows {
+		if i == limit {
+			last := res.Runs[limit-1]
+			res.Next = cursor{Time: last.CreatedAt, ID: last.ID}.encode()
+			break
+		}
+		run, err := toRun(row)
+		if err != nil {
+			return nil, err
+		}
+		res.Runs = append(res.Runs, *run)
+	}
+	return res, nil
+}
+
+// ListRunsWithJobs lists a page of the runs of every job newest first,
+// filtered by namespace, state and time window
+func ListRunsWithJobs(ctx context.Context, deps Deps, opts lineage.ListOptions) (*lineage.RunWithJobPage, error) {
+	after, err := newestFirstCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+	limit := pageLimit(opts)
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRunsPage(ctx, db.ListRunsPageParams{
+		Namespace:       opts.Namespace,
+		State:           int32(opts.State),
+		UpdatedSince:    opts.UpdatedSince,
+		UpdatedBefore:   updatedBefore(opts),
+		BeforeCreatedAt: after.Time,
+		BeforeID:        after.ID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to list runs")
+	}
+	res := &lineage.RunWithJobPage{}
+
+	for i, row := range rows {
+		if i == limit {
+			last := res.Runs[limit-1].Run
+			res.Next = cursor{Time: last.CreatedAt, ID: last.ID}.encode()
+			break
+		}
+		run, err := toRun(db.LineageRun{
+			ID:                  row.ID,
+			RunUuid:             row.RunUuid,
+			JobVersionID:        row.JobVersionID,
+			ParentRunID:         row.ParentRunID,
+			LastEventType:       row.LastEventType,
+			Facets:              row.Facets,
+			StartedAt:           row.StartedAt,
+			EndedAt:             row.EndedAt,
+			NominalStartedAt:    row.NominalStartedAt,
+			NominalEndedAt:      row.NominalEndedAt,
+			ErrorMessage:        row.ErrorMessage,
+			ProgrammingLanguage: row.ProgrammingLanguage,
+			Stacktrace:          row.Stacktrace,
+			CreatedAt:           row.CreatedAt,
+			UpdatedAt:           row.UpdatedAt,
+			IsPlaceholder:       row.IsPlaceholder,
+			State:               row.State,
+			LastEventTime:       row.LastEventTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res.Runs = append(res.Runs, lineage.RunWithJob{
+			Run:           *run,
+			JobID:         row.JobID,
+			JobName:       row.JobName,
+			NamespaceName: row.NamespaceName,
+		})
+	}
+	return res, nil
+}
+
+// ListRunsByJobID lists the runs of every version of a job, newest first
+func ListRunsByJobID(ctx context.Context, deps Deps, jobID int64) ([]lineage.Run, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRunsByJobID(ctx, jobID)
 	if err != nil {
-		return nil, eris.Wrap(err, "Failed to list dataset versions")
+		return nil, eris.Wrapf(err, "Failed to list runs for job[%d]", jobID)
 	}
 	var res []lineage.Run
 
 	for _, row := range rows {
-		res = append(res, lineage.Run{
-			ID:           row.ID,
-			JobVersionID: row.JobVersionID,
-			//Facets:              row.Facets.RawMessage,
-			ParentRunID:         row.ParentRunID.Int64,
-			LastEventType:       lineage.RunEventType(row.LastEventType),
-			NominalStartedAt:    row.NominalStartedAt.Time,
-			NominalEndedAt:      row.NominalEndedAt.Time,
-			StartedAt:           row.StartedAt.Time,
-			EndedAt:             row.EndedAt.Time,
-			ErrorMessage:        row.ErrorMessage.String,
-			ProgrammingLanguage: row.ProgrammingLanguage.String,
-			Stacktrace:          row.Stacktrace.String,
+		run, err := toRun(row)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *run)
+	}
+	return res, nil
+}
+
+// ListRunsByJobIDPage lists limit runs of every version of a job newest
+// first, starting at offset
+func ListRunsByJobIDPage(ctx context.Context, deps Deps, jobID int64, limit int, offset int) ([]lineage.Run, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRunsByJobIDPage(ctx, db.ListRunsByJobIDPageParams{
+		JobID:     jobID,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list runs for job[%d]", jobID)
+	}
+	var res []lineage.Run
+
+	for _, row := range rows {
+		run, err := toRun(row)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *run)
+	}
+	return res, nil
+}
+
+// ListRunDatasetVersionsByJobIDPage lists the datasets read and written by
+// the same page of runs as ListRunsByJobIDPage
+func ListRunDatasetVersionsByJobIDPage(ctx context.Context, deps Deps, jobID int64, limit int, offset int) ([]lineage.DatasetEdge, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListRunDatasetVersionsByJobIDPage(ctx, db.ListRunDatasetVersionsByJobIDPageParams{
+		JobID:     jobID,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list datasets of runs for job[%d]", jobID)
+	}
+	var res []lineage.DatasetEdge
+
+	for _, row := range rows {
+		res = append(res, lineage.DatasetEdge{
+			ID:            row.RunID,
+			DatasetName:   row.DatasetName,
+			NamespaceName: row.NamespaceName,
+			IOType:        lineage.IOType(row.IoType),
+		})
+	}
+	return res, nil
+}
+
+// ListLatestRunsByNamespacePage lists the newest run of each job of the same
+// page of jobs as ListJobsWithNamespacesByNamespacePage, by job id
+func ListLatestRunsByNamespacePage(ctx context.Context, deps Deps, namespace string, limit int, offset int) (map[int64]lineage.Run, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListLatestRunsByNamespacePage(ctx, db.ListLatestRunsByNamespacePageParams{
+		NamespaceName: namespace,
+		RowLimit:      int32(limit),
+		RowOffset:     int32(offset),
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list latest runs in namespace[%s]", namespace)
+	}
+	return toLatestRuns(rows)
+}
+
+// ListLatestRunsByJobIDs lists the newest run of each of the jobs, by job id
+func ListLatestRunsByJobIDs(ctx context.Context, deps Deps, jobIDs []int64) (map[int64]lineage.Run, error) {
+	qtx := deps.GetStore().Queries()
+	rows, err := qtx.ListLatestRunsByJobIDs(ctx, idList(jobIDs))
+	if err != nil {
+		return nil, eris.Wrapf(err, "Failed to list latest runs of jobs%v", jobIDs)
+	}
+	latest := make([]db.ListLatestRunsByNamespacePageRow, 0, len(rows))
+	for _, row := range rows {
+		latest = append(latest, db.ListLatestRunsByNamespacePageRow(row))
+	}
+	return toLatestRuns(latest)
+}
+
+func toLatestRuns(rows []db.ListLatestRunsByNamespacePageRow) (map[int64]lineage.Run, error) {
+	res := map[int64]lineage.Run{}
+
+	for _, row := range rows {
+		run, err := toRun(db.LineageRun{
+			ID:                  row.ID,
+			RunUuid:             row.RunUuid,
+			JobVersionID:        row.JobVersionID,
+			ParentRunID:         row.ParentRunID,
+			LastEventType:       row.LastEventType,
+			Facets:              row.Facets,
+			StartedAt:           row.StartedAt,
+			EndedAt:             row.EndedAt,
+			NominalStartedAt:    row.NominalStartedAt,
+			NominalEndedAt:      row.NominalEndedAt,
+			ErrorMessage:        row.ErrorMessage,
+			ProgrammingLanguage: row.ProgrammingLanguage,
+			Stacktrace:          row.Stacktrace,
 			CreatedAt:           row.CreatedAt,
-			UpdatedAt:           row.UpdatedAt.Time,
+			UpdatedAt:           row.UpdatedAt,
+			IsPlaceholder:       row.IsPlaceholder,
+			State:               row.State,
+			LastEventTime:       row.LastEventTime,
 		})
+		if err != nil {
+			return nil, err
+		}
+		res[row.JobID] = *run
 	}
 	return res, nil
 }
 
+// CountRunsByJobID counts the runs of every version of a job
+func CountRunsByJobID(ctx context.Context, deps Deps, jobID int64) (int, error) {
+	n, err := deps.GetStore().Queries().CountRunsByJobID(ctx, jobID)
+	if err != nil {
+		return 0, eris.Wrapf(err, "Failed to count runs for job[%d]", jobID)
+	}
+	return int(n), nil
+}
+
 func ListRunEventsByRunID(ctx context.Context, deps Deps, runID int64) ([]lineage.RunEvent, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListRunEventsByRunID(ctx, runID)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to list run events")
@@ -99,8 +328,7 @@ func ListRunEventsByRunID(ctx context.Context, deps Deps, runID int64) ([]lineag
 }
 
 func ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Context, deps Deps, id int64) ([]lineage.RunIODatasetWithRelationships, error) {
-	pg := deps.GetDB()
-	qtx := db.New(pg)
+	qtx := deps.GetStore().Queries()
 	rows, err := qtx.ListRunDatasetVersionsWithRelationshipsByRunID(ctx, id)
 	if err != nil {
 		return nil, eris.Wrap(err, "Failed to get dataset")
@@ -133,10 +361,14 @@ func ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Context, deps De
 				IOType:           lineage.IOType(row.IoType),
 				InputFacets:      *inFacets,
 				OutputFacets:     *outFacets,
+				FirstEventType:   lineage.RunEventType(row.FirstEventType),
+				LastEventType:    lineage.RunEventType(row.LastEventType),
 				CreatedAt:        row.CreatedAt,
+				UpdatedAt:        row.UpdatedAt.Time,
 			},
 			DatasetVersion: lineage.DatasetVersion{
 				ID:                 row.VersionID,
+				VersionUUID:        row.VersionUuid,
 				DatasetID:          row.VersionDatasetID,
 				DatasetNamespaceID: row.VersionNamespaceID,
 				Name:               row.VersionName,
@@ -153,3 +385,69 @@ func ListRunDatasetVersionsWithRelationshipsByRunID(ctx context.Context, deps De
 	}
 	return res, nil
 }
+
+// GetRunTree returns the tree of runs the given run belongs to, rooted at its
+// top most ancestor
+func GetRunTree(ctx context.Context, deps Deps, runID int64) (*lineage.RunTreeNode, error) {
+	qtx := deps.GetStore().Queries()
+
+	row, err := qtx.GetRunByID(ctx, runID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not find run with id[%d]", runID)
+	}
+
+	// parent ids come from producers so guard against cycles
+	seen := map[int64]bool{row.ID: true}
+	for row.ParentRunID.Valid && !seen[row.ParentRunID.Int64] {
+		parentID := row.ParentRunID.Int64
+		row, err = qtx.GetRunByID(ctx, parentID)
+		if err != nil {
+			return nil, eris.Wrapf(err, "could not find parent run with id[%d]", parentID)
+		}
+		seen[row.ID] = true
+	}
+
+	return buildRunTreeNode(ctx, qtx, row, map[int64]bool{})
+}
+
+func buildRunTreeNode(
+	ctx context.Context, qtx db.Querier, row db.LineageRun, seen map[int64]bool,
+) (*lineage.RunTreeNode, error) {
+	seen[row.ID] = true
+
+	run, err := toRun(row)
+	if err != nil {
+		return nil, err
+	}
+	jv, err := qtx.GetJobVersionByID(ctx, row.JobVersionID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not get job version[%d]", row.JobVersionID)
+	}
+	ns, err := qtx.GetJobNamespaceByID(ctx, jv.NamespaceID)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not get job namespace[%d]", jv.NamespaceID)
+	}
+
+	node := &lineage.RunTreeNode{
+		Run:           *run,
+		JobID:         jv.JobID,
+		JobName:       jv.Name,
+		NamespaceName: ns.Name,
+	}
+
+	rows, err := qtx.ListRunsByParentRunID(ctx, utils.NullInt64(&row.ID))
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not list children of run[%d]", row.ID)
+	}
+	for _, child := range rows {
+		if seen[child.ID] {
+			continue
+		}
+		n, err := buildRunTreeNode(ctx, qtx, child, seen)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, *n)
+	}
+	return node, nil
+}
@@ -0,0 +1,109 @@
+package ops
+
+import (
+	"context"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchMatchesPerResult bounds the matching texts listed per result, a
+// dataset can match on many of its fields
+const searchMatchesPerResult = 10
+
+// searchResults groups the matching texts by dataset or job, the results
+// keep the order of the rows
+type searchResults struct {
+	res    []lineage.SearchResult
+	scores []int64
+	index  map[lineage.NodeID]int
+}
+
+func (s *searchResults) add(nodeID lineage.NodeID, id int64, createdAt time.Time, updatedAt time.Time, score int64, m lineage.SearchMatch) {
+	if s.index == nil {
+		s.index = make(map[lineage.NodeID]int)
+	}
+	i, ok := s.index[nodeID]
+	if !ok {
+		i = len(s.res)
+		s.index[nodeID] = i
+		s.res = append(s.res, lineage.SearchResult{NodeID: nodeID, ID: id, CreatedAt: createdAt, UpdatedAt: updatedAt})
+		s.scores = append(s.scores, score)
+	}
+	s.res[i].Matches = append(s.res[i].Matches, m)
+}
+
+// FullTextSearch finds datasets and jobs whose name, namespace, fields,
+// descriptions, owners or SQL contain the query. Each result lists what
+// matched. The database ranks the results by their best match, a match on
+// the name before one on a field and one on a field before one in the SQL,
+// then by how much of the matching text the query is.
+func FullTextSearch(ctx context.Context, deps Deps, query string, nodeType lineage.NodeType, limit int) ([]lineage.SearchResult, error) {
+	qtx := deps.GetStore().Queries()
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	var datasets, jobs searchResults
+
+	if nodeType != lineage.NodeTypeJob {
+		rows, err := qtx.SearchDatasetDocuments(ctx, db.SearchDatasetDocumentsParams{
+			EntityType:       int32(lineage.FacetEntityTypeDataset),
+			Pattern:          pattern,
+			RowLimit:         int32(limit),
+			MatchesPerResult: searchMatchesPerResult,
+		})
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to search datasets for [%s]", query)
+		}
+		for _, row := range rows {
+			nodeID := lineage.NodeID{Type: lineage.NodeTypeDataset, Namespace: row.NamespaceName, Name: row.Name}
+			m := lineage.SearchMatch{Field: lineage.SearchField(row.Field), Content: row.Content}
+			datasets.add(nodeID, row.ID, row.CreatedAt, row.UpdatedAt.Time, row.Score, m)
+		}
+	}
+
+	if nodeType != lineage.NodeTypeDataset {
+		rows, err := qtx.SearchJobDocuments(ctx, db.SearchJobDocumentsParams{
+			EntityType:       int32(lineage.FacetEntityTypeJob),
+			Pattern:          pattern,
+			RowLimit:         int32(limit),
+			MatchesPerResult: searchMatchesPerResult,
+		})
+		if err != nil {
+			return nil, eris.Wrapf(err, "Failed to search jobs for [%s]", query)
+		}
+		for _, row := range rows {
+			nodeID := lineage.NodeID{Type: lineage.NodeTypeJob, Namespace: row.NamespaceName, Name: row.Name}
+			m := lineage.SearchMatch{Field: lineage.SearchField(row.Field), Content: row.Content}
+			jobs.add(nodeID, row.ID, row.CreatedAt, row.UpdatedAt.Time, row.Score, m)
+		}
+	}
+
+	// both lists are ranked, merge them
+	res := make([]lineage.SearchResult, 0, limit)
+	i, j := 0, 0
+	for len(res) < limit {
+		switch {
+		case i < len(datasets.res) && (j == len(jobs.res) || !searchRanksBefore(jobs.scores[j], jobs.res[j].NodeID.Name, datasets.scores[i], datasets.res[i].NodeID.Name)):
+			res = append(res, datasets.res[i])
+			i++
+		case j < len(jobs.res):
+			res = append(res, jobs.res[j])
+			j++
+		default:
+			return res, nil
+		}
+	}
+	return res, nil
+}
+
+// searchRanksBefore orders results like the search queries do
+func searchRanksBefore(score int64, name string, otherScore int64, otherName string) bool {
+	if score != otherScore {
+		return score < otherScore
+	}
+	return name < otherName
+}
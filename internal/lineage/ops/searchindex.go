@@ -0,0 +1,173 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"oplin/internal/lineage"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	ol "oplin/internal/openlineage"
+	"oplin/internal/utils"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// searchFacets are the facets of a dataset or job whose text is indexed
+type searchFacets struct {
+	Documentation ol.DocumentationFacet `json:"documentation"`
+	Ownership     ol.OwnershipFacet     `json:"ownership"`
+	SQL           ol.SQLJobFacet        `json:"sql"`
+}
+
+func facetMatches(msg json.RawMessage) []lineage.SearchMatch {
+	var res []lineage.SearchMatch
+	if len(msg) == 0 {
+		return res
+	}
+	var fs searchFacets
+	// facets that do not follow the spec are not indexed
+	_ = json.Unmarshal(msg, &fs)
+	res = append(res, lineage.SearchMatch{Field: lineage.SearchFieldDescription, Content: fs.Documentation.Description})
+	for _, o := range fs.Ownership.Owners {
+		res = append(res, lineage.SearchMatch{Field: lineage.SearchFieldOwner, Content: o.Name})
+	}
+	res = append(res, lineage.SearchMatch{Field: lineage.SearchFieldSQL, Content: fs.SQL.Query})
+	return res
+}
+
+// SearchFacetsChanged is true when the facets differ in the text indexed for
+// search, other changes to the facets leave the index as it is
+func SearchFacetsChanged(before json.RawMessage, after json.RawMessage) bool {
+	a, b := indexedMatches(facetMatches(before)), indexedMatches(facetMatches(after))
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// indexedMatches drops the matches without text, they are not indexed
+func indexedMatches(matches []lineage.SearchMatch) []lineage.SearchMatch {
+	var res []lineage.SearchMatch
+	for _, m := range matches {
+		if strings.TrimSpace(m.Content) != "" {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// writeSearchDocuments replaces the indexed texts of the entity
+func writeSearchDocuments(
+	ctx context.Context, qtx db.Querier, entityType lineage.FacetEntityType, entityID int64, matches []lineage.SearchMatch,
+) error {
+	err := qtx.DeleteSearchDocumentsByEntity(ctx, db.DeleteSearchDocumentsByEntityParams{
+		EntityType: int32(entityType),
+		EntityID:   entityID,
+	})
+	if err != nil {
+		return eris.Wrapf(err, "Failed to delete search documents of %s[%d]", entityType, entityID)
+	}
+	now := utils.NowUTC()
+	for _, m := range indexedMatches(matches) {
+		err := qtx.CreateSearchDocument(ctx, db.CreateSearchDocumentParams{
+			EntityType: int32(entityType),
+			EntityID:   entityID,
+			Field:      string(m.Field),
+			Content:    m.Content,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return eris.Wrapf(err, "Failed to create search document of %s[%d]", entityType, entityID)
+		}
+	}
+	return nil
+}
+
+// IndexDataset indexes the name, namespace, facets and the fields of the
+// current version of the dataset for full text search
+func IndexDataset(ctx context.Context, qtx db.Querier, id int64) error {
+	ds, err := qtx.GetDatasetByID(ctx, id)
+	if err != nil {
+		return eris.Wrapf(err, "Failed to get dataset[%d]", id)
+	}
+	ns, err := qtx.GetDatasetNamespaceByID(ctx, ds.NamespaceID)
+	if err != nil {
+		return eris.Wrapf(err, "Failed to get dataset namespace[%d]", ds.NamespaceID)
+	}
+
+	matches := []lineage.SearchMatch{
+		{Field: lineage.SearchFieldName, Content: ds.Name},
+		{Field: lineage.SearchFieldNamespace, Content: ns.Name},
+	}
+	if ds.CurrentVersionID.Valid {
+		fields, err := qtx.ListFieldsByDatasetVersionID(ctx, ds.CurrentVersionID.Int64)
+		if err != nil {
+			return eris.Wrapf(err, "Failed to list fields of dataset version[%d]", ds.CurrentVersionID.Int64)
+		}
+		for _, f := range fields {
+			matches = append(matches, lineage.SearchMatch{Field: lineage.SearchFieldField, Content: f.Name})
+			matches = append(matches, lineage.SearchMatch{Field: lineage.SearchFieldDescription, Content: f.Description.String})
+		}
+	}
+	matches = append(matches, facetMatches(ds.Facets.RawMessage)...)
+	return writeSearchDocuments(ctx, qtx, lineage.FacetEntityTypeDataset, ds.ID, matches)
+}
+
+// IndexJob indexes the name, namespace and facets of the job for full text
+// search
+func IndexJob(ctx context.Context, qtx db.Querier, id int64) error {
+	job, err := qtx.GetJobByID(ctx, id)
+	if err != nil {
+		return eris.Wrapf(err, "Failed to get job[%d]", id)
+	}
+	ns, err := qtx.GetJobNamespaceByID(ctx, job.NamespaceID)
+	if err != nil {
+		return eris.Wrapf(err, "Failed to get job namespace[%d]", job.NamespaceID)
+	}
+
+	matches := []lineage.SearchMatch{
+		{Field: lineage.SearchFieldName, Content: job.Name},
+		{Field: lineage.SearchFieldNamespace, Content: ns.Name},
+	}
+	matches = append(matches, facetMatches(job.Facets.RawMessage)...)
+	return writeSearchDocuments(ctx, qtx, lineage.FacetEntityTypeJob, job.ID, matches)
+}
+
+// ReindexSearch rebuilds the search index from every dataset and job, for
+// lineage recorded before the index existed. It returns the number of
+// datasets and jobs indexed.
+func ReindexSearch(ctx context.Context, deps Deps) (int, error) {
+	var count int
+	err := deps.GetStore().Tx(ctx, func(qtx store.Tx) error {
+		if err := qtx.DeleteSearchDocuments(ctx); err != nil {
+			return eris.Wrap(err, "Failed to delete search documents")
+		}
+		dsIDs, err := qtx.ListDatasetIDs(ctx)
+		if err != nil {
+			return eris.Wrap(err, "Failed to list datasets")
+		}
+		for _, id := range dsIDs {
+			if err := IndexDataset(ctx, qtx, id); err != nil {
+				return err
+			}
+		}
+		jobIDs, err := qtx.ListJobIDs(ctx)
+		if err != nil {
+			return eris.Wrap(err, "Failed to list jobs")
+		}
+		for _, id := range jobIDs {
+			if err := IndexJob(ctx, qtx, id); err != nil {
+				return err
+			}
+		}
+		count = len(dsIDs) + len(jobIDs)
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,19 @@
+package store
+
+import (
+	"database/sql"
+	"oplin/internal/lineage/db"
+
+	_ "github.com/lib/pq"
+	"github.com/rotisserie/eris"
+)
+
+// OpenPostgres opens the Postgres database at the dsn, either a url or
+// key=value pairs
+func OpenPostgres(dsn string) (*SQLStore, error) {
+	DB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, eris.Wrap(err, "could not open postgres")
+	}
+	return NewSQLStore(DB, db.DialectPostgres), nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"oplin/internal/lineage/db"
+	"sync"
+
+	"github.com/rotisserie/eris"
+)
+
+// txLockID is the key of the advisory lock Postgres transactions hold shared
+// and an exclusive transaction holds alone, so a replay does not run beside
+// the ingestion of the other replicas
+const txLockID = 7171415
+
+// SQLStore keeps the lineage in a SQL database of one of the dialects the
+// queries support
+type SQLStore struct {
+	DB      *sql.DB
+	Dialect db.Dialect
+	queries *db.Queries
+	// txLock stands in for the advisory lock on SQLite, the database belongs
+	// to one process
+	txLock sync.RWMutex
+}
+
+// NewSQLStore returns the store for the database of the dialect
+func NewSQLStore(DB *sql.DB, dialect db.Dialect) *SQLStore {
+	return &SQLStore{DB: DB, Dialect: dialect, queries: db.NewWithDialect(DB, dialect)}
+}
+
+func (s *SQLStore) Queries() db.Querier {
+	return s.queries
+}
+
+func (s *SQLStore) Tx(ctx context.Context, f func(tx Tx) error) error {
+	return s.tx(ctx, false, f)
+}
+
+func (s *SQLStore) ExclusiveTx(ctx context.Context, f func(tx Tx) error) error {
+	return s.tx(ctx, true, f)
+}
+
+func (s *SQLStore) tx(ctx context.Context, exclusive bool, f func(tx Tx) error) error {
+	if s.Dialect == db.DialectSQLite {
+		if exclusive {
+			s.txLock.Lock()
+			defer s.txLock.Unlock()
+		} else {
+			s.txLock.RLock()
+			defer s.txLock.RUnlock()
+		}
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return eris.Wrap(err, "begin transaction failed")
+	}
+	defer tx.Rollback()
+
+	if s.Dialect == db.DialectPostgres {
+		lock := "select pg_advisory_xact_lock_shared($1)"
+		if exclusive {
+			lock = "select pg_advisory_xact_lock($1)"
+		}
+		if _, err := tx.ExecContext(ctx, lock, txLockID); err != nil {
+			return eris.Wrap(err, "lock transaction failed")
+		}
+	}
+
+	err = f(&sqlTx{Queries: db.NewWithDialect(tx, s.Dialect), tx: tx})
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return eris.Wrap(err, "commit failed")
+	}
+	return nil
+}
+
+func (s *SQLStore) MigrateUp(ctx context.Context) ([]db.Migration, error) {
+	return db.MigrateUp(ctx, s.DB, s.Dialect)
+}
+
+func (s *SQLStore) MigrateDown(ctx context.Context, steps int) ([]db.Migration, error) {
+	return db.MigrateDown(ctx, s.DB, s.Dialect, steps)
+}
+
+func (s *SQLStore) MigrationStatuses(ctx context.Context) ([]db.MigrationStatus, error) {
+	return db.MigrationStatuses(ctx, s.DB, s.Dialect)
+}
+
+func (s *SQLStore) Reset(ctx context.Context) error {
+	return db.InitializeTestDB(ctx, s.DB, s.Dialect)
+}
+
+func (s *SQLStore) Close() error {
+	return s.DB.Close()
+}
+
+type sqlTx struct {
+	*db.Queries
+	tx *sql.Tx
+}
+
+func (t *sqlTx) Savepoint(ctx context.Context, f func() error) error {
+	if _, err := t.tx.ExecContext(ctx, "savepoint sp"); err != nil {
+		return eris.Wrap(err, "create savepoint failed")
+	}
+	if err := f(); err != nil {
+		if _, rbErr := t.tx.ExecContext(ctx, "rollback to savepoint sp"); rbErr != nil {
+			return eris.Wrapf(rbErr, "rollback to savepoint failed after[%v]", err)
+		}
+		if _, rlErr := t.tx.ExecContext(ctx, "release savepoint sp"); rlErr != nil {
+			return eris.Wrapf(rlErr, "release savepoint failed after[%v]", err)
+		}
+		return err
+	}
+	if _, err := t.tx.ExecContext(ctx, "release savepoint sp"); err != nil {
+		return eris.Wrap(err, "release savepoint failed")
+	}
+	return nil
+}
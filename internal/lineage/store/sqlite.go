@@ -0,0 +1,31 @@
+package store
+
+import (
+	"database/sql"
+	"oplin/internal/lineage/db"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	_ "modernc.org/sqlite"
+)
+
+// sqlitePragmas enforce foreign keys like Postgres does and let writers wait
+// for each other, transactions take the write lock when they begin so they
+// cannot fail to upgrade half way
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(wal)&_txlock=immediate&_time_format=sqlite"
+
+// OpenSQLite opens or creates the SQLite file at the path
+func OpenSQLite(path string) (*SQLStore, error) {
+	if path == "" {
+		return nil, eris.New("sqlite store needs a path")
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	DB, err := sql.Open("sqlite", "file:"+path+sep+sqlitePragmas)
+	if err != nil {
+		return nil, eris.Wrapf(err, "could not open sqlite[%s]", path)
+	}
+	return NewSQLStore(DB, db.DialectSQLite), nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"oplin/internal/lineage/db"
+	"sync/atomic"
+
+	"github.com/rotisserie/eris"
+)
+
+var memoryStores int64
+
+// SQLiteMemoryStore is the SQLite store on a database in memory that is gone
+// once the store is closed. It is the same SQLStore as a SQLite file, not a
+// separate implementation. Every memory store has a database of its own so
+// tests using one can run in parallel.
+type SQLiteMemoryStore struct {
+	*SQLStore
+	// keep holds a connection open, the database is dropped when its last
+	// connection closes
+	keep *sql.Conn
+}
+
+// OpenSQLiteMemory opens an empty SQLite memory store with the schema
+// migrated
+func OpenSQLiteMemory() (*SQLiteMemoryStore, error) {
+	name := fmt.Sprintf("/oplin-%d", atomic.AddInt64(&memoryStores, 1))
+	DB, err := sql.Open("sqlite", "file:"+name+"?vfs=memdb&"+sqlitePragmas)
+	if err != nil {
+		return nil, eris.Wrap(err, "could not open memory store")
+	}
+	ctx := context.Background()
+	keep, err := DB.Conn(ctx)
+	if err != nil {
+		DB.Close()
+		return nil, eris.Wrap(err, "could not open memory store")
+	}
+	s := &SQLiteMemoryStore{SQLStore: NewSQLStore(DB, db.DialectSQLite), keep: keep}
+	if _, err := s.MigrateUp(ctx); err != nil {
+		s.Close()
+		return nil, eris.Wrap(err, "could not migrate memory store")
+	}
+	return s, nil
+}
+
+func (s *SQLiteMemoryStore) Close() error {
+	s.keep.Close()
+	return s.SQLStore.Close()
+}
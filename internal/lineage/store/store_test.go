@@ -0,0 +1,80 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"oplin/internal/lineage/store/storetest"
+	"oplin/internal/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteMemoryStore(t *testing.T) {
+	t.Parallel()
+	storetest.Run(t, func(t *testing.T) store.Store {
+		st, err := store.OpenSQLiteMemory()
+		require.Nil(t, err)
+		return st
+	})
+}
+
+func TestSQLiteStore(t *testing.T) {
+	t.Parallel()
+	storetest.Run(t, func(t *testing.T) store.Store {
+		st, err := store.Open("sqlite://" + filepath.Join(t.TempDir(), "oplin.db"))
+		require.Nil(t, err)
+		return st
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	st := store.NewSQLStore(utils.GetTestDB(), db.DialectPostgres)
+	if err := st.DB.Ping(); err != nil {
+		// CI has a Postgres and sets OPLIN_TEST_POSTGRES so the only other
+		// backend is never skipped there
+		if os.Getenv("OPLIN_TEST_POSTGRES") == "required" {
+			t.Fatalf("test postgres is not available[%v]", err)
+		}
+		t.Skipf("test postgres is not available[%v]", err)
+	}
+	st.Close()
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewSQLStore(utils.GetTestDB(), db.DialectPostgres)
+	})
+}
+
+func TestSQLiteMemoryStoresAreSeparate(t *testing.T) {
+	a, err := store.OpenSQLiteMemory()
+	require.Nil(t, err)
+	defer a.Close()
+	b, err := store.OpenSQLiteMemory()
+	require.Nil(t, err)
+	defer b.Close()
+
+	_, err = a.Queries().CreateDatasetNamespace(context.Background(), db.CreateDatasetNamespaceParams{Name: "ns", CreatedAt: utils.NowUTC()})
+	require.Nil(t, err)
+	rows, err := b.Queries().ListDatasetNamespaces(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 0, len(rows))
+}
+
+func TestMigrationStatusesOfNewDatabase(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "oplin.db"))
+	require.Nil(t, err)
+	defer st.Close()
+
+	_, err = st.MigrationStatuses(ctx)
+	require.True(t, errors.Is(err, db.ErrMigrationsNotInitialized))
+
+	// the status left nothing behind
+	var count int
+	err = st.DB.QueryRowContext(ctx, "select count(*) from sqlite_master where name = 'schema_migrations'").Scan(&count)
+	require.Nil(t, err)
+	require.Equal(t, 0, count)
+}
@@ -0,0 +1,261 @@
+// Package storetest is the conformance suite every store must pass, it
+// checks the behaviour ops relies on that differs between databases
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/store"
+	"oplin/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tabbed/pqtype"
+)
+
+var errAbort = errors.New("abort")
+
+// Run runs the suite against the stores returned by open, every test gets a
+// store that has been reset
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		f    func(t *testing.T, st store.Store)
+	}{
+		{"Queries", testQueries},
+		{"TxCommit", testTxCommit},
+		{"TxRollback", testTxRollback},
+		{"Savepoint", testSavepoint},
+		{"ExclusiveTx", testExclusiveTx},
+		{"UniqueConstraint", testUniqueConstraint},
+		{"ForeignKey", testForeignKey},
+		{"CaseInsensitiveSearch", testCaseInsensitiveSearch},
+		{"RoundTrip", testRoundTrip},
+		{"Reset", testReset},
+		{"Migrations", testMigrations},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := open(t)
+			defer st.Close()
+			require.Nil(t, st.Reset(context.Background()))
+			tt.f(t, st)
+		})
+	}
+}
+
+func createNamespace(t *testing.T, qtx db.Querier, name string) db.LineageDatasetNamespace {
+	ns, err := qtx.CreateDatasetNamespace(context.Background(), db.CreateDatasetNamespaceParams{
+		Name:      name,
+		CreatedAt: utils.NowUTC(),
+	})
+	require.Nil(t, err)
+	return ns
+}
+
+func createDataset(ctx context.Context, qtx db.Querier, nsID int64, name string) (db.LineageDataset, error) {
+	return qtx.CreateDataset(ctx, db.CreateDatasetParams{
+		NamespaceID: nsID,
+		Name:        name,
+		CreatedAt:   utils.NowUTC(),
+	})
+}
+
+func testQueries(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := createNamespace(t, st.Queries(), "postgres://db:5432")
+
+	got, err := st.Queries().GetDatasetNamespaceByName(ctx, "postgres://db:5432")
+	assert.Nil(t, err)
+	assert.Equal(t, ns.ID, got.ID)
+
+	_, err = st.Queries().GetDatasetNamespaceByName(ctx, "missing")
+	assert.True(t, utils.IsNoRowsError(err))
+}
+
+func testTxCommit(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	err := st.Tx(ctx, func(qtx store.Tx) error {
+		ns := createNamespace(t, qtx, "ns")
+		_, err := createDataset(ctx, qtx, ns.ID, "orders")
+		return err
+	})
+	assert.Nil(t, err)
+
+	rows, err := st.Queries().ListDatasetsWithNamespaces(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rows))
+}
+
+func testTxRollback(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	err := st.Tx(ctx, func(qtx store.Tx) error {
+		createNamespace(t, qtx, "ns")
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	rows, err := st.Queries().ListDatasetNamespaces(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rows))
+}
+
+func testSavepoint(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	err := st.Tx(ctx, func(qtx store.Tx) error {
+		ns := createNamespace(t, qtx, "ns")
+		err := qtx.Savepoint(ctx, func() error {
+			if _, err := createDataset(ctx, qtx, ns.ID, "rolled_back"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assert.Equal(t, errAbort, err)
+
+		// a failing statement only spoils its savepoint
+		err = qtx.Savepoint(ctx, func() error {
+			_, err := createDataset(ctx, qtx, ns.ID+1000, "no_namespace")
+			return err
+		})
+		assert.NotNil(t, err)
+
+		return qtx.Savepoint(ctx, func() error {
+			_, err := createDataset(ctx, qtx, ns.ID, "kept")
+			return err
+		})
+	})
+	assert.Nil(t, err)
+
+	rows, err := st.Queries().ListDatasetsWithNamespaces(ctx)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(rows))
+	assert.Equal(t, "kept", rows[0].Name)
+}
+
+func testExclusiveTx(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	order := make(chan string, 2)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		err := st.Tx(ctx, func(qtx store.Tx) error {
+			close(started)
+			<-release
+			order <- "tx"
+			return nil
+		})
+		assert.Nil(t, err)
+	}()
+	<-started
+
+	// the exclusive transaction waits for the one running
+	done := make(chan error)
+	go func() {
+		done <- st.ExclusiveTx(ctx, func(qtx store.Tx) error {
+			order <- "exclusive"
+			createNamespace(t, qtx, "ns")
+			return nil
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	require.Nil(t, <-done)
+	assert.Equal(t, "tx", <-order)
+	assert.Equal(t, "exclusive", <-order)
+
+	rows, err := st.Queries().ListDatasetNamespaces(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rows))
+}
+
+func testUniqueConstraint(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	createNamespace(t, st.Queries(), "ns")
+	_, err := st.Queries().CreateDatasetNamespace(ctx, db.CreateDatasetNamespaceParams{
+		Name:      "ns",
+		CreatedAt: utils.NowUTC(),
+	})
+	assert.NotNil(t, err)
+}
+
+func testForeignKey(t *testing.T, st store.Store) {
+	_, err := createDataset(context.Background(), st.Queries(), 1000, "orders")
+	assert.NotNil(t, err)
+}
+
+func testCaseInsensitiveSearch(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := createNamespace(t, st.Queries(), "ns")
+	_, err := createDataset(ctx, st.Queries(), ns.ID, "Orders_Monthly")
+	require.Nil(t, err)
+
+	rows, err := st.Queries().SearchDatasetsWithNamespaces(ctx, db.SearchDatasetsWithNamespacesParams{
+		Pattern:  "%orders%",
+		RowLimit: 10,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rows))
+}
+
+func testRoundTrip(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := createNamespace(t, st.Queries(), "ns")
+	createdAt := time.Date(2023, 2, 5, 15, 48, 28, 660754000, time.UTC)
+	facets := json.RawMessage(`{"documentation":{"description":"orders"}}`)
+	ds, err := st.Queries().CreateDataset(ctx, db.CreateDatasetParams{
+		NamespaceID: ns.ID,
+		Name:        "orders",
+		Facets:      pqtype.NullRawMessage{RawMessage: facets, Valid: true},
+		CreatedAt:   createdAt,
+	})
+	require.Nil(t, err)
+
+	got, err := st.Queries().GetDatasetByID(ctx, ds.ID)
+	assert.Nil(t, err)
+	assert.True(t, createdAt.Equal(got.CreatedAt))
+	assert.False(t, got.UpdatedAt.Valid)
+	assert.False(t, got.CurrentVersionID.Valid)
+	assert.JSONEq(t, string(facets), string(got.Facets.RawMessage))
+}
+
+func testReset(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	createNamespace(t, st.Queries(), "ns")
+	require.Nil(t, st.Reset(ctx))
+
+	rows, err := st.Queries().ListDatasetNamespaces(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rows))
+}
+
+func testMigrations(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	statuses, err := st.MigrationStatuses(ctx)
+	require.Nil(t, err)
+	require.NotEmpty(t, statuses)
+	for _, s := range statuses {
+		assert.True(t, s.Applied)
+	}
+
+	reverted, err := st.MigrateDown(ctx, len(statuses))
+	assert.Nil(t, err)
+	assert.Equal(t, len(statuses), len(reverted))
+	assert.Equal(t, statuses[len(statuses)-1].Version, reverted[0].Version)
+
+	// nothing is left to revert or to baseline
+	reverted, err = st.MigrateDown(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(reverted))
+
+	applied, err := st.MigrateUp(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, len(statuses), len(applied))
+
+	applied, err = st.MigrateUp(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(applied))
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"log"
+	"oplin/internal/lineage/db"
+	"oplin/internal/utils"
+	"os"
+)
+
+// GetTestStore returns the store tests run against, a memory store of its
+// own unless OPLIN_TEST_STORE gives a shared one, postgres for the test
+// Postgres database or a store url, in which case the tests cannot run in
+// parallel
+func GetTestStore() Store {
+	url := os.Getenv("OPLIN_TEST_STORE")
+	switch url {
+	case "":
+		url = "memory"
+	case "postgres":
+		return NewSQLStore(utils.GetTestDB(), db.DialectPostgres)
+	}
+	s, err := Open(url)
+	if err != nil {
+		log.Fatalf("Could not get test store[%s]", err)
+	}
+	return s
+}
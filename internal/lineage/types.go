@@ -1,11 +1,14 @@
 package lineage
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"oplin/internal/openlineage"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type RunEventType int
@@ -60,6 +63,88 @@ func RunEventTypeFromString(str string) (RunEventType, error) {
 	return val, nil
 }
 
+// RunState is where a run is in its lifecycle. A run is NEW until its first
+// event, RUNNING once started and ends COMPLETED, ABORTED or FAILED.
+type RunState int
+
+const (
+	RunStateUnknown   RunState = 0
+	RunStateNew       RunState = 1
+	RunStateRunning   RunState = 2
+	RunStateCompleted RunState = 3
+	RunStateAborted   RunState = 4
+	RunStateFailed    RunState = 5
+	runStateSentinal  RunState = 6
+)
+
+var runStateMap = map[string]RunState{
+	"new":       RunStateNew,
+	"running":   RunStateRunning,
+	"completed": RunStateCompleted,
+	"aborted":   RunStateAborted,
+	"failed":    RunStateFailed,
+}
+
+var runStateToStringMap = map[RunState]string{
+	RunStateNew:       "new",
+	RunStateRunning:   "running",
+	RunStateCompleted: "completed",
+	RunStateAborted:   "aborted",
+	RunStateFailed:    "failed",
+}
+
+func (s RunState) String() string {
+	return strings.ToUpper(runStateToStringMap[s])
+}
+
+func RunStateFromString(str string) (RunState, error) {
+	val, ok := runStateMap[strings.ToLower(str)]
+	if !ok {
+		return RunStateUnknown, errors.New(fmt.Sprintf("No state matching [%s]", str))
+	}
+	return val, nil
+}
+
+// IsFinished is true for the states a run ends in, events do not move a run
+// out of them
+func (s RunState) IsFinished() bool {
+	return s == RunStateCompleted || s == RunStateAborted || s == RunStateFailed
+}
+
+// RunStates are the states of a run in the order a run goes through them
+var RunStates = []RunState{RunStateNew, RunStateRunning, RunStateCompleted, RunStateAborted, RunStateFailed}
+
+// RequestStatus is where a received request is in the ingestion queue.
+// Requests recorded as they are received are PROCESSED straight away, queued
+// requests are PENDING until a worker records them or gives up on them.
+type RequestStatus int
+
+const (
+	RequestStatusUnknown   RequestStatus = 0
+	RequestStatusPending   RequestStatus = 1
+	RequestStatusProcessed RequestStatus = 2
+	RequestStatusFailed    RequestStatus = 3
+)
+
+var requestStatusToStringMap = map[RequestStatus]string{
+	RequestStatusPending:   "pending",
+	RequestStatusProcessed: "processed",
+	RequestStatusFailed:    "failed",
+}
+
+func (s RequestStatus) String() string {
+	return strings.ToUpper(requestStatusToStringMap[s])
+}
+
+var ioTypeToStringMap = map[IOType]string{
+	IOTypeInput:  "input",
+	IOTypeOutput: "output",
+}
+
+func (t IOType) String() string {
+	return strings.ToUpper(ioTypeToStringMap[t])
+}
+
 type RunEventDatasetType int
 
 const (
@@ -81,6 +166,7 @@ type Dataset struct {
 
 type DatasetVersion struct {
 	ID                 int64
+	VersionUUID        uuid.UUID
 	DatasetID          int64
 	DatasetNamespaceID int64
 	Name               string
@@ -111,6 +197,7 @@ type Job struct {
 
 type JobVersion struct {
 	ID             int64
+	VersionUUID    uuid.UUID
 	JobID          int64
 	JobNamespaceID int64
 	Name           string
@@ -125,10 +212,13 @@ func NewJob() *Job {
 
 type Run struct {
 	ID                  int64
+	RunUUID             uuid.UUID
 	JobVersionID        int64
 	Facets              openlineage.RunFacets
 	ParentRunID         int64
 	LastEventType       RunEventType
+	State               RunState
+	LastEventTime       time.Time
 	NominalStartedAt    time.Time
 	NominalEndedAt      time.Time
 	StartedAt           time.Time
@@ -138,6 +228,32 @@ type Run struct {
 	Stacktrace          string
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
+	IsPlaceholder       bool
+}
+
+// Duration is how long the run took, zero until it has finished
+func (r Run) Duration() time.Duration {
+	if !r.State.IsFinished() || r.StartedAt.IsZero() || r.EndedAt.IsZero() {
+		return 0
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// RunWithJob is a run along with the job it is a run of
+type RunWithJob struct {
+	Run           Run
+	JobID         int64
+	JobName       string
+	NamespaceName string
+}
+
+// RunTreeNode is a run along with its job and the runs it started
+type RunTreeNode struct {
+	Run           Run
+	JobID         int64
+	JobName       string
+	NamespaceName string
+	Children      []RunTreeNode
 }
 
 type JobNamespace struct {
@@ -161,6 +277,9 @@ type RunEvent struct {
 	EventTime time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Duplicate is set when the event had already been recorded as this run
+	// event
+	Duplicate bool
 }
 
 type DatasetWithNamespace struct {
@@ -179,7 +298,30 @@ type RunIODataset struct {
 	IOType           IOType
 	InputFacets      openlineage.InputDatasetFacets
 	OutputFacets     openlineage.OutputDatasetFacets
+	FirstEventType   RunEventType
+	LastEventType    RunEventType
 	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// JobVersionIODataset is a dataset a job version declares as an input or
+// output through a job event rather than a run
+type JobVersionIODataset struct {
+	JobVersionID  int64
+	DatasetID     int64
+	DatasetName   string
+	NamespaceName string
+	IOType        IOType
+	CreatedAt     time.Time
+}
+
+// DatasetEdge is a dataset read or written by a job or a run, ID is the id
+// of the job or the run
+type DatasetEdge struct {
+	ID            int64
+	DatasetName   string
+	NamespaceName string
+	IOType        IOType
 }
 
 type RunIODatasetWithRelationships struct {
@@ -198,7 +340,337 @@ type Field struct {
 }
 
 type Request struct {
+	ID            int64
+	Payload       []byte
+	Status        RequestStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	ErrorMessage  string
+	RunEventID    int64
+	CreatedAt     time.Time
+	ProcessedAt   time.Time
+}
+
+// FailedEvent is an event that could not be recorded, kept with its error
+// so it can be fixed and resubmitted or discarded. RequestID is the queued
+// request it came from.
+type FailedEvent struct {
+	ID           int64
+	Payload      []byte
+	Producer     string
+	ErrorMessage string
+	Stacktrace   string
+	RequestID    int64
+	Attempts     int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// APIToken authenticates a producer sending events. Only a hash of the token
+// is stored, Prefix is its start to tell tokens apart. A token with a
+// Producer or Namespace may only send events from that producer or for jobs
+// and datasets in that namespace.
+type APIToken struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Producer   string
+	Namespace  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// Allows is true when the token may send an event with the producer and the
+// namespace
+func (t *APIToken) Allows(producer string, namespace string) bool {
+	if t.Producer != "" && t.Producer != producer {
+		return false
+	}
+	if t.Namespace != "" && t.Namespace != namespace {
+		return false
+	}
+	return true
+}
+
+type Namespace struct {
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type NodeType int
+
+const (
+	NodeTypeUnknown  NodeType = 0
+	NodeTypeDataset  NodeType = 1
+	NodeTypeJob      NodeType = 2
+	nodeTypeSentinal NodeType = 3
+)
+
+var nodeTypeMap = map[string]NodeType{
+	"dataset": NodeTypeDataset,
+	"job":     NodeTypeJob,
+}
+
+var nodeTypeToStringMap = map[NodeType]string{
+	NodeTypeDataset: "dataset",
+	NodeTypeJob:     "job",
+}
+
+func (t NodeType) String() string {
+	return strings.ToUpper(nodeTypeToStringMap[t])
+}
+
+// NodeID identifies a dataset or job in the lineage graph by namespace and
+// name. Its string form is type:namespace:name, e.g. dataset:pg://db:5432:orders
+type NodeID struct {
+	Type      NodeType
+	Namespace string
+	Name      string
+}
+
+func (n NodeID) String() string {
+	return fmt.Sprintf("%s:%s:%s", nodeTypeToStringMap[n.Type], n.Namespace, n.Name)
+}
+
+// NodeIDFromString parses a type:namespace:name node id. Namespaces are often
+// URIs containing colons, so the name is everything after the last colon.
+func NodeIDFromString(str string) (NodeID, error) {
+	typ, rest, ok := strings.Cut(str, ":")
+	if !ok {
+		return NodeID{}, errors.New(fmt.Sprintf("Invalid node id [%s]", str))
+	}
+	nodeType, ok := nodeTypeMap[strings.ToLower(typ)]
+	if !ok {
+		return NodeID{}, errors.New(fmt.Sprintf("No node type matching [%s]", typ))
+	}
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 || i == len(rest)-1 {
+		return NodeID{}, errors.New(fmt.Sprintf("Invalid node id [%s]", str))
+	}
+	return NodeID{Type: nodeType, Namespace: rest[:i], Name: rest[i+1:]}, nil
+}
+
+type LineageNode struct {
+	NodeID NodeID
+	// ID is the id of the dataset or job depending on the node type
+	ID        int64
+	UpdatedAt time.Time
+}
+
+// LineageEdge points in the direction data flows: from an input dataset to
+// the job reading it and from a job to the dataset it writes
+type LineageEdge struct {
+	Origin      NodeID
+	Destination NodeID
+}
+
+type LineageGraph struct {
+	Nodes []LineageNode
+	Edges []LineageEdge
+}
+
+type SearchResult struct {
+	NodeID    NodeID
+	ID        int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Matches are the indexed texts that matched, only full text search
+	// sets them
+	Matches []SearchMatch
+}
+
+// SearchField is what an indexed text of a dataset or job is
+type SearchField string
+
+const (
+	SearchFieldName        SearchField = "name"
+	SearchFieldNamespace   SearchField = "namespace"
+	SearchFieldField       SearchField = "field"
+	SearchFieldDescription SearchField = "description"
+	SearchFieldOwner       SearchField = "owner"
+	SearchFieldSQL         SearchField = "sql"
+)
+
+// SearchMatch is an indexed text of a dataset or job
+type SearchMatch struct {
+	Field   SearchField
+	Content string
+}
+
+type FacetChange string
+
+const (
+	FacetChangeAdded   FacetChange = "ADDED"
+	FacetChangeRemoved FacetChange = "REMOVED"
+	FacetChangeChanged FacetChange = "CHANGED"
+)
+
+// FacetDiff is a facet that differs between two versions, with the facets
+// as indented json
+type FacetDiff struct {
+	Name     string
+	Change   FacetChange
+	Previous string
+	Current  string
+}
+
+type SchemaChangeType int
+
+const (
+	SchemaChangeTypeUnknown  SchemaChangeType = 0
+	SchemaChangeTypeAdded    SchemaChangeType = 1
+	SchemaChangeTypeRemoved  SchemaChangeType = 2
+	SchemaChangeTypeChanged  SchemaChangeType = 3
+	schemaChangeTypeSentinal SchemaChangeType = 4
+)
+
+var schemaChangeTypeToStringMap = map[SchemaChangeType]string{
+	SchemaChangeTypeAdded:   "added",
+	SchemaChangeTypeRemoved: "removed",
+	SchemaChangeTypeChanged: "changed",
+}
+
+func (t SchemaChangeType) String() string {
+	return strings.ToUpper(schemaChangeTypeToStringMap[t])
+}
+
+// SchemaChange is a field that differs between a dataset version and the
+// version before it
+type SchemaChange struct {
+	ID                  int64
+	DatasetVersionID    int64
+	PreviousVersionID   int64
+	ChangeType          SchemaChangeType
+	FieldName           string
+	PreviousDataType    string
+	DataType            string
+	PreviousDescription string
+	Description         string
+	CreatedAt           time.Time
+}
+
+type FacetEntityType int
+
+const (
+	FacetEntityTypeUnknown  FacetEntityType = 0
+	FacetEntityTypeJob      FacetEntityType = 1
+	FacetEntityTypeRun      FacetEntityType = 2
+	FacetEntityTypeDataset  FacetEntityType = 3
+	facetEntityTypeSentinal FacetEntityType = 4
+)
+
+var facetEntityTypeToStringMap = map[FacetEntityType]string{
+	FacetEntityTypeJob:     "job",
+	FacetEntityTypeRun:     "run",
+	FacetEntityTypeDataset: "dataset",
+}
+
+func (t FacetEntityType) String() string {
+	return strings.ToUpper(facetEntityTypeToStringMap[t])
+}
+
+// Facet is a single facet of a job, run or dataset kept by name whether or
+// not the spec defines it. Payload is the facet as it was sent.
+type Facet struct {
+	ID         int64
+	EntityType FacetEntityType
+	EntityID   int64
+	Name       string
+	Producer   string
+	SchemaURL  string
+	Payload    json.RawMessage
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// LifecycleStateChange is a CREATE, ALTER, DROP, RENAME, TRUNCATE or
+// OVERWRITE of a dataset with the run that caused it, if any. A RENAME keeps
+// the identifier the dataset had before and links to that dataset when it is
+// known.
+type LifecycleStateChange struct {
+	ID                int64
+	DatasetID         int64
+	DatasetNamespace  string
+	DatasetName       string
+	Change            string
+	PreviousNamespace string
+	PreviousName      string
+	PreviousDatasetID int64
+	RunID             int64
+	RunUUID           uuid.UUID
+	CreatedAt         time.Time
+}
+
+// DatasetAlias is another name of a dataset, e.g. the table name of a path.
+// Aliases come from the symlinks facet or from merging datasets by hand.
+type DatasetAlias struct {
 	ID        int64
-	Payload   []byte
+	Namespace string
+	Name      string
+	DatasetID int64
+	IsManual  bool
 	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ListSort is the order of a paged list
+type ListSort string
+
+const (
+	// ListSortName orders by namespace then name
+	ListSortName ListSort = "name"
+	// ListSortUpdated puts the most recently updated first
+	ListSortUpdated ListSort = "updated"
+)
+
+// ListOptions pick the rows and the page of a list. Lists are paged by
+// keyset, After is the cursor of the previous page and empty for the first.
+// Zero values do not filter. State and JobVersionID only apply to runs,
+// requests are always newest first.
+type ListOptions struct {
+	Sort         ListSort
+	Namespace    string
+	NamePrefix   string
+	UpdatedSince time.Time
+	// UpdatedBefore closes the time window of a list of runs, the zero time
+	// leaves it open
+	UpdatedBefore time.Time
+	State         RunState
+	JobVersionID  int64
+	After         string
+	Limit         int
+}
+
+// DatasetPage is a page of datasets, Next is the cursor of the next page and
+// empty on the last page
+type DatasetPage struct {
+	Datasets []DatasetWithNamespace
+	Next     string
+}
+
+// RunWithJobPage is a page of runs of any job
+type RunWithJobPage struct {
+	Runs []RunWithJob
+	Next string
+}
+
+type JobPage struct {
+	Jobs []JobWithNamespace
+	Next string
+}
+
+type RequestPage struct {
+	Requests []Request
+	Next     string
+}
+
+type FailedEventPage struct {
+	FailedEvents []FailedEvent
+	Next         string
+}
+
+type RunPage struct {
+	Runs []Run
+	Next string
 }
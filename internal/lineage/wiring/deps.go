@@ -1,15 +1,15 @@
 package wiring
 
-import "database/sql"
+import "oplin/internal/lineage/store"
 
 type Deps interface {
-	GetDB() *sql.DB
+	GetStore() store.Store
 }
 
 type WiringDeps struct {
-	DB *sql.DB
+	Store store.Store
 }
 
-func (d *WiringDeps) GetDB() *sql.DB {
-	return d.DB
+func (d *WiringDeps) GetStore() store.Store {
+	return d.Store
 }
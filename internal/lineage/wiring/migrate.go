@@ -0,0 +1,78 @@
+package wiring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"oplin/internal/lineage/db"
+	"oplin/internal/lineage/ops"
+	"strconv"
+)
+
+const migrateUsage = "usage: oplin migrate up|down [steps]|status"
+
+// RunMigrate runs the migrate subcommand with its arguments, up applies the
+// pending migrations, down reverts the last one or the given number of them
+// and status lists them
+func RunMigrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	deps := &WiringDeps{Store: st}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := ops.MigrateUp(ctx, deps)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %s\n", m)
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps[%s], %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := ops.MigrateDown(ctx, deps, steps)
+		if err != nil {
+			return err
+		}
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %s\n", m)
+		}
+	case "status":
+		statuses, err := ops.ListMigrationStatuses(ctx, deps)
+		if errors.Is(err, db.ErrMigrationsNotInitialized) {
+			fmt.Fprintln(out, "not initialised, run oplin migrate up")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Fprintf(out, "%s applied %s\n", s.Migration, formatTime(s.AppliedAt))
+			} else {
+				fmt.Fprintf(out, "%s pending\n", s.Migration)
+			}
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
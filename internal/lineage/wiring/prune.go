@@ -0,0 +1,45 @@
+package wiring
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+)
+
+// RunPrune runs the prune subcommand, it deletes what the retention flags do
+// not keep. With --dry-run it only reports what would be deleted.
+func RunPrune(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "report what would be deleted without deleting it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	policy := newRetentionPolicy()
+	if policy.IsZero() {
+		return fmt.Errorf("nothing to prune, set -retain_request_days, -retain_runs_per_job or -collapse_dataset_versions")
+	}
+
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	deps := &WiringDeps{Store: st}
+
+	res, err := ol_ops.Prune(context.Background(), deps, policy, *dryRun)
+	if err != nil {
+		return err
+	}
+	verb := "deleted"
+	if *dryRun {
+		verb = "would delete"
+	}
+	fmt.Fprintf(out, "%s %d requests\n", verb, res.Requests)
+	fmt.Fprintf(out, "%s %d runs with %d run events\n", verb, res.Runs, res.RunEvents)
+	fmt.Fprintf(out, "%s %d dataset versions\n", verb, res.DatasetVersions)
+	return nil
+}
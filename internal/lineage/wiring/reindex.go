@@ -0,0 +1,26 @@
+package wiring
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"oplin/internal/lineage/ops"
+)
+
+// RunReindex runs the reindex subcommand, it rebuilds the search index from
+// the lineage already recorded
+func RunReindex(out io.Writer) error {
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	deps := &WiringDeps{Store: st}
+
+	count, err := ops.ReindexSearch(context.Background(), deps)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "indexed %d datasets and jobs\n", count)
+	return nil
+}
@@ -0,0 +1,53 @@
+package wiring
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+)
+
+// replayProgressEvery is how many requests the replay subcommand records
+// between progress lines
+const replayProgressEvery = 1000
+
+// RunReplay runs the replay subcommand, it rebuilds the lineage from the
+// stored requests. Once requests were pruned it only replays with --force.
+func RunReplay(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(out)
+	force := fs.Bool("force", false, "replay even though requests were pruned, the lineage recorded from them is lost")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	deps := &WiringDeps{Store: st}
+
+	progress := func(done int, total int) {
+		if done%replayProgressEvery == 0 || done == total {
+			fmt.Fprintf(out, "replayed %d of %d requests\n", done, total)
+		}
+	}
+	res, err := ol_ops.Replay(context.Background(), deps, *force, progress)
+	if errors.Is(err, ol_ops.ErrRequestsPruned) {
+		return fmt.Errorf("%w, replay --force rebuilds from the requests left", err)
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(out, "request %d failed: %v\n", e.RequestID, e.Err)
+	}
+	fmt.Fprintf(out, "replayed %d requests, %d failed\n", res.Requests, res.Failed)
+	if res.Aliases > 0 || res.LostAliases > 0 {
+		fmt.Fprintf(out, "restored %d merges and splits, %d lost their dataset\n", res.Aliases, res.LostAliases)
+	}
+	return nil
+}
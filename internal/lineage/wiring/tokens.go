@@ -0,0 +1,75 @@
+package wiring
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"oplin/internal/lineage/ops"
+	"strconv"
+)
+
+const tokenUsage = "usage: oplin token create --name NAME [--producer URI] [--namespace NS]|list|revoke ID"
+
+// RunToken runs the token subcommand, create prints a new API token once,
+// list shows the tokens and revoke deletes one
+func RunToken(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(tokenUsage)
+	}
+
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	deps := &WiringDeps{Store: st}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+		fs.SetOutput(out)
+		name := fs.String("name", "", "what the token is for")
+		producer := fs.String("producer", "", "the only producer the token may send events for")
+		namespace := fs.String("namespace", "", "the only namespace the token may send events for")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		tok, secret, err := ops.CreateAPIToken(ctx, deps, *name, *producer, *namespace)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created token %d %s\n", tok.ID, tok.Name)
+		fmt.Fprintln(out, secret)
+	case "list":
+		tokens, err := ops.ListAPITokens(ctx, deps)
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			lastUsed := "never"
+			if !t.LastUsedAt.IsZero() {
+				lastUsed = formatTime(t.LastUsedAt)
+			}
+			fmt.Fprintf(out, "%d %s %s producer[%s] namespace[%s] last used %s\n",
+				t.ID, t.Name, t.Prefix, t.Producer, t.Namespace, lastUsed)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(tokenUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id[%s], %s", args[1], tokenUsage)
+		}
+		if err := ops.RevokeAPIToken(ctx, deps, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "revoked token %d\n", id)
+	default:
+		return errors.New(tokenUsage)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package wiring
 
 import (
 	"context"
-	"database/sql"
 	"flag"
 	"fmt"
 	"html/template"
@@ -11,35 +10,83 @@ import (
 	"log"
 	"net/http"
 	"oplin/internal/lineage/api"
+	"oplin/internal/lineage/auth"
 	"oplin/internal/lineage/htmx/datasets"
+	"oplin/internal/lineage/htmx/failed"
+	"oplin/internal/lineage/htmx/graph"
 	"oplin/internal/lineage/htmx/jobs"
 	"oplin/internal/lineage/htmx/requests"
 	"oplin/internal/lineage/htmx/runs"
+	"oplin/internal/lineage/htmx/search"
+	"oplin/internal/lineage/htmx/tokens"
+	"oplin/internal/lineage/marquez"
 	"oplin/internal/lineage/ops"
+	ol_ops "oplin/internal/lineage/ops/openlineage"
+	"oplin/internal/lineage/store"
+	"oplin/internal/openlineage"
 	"oplin/resources"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
 )
 
+var storeURL string
 var dbHost string
 var dbName string
 var dbUser string
 var dbPassword string
 var dbSslmode string
 var dbPort int
+var marquezPrefix string
+var validationMode string
+var autoMigrate bool
+var ingestMode string
+var workers int
+var workerPoll time.Duration
+var retainRequestDays int
+var retainRunsPerJob int
+var collapseDatasetVersions bool
+var pruneInterval time.Duration
+var ingestAuth bool
+var uiAuth string
+var basicAuthUser string
+var basicAuthPassword string
+var oidcIssuer string
+var oidcClientID string
+var oidcClientSecret string
+var oidcRedirectURL string
+var sessionSecret string
 
 // init parses the command line flags
 func init() {
+	flag.StringVar(&storeURL, "store", "", "where the lineage is stored, postgres://... or sqlite:///path/to/oplin.db, the db options are used when it is empty")
 	flag.StringVar(&dbHost, "db_host", "", "the name of the host")
 	flag.StringVar(&dbName, "db_name", "", "the name of the database")
 	flag.StringVar(&dbUser, "db_user", "", "the name of the user")
 	flag.StringVar(&dbPassword, "db_password", "", "the database users password")
 	flag.StringVar(&dbSslmode, "db_sslmode", "", "the sslmode (disable)")
 	flag.IntVar(&dbPort, "db_port", 0, "the database port")
+	flag.StringVar(&marquezPrefix, "marquez_prefix", "/marquez", "the path prefix of the Marquez compatible API")
+	flag.BoolVar(&autoMigrate, "migrate", true, "apply pending migrations at startup, see oplin migrate")
+	flag.StringVar(&validationMode, "validation", "lenient", "how events are validated, lenient accepts custom event types and facets (lenient|strict)")
+	flag.StringVar(&ingestMode, "ingest", "sync", "how events are recorded, async queues them for the request workers and answers with a 202 (sync|async)")
+	flag.IntVar(&workers, "workers", 2, "the number of request workers recording queued events")
+	flag.DurationVar(&workerPoll, "worker_poll", time.Second, "how long a request worker waits when no queued event is due")
+	flag.IntVar(&retainRequestDays, "retain_request_days", 0, "drop the stored requests older than the number of days, 0 keeps them")
+	flag.IntVar(&retainRunsPerJob, "retain_runs_per_job", 0, "keep only the last runs of every job, 0 keeps them all")
+	flag.BoolVar(&collapseDatasetVersions, "collapse_dataset_versions", false, "drop the dataset versions no run refers to")
+	flag.DurationVar(&pruneInterval, "prune_interval", time.Hour, "how often the server prunes with the retention flags")
+	flag.BoolVar(&ingestAuth, "ingest_auth", false, "require an API token to send events, see oplin token")
+	flag.StringVar(&uiAuth, "ui_auth", "none", "how people sign in to the UI and the admin API (none|basic|oidc)")
+	flag.StringVar(&basicAuthUser, "basic_auth_user", "", "the user of -ui_auth basic")
+	flag.StringVar(&basicAuthPassword, "basic_auth_password", "", "the password of -ui_auth basic")
+	flag.StringVar(&oidcIssuer, "oidc_issuer", "", "the issuer url of -ui_auth oidc")
+	flag.StringVar(&oidcClientID, "oidc_client_id", "", "the client id of -ui_auth oidc")
+	flag.StringVar(&oidcClientSecret, "oidc_client_secret", "", "the client secret of -ui_auth oidc")
+	flag.StringVar(&oidcRedirectURL, "oidc_redirect_url", "", "the callback registered with the issuer, https://host/auth/callback")
+	flag.StringVar(&sessionSecret, "session_secret", "", "signs the UI sessions, replicas must share it, random when empty")
 }
 
 // firstSet returns the first non-empty string in the slice of strings
@@ -68,9 +115,111 @@ func buildDSN() string {
 		firstSet(dbSslmode, os.Getenv("OPLIN_DB_SSLMODE"), "disable"))
 }
 
+// newValidator compiles the bundled OpenLineage schema for the validation mode
+func newValidator() *openlineage.Validator {
+	mode, err := openlineage.ValidationModeFromString(validationMode)
+	if err != nil {
+		log.Fatalf("invalid -validation[%v]", err)
+	}
+	spec, err := resources.Static.ReadFile("static/openapi/OpenLineage.json")
+	if err != nil {
+		log.Fatalf("could not load the OpenLineage schema[%v]", err)
+	}
+	v, err := openlineage.NewValidator(spec, mode)
+	if err != nil {
+		log.Fatalf("could not compile the OpenLineage schema[%v]", err)
+	}
+	return v
+}
+
+// newIngestMode parses the ingest mode
+func newIngestMode() ol_ops.IngestMode {
+	mode, err := ol_ops.IngestModeFromString(ingestMode)
+	if err != nil {
+		log.Fatalf("invalid -ingest[%v]", err)
+	}
+	return mode
+}
+
+// newRetentionPolicy builds the retention policy from the flags
+func newRetentionPolicy() ol_ops.RetentionPolicy {
+	return ol_ops.RetentionPolicy{
+		RequestDays:             retainRequestDays,
+		RunsPerJob:              retainRunsPerJob,
+		CollapseDatasetVersions: collapseDatasetVersions,
+	}
+}
+
+// newTokenAuth requires API tokens to send events when -ingest_auth is set
+func newTokenAuth(deps Deps) gin.HandlerFunc {
+	if !ingestAuth {
+		return auth.NoAuth()
+	}
+	return auth.RequireToken(deps)
+}
+
+// newUIAuth builds the login of the UI for -ui_auth, the OIDC routes are
+// added to the engine. The second handler is the login of the read API, nil
+// when nobody signs in. -ingest_auth needs a login as the tokens are made on
+// the pages.
+func newUIAuth(r *gin.Engine) (gin.HandlerFunc, gin.HandlerFunc) {
+	switch uiAuth {
+	case "", "none":
+		if ingestAuth {
+			log.Fatalf("-ingest_auth needs -ui_auth basic or oidc, anyone could create tokens otherwise")
+		}
+		return auth.NoAuth(), nil
+	case "basic":
+		user := firstSet(basicAuthUser, os.Getenv("OPLIN_BASIC_AUTH_USER"), "")
+		password := firstSet(basicAuthPassword, os.Getenv("OPLIN_BASIC_AUTH_PASSWORD"), "")
+		if user == "" || password == "" {
+			log.Fatalf("-ui_auth basic needs -basic_auth_user and -basic_auth_password")
+		}
+		basic := auth.Basic(user, password)
+		return basic, basic
+	case "oidc":
+		o, err := auth.NewOIDC(context.Background(), auth.OIDCConfig{
+			Issuer:       firstSet(oidcIssuer, os.Getenv("OPLIN_OIDC_ISSUER"), ""),
+			ClientID:     firstSet(oidcClientID, os.Getenv("OPLIN_OIDC_CLIENT_ID"), ""),
+			ClientSecret: firstSet(oidcClientSecret, os.Getenv("OPLIN_OIDC_CLIENT_SECRET"), ""),
+			RedirectURL:  firstSet(oidcRedirectURL, os.Getenv("OPLIN_OIDC_REDIRECT_URL"), ""),
+			SessionKey:   []byte(firstSet(sessionSecret, os.Getenv("OPLIN_SESSION_SECRET"), "")),
+		})
+		if err != nil {
+			log.Fatalf("could not set up oidc[%v]", err)
+		}
+		o.Register(r)
+		return o.RequireLogin(), o.RequireSession()
+	default:
+		log.Fatalf("invalid -ui_auth[%s]", uiAuth)
+		return nil, nil
+	}
+}
+
+// newReadAuth protects reading the lineage once -ui_auth is on, with an API
+// token or the login of the UI
+func newReadAuth(deps Deps, login gin.HandlerFunc) gin.HandlerFunc {
+	if login == nil {
+		return auth.NoAuth()
+	}
+	return auth.TokenOrLogin(deps, login)
+}
+
+// newCSRF protects the forms of the pages and the admin API from other sites
+// once somebody signs in, there is nothing to protect otherwise
+func newCSRF(login gin.HandlerFunc) gin.HandlerFunc {
+	if login == nil {
+		return auth.NoAuth()
+	}
+	return auth.CSRF()
+}
+
 // NewGinEngine creates a new gin.Engine
 func NewGinEngine() *gin.Engine {
 	r := gin.Default()
+	// Match on the escaped path so names containing %2F stay a single segment
+	r.UseRawPath = true
+	r.UnescapePathValues = true
 	r.Use(gin.CustomRecovery(ErrorHandler))
 	return r
 }
@@ -95,28 +244,61 @@ func formatTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
+// formatDuration rounds the duration to the second, or the millisecond when
+// shorter than a second, and leaves out a zero duration
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
 func bytesToString(b []byte) string {
 	return fmt.Sprintf("%s", b)
 }
 
-// SetupLineage sets up the lineage service
-func SetupLineage(r *gin.Engine) error {
-	dsn := buildDSN()
-	return setupLineage(r, dsn)
+// openStore opens the store given by -store, Postgres configured by the
+// db flags when it is not set
+func openStore() (store.Store, error) {
+	url := firstSet(storeURL, os.Getenv("OPLIN_STORE"), "")
+	if url == "" {
+		return store.OpenPostgres(buildDSN())
+	}
+	return store.Open(url)
 }
 
-func setupLineage(r *gin.Engine, dsn string) error {
-	DB, err := sql.Open("postgres", dsn)
+// SetupLineage sets up the lineage service
+func SetupLineage(r *gin.Engine) error {
+	st, err := openStore()
 	if err != nil {
 		return err
 	}
-	deps := &WiringDeps{DB: DB}
+	return setupLineage(r, st)
+}
 
-	// Initialize the schema if it does not exist
-	ctx := context.Background()
-	err = ops.InitializeDB(ctx, deps)
-	if err != nil {
-		return err
+func setupLineage(r *gin.Engine, st store.Store) error {
+	deps := &WiringDeps{Store: st}
+
+	// Bring the schema up to date, replicas wait for each other on a lock
+	if autoMigrate {
+		ctx := context.Background()
+		err := ops.InitializeDB(ctx, deps)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Queued events are recorded by the workers of every replica
+	for i := 0; i < workers; i++ {
+		go ol_ops.RunRequestWorker(context.Background(), deps, workerPoll)
+	}
+
+	// Pruning is off unless a retention flag is set
+	if policy := newRetentionPolicy(); !policy.IsZero() {
+		go ol_ops.RunPruner(context.Background(), deps, policy, pruneInterval)
 	}
 
 	SetupRouter(r, deps)
@@ -128,7 +310,42 @@ func SetupRouter(r *gin.Engine,
 	deps Deps,
 ) {
 	// API
-	r.POST("/api/v1/lineage", api.MakeCreateWithOpenLineageRunEvent(deps))
+	validator := newValidator()
+	mode := newIngestMode()
+	requireToken := newTokenAuth(deps)
+	requireLogin, apiLogin := newUIAuth(r)
+	requireRead := newReadAuth(deps, apiLogin)
+	csrf := newCSRF(apiLogin)
+	r.POST("/api/v1/lineage", requireToken, api.MakeCreateWithOpenLineageRunEvent(deps, validator, mode))
+	r.POST("/api/v1/lineage/batch", requireToken, api.MakeCreateWithOpenLineageRunEvents(deps, validator, mode))
+	r.GET("/api/v1/lineage/requests/:id", requireRead, api.MakeGetRequest(deps))
+	r.GET("/api/v1/lineage/graph", requireRead, api.MakeGetLineageGraph(deps))
+	r.GET("/api/v1/namespaces", requireRead, api.MakeListNamespaces(deps))
+	r.GET("/api/v1/namespaces/:namespace/datasets", requireRead, api.MakeListDatasets(deps))
+	r.GET("/api/v1/namespaces/:namespace/datasets/:dataset", requireRead, api.MakeGetDataset(deps))
+	r.GET("/api/v1/namespaces/:namespace/datasets/:dataset/versions", requireRead, api.MakeListDatasetVersions(deps))
+	r.GET("/api/v1/namespaces/:namespace/datasets/:dataset/versions/:version", requireRead, api.MakeGetDatasetVersion(deps))
+	r.GET("/api/v1/namespaces/:namespace/datasets/:dataset/facets", requireRead, api.MakeListDatasetFacets(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs", requireRead, api.MakeListJobs(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs/:job", requireRead, api.MakeGetJob(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs/:job/runs", requireRead, api.MakeListJobRuns(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs/:job/versions", requireRead, api.MakeListJobVersions(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs/:job/versions/:version", requireRead, api.MakeGetJobVersion(deps))
+	r.GET("/api/v1/namespaces/:namespace/jobs/:job/facets", requireRead, api.MakeListJobFacets(deps))
+	r.GET("/api/v1/runs/:id", requireRead, api.MakeGetRun(deps))
+	r.GET("/api/v1/runs/:id/facets", requireRead, api.MakeListRunFacets(deps))
+	r.GET("/api/v1/search", requireRead, api.MakeSearch(deps))
+
+	// Admin API, behind the login of the UI
+	admin := r.Group("/api/v1/admin", requireLogin, csrf)
+	admin.POST("/datasets/merge", api.MakeMergeDatasets(deps))
+	admin.POST("/datasets/split", api.MakeSplitDataset(deps))
+	replay := api.NewReplayJob()
+	admin.POST("/replay", api.MakeReplay(deps, replay))
+	admin.GET("/replay", api.MakeGetReplay(replay))
+
+	// Marquez compatible API
+	SetupMarquezRouter(r.Group(marquezPrefix), deps, requireRead)
 
 	// Static
 	static, err := fs.Sub(resources.Static, "static")
@@ -140,39 +357,82 @@ func SetupRouter(r *gin.Engine,
 
 	// Templates
 	r.SetFuncMap(template.FuncMap{
-		"formatTime":    formatTime,
-		"bytesToString": bytesToString,
+		"formatTime":     formatTime,
+		"formatDuration": formatDuration,
+		"bytesToString":  bytesToString,
 	})
 	templ := template.Must(template.New("").Funcs(r.FuncMap).ParseFS(resources.Templates, "templates/**/*.html"))
 	r.SetHTMLTemplate(templ)
 
+	// The pages are behind the login of the UI
 	listDatasets := datasets.MakeListDatasets(deps)
+	ui := r.Group("/", requireLogin, csrf)
 
 	// Datasets
-	r.GET("/lineage/datasets/versions/fields", datasets.MakeGetDatasetVersionFields(deps))
-	r.GET("/lineage/datasets/versions/lineage", datasets.MakeGetDatasetVersionLineage(deps))
-	r.GET("/lineage/datasets/:id", datasets.MakeGetDataset(deps))
-	r.GET("/lineage/datasets/:id/fields", datasets.MakeGetDatasetFields(deps))
-	r.GET("/lineage/datasets/:id/lineage", datasets.MakeGetDatasetLineage(deps))
-	r.GET("/lineage/datasets/:id/ownership", datasets.MakeGetDatasetOwnership(deps))
-	r.GET("/lineage/datasets/:id/quality", datasets.MakeGetDatasetQuality(deps))
-	r.GET("/lineage/datasets/:id/more", datasets.MakeGetDatasetMore(deps))
-	r.GET("/lineage/datasets", listDatasets)
+	ui.GET("/lineage/datasets/versions/fields", datasets.MakeGetDatasetVersionFields(deps))
+	ui.GET("/lineage/datasets/versions/lineage", datasets.MakeGetDatasetVersionLineage(deps))
+	ui.GET("/lineage/datasets/:id", datasets.MakeGetDataset(deps))
+	ui.GET("/lineage/datasets/:id/fields", datasets.MakeGetDatasetFields(deps))
+	ui.GET("/lineage/datasets/:id/lineage", datasets.MakeGetDatasetLineage(deps))
+	ui.GET("/lineage/datasets/:id/graph", datasets.MakeGetDatasetGraph(deps))
+	ui.GET("/lineage/datasets/:id/ownership", datasets.MakeGetDatasetOwnership(deps))
+	ui.GET("/lineage/datasets/:id/quality", datasets.MakeGetDatasetQuality(deps))
+	ui.GET("/lineage/datasets/:id/history", datasets.MakeGetDatasetHistory(deps))
+	ui.GET("/lineage/datasets/:id/more", datasets.MakeGetDatasetMore(deps))
+	ui.GET("/lineage/datasets", listDatasets)
 
 	// Jobs
-	r.GET("/lineage/jobs/:id/runs", jobs.MakeGetJobRuns(deps))
-	r.GET("/lineage/jobs/:id/ownership", jobs.MakeGetJobOwnership(deps))
-	r.GET("/lineage/jobs/:id/sourcecode", jobs.MakeGetJobSourceCode(deps))
-	r.GET("/lineage/jobs/:id", jobs.MakeGetJob(deps))
-	r.GET("/lineage/jobs", jobs.MakeListJobs(deps))
+	ui.GET("/lineage/jobs/:id/runs", jobs.MakeGetJobRuns(deps))
+	ui.GET("/lineage/jobs/:id/versions", jobs.MakeGetJobVersions(deps))
+	ui.GET("/lineage/jobs/:id/graph", jobs.MakeGetJobGraph(deps))
+	ui.GET("/lineage/jobs/:id/ownership", jobs.MakeGetJobOwnership(deps))
+	ui.GET("/lineage/jobs/:id/sourcecode", jobs.MakeGetJobSourceCode(deps))
+	ui.GET("/lineage/jobs/:id/more", jobs.MakeGetJobMore(deps))
+	ui.GET("/lineage/jobs/:id", jobs.MakeGetJob(deps))
+	ui.GET("/lineage/jobs", jobs.MakeListJobs(deps))
+
+	// Graph
+	ui.GET("/lineage/graph", graph.MakeGetGraph(deps))
 
 	// Requests
-	r.GET("/lineage/requests", requests.MakeGetRequests(deps))
+	ui.GET("/lineage/requests", requests.MakeGetRequests(deps))
+
+	// Failed events
+	ui.GET("/lineage/failed/:id", failed.MakeGetFailedEvent(deps))
+	ui.POST("/lineage/failed/:id/resubmit", failed.MakeResubmitFailedEvent(deps, validator))
+	ui.POST("/lineage/failed/:id/discard", failed.MakeDiscardFailedEvent(deps))
+	ui.GET("/lineage/failed", failed.MakeListFailedEvents(deps))
+
+	// API tokens
+	ui.POST("/lineage/tokens/:id/revoke", tokens.MakeRevokeAPIToken(deps))
+	ui.POST("/lineage/tokens", tokens.MakeCreateAPIToken(deps))
+	ui.GET("/lineage/tokens", tokens.MakeListAPITokens(deps))
 
 	// Runs
-	r.GET("/lineage/runs/:id", runs.MakeGetRun(deps))
+	ui.GET("/lineage/runs/:id", runs.MakeGetRun(deps))
+	ui.GET("/lineage/runs", runs.MakeListRuns(deps))
+
+	// Search
+	ui.GET("/lineage/search", search.MakeSearch(deps))
 
 	// Home
-	r.GET("/index", listDatasets)
-	r.GET("/", listDatasets)
+	ui.GET("/index", listDatasets)
+	ui.GET("/", listDatasets)
+}
+
+// SetupMarquezRouter sets up the Marquez compatible API on the group so
+// Marquez clients can point their base url at it, requireRead protects the
+// reads
+func SetupMarquezRouter(g *gin.RouterGroup, deps Deps, requireRead gin.HandlerFunc) {
+	g.POST("/api/v1/lineage", newTokenAuth(deps), api.MakeCreateWithOpenLineageRunEvent(deps, newValidator(), newIngestMode()))
+	g.GET("/api/v1/lineage", requireRead, marquez.MakeGetLineage(deps))
+	g.GET("/api/v1/search", requireRead, marquez.MakeSearch(deps))
+	g.GET("/api/v1/namespaces", requireRead, marquez.MakeListNamespaces(deps))
+	g.GET("/api/v1/namespaces/:namespace", requireRead, marquez.MakeGetNamespace(deps))
+	g.GET("/api/v1/namespaces/:namespace/datasets", requireRead, marquez.MakeListDatasets(deps))
+	g.GET("/api/v1/namespaces/:namespace/datasets/:dataset", requireRead, marquez.MakeGetDataset(deps))
+	g.GET("/api/v1/namespaces/:namespace/jobs", requireRead, marquez.MakeListJobs(deps))
+	g.GET("/api/v1/namespaces/:namespace/jobs/:job", requireRead, marquez.MakeGetJob(deps))
+	g.GET("/api/v1/namespaces/:namespace/jobs/:job/runs", requireRead, marquez.MakeListJobRuns(deps))
+	g.GET("/api/v1/jobs/runs/:id", requireRead, marquez.MakeGetRun(deps))
 }
@@ -119,9 +119,10 @@ type ColumnLineageFacet struct {
 }
 
 type SchemaField struct {
-	Name        string `json:"name"`
-	Type        string `json:"type"`
-	Description string `json:"description"`
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Fields      []SchemaField `json:"fields,omitempty"`
 }
 
 type SchemaFacet struct {
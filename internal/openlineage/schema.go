@@ -2,6 +2,7 @@ package openlineage
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -61,3 +62,50 @@ type RunEvent struct {
 	Producer  string          `json:"producer"`
 	SchemaURL string          `json:"schemaURL"`
 }
+
+// DatasetEvent carries static metadata about a dataset, e.g. a schema
+// registered in a catalog, without a run
+type DatasetEvent struct {
+	EventTime time.Time `json:"eventTime"`
+	Dataset   *Dataset  `json:"dataset"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+// JobEvent carries static metadata about a job, e.g. a view definition, with
+// the datasets it declares as inputs and outputs, without a run
+type JobEvent struct {
+	EventTime time.Time       `json:"eventTime"`
+	Job       *Job            `json:"job"`
+	Inputs    []InputDataset  `json:"inputs"`
+	Outputs   []OutputDataset `json:"outputs"`
+	Producer  string          `json:"producer"`
+	SchemaURL string          `json:"schemaURL"`
+}
+
+type EventKind int
+
+const (
+	EventKindRun EventKind = iota + 1
+	EventKindDataset
+	EventKindJob
+)
+
+// DetectEventKind tells run, dataset and job events apart by their top level
+// keys. Only run events have a run and only dataset events have a dataset.
+func DetectEventKind(msg []byte) (EventKind, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &keys); err != nil {
+		return 0, err
+	}
+	if _, ok := keys["run"]; ok {
+		return EventKindRun, nil
+	}
+	if _, ok := keys["dataset"]; ok {
+		return EventKindDataset, nil
+	}
+	if _, ok := keys["job"]; ok {
+		return EventKindJob, nil
+	}
+	return 0, errors.New("event has neither a run, a dataset nor a job")
+}
@@ -0,0 +1,237 @@
+package openlineage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+type ValidationMode int
+
+const (
+	// ValidationModeLenient accepts custom event types and facets, the spec
+	// allows producers to add their own
+	ValidationModeLenient ValidationMode = iota + 1
+	// ValidationModeStrict only accepts the event types and facets defined by
+	// the spec and asserts formats such as uri and date-time
+	ValidationModeStrict
+)
+
+var validationModeMap = map[string]ValidationMode{
+	"lenient": ValidationModeLenient,
+	"strict":  ValidationModeStrict,
+}
+
+func ValidationModeFromString(str string) (ValidationMode, error) {
+	val, ok := validationModeMap[strings.ToLower(str)]
+	if !ok {
+		return 0, fmt.Errorf("No validation mode matching [%s]", str)
+	}
+	return val, nil
+}
+
+// FieldError is a validation failure of the value at Path, a JSON pointer
+// into the event
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// knownFacets are the facets defined by the bundled spec by where they appear
+var knownFacets = map[string][]string{
+	"run":     {"errorMessage", "externalQuery", "nominalTime", "parent"},
+	"job":     {"documentation", "ownership", "sourceCode", "sourceCodeLocation", "sql"},
+	"dataset": {"columnLineage", "dataQualityAssertions", "dataSource", "lifecycleStateChange", "ownership", "schema", "storage", "symlinks", "version"},
+	"input":   {"dataQualityMetrics"},
+	"output":  {"outputStatistics"},
+}
+
+// Validator validates events against the OpenLineage JSON schema
+type Validator struct {
+	mode    ValidationMode
+	run     *jsonschema.Schema
+	dataset *jsonschema.Schema
+	job     *jsonschema.Schema
+}
+
+// NewValidator compiles the OpenLineage JSON schema. The spec only defines
+// run events so dataset and job events are checked with schemas built from
+// its definitions. Facet schemas are not bundled and accept any facet.
+func NewValidator(spec []byte, mode ValidationMode) (*Validator, error) {
+	var doc struct {
+		ID string `json:"$id"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	if doc.ID == "" {
+		return nil, errors.New("the schema has no $id")
+	}
+	base := doc.ID[:strings.LastIndex(doc.ID, "/")+1]
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	c.AssertFormat = mode == ValidationModeStrict
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		if strings.HasPrefix(s, base+"facets/") {
+			return io.NopCloser(strings.NewReader("{}")), nil
+		}
+		return nil, fmt.Errorf("schema[%s] is not bundled", s)
+	}
+
+	staticEvent := func(required string, properties string) string {
+		return fmt.Sprintf(`{
+			"type": "object",
+			"required": [%s, "eventTime", "producer", "schemaURL"],
+			"properties": {
+				%s,
+				"eventTime": {"type": "string", "format": "date-time"},
+				"producer": {"type": "string", "format": "uri"},
+				"schemaURL": {"type": "string", "format": "uri"}
+			}
+		}`, required, properties)
+	}
+	resources := map[string]string{
+		doc.ID: string(spec),
+		base + "DatasetEvent.json": staticEvent(`"dataset"`,
+			fmt.Sprintf(`"dataset": {"$ref": "%s#/$defs/Dataset"}`, doc.ID)),
+		base + "JobEvent.json": staticEvent(`"job"`, fmt.Sprintf(`
+			"job": {"$ref": "%[1]s#/$defs/Job"},
+			"inputs": {"type": "array", "items": {"$ref": "%[1]s#/$defs/InputDataset"}},
+			"outputs": {"type": "array", "items": {"$ref": "%[1]s#/$defs/OutputDataset"}}`, doc.ID)),
+	}
+	for url, r := range resources {
+		if err := c.AddResource(url, strings.NewReader(r)); err != nil {
+			return nil, err
+		}
+	}
+
+	v := &Validator{mode: mode}
+	var err error
+	if v.run, err = c.Compile(doc.ID); err != nil {
+		return nil, err
+	}
+	if v.dataset, err = c.Compile(base + "DatasetEvent.json"); err != nil {
+		return nil, err
+	}
+	if v.job, err = c.Compile(base + "JobEvent.json"); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Validate returns the field errors of the event, none when it is valid
+func (v *Validator) Validate(kind EventKind, msg []byte) []FieldError {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return []FieldError{{Path: "", Message: err.Error()}}
+	}
+
+	var schema *jsonschema.Schema
+	switch kind {
+	case EventKindDataset:
+		schema = v.dataset
+	case EventKindJob:
+		schema = v.job
+	default:
+		schema = v.run
+	}
+
+	var res []FieldError
+	if err := schema.Validate(doc); err != nil {
+		var ve *jsonschema.ValidationError
+		if !errors.As(err, &ve) {
+			return []FieldError{{Path: "", Message: err.Error()}}
+		}
+		for _, leaf := range leaves(ve) {
+			// custom event types are allowed unless strict
+			if v.mode != ValidationModeStrict && leaf.InstanceLocation == "/eventType" &&
+				strings.HasSuffix(leaf.KeywordLocation, "/enum") {
+				continue
+			}
+			res = append(res, FieldError{Path: leaf.InstanceLocation, Message: leaf.Message})
+		}
+	}
+	if v.mode == ValidationModeStrict {
+		res = append(res, unknownFacets(doc)...)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res
+}
+
+func leaves(ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return []*jsonschema.ValidationError{ve}
+	}
+	var res []*jsonschema.ValidationError
+	for _, c := range ve.Causes {
+		res = append(res, leaves(c)...)
+	}
+	return res
+}
+
+// unknownFacets finds facets the spec does not define in every place an
+// event can carry facets
+func unknownFacets(doc interface{}) []FieldError {
+	var res []FieldError
+	check := func(facets interface{}, path string, where string) {
+		m, ok := facets.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name := range m {
+			if !contains(knownFacets[where], name) {
+				res = append(res, FieldError{Path: path + "/" + name, Message: fmt.Sprintf("unknown %s facet", where)})
+			}
+		}
+	}
+	datasets := func(v interface{}, path string, ioKey string, where string) {
+		xs, ok := v.([]interface{})
+		if !ok {
+			return
+		}
+		for i, x := range xs {
+			ds, ok := x.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			p := fmt.Sprintf("%s/%d", path, i)
+			check(ds["facets"], p+"/facets", "dataset")
+			check(ds[ioKey], p+"/"+ioKey, where)
+		}
+	}
+
+	ev, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if run, ok := ev["run"].(map[string]interface{}); ok {
+		check(run["facets"], "/run/facets", "run")
+	}
+	if job, ok := ev["job"].(map[string]interface{}); ok {
+		check(job["facets"], "/job/facets", "job")
+	}
+	if ds, ok := ev["dataset"].(map[string]interface{}); ok {
+		check(ds["facets"], "/dataset/facets", "dataset")
+	}
+	datasets(ev["inputs"], "/inputs", "inputFacets", "input")
+	datasets(ev["outputs"], "/outputs", "outputFacets", "output")
+	return res
+}
+
+func contains(xs []string, s string) bool {
+	for _, x := range xs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
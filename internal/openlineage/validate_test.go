@@ -0,0 +1,85 @@
+package openlineage_test
+
+import (
+	"fmt"
+	"oplin/internal/openlineage"
+	"oplin/resources"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidator(t *testing.T, mode openlineage.ValidationMode) *openlineage.Validator {
+	spec, err := resources.Static.ReadFile("static/openapi/OpenLineage.json")
+	require.Nil(t, err)
+	v, err := openlineage.NewValidator(spec, mode)
+	require.Nil(t, err)
+	return v
+}
+
+const validRunEvent = `{
+	"eventType": "START",
+	"eventTime": "2023-02-05T15:48:28.660754+02:00",
+	"run": {"runId": "6871ff97-c518-4081-97aa-8520f7e634b7", "facets": {%s}},
+	"job": {"namespace": "abs", "name": "xyz"},
+	"producer": "https://github.com/OpenLineage/OpenLineage/blob/v1-0-0/client",
+	"schemaURL": "https://openlineage.io/spec/1-0-5/OpenLineage.json#/$defs/RunEvent"
+}`
+
+func TestValidateRunEvent(t *testing.T) {
+	v := newValidator(t, openlineage.ValidationModeLenient)
+
+	errs := v.Validate(openlineage.EventKindRun, []byte(fmt.Sprintf(validRunEvent, "")))
+	assert.Equal(t, 0, len(errs))
+
+	errs = v.Validate(openlineage.EventKindRun, []byte(`{
+		"eventTime": "2023-02-05T15:48:28.660754+02:00",
+		"run": {},
+		"producer": "test",
+		"schemaURL": "test"
+	}`))
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "", errs[0].Path)
+	assert.Contains(t, errs[0].Message, "job")
+	assert.Equal(t, "/run", errs[1].Path)
+	assert.Contains(t, errs[1].Message, "runId")
+}
+
+func TestValidateModes(t *testing.T) {
+	lenient := newValidator(t, openlineage.ValidationModeLenient)
+	strict := newValidator(t, openlineage.ValidationModeStrict)
+
+	custom := strings.Replace(fmt.Sprintf(validRunEvent, `"myFacet": {"a": 1}`), "START", "RESUME", 1)
+
+	errs := lenient.Validate(openlineage.EventKindRun, []byte(custom))
+	assert.Equal(t, 0, len(errs))
+
+	errs = strict.Validate(openlineage.EventKindRun, []byte(custom))
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "/eventType", errs[0].Path)
+	assert.Equal(t, "/run/facets/myFacet", errs[1].Path)
+}
+
+func TestValidateStaticEvents(t *testing.T) {
+	v := newValidator(t, openlineage.ValidationModeStrict)
+
+	errs := v.Validate(openlineage.EventKindDataset, []byte(`{
+		"eventTime": "2023-02-05T15:48:28.660754+02:00",
+		"dataset": {"namespace": "ns", "name": "table", "facets": {"schema": {"fields": []}}},
+		"producer": "https://example.com/producer",
+		"schemaURL": "https://openlineage.io/spec/2-0-0/OpenLineage.json#/$defs/DatasetEvent"
+	}`))
+	assert.Equal(t, 0, len(errs))
+
+	errs = v.Validate(openlineage.EventKindJob, []byte(`{
+		"eventTime": "2023-02-05T15:48:28.660754+02:00",
+		"job": {"namespace": "ns", "name": "view"},
+		"inputs": [{"namespace": "ns", "name": "table", "inputFacets": {"rowCount": {}}}],
+		"producer": "https://example.com/producer"
+	}`))
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "", errs[0].Path)
+	assert.Equal(t, "/inputs/0/inputFacets/rowCount", errs[1].Path)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"github.com/tabbed/pqtype"
+	"reflect"
 	"time"
 )
 
@@ -74,3 +75,60 @@ func MergeFacets(a, b []byte) ([]byte, error) {
 	}
 	return json.Marshal(mapC)
 }
+
+// JSONEqual returns true if the two json documents are semantically equal
+func JSONEqual(a, b []byte) bool {
+	var x, y interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &x); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &y); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// ignoredFacetKeys are carried by every facet and describe who produced it
+// rather than what it says
+var ignoredFacetKeys = map[string]bool{"_producer": true, "_schemaURL": true}
+
+func stripIgnoredFacetKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{})
+		for k, x := range t {
+			if !ignoredFacetKeys[k] {
+				res[k] = stripIgnoredFacetKeys(x)
+			}
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, x := range t {
+			res[i] = stripIgnoredFacetKeys(x)
+		}
+		return res
+	}
+	return v
+}
+
+// FacetsEqual returns true if the two facet documents are equal ignoring the
+// _producer and _schemaURL keys, so upgrading a producer is not a change
+func FacetsEqual(a, b []byte) bool {
+	var x, y interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &x); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &y); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(stripIgnoredFacetKeys(x), stripIgnoredFacetKeys(y))
+}